@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// newLogger builds the export logger: stderr gets warnings by default, debug
+// with -verbose and errors only with -quiet; -log-file always gets debug.
+func newLogger(opts cliOptions) (*slog.Logger, func() error, error) {
+	if opts.Verbose && opts.Quiet {
+		return nil, nil, errors.New("-verbose and -quiet cannot be used together")
+	}
+
+	level := slog.LevelWarn
+	switch {
+	case opts.Verbose:
+		level = slog.LevelDebug
+	case opts.Quiet:
+		level = slog.LevelError
+	}
+	handlers := []slog.Handler{slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level, ReplaceAttr: dropTimeAttr})}
+	closeLog := func() error { return nil }
+
+	if opts.LogFile != "" {
+		f, err := os.Create(opts.LogFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open log file: %w", err)
+		}
+		handlers = append(handlers, slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		closeLog = f.Close
+	}
+
+	if len(handlers) == 1 {
+		return slog.New(handlers[0]), closeLog, nil
+	}
+	return slog.New(fanoutHandler(handlers)), closeLog, nil
+}
+
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithGroup(name)
+	}
+	return out
+}
+
+func dropTimeAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return attr
+}
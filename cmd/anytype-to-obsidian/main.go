@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -34,6 +40,73 @@ type cliOptions struct {
 	ExcludeProperties         string
 	IncludeProperties         string
 	LinkAsNoteProperties      string
+	ScaffoldEmptyNotes        bool
+	TransliterateFilenames    bool
+	WriteDefaultTemplateMap   bool
+	VerifyLinks               string
+	StrictRelations           bool
+	ExportPeople              bool
+	ColumnLayout              string
+	LinkCards                 bool
+	RelationBlocks            string
+	JSONStats                 bool
+	Verbose                   bool
+	Quiet                     bool
+	LogFile                   string
+	Atomic                    bool
+	MergeStrategy             string
+	EmbedAnytypeMetadata      bool
+	FilenameScheme            string
+	MaxFilenameLength         int
+	ASCIIFilenames            bool
+	EmojiInFilename           bool
+	MaxImageDimension         int
+	ImageQuality              int
+	KeepOriginalImages        bool
+	IconStyle                 string
+	NoDynamicTimestamps       bool
+	FailFast                  bool
+	LowMemory                 bool
+	Profile                   bool
+	OutputZip                 string
+	NFCFilenames              bool
+	PropertyOrder             string
+	PropertiesStyle           string
+	TagHierarchy              string
+	TagsFromProperties        string
+	NoMergeTags               bool
+	KanbanBoards              bool
+	SplitBySpace              bool
+	MissingStubs              bool
+	IncludeDeleted            bool
+	IncludeSystemObjects      bool
+	KeepSystemObjectTypes     string
+	TagColorsCSS              bool
+	Format                    string
+	HTMLEmbedImages           bool
+	Flavor                    string
+	TypeAsTag                 bool
+	ExportTablesDir           string
+	ExportTablesFormat        string
+	QueryTarget               string
+	Properties                string
+	SkipEmptyNotes            bool
+	ReportDuplicates          bool
+	WebClippings              bool
+	ExportVCards              bool
+	ExportICS                 bool
+	Protect                   string
+	PrettierCommand           string
+	PrettierConfig            string
+	PrettierTargets           string
+	Formatter                 string
+	Update                    string
+	GraphStats                bool
+	TOCMode                   string
+	CalloutTypes              string
+	ToggleHeadings            string
+	ComplexTables             string
+	MultiValueLists           bool
 }
 
 type cliField struct {
@@ -51,9 +124,14 @@ type cliModel struct {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify-vault" {
+		os.Exit(runVerifyVault(os.Args[2:]))
+	}
+
 	opts := defaultCLIOptions()
+	interactive := len(os.Args) == 1
 
-	if len(os.Args) == 1 {
+	if interactive {
 		interactiveOpts, err := runInteractiveOptions(opts)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "interactive setup failed: %v\n", err)
@@ -61,7 +139,7 @@ func main() {
 		}
 		opts = interactiveOpts
 	} else {
-		flag.StringVar(&opts.Input, "input", opts.Input, "Path to Anytype-json export directory")
+		flag.StringVar(&opts.Input, "input", opts.Input, "Path to Anytype-json export directory or the .zip archive Anytype produced")
 		flag.StringVar(&opts.Output, "output", opts.Output, "Path to output Obsidian vault")
 		flag.BoolVar(&opts.DisableIconizeIcons, "disable-iconize-icons", opts.DisableIconizeIcons, "Disable exporting icons to .obsidian/plugins/obsidian-icon-folder/data.json")
 		flag.BoolVar(&opts.DisablePrettyPropertyIcon, "disable-pretty-properties-icon", opts.DisablePrettyPropertyIcon, "Disable converting iconImage/iconEmoji to the Pretty Properties icon frontmatter")
@@ -76,10 +154,85 @@ func main() {
 		flag.StringVar(&opts.ExcludeProperties, "exclude-properties", opts.ExcludeProperties, "Comma-separated property keys/names to always exclude from frontmatter")
 		flag.StringVar(&opts.IncludeProperties, "force-include-properties", opts.IncludeProperties, "Comma-separated property keys/names to always include in frontmatter")
 		flag.StringVar(&opts.LinkAsNoteProperties, "link-as-note-properties", opts.LinkAsNoteProperties, "Comma-separated property keys/names to render relation values as note links when possible (e.g. type,tag,status)")
+		flag.BoolVar(&opts.ScaffoldEmptyNotes, "scaffold-empty-notes", opts.ScaffoldEmptyNotes, "Scaffold empty note bodies from the type recommended layout (e.g. task checklist heading)")
+		flag.BoolVar(&opts.TransliterateFilenames, "transliterate-filenames", opts.TransliterateFilenames, "Romanize non-Latin note/base/template filenames (Cyrillic, Greek, kana, accented Latin) and keep the original title as an alias")
+		flag.BoolVar(&opts.WriteDefaultTemplateMap, "default-template-map", opts.WriteDefaultTemplateMap, "Write templates/README.md mapping each type to its default Anytype template")
+		flag.StringVar(&opts.VerifyLinks, "verify", opts.VerifyLinks, "Verify links after export: off, warn, fail")
+		flag.BoolVar(&opts.StrictRelations, "strict-relations", opts.StrictRelations, "Fail the export and list every object relation target that is neither exported nor known by name")
+		flag.BoolVar(&opts.ExportPeople, "export-people", opts.ExportPeople, "Export space members (participant objects) as notes under people/ and link creator/assignee relations to them")
+		flag.StringVar(&opts.ColumnLayout, "column-layout", opts.ColumnLayout, "How to export Anytype column layouts: flatten, html, multi-column")
+		flag.BoolVar(&opts.LinkCards, "link-cards", opts.LinkCards, "Render card-style link blocks as callouts with title link, description and cover")
+		flag.StringVar(&opts.RelationBlocks, "relation-blocks", opts.RelationBlocks, "How to render inline relation blocks in notes: dataview, line, skip")
+		flag.BoolVar(&opts.JSONStats, "json-stats", opts.JSONStats, "Print export statistics as JSON on stdout")
+		flag.BoolVar(&opts.Verbose, "verbose", opts.Verbose, "Log per-object decisions (skipped objects, unresolved relations, missing files) to stderr")
+		flag.BoolVar(&opts.Quiet, "quiet", opts.Quiet, "Only log errors to stderr")
+		flag.StringVar(&opts.LogFile, "log-file", opts.LogFile, "Write a detailed debug log of the export to this file (e.g. export.log)")
+		flag.BoolVar(&opts.Atomic, "atomic", opts.Atomic, "Render into a temp folder next to the output and replace the output directory only after a successful export, keeping files the exporter did not write")
+		flag.StringVar(&opts.MergeStrategy, "merge-strategy", opts.MergeStrategy, "How to treat notes already in the output vault: overwrite, skip-existing, keep-newer, suffix-conflicts")
+		flag.BoolVar(&opts.EmbedAnytypeMetadata, "embed-anytype-metadata", opts.EmbedAnytypeMetadata, "Write an anytype: frontmatter block (object id, space id, type id, last modified) on every note for round-tripping")
+		flag.StringVar(&opts.FilenameScheme, "filename-scheme", opts.FilenameScheme, "Note filename scheme: name, id, name-id-suffix, zettel")
+		flag.IntVar(&opts.MaxFilenameLength, "max-filename-length", opts.MaxFilenameLength, "Maximum filename length in bytes; longer names are truncated with a short hash (-1 disables the limit)")
+		flag.BoolVar(&opts.ASCIIFilenames, "ascii-filenames", opts.ASCIIFilenames, "Use lowercase ASCII slugs for filenames (transliterating Cyrillic, Greek, kana, CJK; dropping emoji) and keep original titles as aliases; implies -transliterate-filenames")
+		flag.BoolVar(&opts.EmojiInFilename, "emoji-in-filename", opts.EmojiInFilename, "Prefix note filenames with the object's icon emoji (e.g. \"✨ Task One.md\")")
+		flag.IntVar(&opts.MaxImageDimension, "max-image-dimension", opts.MaxImageDimension, "Downscale JPEG/PNG attachments whose longest side exceeds this many pixels (0 disables)")
+		flag.IntVar(&opts.ImageQuality, "image-quality", opts.ImageQuality, "JPEG quality (1-100) used when re-encoding resized images")
+		flag.BoolVar(&opts.KeepOriginalImages, "keep-original-images", opts.KeepOriginalImages, "Keep the original of every resized image under files/originals/")
+		flag.StringVar(&opts.IconStyle, "icon-style", opts.IconStyle, "Where emoji icons go: property (frontmatter icon), heading (generated H1) or filename")
+		flag.BoolVar(&opts.NoDynamicTimestamps, "no-dynamic-timestamps", opts.NoDynamicTimestamps, "Keep run-time values out of the vault: anchor relative date filters to the base's last modification and omit elapsed time and run-time mtimes from _anytype")
+		flag.BoolVar(&opts.FailFast, "fail-fast", opts.FailFast, "Abort on the first unreadable .pb.json snapshot instead of skipping it and listing it in the report")
+		flag.BoolVar(&opts.LowMemory, "low-memory", opts.LowMemory, "Keep only indexing data in memory and re-read each object's blocks while rendering; slower, but suited to very large exports")
+		flag.BoolVar(&opts.Profile, "profile", opts.Profile, "Write CPU/heap pprof profiles and per-phase timings to _anytype/profile/")
+		flag.StringVar(&opts.OutputZip, "output-zip", opts.OutputZip, "Write the vault into this .zip archive instead of the -output directory")
+		flag.BoolVar(&opts.NFCFilenames, "nfc-filenames", opts.NFCFilenames, "Normalize note, base, template and attachment filenames (and so every link) to Unicode NFC")
+		flag.StringVar(&opts.PropertyOrder, "property-order", opts.PropertyOrder, "Comma-separated frontmatter keys to write first (e.g. title,type,tags,status,*); * keeps the remaining keys in their default order")
+		flag.StringVar(&opts.PropertiesStyle, "properties-style", opts.PropertiesStyle, "Where object properties go: frontmatter (default), inline (Dataview Key:: value lines at the top of the note), or both")
+		flag.StringVar(&opts.TagHierarchy, "tag-hierarchy", opts.TagHierarchy, "Comma-separated relation or relation=prefix entries whose options become nested tags (e.g. area,topic=learning); the relation name is the prefix when none is given")
+		flag.StringVar(&opts.TagsFromProperties, "tags-from-properties", opts.TagsFromProperties, "Comma-separated property keys/names merged into tags (default: tag)")
+		flag.BoolVar(&opts.NoMergeTags, "no-merge-tags", opts.NoMergeTags, "Write the tag relation under its own key instead of tags")
+		flag.BoolVar(&opts.KanbanBoards, "kanban-boards", opts.KanbanBoards, "Also write each Anytype Board view as an Obsidian Kanban plugin board next to its base")
+		flag.BoolVar(&opts.SplitBySpace, "split-by-space", opts.SplitBySpace, "Write one vault per space into <output>/<Space Name>/")
+		flag.BoolVar(&opts.MissingStubs, "missing-stubs", opts.MissingStubs, "Write stub notes in Missing/ for linked objects absent from the export (e.g. other spaces)")
+		flag.BoolVar(&opts.IncludeDeleted, "include-deleted", opts.IncludeDeleted, "Export objects deleted from the bin into trash/")
+		flag.BoolVar(&opts.IncludeSystemObjects, "include-system-objects", opts.IncludeSystemObjects, "Export system objects (dashboards, workspace, widgets, profile) as notes")
+		flag.StringVar(&opts.KeepSystemObjectTypes, "keep-system-object-types", opts.KeepSystemObjectTypes, "Comma-separated system object kinds to export anyway (e.g. Home,Dashboard)")
+		flag.BoolVar(&opts.TagColorsCSS, "tag-colors-css", opts.TagColorsCSS, "Write a CSS snippet coloring tags with their Anytype option colors")
+		flag.StringVar(&opts.Format, "format", opts.Format, "Output format: markdown, html (static site instead of a vault), or both")
+		flag.BoolVar(&opts.HTMLEmbedImages, "html-embed-images", opts.HTMLEmbedImages, "Inline images into the HTML pages as base64 data URIs")
+		flag.StringVar(&opts.Flavor, "flavor", opts.Flavor, "Target app: obsidian (vault), logseq (graph with pages/, journals/ and assets/) or portable (plain CommonMark without Obsidian syntax)")
+		flag.BoolVar(&opts.TypeAsTag, "type-as-tag", opts.TypeAsTag, "Add a type/<Name> tag for each note's Anytype type")
+		flag.StringVar(&opts.ExportTablesDir, "export-tables-dir", opts.ExportTablesDir, "Also write every set and collection as a table of its members into this directory")
+		flag.StringVar(&opts.ExportTablesFormat, "export-tables-format", opts.ExportTablesFormat, "Format of -export-tables-dir files: csv or json")
+		flag.StringVar(&opts.QueryTarget, "query-target", opts.QueryTarget, "Where set and collection views go: bases (.base files) or projects (Projects plugin data.json)")
+		flag.StringVar(&opts.Properties, "properties", opts.Properties, "Which properties to export: all (default), featured (also list the object's featured properties under featured:), or featured-only (only featured and type-recommended properties)")
+		flag.BoolVar(&opts.SkipEmptyNotes, "skip-empty-notes", opts.SkipEmptyNotes, "Skip objects with no body text and only hidden properties (e.g. auto-created stubs)")
+		flag.BoolVar(&opts.ReportDuplicates, "report-duplicates", opts.ReportDuplicates, "Write duplicates.md listing notes that share a name, grouped by identical content")
+		flag.BoolVar(&opts.WebClippings, "web-clippings", opts.WebClippings, "Export Bookmark objects as web clippings: source URL property, description blockquote and preview image")
+		flag.BoolVar(&opts.ExportVCards, "export-vcards", opts.ExportVCards, "Also write people with email or phone relations as vCards into contacts/")
+		flag.BoolVar(&opts.ExportICS, "export-ics", opts.ExportICS, "Also write anytype-events.ics with an event for every date relation set on a note, such as due dates")
+		flag.StringVar(&opts.Protect, "protect", opts.Protect, "Comma-separated globs of vault paths the exporter never writes, e.g. Daily/**,Inbox.md")
+		flag.StringVar(&opts.PrettierCommand, "prettier-command", opts.PrettierCommand, "Formatter command to run instead of npx prettier, e.g. \"dprint fmt\" or \"./node_modules/.bin/prettier --write\"; target paths are appended")
+		flag.StringVar(&opts.PrettierConfig, "prettier-config", opts.PrettierConfig, "Formatter config: empty ignores config files, auto lets the formatter find them, or a path passed as --config")
+		flag.StringVar(&opts.PrettierTargets, "prettier-targets", opts.PrettierTargets, "Comma-separated vault folders or globs to format (default notes,people,trash,bases,templates)")
+		flag.StringVar(&opts.Formatter, "formatter", opts.Formatter, "Post-export formatter: prettier, builtin (offline markdown normalizer, no Node needed) or none; empty follows -prettier")
+		flag.StringVar(&opts.Update, "update", opts.Update, "What to write into an existing vault: all (default) or frontmatter (rewrite only the properties of already exported notes, keeping their bodies)")
+		flag.BoolVar(&opts.GraphStats, "graph-stats", opts.GraphStats, "Write graph-stats.md with notes per type, the most linked notes, orphan notes and relations pointing at missing objects")
+		flag.StringVar(&opts.TOCMode, "toc-mode", opts.TOCMode, "How to render Anytype table of contents blocks: wikilink-anchors ([[#Heading]] links), links (markdown links to #Heading), or skip (rely on Obsidian's outline pane)")
+		flag.StringVar(&opts.CalloutTypes, "callout-types", opts.CalloutTypes, "Comma-separated icon=type pairs mapping Anytype callout icons to Obsidian callout types, extending the defaults (e.g. 🚀=tip,🧠=abstract)")
+		flag.StringVar(&opts.ToggleHeadings, "toggle-headings", opts.ToggleHeadings, "How to render Anytype toggle headings: heading (content below the heading) or callout (content folded into a collapsed callout under the heading)")
+		flag.StringVar(&opts.ComplexTables, "complex-tables", opts.ComplexTables, "How to render Anytype tables markdown cannot hold (merged cells, several blocks in a cell, many columns): markdown (lossy markdown table) or html (HTML table)")
+		flag.BoolVar(&opts.MultiValueLists, "multi-value-lists", opts.MultiValueLists, "Always write relations that allow several values (relationMaxCount other than 1) as YAML lists, even with a single value")
 		flag.Parse()
 	}
 
+	logger, closeLog, err := newLogger(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+
 	exp := exporter.Exporter{
+		Logger:                    logger,
 		InputDir:                  opts.Input,
 		OutputDir:                 opts.Output,
 		DisableIconizeIcons:       opts.DisableIconizeIcons,
@@ -95,15 +248,131 @@ func main() {
 		ExcludePropertyKeys:       parseCommaSeparatedList(opts.ExcludeProperties),
 		ForceIncludePropertyKeys:  parseCommaSeparatedList(opts.IncludeProperties),
 		LinkAsNotePropertyKeys:    parseCommaSeparatedList(opts.LinkAsNoteProperties),
+		ScaffoldEmptyNotes:        opts.ScaffoldEmptyNotes,
+		TransliterateFilenames:    opts.TransliterateFilenames,
+		WriteDefaultTemplateMap:   opts.WriteDefaultTemplateMap,
+		VerifyLinks:               opts.VerifyLinks,
+		StrictRelations:           opts.StrictRelations,
+		ExportPeople:              opts.ExportPeople,
+		ColumnLayout:              opts.ColumnLayout,
+		LinkCards:                 opts.LinkCards,
+		RelationBlocks:            opts.RelationBlocks,
+		Atomic:                    opts.Atomic,
+		MergeStrategy:             opts.MergeStrategy,
+		EmbedAnytypeMetadata:      opts.EmbedAnytypeMetadata,
+		FilenameScheme:            opts.FilenameScheme,
+		MaxFilenameLength:         opts.MaxFilenameLength,
+		ASCIIFilenames:            opts.ASCIIFilenames,
+		EmojiInFilename:           opts.EmojiInFilename,
+		MaxImageDimension:         opts.MaxImageDimension,
+		ImageQuality:              opts.ImageQuality,
+		KeepOriginalImages:        opts.KeepOriginalImages,
+		IconStyle:                 opts.IconStyle,
+		NoDynamicTimestamps:       opts.NoDynamicTimestamps,
+		FailFast:                  opts.FailFast,
+		LowMemory:                 opts.LowMemory,
+		Profile:                   opts.Profile,
+		OutputZip:                 opts.OutputZip,
+		NFCFilenames:              opts.NFCFilenames,
+		PropertyOrder:             parseCommaSeparatedList(opts.PropertyOrder),
+		PropertiesStyle:           opts.PropertiesStyle,
+		TagHierarchy:              parseCommaSeparatedList(opts.TagHierarchy),
+		TagsFromProperties:        parseCommaSeparatedList(opts.TagsFromProperties),
+		NoMergeTags:               opts.NoMergeTags,
+		KanbanBoards:              opts.KanbanBoards,
+		SplitBySpace:              opts.SplitBySpace,
+		MissingStubs:              opts.MissingStubs,
+		IncludeDeleted:            opts.IncludeDeleted,
+		IncludeSystemObjects:      opts.IncludeSystemObjects,
+		KeepSystemObjectTypes:     parseCommaSeparatedList(opts.KeepSystemObjectTypes),
+		TagColorsCSS:              opts.TagColorsCSS,
+		Format:                    opts.Format,
+		HTMLEmbedImages:           opts.HTMLEmbedImages,
+		Flavor:                    opts.Flavor,
+		TypeAsTag:                 opts.TypeAsTag,
+		ExportTablesDir:           opts.ExportTablesDir,
+		ExportTablesFormat:        opts.ExportTablesFormat,
+		QueryTarget:               opts.QueryTarget,
+		Properties:                opts.Properties,
+		SkipEmptyNotes:            opts.SkipEmptyNotes,
+		ReportDuplicates:          opts.ReportDuplicates,
+		WebClippings:              opts.WebClippings,
+		ExportVCards:              opts.ExportVCards,
+		ExportICS:                 opts.ExportICS,
+		Protect:                   parseCommaSeparatedList(opts.Protect),
+		PrettierCommand:           opts.PrettierCommand,
+		PrettierConfig:            opts.PrettierConfig,
+		PrettierTargets:           parseCommaSeparatedList(opts.PrettierTargets),
+		Formatter:                 opts.Formatter,
+		Update:                    opts.Update,
+		GraphStats:                opts.GraphStats,
+		TOCMode:                   opts.TOCMode,
+		CalloutTypes:              parseCommaSeparatedList(opts.CalloutTypes),
+		ToggleHeadings:            opts.ToggleHeadings,
+		ComplexTables:             opts.ComplexTables,
+		MultiValueLists:           opts.MultiValueLists,
 	}
 
-	stats, err := exp.Run()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	stats, err := exp.RunContext(ctx)
+	stop()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "export failed: %v\n", err)
+		logger.Debug("export failed", "error", err)
+		closeLog()
+		if errors.Is(err, context.Canceled) {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 
-	fmt.Printf("exported %d notes, copied %d files\n", stats.Notes, stats.Files)
+	switch {
+	case opts.JSONStats:
+		if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
+			fmt.Fprintf(os.Stderr, "write stats: %v\n", err)
+			os.Exit(1)
+		}
+	case interactive:
+		output := opts.Output
+		if opts.OutputZip != "" {
+			output = opts.OutputZip
+		}
+		if err := runExportSummary(stats, output); err != nil {
+			fmt.Printf("exported %d notes, copied %d files\n", stats.Notes, stats.Files)
+		}
+	case strings.EqualFold(strings.TrimSpace(opts.Update), "frontmatter"):
+		fmt.Printf("updated frontmatter of %d notes\n", stats.Notes)
+	default:
+		fmt.Printf("exported %d notes, copied %d files\n", stats.Notes, stats.Files)
+		if stats.SkippedEmpty > 0 {
+			fmt.Printf("skipped %d empty notes\n", stats.SkippedEmpty)
+		}
+	}
+}
+
+func runVerifyVault(args []string) int {
+	fs := flag.NewFlagSet("verify-vault", flag.ExitOnError)
+	output := fs.String("output", defaultCLIOptions().Output, "Path to an existing exported Obsidian vault")
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		*output = fs.Arg(0)
+	}
+
+	problems, err := exporter.VerifyVault(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify failed: %v\n", err)
+		return 1
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+		}
+		fmt.Fprintf(os.Stderr, "vault %s has %d problems\n", *output, len(problems))
+		return 1
+	}
+
+	fmt.Printf("vault %s is consistent with its index\n", *output)
+	return 0
 }
 
 func defaultCLIOptions() cliOptions {
@@ -123,6 +392,73 @@ func defaultCLIOptions() cliOptions {
 		ExcludeProperties:         "",
 		IncludeProperties:         "",
 		LinkAsNoteProperties:      "",
+		ScaffoldEmptyNotes:        false,
+		TransliterateFilenames:    false,
+		WriteDefaultTemplateMap:   false,
+		VerifyLinks:               "off",
+		StrictRelations:           false,
+		ExportPeople:              false,
+		ColumnLayout:              "flatten",
+		LinkCards:                 false,
+		RelationBlocks:            "dataview",
+		JSONStats:                 false,
+		Verbose:                   false,
+		Quiet:                     false,
+		LogFile:                   "",
+		Atomic:                    false,
+		MergeStrategy:             "overwrite",
+		EmbedAnytypeMetadata:      false,
+		FilenameScheme:            "name",
+		MaxFilenameLength:         255,
+		ASCIIFilenames:            false,
+		EmojiInFilename:           false,
+		MaxImageDimension:         0,
+		ImageQuality:              exporter.DefaultImageQuality,
+		KeepOriginalImages:        false,
+		IconStyle:                 "property",
+		NoDynamicTimestamps:       false,
+		FailFast:                  false,
+		LowMemory:                 false,
+		Profile:                   false,
+		OutputZip:                 "",
+		NFCFilenames:              false,
+		PropertyOrder:             "",
+		PropertiesStyle:           "",
+		TagHierarchy:              "",
+		TagsFromProperties:        "",
+		NoMergeTags:               false,
+		KanbanBoards:              false,
+		SplitBySpace:              false,
+		MissingStubs:              false,
+		IncludeDeleted:            false,
+		IncludeSystemObjects:      false,
+		KeepSystemObjectTypes:     "",
+		TagColorsCSS:              false,
+		Format:                    "markdown",
+		HTMLEmbedImages:           false,
+		Flavor:                    "obsidian",
+		TypeAsTag:                 false,
+		ExportTablesDir:           "",
+		ExportTablesFormat:        "csv",
+		QueryTarget:               "bases",
+		Properties:                "all",
+		SkipEmptyNotes:            false,
+		ReportDuplicates:          false,
+		WebClippings:              false,
+		ExportVCards:              false,
+		ExportICS:                 false,
+		Protect:                   "",
+		PrettierCommand:           "",
+		PrettierConfig:            "",
+		PrettierTargets:           "",
+		Formatter:                 "",
+		Update:                    "",
+		GraphStats:                false,
+		TOCMode:                   "wikilink-anchors",
+		CalloutTypes:              "",
+		ToggleHeadings:            "heading",
+		ComplexTables:             "markdown",
+		MultiValueLists:           false,
 	}
 }
 
@@ -144,7 +480,7 @@ func runInteractiveOptions(defaults cliOptions) (cliOptions, error) {
 
 func newCLIModel(defaults cliOptions) *cliModel {
 	fields := []cliField{
-		{key: "input", label: "Input directory", description: "Path to Anytype JSON export folder.", value: defaults.Input},
+		{key: "input", label: "Input", description: "Path to Anytype JSON export folder or .zip archive.", value: defaults.Input},
 		{key: "output", label: "Output vault directory", description: "Path where the Obsidian vault will be written.", value: defaults.Output},
 		{key: "disableIconizeIcons", label: "Disable Iconize export", description: "Skip writing Iconize plugin data and generated Anytype icon pack files.", value: fmt.Sprintf("%t", defaults.DisableIconizeIcons)},
 		{key: "disablePrettyPropertyIcon", label: "Disable Pretty Properties icon conversion", description: "Keep Anytype iconImage/iconEmoji properties instead of exporting a single icon property.", value: fmt.Sprintf("%t", defaults.DisablePrettyPropertyIcon)},
@@ -159,6 +495,70 @@ func newCLIModel(defaults cliOptions) *cliModel {
 		{key: "excludeProperties", label: "Always exclude properties", description: "Comma-separated property keys or names to exclude.", value: defaults.ExcludeProperties},
 		{key: "includeProperties", label: "Always include properties", description: "Comma-separated property keys or names to force include.", value: defaults.IncludeProperties},
 		{key: "linkAsNoteProperties", label: "Link as notes properties", description: "Comma-separated relation keys to render as note links (e.g. type,tag,status).", value: defaults.LinkAsNoteProperties},
+		{key: "scaffoldEmptyNotes", label: "Scaffold empty notes", description: "Fill empty note bodies with a minimal structure based on the type recommended layout.", value: fmt.Sprintf("%t", defaults.ScaffoldEmptyNotes)},
+		{key: "transliterateFilenames", label: "Transliterate filenames", description: "Romanize non-Latin filenames while keeping the original title as an alias.", value: fmt.Sprintf("%t", defaults.TransliterateFilenames)},
+		{key: "writeDefaultTemplateMap", label: "Write default template map", description: "Write templates/README.md linking each type to its default template.", value: fmt.Sprintf("%t", defaults.WriteDefaultTemplateMap)},
+		{key: "verifyLinks", label: "Verify links", description: "Check that every wikilink and embed in the vault resolves: off, warn, or fail.", value: defaults.VerifyLinks},
+		{key: "strictRelations", label: "Strict relations", description: "Fail and list unresolved object relation targets instead of writing Unknown (id).", value: fmt.Sprintf("%t", defaults.StrictRelations)},
+		{key: "exportPeople", label: "Export people", description: "Write space members to people/ and link creator/assignee relations to them.", value: fmt.Sprintf("%t", defaults.ExportPeople)},
+		{key: "columnLayout", label: "Column layout", description: "Keep multi-column rows: flatten, html (div wrappers), or multi-column (Multi-Column Markdown plugin).", value: defaults.ColumnLayout},
+		{key: "linkCards", label: "Link cards", description: "Render Anytype card links as callouts with description and cover instead of a bare link.", value: fmt.Sprintf("%t", defaults.LinkCards)},
+		{key: "relationBlocks", label: "Relation blocks", description: "Render inline relation blocks as Dataview fields (dataview), bold label lines (line), or skip them.", value: defaults.RelationBlocks},
+		{key: "logFile", label: "Log file", description: "Optional path for a detailed debug log of the export; leave empty to skip it.", value: defaults.LogFile},
+		{key: "atomic", label: "Atomic export", description: "Render into a temp folder next to the vault and swap it into place only if the export succeeds.", value: fmt.Sprintf("%t", defaults.Atomic)},
+		{key: "mergeStrategy", label: "Merge strategy", description: "How to treat files already in the vault: overwrite, skip-existing, keep-newer, or suffix-conflicts.", value: defaults.MergeStrategy},
+		{key: "embedAnytypeMetadata", label: "Embed Anytype metadata", description: "Add an anytype: block with the object, space and type IDs and last modified date to each note.", value: fmt.Sprintf("%t", defaults.EmbedAnytypeMetadata)},
+		{key: "filenameScheme", label: "Filename scheme", description: "How to name note files: name, id, name-id-suffix, or zettel (created timestamp prefix).", value: defaults.FilenameScheme},
+		{key: "maxFilenameLength", label: "Max filename length", description: "Maximum filename length in bytes (at least 23, or -1 for no limit); longer names are truncated with a short hash.", value: strconv.Itoa(defaults.MaxFilenameLength)},
+		{key: "asciiFilenames", label: "ASCII filenames", description: "Write lowercase ASCII slug filenames and keep the original titles as aliases.", value: fmt.Sprintf("%t", defaults.ASCIIFilenames)},
+		{key: "emojiInFilename", label: "Emoji in filename", description: "Prefix note filenames with the object icon emoji.", value: fmt.Sprintf("%t", defaults.EmojiInFilename)},
+		{key: "maxImageDimension", label: "Max image dimension", description: "Downscale JPEG/PNG attachments larger than this many pixels on the longest side (0 disables resizing).", value: strconv.Itoa(defaults.MaxImageDimension)},
+		{key: "imageQuality", label: "Image quality", description: "JPEG quality from 1 to 100 used when re-encoding resized images.", value: strconv.Itoa(defaults.ImageQuality)},
+		{key: "keepOriginalImages", label: "Keep original images", description: "Keep the originals of resized images under files/originals/.", value: fmt.Sprintf("%t", defaults.KeepOriginalImages)},
+		{key: "iconStyle", label: "Icon style", description: "Where to put the emoji icon: property, heading, or filename.", value: defaults.IconStyle},
+		{key: "noDynamicTimestamps", label: "No dynamic timestamps", description: "Make repeated exports of the same input byte-identical.", value: fmt.Sprintf("%t", defaults.NoDynamicTimestamps)},
+		{key: "failFast", label: "Fail fast", description: "Abort on unreadable snapshots instead of skipping them.", value: fmt.Sprintf("%t", defaults.FailFast)},
+		{key: "lowMemory", label: "Low memory", description: "Re-read object bodies per note to cap memory use on huge exports.", value: fmt.Sprintf("%t", defaults.LowMemory)},
+		{key: "profile", label: "Profile", description: "Write pprof profiles and phase timings to _anytype/profile.", value: fmt.Sprintf("%t", defaults.Profile)},
+		{key: "outputZip", label: "Output zip", description: "Write the vault into this .zip archive instead of the output directory; leave empty to skip it.", value: defaults.OutputZip},
+		{key: "nfcFilenames", label: "NFC filenames", description: "Normalize filenames and links to Unicode NFC.", value: fmt.Sprintf("%t", defaults.NFCFilenames)},
+		{key: "propertyOrder", label: "Property order", description: "Comma-separated frontmatter keys to write first, where * stands for the remaining keys.", value: defaults.PropertyOrder},
+		{key: "propertiesStyle", label: "Properties style", description: "Where to write properties: frontmatter, inline (Dataview fields in the body), or both.", value: defaults.PropertiesStyle},
+		{key: "tagHierarchy", label: "Tag hierarchy", description: "Comma-separated relations whose options become nested tags (relation or relation=prefix).", value: defaults.TagHierarchy},
+		{key: "tagsFromProperties", label: "Tags from properties", description: "Comma-separated properties merged into tags; empty uses the tag relation.", value: defaults.TagsFromProperties},
+		{key: "noMergeTags", label: "No merge tags", description: "Keep tag relations as their own properties instead of merging them into tags.", value: fmt.Sprintf("%t", defaults.NoMergeTags)},
+		{key: "kanbanBoards", label: "Kanban boards", description: "Write Board views as Kanban plugin boards.", value: fmt.Sprintf("%t", defaults.KanbanBoards)},
+		{key: "splitBySpace", label: "Split by space", description: "Write one vault per space into subfolders of the output directory.", value: fmt.Sprintf("%t", defaults.SplitBySpace)},
+		{key: "missingStubs", label: "Missing object stubs", description: "Write stub notes for links to objects missing from the export.", value: fmt.Sprintf("%t", defaults.MissingStubs)},
+		{key: "includeDeleted", label: "Include deleted objects", description: "Recover deleted objects into trash/.", value: fmt.Sprintf("%t", defaults.IncludeDeleted)},
+		{key: "includeSystemObjects", label: "Include system objects", description: "Export dashboards, the workspace, widgets and the profile as notes.", value: fmt.Sprintf("%t", defaults.IncludeSystemObjects)},
+		{key: "keepSystemObjectTypes", label: "Keep system object kinds", description: "Comma-separated system object kinds to export even without system objects.", value: defaults.KeepSystemObjectTypes},
+		{key: "tagColorsCSS", label: "Tag colors CSS snippet", description: "Color tags natively with the .obsidian/snippets/anytype-tag-colors.css snippet.", value: fmt.Sprintf("%t", defaults.TagColorsCSS)},
+		{key: "format", label: "Format", description: "Output format: markdown, html, or both (HTML pages next to the notes).", value: defaults.Format},
+		{key: "htmlEmbedImages", label: "Embed HTML images", description: "Inline images into HTML pages as data URIs.", value: fmt.Sprintf("%t", defaults.HTMLEmbedImages)},
+		{key: "flavor", label: "Flavor", description: "Output flavor: obsidian, logseq for a Logseq graph, or portable for plain CommonMark.", value: defaults.Flavor},
+		{key: "typeAsTag", label: "Type as tag", description: "Tag each note with type/<Anytype type name>.", value: fmt.Sprintf("%t", defaults.TypeAsTag)},
+		{key: "exportTablesDir", label: "Export tables dir", description: "Also write sets and collections as CSV/JSON tables into this directory; leave empty to skip it.", value: defaults.ExportTablesDir},
+		{key: "exportTablesFormat", label: "Export tables format", description: "File format of exported tables: csv or json.", value: defaults.ExportTablesFormat},
+		{key: "queryTarget", label: "Query target", description: "Where sets and collections go: bases, or projects for the Projects plugin.", value: defaults.QueryTarget},
+		{key: "properties", label: "Properties", description: "Which properties to write: all, featured, or featured-only.", value: defaults.Properties},
+		{key: "skipEmptyNotes", label: "Skip empty notes", description: "Leave out objects without body text or visible properties.", value: fmt.Sprintf("%t", defaults.SkipEmptyNotes)},
+		{key: "reportDuplicates", label: "Report duplicates", description: "Write duplicates.md with notes sharing a name and merge candidates.", value: fmt.Sprintf("%t", defaults.ReportDuplicates)},
+		{key: "webClippings", label: "Web clippings", description: "Lay out Bookmark objects like Obsidian Web Clipper notes.", value: fmt.Sprintf("%t", defaults.WebClippings)},
+		{key: "exportVCards", label: "Export vCards", description: "Write contacts/<name>.vcf for people with an email or phone.", value: fmt.Sprintf("%t", defaults.ExportVCards)},
+		{key: "exportICS", label: "Export ICS", description: "Write anytype-events.ics with the notes' due and event dates.", value: fmt.Sprintf("%t", defaults.ExportICS)},
+		{key: "protect", label: "Protect", description: "Comma-separated vault path globs the export never writes, such as Daily/**,Inbox.md.", value: defaults.Protect},
+		{key: "prettierCommand", label: "Prettier command", description: "Formatter command line to run instead of npx prettier.", value: defaults.PrettierCommand},
+		{key: "prettierConfig", label: "Prettier config", description: "Prettier config to use: empty ignores config files, auto finds them, or a config file path.", value: defaults.PrettierConfig},
+		{key: "prettierTargets", label: "Prettier targets", description: "Comma-separated vault folders or globs to format.", value: defaults.PrettierTargets},
+		{key: "formatter", label: "Formatter", description: "Markdown formatter: prettier, builtin (offline, no Node), or none; empty follows Run Prettier.", value: defaults.Formatter},
+		{key: "update", label: "Update", description: "What to update: all, or frontmatter to refresh only the properties of exported notes.", value: defaults.Update},
+		{key: "graphStats", label: "Graph stats", description: "Write graph-stats.md to audit links, orphans and types.", value: fmt.Sprintf("%t", defaults.GraphStats)},
+		{key: "tocMode", label: "TOC mode", description: "How to write tables of contents: wikilink-anchors, links, or skip (use the outline pane).", value: defaults.TOCMode},
+		{key: "calloutTypes", label: "Callout types", description: "Comma-separated icon=type pairs added to the callout icon mapping.", value: defaults.CalloutTypes},
+		{key: "toggleHeadings", label: "Toggle headings", description: "How to write toggle headings: heading, or callout to fold their content.", value: defaults.ToggleHeadings},
+		{key: "complexTables", label: "Complex tables", description: "How to write complex tables: markdown, or html for tables markdown would flatten.", value: defaults.ComplexTables},
+		{key: "multiValueLists", label: "Multi-value lists", description: "Write multi-value relations as lists even when they hold one value.", value: fmt.Sprintf("%t", defaults.MultiValueLists)},
 	}
 
 	inputs := make([]textinput.Model, len(fields))
@@ -350,6 +750,274 @@ func (m *cliModel) resolveOptions() (cliOptions, error) {
 			opts.IncludeProperties = value
 		case "linkAsNoteProperties":
 			opts.LinkAsNoteProperties = value
+		case "scaffoldEmptyNotes":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field scaffold-empty-notes: %w", err)
+			}
+			opts.ScaffoldEmptyNotes = parsed
+		case "transliterateFilenames":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field transliterate-filenames: %w", err)
+			}
+			opts.TransliterateFilenames = parsed
+		case "writeDefaultTemplateMap":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field default-template-map: %w", err)
+			}
+			opts.WriteDefaultTemplateMap = parsed
+		case "verifyLinks":
+			opts.VerifyLinks = value
+		case "strictRelations":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field strict-relations: %w", err)
+			}
+			opts.StrictRelations = parsed
+		case "exportPeople":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field export-people: %w", err)
+			}
+			opts.ExportPeople = parsed
+		case "columnLayout":
+			opts.ColumnLayout = value
+		case "linkCards":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field link-cards: %w", err)
+			}
+			opts.LinkCards = parsed
+		case "relationBlocks":
+			opts.RelationBlocks = value
+		case "logFile":
+			opts.LogFile = value
+		case "atomic":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field atomic: %w", err)
+			}
+			opts.Atomic = parsed
+		case "mergeStrategy":
+			opts.MergeStrategy = value
+		case "embedAnytypeMetadata":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field embed-anytype-metadata: %w", err)
+			}
+			opts.EmbedAnytypeMetadata = parsed
+		case "filenameScheme":
+			opts.FilenameScheme = value
+		case "maxFilenameLength":
+			parsed, err := parseInteractiveInt(value)
+			if err != nil {
+				return opts, fmt.Errorf("field max-filename-length: %w", err)
+			}
+			opts.MaxFilenameLength = parsed
+		case "asciiFilenames":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field ascii-filenames: %w", err)
+			}
+			opts.ASCIIFilenames = parsed
+		case "emojiInFilename":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field emoji-in-filename: %w", err)
+			}
+			opts.EmojiInFilename = parsed
+		case "maxImageDimension":
+			parsed, err := parseInteractiveInt(value)
+			if err != nil {
+				return opts, fmt.Errorf("field max-image-dimension: %w", err)
+			}
+			opts.MaxImageDimension = parsed
+		case "imageQuality":
+			parsed, err := parseInteractiveInt(value)
+			if err != nil {
+				return opts, fmt.Errorf("field image-quality: %w", err)
+			}
+			opts.ImageQuality = parsed
+		case "keepOriginalImages":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field keep-original-images: %w", err)
+			}
+			opts.KeepOriginalImages = parsed
+		case "iconStyle":
+			opts.IconStyle = value
+		case "noDynamicTimestamps":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field no-dynamic-timestamps: %w", err)
+			}
+			opts.NoDynamicTimestamps = parsed
+		case "failFast":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field fail-fast: %w", err)
+			}
+			opts.FailFast = parsed
+		case "lowMemory":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field low-memory: %w", err)
+			}
+			opts.LowMemory = parsed
+		case "profile":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field profile: %w", err)
+			}
+			opts.Profile = parsed
+		case "outputZip":
+			opts.OutputZip = value
+		case "nfcFilenames":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field nfc-filenames: %w", err)
+			}
+			opts.NFCFilenames = parsed
+		case "propertyOrder":
+			opts.PropertyOrder = value
+		case "propertiesStyle":
+			opts.PropertiesStyle = value
+		case "tagHierarchy":
+			opts.TagHierarchy = value
+		case "tagsFromProperties":
+			opts.TagsFromProperties = value
+		case "noMergeTags":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field no-merge-tags: %w", err)
+			}
+			opts.NoMergeTags = parsed
+		case "kanbanBoards":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field kanban-boards: %w", err)
+			}
+			opts.KanbanBoards = parsed
+		case "splitBySpace":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field split-by-space: %w", err)
+			}
+			opts.SplitBySpace = parsed
+		case "missingStubs":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field missing-stubs: %w", err)
+			}
+			opts.MissingStubs = parsed
+		case "includeDeleted":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field include-deleted: %w", err)
+			}
+			opts.IncludeDeleted = parsed
+		case "includeSystemObjects":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field include-system-objects: %w", err)
+			}
+			opts.IncludeSystemObjects = parsed
+		case "keepSystemObjectTypes":
+			opts.KeepSystemObjectTypes = value
+		case "tagColorsCSS":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field tag-colors-css: %w", err)
+			}
+			opts.TagColorsCSS = parsed
+		case "format":
+			opts.Format = value
+		case "htmlEmbedImages":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field html-embed-images: %w", err)
+			}
+			opts.HTMLEmbedImages = parsed
+		case "flavor":
+			opts.Flavor = value
+		case "typeAsTag":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field type-as-tag: %w", err)
+			}
+			opts.TypeAsTag = parsed
+		case "exportTablesDir":
+			opts.ExportTablesDir = value
+		case "exportTablesFormat":
+			opts.ExportTablesFormat = value
+		case "queryTarget":
+			opts.QueryTarget = value
+		case "properties":
+			opts.Properties = value
+		case "skipEmptyNotes":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field skip-empty-notes: %w", err)
+			}
+			opts.SkipEmptyNotes = parsed
+		case "reportDuplicates":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field report-duplicates: %w", err)
+			}
+			opts.ReportDuplicates = parsed
+		case "webClippings":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field web-clippings: %w", err)
+			}
+			opts.WebClippings = parsed
+		case "exportVCards":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field export-vcards: %w", err)
+			}
+			opts.ExportVCards = parsed
+		case "exportICS":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field export-ics: %w", err)
+			}
+			opts.ExportICS = parsed
+		case "protect":
+			opts.Protect = value
+		case "prettierCommand":
+			opts.PrettierCommand = value
+		case "prettierConfig":
+			opts.PrettierConfig = value
+		case "prettierTargets":
+			opts.PrettierTargets = value
+		case "formatter":
+			opts.Formatter = value
+		case "update":
+			opts.Update = value
+		case "graphStats":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field graph-stats: %w", err)
+			}
+			opts.GraphStats = parsed
+		case "tocMode":
+			opts.TOCMode = value
+		case "calloutTypes":
+			opts.CalloutTypes = value
+		case "toggleHeadings":
+			opts.ToggleHeadings = value
+		case "complexTables":
+			opts.ComplexTables = value
+		case "multiValueLists":
+			parsed, err := parseInteractiveBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("field multi-value-lists: %w", err)
+			}
+			opts.MultiValueLists = parsed
 		}
 	}
 
@@ -367,6 +1035,14 @@ func parseInteractiveBool(value string) (bool, error) {
 	}
 }
 
+func parseInteractiveInt(value string) (int, error) {
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("expected integer value, got %q", value)
+	}
+	return parsed, nil
+}
+
 func parseCommaSeparatedList(value string) []string {
 	if strings.TrimSpace(value) == "" {
 		return nil
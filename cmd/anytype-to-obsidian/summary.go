@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sleroq/anytype-to-obsidian/internal/app/exporter"
+)
+
+type summaryModel struct {
+	stats     exporter.Stats
+	outputDir string
+	status    string
+}
+
+func runExportSummary(stats exporter.Stats, outputDir string) error {
+	_, err := tea.NewProgram(&summaryModel{stats: stats, outputDir: outputDir}).Run()
+	return err
+}
+
+func (m *summaryModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *summaryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc", "q", "enter":
+			return m, tea.Quit
+		case "o":
+			if m.stats.ReportPath == "" {
+				m.status = "no report was written"
+				return m, nil
+			}
+			if err := openInSystemViewer(m.stats.ReportPath); err != nil {
+				m.status = fmt.Sprintf("failed to open report: %v", err)
+			} else {
+				m.status = "opened " + m.stats.ReportPath
+			}
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *summaryModel) View() string {
+	var b strings.Builder
+	b.WriteString(focusedStyle.Render("Export finished") + "\n\n")
+	fmt.Fprintf(&b, "Output:     %s\n", m.outputDir)
+	fmt.Fprintf(&b, "Notes:      %d\n", m.stats.Notes)
+	fmt.Fprintf(&b, "Bases:      %d\n", m.stats.Bases)
+	fmt.Fprintf(&b, "Templates:  %d\n", m.stats.Templates)
+	fmt.Fprintf(&b, "Files:      %d\n", m.stats.Files)
+	if m.stats.SkippedEmpty > 0 {
+		fmt.Fprintf(&b, "Skipped:    %d empty\n", m.stats.SkippedEmpty)
+	}
+	warnings := fmt.Sprintf("%d", m.stats.Warnings)
+	if m.stats.Warnings > 0 {
+		warnings = focusedStyle.Render(warnings)
+	}
+	fmt.Fprintf(&b, "Warnings:   %s\n", warnings)
+	fmt.Fprintf(&b, "Elapsed:    %s\n\n", m.stats.Elapsed.Round(10*time.Millisecond))
+	if m.status != "" {
+		b.WriteString(blurredStyle.Render(m.status) + "\n\n")
+	}
+	b.WriteString(blurredStyle.Render("o: open report, q/Enter: quit") + "\n")
+	return b.String()
+}
+
+func openInSystemViewer(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,114 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sleroq/anytype-to-obsidian/internal/infra/exportfs"
+)
+
+// runAtomic renders the vault into a fresh sibling directory and only
+// replaces the output directory once the export succeeded, so a failed run
+// never leaves a mixed vault behind. Everything in the previous vault that
+// the exporter did not write (per its _anytype/index.json), such as
+// .obsidian, .git or notes kept by hand, seeds the new vault so it survives
+// the swap. Only directories this run created are ever removed.
+func (e Exporter) runAtomic(ctx context.Context) (Stats, error) {
+	finalDir := filepath.Clean(e.OutputDir)
+	parent, base := filepath.Dir(finalDir), filepath.Base(finalDir)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return Stats{}, err
+	}
+	tmpDir, err := os.MkdirTemp(parent, base+".tmp-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+
+	owned, err := exportedPaths(finalDir)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return Stats{}, err
+	}
+	err = exportfs.CopyTree(finalDir, tmpDir, func(rel string) bool {
+		if rel == "_anytype" {
+			return true
+		}
+		_, ok := owned[rel]
+		return ok
+	})
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return Stats{}, fmt.Errorf("seed temp vault: %w", err)
+	}
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.Atomic = false
+	stats, err := staged.RunContext(ctx)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return Stats{}, err
+	}
+
+	oldDir := ""
+	if _, err := os.Stat(finalDir); err == nil {
+		holder, err := os.MkdirTemp(parent, base+".old-*")
+		if err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return Stats{}, fmt.Errorf("move previous vault aside: %w", err)
+		}
+		oldDir = filepath.Join(holder, base)
+		if err := os.Rename(finalDir, oldDir); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			_ = os.Remove(holder)
+			return Stats{}, fmt.Errorf("move previous vault aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmpDir, finalDir); err != nil {
+		if oldDir != "" {
+			_ = os.Rename(oldDir, finalDir)
+			_ = os.Remove(filepath.Dir(oldDir))
+		}
+		return Stats{}, fmt.Errorf("move new vault into place: %w", err)
+	}
+	if oldDir != "" {
+		if err := os.RemoveAll(filepath.Dir(oldDir)); err != nil {
+			return Stats{}, fmt.Errorf("remove previous vault: %w", err)
+		}
+	}
+
+	if stats.ReportPath != "" {
+		if rel, err := filepath.Rel(tmpDir, stats.ReportPath); err == nil {
+			stats.ReportPath = filepath.Join(finalDir, rel)
+		}
+	}
+	return stats, nil
+}
+
+// exportedPaths returns the vault-relative paths the previous export
+// recorded in _anytype/index.json. A vault without an index has none.
+func exportedPaths(vaultDir string) (map[string]struct{}, error) {
+	owned := map[string]struct{}{}
+	raw, err := os.ReadFile(filepath.Join(vaultDir, "_anytype", "index.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return owned, nil
+		}
+		return nil, fmt.Errorf("read previous index: %w", err)
+	}
+	var idx indexFile
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, fmt.Errorf("decode previous index: %w", err)
+	}
+	for _, relPath := range idx.Notes {
+		owned[strings.TrimPrefix(filepath.ToSlash(relPath), "./")] = struct{}{}
+	}
+	for relPath := range idx.Files {
+		owned[strings.TrimPrefix(filepath.ToSlash(relPath), "./")] = struct{}{}
+	}
+	return owned, nil
+}
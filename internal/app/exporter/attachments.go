@@ -0,0 +1,145 @@
+package exporter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type attachmentRename struct {
+	from string
+	to   string
+}
+
+// escapeAttachmentNames renames the copies of input attachments under
+// files/ whose names the escaping mode forbids (e.g. "?", ":" or "*" on
+// Windows) or that exceed the filename length limit, applying the same rules
+// as note filenames. Collisions are resolved among the input files only, so
+// re-exporting into the same vault replaces the previous escaped copies.
+// Files on or renamed onto a protected path keep their name, and
+// attachments that were not copied are renamed in fileObjects alone.
+// fileObjects is updated so every file link, cover and icon follows the new
+// name. It returns the renames in path order.
+func escapeAttachmentNames(input fs.FS, outputDir string, fileObjects map[string]string, naming filenameOptions, protect []string) ([]attachmentRename, error) {
+	var relPaths []string
+	err := fs.WalkDir(input, "files", func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) && p == "files" {
+				return fs.SkipDir
+			}
+			return walkErr
+		}
+		if d.Type().IsRegular() {
+			relPaths = append(relPaths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	var pending []string
+	for _, rel := range relPaths {
+		if escapeAttachmentPath(rel, naming) == rel || matchVaultGlobs(rel, protect) {
+			used[naming.collisionKey(rel)] = true
+			continue
+		}
+		pending = append(pending, rel)
+	}
+
+	var renames []attachmentRename
+	renamed := map[string]string{}
+	for _, rel := range pending {
+		target := escapeAttachmentPath(rel, naming)
+		ext := path.Ext(target)
+		stem := strings.TrimSuffix(target, ext)
+		for n := 2; used[naming.collisionKey(target)]; n++ {
+			target = stem + "-" + strconv.Itoa(n) + ext
+		}
+		if matchVaultGlobs(target, protect) {
+			continue
+		}
+		used[naming.collisionKey(target)] = true
+
+		sourceAbs := filepath.Join(outputDir, filepath.FromSlash(rel))
+		targetAbs := filepath.Join(outputDir, filepath.FromSlash(target))
+		if _, err := os.Stat(sourceAbs); err == nil {
+			if err := os.MkdirAll(filepath.Dir(targetAbs), 0o755); err != nil {
+				return nil, err
+			}
+			if err := os.Rename(sourceAbs, targetAbs); err != nil {
+				return nil, err
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		renamed[rel] = target
+		renames = append(renames, attachmentRename{from: rel, to: target})
+	}
+	removeEmptyAttachmentDirs(outputDir, pending)
+
+	for objectID, relPath := range fileObjects {
+		if target, ok := renamed[path.Clean(filepath.ToSlash(strings.TrimSpace(relPath)))]; ok {
+			fileObjects[objectID] = target
+		}
+	}
+	return renames, nil
+}
+
+// escapeAttachmentPath escapes (and, with nfc, normalizes) every segment of
+// a files/ path below the top-level folder, keeping extensions intact.
+func escapeAttachmentPath(rel string, naming filenameOptions) string {
+	segments := strings.Split(rel, "/")
+	for i := 1; i < len(segments); i++ {
+		segment := segments[i]
+		if naming.nfc {
+			segment = norm.NFC.String(segment)
+		}
+		ext := ""
+		if i == len(segments)-1 {
+			ext = path.Ext(segment)
+		}
+		base := strings.TrimSuffix(segment, ext)
+		if strings.TrimSpace(base) == "" {
+			continue
+		}
+		ext = strings.Map(func(r rune) rune {
+			if isForbiddenFileNameRune(r, naming.escaping) {
+				return '-'
+			}
+			return r
+		}, ext)
+		if naming.escaping == "windows" {
+			ext = strings.TrimRight(ext, ". ")
+		}
+		segments[i] = naming.fit(sanitizeName(base, naming.escaping), ext) + ext
+	}
+	return strings.Join(segments, "/")
+}
+
+// removeEmptyAttachmentDirs drops folders left empty after their files moved
+// to an escaped folder name, deepest first.
+func removeEmptyAttachmentDirs(outputDir string, moved []string) {
+	dirs := map[string]bool{}
+	for _, rel := range moved {
+		for dir := path.Dir(rel); dir != "files" && dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	ordered := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		ordered = append(ordered, dir)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	for _, dir := range ordered {
+		_ = os.Remove(filepath.Join(outputDir, filepath.FromSlash(dir)))
+	}
+}
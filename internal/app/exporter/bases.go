@@ -21,6 +21,16 @@ type baseViewSpec struct {
 	Select         []string
 	Sort           []baseSortSpec
 	LocalCardOrder string
+	ColumnSize     []baseColumnSize
+	Summaries      []baseSummary
+	CardSize       int
+	Image          string
+	ImageFit       string
+}
+
+type baseColumnSize struct {
+	Property string
+	Width    int
 }
 
 type baseGroupSpec struct {
@@ -46,7 +56,7 @@ type baseFilterNode struct {
 var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 var basePlainScalarPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(?: [A-Za-z0-9_.-]+)*$`)
 
-func renderBaseFile(obj objectInfo, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, pictureToCover bool, enableBasesKanban bool) (string, bool) {
+func renderBaseFile(obj objectInfo, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys, enableBasesKanban bool, dateAnchor time.Time) (string, bool) {
 	var views []baseViewSpec
 	for _, b := range obj.Blocks {
 		if len(b.Dataview) == 0 {
@@ -56,7 +66,7 @@ func renderBaseFile(obj objectInfo, relations map[string]relationDef, optionName
 		if targetID != "" && targetID != obj.ID {
 			continue
 		}
-		parsed := parseDataviewViews(b.Dataview, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover, enableBasesKanban)
+		parsed := parseDataviewViews(b.Dataview, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, enableBasesKanban, dateAnchor)
 		views = append(views, parsed...)
 	}
 	if len(views) == 0 {
@@ -72,7 +82,7 @@ func renderBaseFile(obj objectInfo, relations map[string]relationDef, optionName
 		}
 	}
 
-	if setOfFilter := buildSetOfTypeFilter(obj, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover); setOfFilter != nil {
+	if setOfFilter := buildSetOfTypeFilter(obj, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys); setOfFilter != nil {
 		for i := range views {
 			views[i].Filters = andBaseFilters(views[i].Filters, setOfFilter)
 		}
@@ -120,6 +130,41 @@ func renderBaseFile(obj objectInfo, relations map[string]relationDef, optionName
 				buf.WriteString("\n")
 			}
 		}
+		if len(v.ColumnSize) > 0 {
+			buf.WriteString("    columnSize:\n")
+			for _, c := range v.ColumnSize {
+				buf.WriteString("      ")
+				writeBaseYAMLScalar(&buf, c.Property)
+				buf.WriteString(": ")
+				buf.WriteString(strconv.Itoa(c.Width))
+				buf.WriteString("\n")
+			}
+		}
+		if len(v.Summaries) > 0 {
+			buf.WriteString("    summaries:\n")
+			for _, summary := range v.Summaries {
+				buf.WriteString("      ")
+				writeBaseYAMLScalar(&buf, summary.Property)
+				buf.WriteString(": ")
+				writeBaseYAMLScalar(&buf, summary.Summary)
+				buf.WriteString("\n")
+			}
+		}
+		if v.CardSize > 0 {
+			buf.WriteString("    cardSize: ")
+			buf.WriteString(strconv.Itoa(v.CardSize))
+			buf.WriteString("\n")
+		}
+		if v.Image != "" {
+			buf.WriteString("    image: ")
+			writeBaseYAMLScalar(&buf, v.Image)
+			buf.WriteString("\n")
+		}
+		if v.ImageFit != "" {
+			buf.WriteString("    imageFit: ")
+			writeBaseYAMLScalar(&buf, v.ImageFit)
+			buf.WriteString("\n")
+		}
 		if len(v.Sort) > 0 {
 			buf.WriteString("    sort:\n")
 			for _, s := range v.Sort {
@@ -185,13 +230,13 @@ func normalizeBaseFiltersRoot(node *baseFilterNode) *baseFilterNode {
 	return &baseFilterNode{Op: "and", Items: []baseFilterNode{*node}}
 }
 
-func buildSetOfTypeFilter(obj objectInfo, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, pictureToCover bool) *baseFilterNode {
+func buildSetOfTypeFilter(obj objectInfo, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys) *baseFilterNode {
 	setOfIDs := anyToStringSlice(obj.Details["setOf"])
 	if len(setOfIDs) == 0 {
 		return nil
 	}
 
-	prop := baseFilterPropertyPath("type", relations, pictureToCover)
+	prop := baseFilterPropertyPath("type", relations, keys)
 	if prop == "" {
 		return nil
 	}
@@ -204,7 +249,7 @@ func buildSetOfTypeFilter(obj objectInfo, relations map[string]relationDef, opti
 	return &baseFilterNode{Expr: buildContainsAnyExpression(prop, values)}
 }
 
-func parseDataviewViews(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, pictureToCover bool, enableBasesKanban bool) []baseViewSpec {
+func parseDataviewViews(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys, enableBasesKanban bool, dateAnchor time.Time) []baseViewSpec {
 	var localCardOrderByView map[string]string
 	if enableBasesKanban {
 		localCardOrderByView = parseDataviewLocalCardOrder(raw, relations, optionNamesByID, notes, objectNamesByID, fileObjects)
@@ -264,7 +309,7 @@ func parseDataviewViews(raw map[string]any, relations map[string]relationDef, op
 				continue
 			}
 			relationKey := asString(anyMapGet(relationMap, "key", "Key"))
-			property := baseViewPropertyPath(relationKey, relations, pictureToCover)
+			property := baseViewPropertyPath(relationKey, relations, keys)
 			if property == "" {
 				continue
 			}
@@ -273,6 +318,21 @@ func parseDataviewViews(raw map[string]any, relations map[string]relationDef, op
 			}
 			selectedSeen[property] = struct{}{}
 			view.Select = append(view.Select, property)
+			if width := asInt(anyMapGet(relationMap, "width", "Width")); width > 0 && viewType == "table" {
+				view.ColumnSize = append(view.ColumnSize, baseColumnSize{Property: baseViewOptionProperty(property), Width: width})
+			}
+			if summary, ok := baseSummaries[viewFormulaType(relationMap)]; ok {
+				view.Summaries = append(view.Summaries, baseSummary{Property: baseViewOptionProperty(property), Summary: summary})
+			}
+		}
+		if viewType == "cards" {
+			if raw := anyMapGet(viewMap, "cardSize", "CardSize"); raw != nil {
+				view.CardSize = baseCardSize(raw)
+			}
+			view.Image = baseCardImageProperty(asString(anyMapGet(viewMap, "coverRelationKey", "CoverRelationKey")), relations, keys)
+			if asBool(anyMapGet(viewMap, "coverFit", "CoverFit")) {
+				view.ImageFit = "contain"
+			}
 		}
 
 		sortsRaw := asAnySlice(anyMapGet(viewMap, "sorts", "Sorts"))
@@ -284,7 +344,7 @@ func parseDataviewViews(raw map[string]any, relations map[string]relationDef, op
 				continue
 			}
 			relationKey := asString(anyMapGet(sortMap, "RelationKey", "relationKey"))
-			property := baseViewPropertyPath(relationKey, relations, pictureToCover)
+			property := baseViewPropertyPath(relationKey, relations, keys)
 			if property == "" {
 				continue
 			}
@@ -311,7 +371,7 @@ func parseDataviewViews(raw map[string]any, relations map[string]relationDef, op
 			if len(view.Sort) > 0 && strings.TrimSpace(view.Sort[0].Direction) != "" {
 				direction = view.Sort[0].Direction
 			}
-			view.GroupBy = &baseGroupSpec{Property: baseViewPropertyPath(groupKey, relations, pictureToCover), Direction: direction}
+			view.GroupBy = &baseGroupSpec{Property: baseViewPropertyPath(groupKey, relations, keys), Direction: direction}
 		}
 
 		filterNodes := make([]baseFilterNode, 0)
@@ -320,7 +380,7 @@ func parseDataviewViews(raw map[string]any, relations map[string]relationDef, op
 			if !ok {
 				continue
 			}
-			if node, ok := convertAnytypeFilterNode(filterMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover); ok {
+			if node, ok := convertAnytypeFilterNode(filterMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, dateAnchor); ok {
 				filterNodes = append(filterNodes, node)
 			}
 		}
@@ -500,7 +560,7 @@ func writeBaseFilterNode(buf *bytes.Buffer, node baseFilterNode, indent int) {
 	}
 }
 
-func convertAnytypeFilterNode(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, pictureToCover bool) (baseFilterNode, bool) {
+func convertAnytypeFilterNode(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys, dateAnchor time.Time) (baseFilterNode, bool) {
 	op := strings.TrimSpace(strings.ToLower(asString(anyMapGet(raw, "operator", "Operator"))))
 	nestedRaw := asAnySlice(anyMapGet(raw, "nestedFilters", "NestedFilters"))
 	if op == "and" || op == "or" {
@@ -510,7 +570,7 @@ func convertAnytypeFilterNode(raw map[string]any, relations map[string]relationD
 			if !ok {
 				continue
 			}
-			if node, ok := convertAnytypeFilterNode(nestedMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover); ok {
+			if node, ok := convertAnytypeFilterNode(nestedMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, dateAnchor); ok {
 				items = append(items, node)
 			}
 		}
@@ -526,7 +586,7 @@ func convertAnytypeFilterNode(raw map[string]any, relations map[string]relationD
 			if !ok {
 				continue
 			}
-			if node, ok := convertAnytypeFilterNode(nestedMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover); ok {
+			if node, ok := convertAnytypeFilterNode(nestedMap, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, dateAnchor); ok {
 				items = append(items, node)
 			}
 		}
@@ -538,14 +598,14 @@ func convertAnytypeFilterNode(raw map[string]any, relations map[string]relationD
 		}
 	}
 
-	expr := buildFilterExpression(raw, relations, optionNamesByID, notes, objectNamesByID, fileObjects, pictureToCover)
+	expr := buildFilterExpression(raw, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, dateAnchor)
 	if strings.TrimSpace(expr) == "" {
 		return baseFilterNode{}, false
 	}
 	return baseFilterNode{Expr: expr}, true
 }
 
-func buildFilterExpression(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, pictureToCover bool) string {
+func buildFilterExpression(raw map[string]any, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys, dateAnchor time.Time) string {
 	relationKey := strings.TrimSpace(asString(anyMapGet(raw, "RelationKey", "relationKey")))
 	if relationKey == "" {
 		return ""
@@ -554,7 +614,7 @@ func buildFilterExpression(raw map[string]any, relations map[string]relationDef,
 	if condition == "" {
 		return ""
 	}
-	prop := baseFilterPropertyPath(relationKey, relations, pictureToCover)
+	prop := baseFilterPropertyPath(relationKey, relations, keys)
 	if prop == "" {
 		return ""
 	}
@@ -563,7 +623,10 @@ func buildFilterExpression(raw map[string]any, relations map[string]relationDef,
 	includeTime := asBool(anyMapGet(raw, "includeTime", "IncludeTime"))
 	quickOption := strings.TrimSpace(asString(anyMapGet(raw, "quickOption", "QuickOption")))
 	if isDateCondition(relationKey, raw, relations) && (quickOption != "" || !includeTime) {
-		condition, value = normalizeDateFilterCondition(condition, value, quickOption, includeTime)
+		if dateAnchor.IsZero() && isRelativeDateQuickOption(quickOption) {
+			return ""
+		}
+		condition, value = normalizeDateFilterCondition(condition, value, quickOption, includeTime, dateAnchor)
 	}
 
 	mapped := convertPropertyValue(relationKey, value, relations, optionNamesByID, notes, "", objectNamesByID, fileObjects, false, false)
@@ -641,11 +704,13 @@ func buildFilterExpression(raw map[string]any, relations map[string]relationDef,
 	}
 }
 
-func normalizeDateFilterCondition(condition string, value any, quickOption string, includeTime bool) (string, any) {
+// normalizeDateFilterCondition resolves relative quick options ("Today",
+// "LastWeek", ...) against now into concrete date bounds.
+func normalizeDateFilterCondition(condition string, value any, quickOption string, includeTime bool, now time.Time) (string, any) {
 	if strings.TrimSpace(quickOption) == "" && includeTime {
 		return condition, value
 	}
-	from, to := dateRangeFromQuickOption(quickOption, value, time.Now())
+	from, to := dateRangeFromQuickOption(quickOption, value, now)
 	switch condition {
 	case "Equal", "In":
 		return "AndRange", []any{from.Unix(), to.Unix()}
@@ -662,6 +727,17 @@ func normalizeDateFilterCondition(condition string, value any, quickOption strin
 	}
 }
 
+// isRelativeDateQuickOption reports whether the quick option depends on the
+// current date rather than on the filter's own value.
+func isRelativeDateQuickOption(quickOption string) bool {
+	switch strings.TrimSpace(quickOption) {
+	case "", "ExactDate":
+		return false
+	default:
+		return true
+	}
+}
+
 func dateRangeFromQuickOption(quickOption string, value any, now time.Time) (time.Time, time.Time) {
 	startOfDay := func(t time.Time) time.Time {
 		y, m, d := t.Date()
@@ -885,7 +961,7 @@ func mappedToString(value any) string {
 	}
 }
 
-func baseViewPropertyPath(rawKey string, relations map[string]relationDef, pictureToCover bool) string {
+func baseViewPropertyPath(rawKey string, relations map[string]relationDef, keys propertyKeys) string {
 	rawKey = strings.TrimSpace(rawKey)
 	if rawKey == "" {
 		return ""
@@ -899,15 +975,57 @@ func baseViewPropertyPath(rawKey string, relations map[string]relationDef, pictu
 		return "file.mtime"
 	}
 	rel, hasRel := relations[rawKey]
-	frontKey := frontmatterKey(rawKey, rel, hasRel, pictureToCover)
+	frontKey := frontmatterKey(rawKey, rel, hasRel, keys)
 	if frontKey == "" {
 		frontKey = rawKey
 	}
 	return frontKey
 }
 
-func baseFilterPropertyPath(rawKey string, relations map[string]relationDef, pictureToCover bool) string {
-	frontKey := baseViewPropertyPath(rawKey, relations, pictureToCover)
+// baseViewOptionProperty names a property the way Bases view options such
+// as columnSize and image do: file.* as is, frontmatter properties below
+// note.
+func baseViewOptionProperty(property string) string {
+	if strings.HasPrefix(property, "file.") {
+		return property
+	}
+	return "note." + property
+}
+
+// baseCardImageProperty names the property a cards view takes its images
+// from. Anytype's "pageCover" is the object cover, exported as banner.
+func baseCardImageProperty(coverKey string, relations map[string]relationDef, keys propertyKeys) string {
+	coverKey = strings.TrimSpace(coverKey)
+	switch coverKey {
+	case "", "none":
+		return ""
+	case "pageCover":
+		return "note.banner"
+	}
+	property := baseViewPropertyPath(coverKey, relations, keys)
+	if property == "" {
+		return ""
+	}
+	return baseViewOptionProperty(property)
+}
+
+// baseCardSize maps Anytype's small, medium and large gallery cards to
+// Bases card widths in pixels.
+func baseCardSize(raw any) int {
+	switch strings.ToLower(strings.TrimSpace(asString(raw))) {
+	case "0", "small":
+		return 160
+	case "1", "medium":
+		return 220
+	case "2", "large":
+		return 300
+	default:
+		return 0
+	}
+}
+
+func baseFilterPropertyPath(rawKey string, relations map[string]relationDef, keys propertyKeys) string {
+	frontKey := baseViewPropertyPath(rawKey, relations, keys)
 	if frontKey == "" {
 		return ""
 	}
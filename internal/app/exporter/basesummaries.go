@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"strings"
+)
+
+type baseSummary struct {
+	Property string
+	Summary  string
+}
+
+// anytypeFormulaTypes names the numeric formulaType values of view
+// relations, the column calculations shown below Anytype tables.
+var anytypeFormulaTypes = map[string]string{
+	"0":  "none",
+	"1":  "count",
+	"2":  "countvalue",
+	"3":  "countdistinct",
+	"4":  "countempty",
+	"5":  "countnotempty",
+	"6":  "percentempty",
+	"7":  "percentnotempty",
+	"8":  "mathsum",
+	"9":  "mathaverage",
+	"10": "mathmedian",
+	"11": "mathmin",
+	"12": "mathmax",
+	"13": "range",
+}
+
+// baseSummaries maps Anytype column calculations to the Bases summaries
+// that compute the same value.
+var baseSummaries = map[string]string{
+	"countdistinct": "Unique",
+	"countempty":    "Empty",
+	"countnotempty": "Filled",
+	"mathsum":       "Sum",
+	"mathaverage":   "Average",
+	"mathmedian":    "Median",
+	"mathmin":       "Min",
+	"mathmax":       "Max",
+	"range":         "Range",
+}
+
+// viewFormulaType returns the normalized column calculation of a view
+// relation, or "" when it has none.
+func viewFormulaType(relationMap map[string]any) string {
+	raw := strings.ToLower(strings.TrimSpace(asString(anyMapGet(relationMap, "formulaType", "FormulaType"))))
+	if name, ok := anytypeFormulaTypes[raw]; ok {
+		raw = name
+	}
+	if raw == "none" {
+		return ""
+	}
+	return raw
+}
+
+// untranslatedViewCalculations lists the column calculations of obj's views
+// that Bases has no summary for, e.g. row counts and percentages, as
+// "view: property (calculation)".
+func untranslatedViewCalculations(obj objectInfo, relations map[string]relationDef, keys propertyKeys) []string {
+	var out []string
+	for _, b := range obj.Blocks {
+		if len(b.Dataview) == 0 {
+			continue
+		}
+		for _, viewRaw := range asAnySlice(anyMapGet(b.Dataview, "views", "Views")) {
+			viewMap, ok := viewRaw.(map[string]any)
+			if !ok {
+				continue
+			}
+			viewName := strings.TrimSpace(asString(anyMapGet(viewMap, "name", "Name")))
+			for _, relationRaw := range asAnySlice(anyMapGet(viewMap, "relations", "Relations")) {
+				relationMap, ok := relationRaw.(map[string]any)
+				if !ok {
+					continue
+				}
+				if visible, ok := anyMapGet(relationMap, "isVisible", "IsVisible").(bool); ok && !visible {
+					continue
+				}
+				formula := viewFormulaType(relationMap)
+				if _, ok := baseSummaries[formula]; ok || formula == "" {
+					continue
+				}
+				property := baseViewPropertyPath(asString(anyMapGet(relationMap, "key", "Key")), relations, keys)
+				out = append(out, viewName+": "+property+" ("+formula+")")
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"net/url"
+	"strings"
+)
+
+// splitBlockTarget reports the object and block a link points at when it
+// targets a single block: "objectID#blockID", or an anytype://object deep
+// link with objectId and blockId parameters.
+func splitBlockTarget(target string) (string, string, bool) {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "anytype://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", "", false
+		}
+		q := u.Query()
+		objectID, blockID := strings.TrimSpace(q.Get("objectId")), strings.TrimSpace(q.Get("blockId"))
+		if objectID == "" || blockID == "" {
+			return "", "", false
+		}
+		return objectID, blockID, true
+	}
+	objectID, blockID, ok := strings.Cut(target, "#")
+	objectID, blockID = strings.TrimSpace(objectID), strings.TrimSpace(blockID)
+	if !ok || objectID == "" || blockID == "" {
+		return "", "", false
+	}
+	return objectID, blockID, true
+}
+
+// blockAnchorID turns an Anytype block id into an Obsidian block id, which
+// may only hold letters, digits and dashes.
+func blockAnchorID(blockID string) string {
+	var b strings.Builder
+	for _, r := range blockID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// blockLinkTarget renders the wikilink target for a link to a single block
+// of an exported note.
+func blockLinkTarget(target string, notes map[string]string, sourceNotePath string) (string, bool) {
+	objectID, blockID, ok := splitBlockTarget(target)
+	if !ok {
+		return "", false
+	}
+	note := notes[objectID]
+	if note == "" {
+		return "", false
+	}
+	return relativeWikiTarget(sourceNotePath, note) + "#^" + blockAnchorID(blockID), true
+}
+
+// collectBlockAnchors returns, per object, the blocks some link points at;
+// those blocks get a "^id" marker so the links resolve.
+func collectBlockAnchors(objects []objectInfo, blocksOf func(objectInfo) []block) map[string]map[string]struct{} {
+	anchors := map[string]map[string]struct{}{}
+	add := func(target string) {
+		objectID, blockID, ok := splitBlockTarget(target)
+		if !ok {
+			return
+		}
+		if anchors[objectID] == nil {
+			anchors[objectID] = map[string]struct{}{}
+		}
+		anchors[objectID][blockID] = struct{}{}
+	}
+	for _, obj := range objects {
+		for _, b := range blocksOf(obj) {
+			if b.Link != nil {
+				add(b.Link.TargetBlockID)
+			}
+			if b.Text == nil || b.Text.Marks == nil {
+				continue
+			}
+			for _, mark := range b.Text.Marks.Marks {
+				switch strings.ToLower(strings.TrimSpace(mark.Type)) {
+				case "mention", "link":
+					add(mark.Param)
+				}
+			}
+		}
+	}
+	return anchors
+}
+
+// appendBlockAnchor attaches "^id" to a rendered block: on the same line for
+// single-line blocks, and after a blank line for code blocks, quotes and
+// other multi-line output, as Obsidian expects.
+func appendBlockAnchor(rendered string, blockID string) string {
+	marker := "^" + blockAnchorID(blockID)
+	body := strings.TrimRight(rendered, "\n")
+	if strings.TrimSpace(body) == "" {
+		return rendered
+	}
+	if strings.Contains(body, "\n") {
+		return body + "\n\n" + marker + "\n"
+	}
+	return body + " " + marker + "\n"
+}
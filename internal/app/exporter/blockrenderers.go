@@ -0,0 +1,385 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// blockRenderer writes block b to buf. It returns true when it also rendered
+// the block's children; otherwise renderBlock renders them below it.
+type blockRenderer func(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool
+
+// builtinBlockRenderers holds the exporter's own renderer for each block
+// kind. It is filled in init because the renderers recurse into
+// renderBlock.
+var builtinBlockRenderers map[string]blockRenderer
+
+func init() {
+	builtinBlockRenderers = map[string]blockRenderer{
+		"text":            renderTextContentBlock,
+		"layout":          renderLayoutBlock,
+		"file":            renderFileBlock,
+		"bookmark":        renderBookmarkBlock,
+		"latex":           renderLatexBlock,
+		"dataview":        renderDataviewBlock,
+		"link":            renderLinkBlock,
+		"table":           renderTableBlock,
+		"div":             renderDividerBlock,
+		"tableOfContents": renderTOCBlock,
+		"relation":        renderRelationContentBlock,
+		"chat":            renderChatContentBlock,
+	}
+}
+
+// silentBlockKinds have nothing to render in a note: the object's root
+// block, the featured relations shown as properties, the header icon, and
+// table columns and rows, which their table renders.
+var silentBlockKinds = map[string]bool{
+	"smartblock":        true,
+	"featuredRelations": true,
+	"icon":              true,
+	"tableColumn":       true,
+	"tableRow":          true,
+}
+
+// renderUnsupportedBlock marks where a block of an unknown kind was with an
+// HTML comment pointing at the raw sidecar that keeps its content, and
+// reports it.
+func renderUnsupportedBlock(buf *bytes.Buffer, ctx bodyContext, b block, kind string) {
+	if kind == "" || silentBlockKinds[kind] {
+		return
+	}
+	kind = strings.ReplaceAll(kind, "--", "-")
+	comment := fmt.Sprintf("<!-- Unsupported Anytype block %q (%s) was not exported", kind, strings.ReplaceAll(b.ID, "--", "-"))
+	if ctx.unsupportedBlock != nil {
+		ctx.unsupportedBlock(b, kind)
+		comment += "; its raw content is in " + rawSidecarPath(ctx.rootID)
+	}
+	buf.WriteString(comment + " -->\n")
+}
+
+// rawSidecarPath is the vault-relative path of an object's raw sidecar.
+func rawSidecarPath(objectID string) string {
+	return "_anytype/raw/" + objectID + ".json"
+}
+
+// blockKind names the content of b: the key it has in the export, such as
+// "text" or "table". Blocks built in memory get the kind of the content
+// field that is set.
+func blockKind(b block) string {
+	switch {
+	case b.Text != nil:
+		return "text"
+	case b.Layout != nil:
+		return "layout"
+	case b.File != nil:
+		return "file"
+	case b.Bookmark != nil:
+		return "bookmark"
+	case b.Latex != nil:
+		return "latex"
+	case len(b.Dataview) > 0:
+		return "dataview"
+	case b.Link != nil:
+		return "link"
+	case b.Table != nil:
+		return "table"
+	case b.Div != nil:
+		return "div"
+	case b.TOC != nil:
+		return "tableOfContents"
+	case b.Relation != nil:
+		return "relation"
+	case b.Chat != nil:
+		return "chat"
+	case b.TableRow != nil:
+		return "tableRow"
+	default:
+		return b.Kind
+	}
+}
+
+// BlockInfo is a block handed to a registered BlockRenderer.
+type BlockInfo struct {
+	ID          string
+	Kind        string
+	Content     json.RawMessage
+	Fields      map[string]any
+	ChildrenIDs []string
+	// ObjectID is the object whose note is being rendered and NotePath its
+	// vault-relative path.
+	ObjectID string
+	NotePath string
+	Depth    int
+	// RenderChildren renders the block's children with the exporter's
+	// renderers.
+	RenderChildren func() string
+}
+
+// BlockRenderer renders a block as markdown. It reports false to leave the
+// block to the exporter's own renderer.
+type BlockRenderer func(BlockInfo) (string, bool)
+
+var (
+	customBlockRenderersMu sync.RWMutex
+	customBlockRenderers   = map[string]BlockRenderer{}
+)
+
+// RegisterBlockRenderer makes render handle blocks of kind, ahead of the
+// built-in renderer. A nil render removes the registration.
+func RegisterBlockRenderer(kind string, render BlockRenderer) {
+	customBlockRenderersMu.Lock()
+	defer customBlockRenderersMu.Unlock()
+	if render == nil {
+		delete(customBlockRenderers, kind)
+		return
+	}
+	customBlockRenderers[kind] = render
+}
+
+func customBlockRenderer(kind string) (BlockRenderer, bool) {
+	customBlockRenderersMu.RLock()
+	defer customBlockRenderersMu.RUnlock()
+	render, ok := customBlockRenderers[kind]
+	return render, ok
+}
+
+// renderCustomBlock offers b to a registered renderer and writes what it
+// returns. Children are left to the renderer, which can render them with
+// RenderChildren.
+func renderCustomBlock(buf *bytes.Buffer, ctx bodyContext, b block, kind string, depth int, render BlockRenderer) bool {
+	markdown, ok := render(BlockInfo{
+		ID:          b.ID,
+		Kind:        kind,
+		Content:     blockContent(b, kind),
+		Fields:      b.Fields,
+		ChildrenIDs: b.ChildrenID,
+		ObjectID:    ctx.rootID,
+		NotePath:    ctx.sourceNotePath,
+		Depth:       depth,
+		RenderChildren: func() string {
+			var children bytes.Buffer
+			renderChildren(&children, ctx, b.ChildrenID, depth+1)
+			return children.String()
+		},
+	})
+	if !ok {
+		return false
+	}
+	if markdown != "" {
+		buf.WriteString(markdown)
+		if !strings.HasSuffix(markdown, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	return true
+}
+
+// blockContent returns the content of b as JSON: as exported for kinds the
+// exporter does not decode, re-encoded from its field otherwise.
+func blockContent(b block, kind string) json.RawMessage {
+	if len(b.Content) > 0 {
+		return b.Content
+	}
+	var content any
+	switch kind {
+	case "text":
+		content = b.Text
+	case "layout":
+		content = b.Layout
+	case "file":
+		content = b.File
+	case "bookmark":
+		content = b.Bookmark
+	case "latex":
+		content = b.Latex
+	case "dataview":
+		content = b.Dataview
+	case "link":
+		content = b.Link
+	case "table":
+		content = b.Table
+	case "div":
+		content = b.Div
+	case "tableOfContents":
+		content = b.TOC
+	case "relation":
+		content = b.Relation
+	case "chat":
+		content = b.Chat
+	case "tableRow":
+		content = b.TableRow
+	default:
+		return nil
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+func renderTextContentBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if b.Text.Style == "Callout" || b.Text.Style == "Toggle" {
+		renderCalloutBlock(buf, ctx, b, depth)
+		return true
+	}
+
+	line := renderTextBlock(*b.Text, b.Fields, ctx.notes, ctx.sourceNotePath, numberedIndex)
+	if _, anchored := ctx.blockAnchors[b.ID]; anchored {
+		line = appendBlockAnchor(line, b.ID)
+	}
+	if line != "" {
+		buf.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+
+	switch {
+	case b.Text.Style == "Quote":
+		renderQuoteChildren(buf, ctx, b, depth)
+		return true
+	case ctx.toggleHeadings == toggleHeadingsCallout && isToggleHeading(b.Text.Style):
+		renderFoldedChildren(buf, ctx, b, depth)
+		return true
+	case isListStyle(b.Text.Style):
+		// Everything nested under a list item, code blocks, quotes and
+		// embeds included, is indented one tab so it stays in the item.
+		ctx.listDepth++
+		var child bytes.Buffer
+		renderChildren(&child, ctx, b.ChildrenID, depth+1)
+		buf.WriteString(prefixLines(child.String(), "\t"))
+		return true
+	}
+	renderChildren(buf, ctx, b.ChildrenID, depth+1)
+	return true
+}
+
+func renderLayoutBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	return b.Layout.Style == "Row" && renderColumnLayout(buf, ctx, b, depth)
+}
+
+func renderFileBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	path := ctx.fileObjects[b.File.TargetObjectID]
+	if path == "" {
+		path = filepath.ToSlash(filepath.Join("files", sanitizeName(strings.TrimSpace(b.File.Name), "posix")))
+	}
+	path = relativePathTarget(ctx.sourceNotePath, path)
+	if strings.EqualFold(b.File.Type, "image") {
+		buf.WriteString("![" + escapeBrackets(b.File.Name) + "](" + path + ")\n")
+	} else if strings.EqualFold(b.File.Type, "audio") {
+		// Obsidian plays embedded audio inline.
+		buf.WriteString("![" + escapeBrackets(b.File.Name) + "](" + path + ")\n")
+		if transcript := ctx.transcripts[b.File.TargetObjectID]; transcript != "" {
+			buf.WriteString("\n> [!quote]- Transcript\n")
+			for _, line := range strings.Split(transcript, "\n") {
+				buf.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+			}
+		}
+	} else {
+		title := b.File.Name
+		if title == "" {
+			title = filepath.Base(path)
+		}
+		buf.WriteString("[" + escapeBrackets(title) + "](" + path + ")\n")
+	}
+	return false
+}
+
+func renderBookmarkBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	title := strings.TrimSpace(b.Bookmark.Title)
+	if title == "" {
+		title = b.Bookmark.URL
+	}
+	if b.Bookmark.URL != "" {
+		buf.WriteString("[" + escapeBrackets(title) + "](" + b.Bookmark.URL + ")\n")
+	}
+	return false
+}
+
+func renderLatexBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if embedTarget, ok := ctx.excalidrawEmbeds[b.ID]; ok && embedTarget != "" {
+		buf.WriteString("![[" + embedTarget + "]]\n")
+	} else if strings.TrimSpace(b.Latex.Text) != "" {
+		buf.WriteString("$$\n" + b.Latex.Text + "\n$$\n")
+	}
+	return false
+}
+
+func renderDataviewBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if len(b.Dataview) == 0 {
+		return false
+	}
+	dataviewTargetID := ctx.rootID
+	if target := strings.TrimSpace(asString(anyMapGet(b.Dataview, "TargetObjectId", "targetObjectId"))); target != "" {
+		dataviewTargetID = target
+	}
+	// An inline set or collection embeds the base it became, or the
+	// target's note when it has no views to turn into a base. A note
+	// never embeds itself.
+	note, ok := ctx.notes[dataviewTargetID]
+	isBase := strings.HasPrefix(filepath.ToSlash(strings.TrimSpace(note)), "bases/")
+	if ok && (isBase || dataviewTargetID != ctx.rootID) {
+		buf.WriteString("![[" + relativeWikiTarget(ctx.sourceNotePath, note) + "]]\n")
+	}
+	return false
+}
+
+func renderLinkBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if target, ok := blockLinkTarget(b.Link.TargetBlockID, ctx.notes, ctx.sourceNotePath); ok {
+		buf.WriteString("[[" + target + "]]\n")
+	} else if note, ok := ctx.notes[b.Link.TargetBlockID]; ok {
+		if card, ok := renderLinkCard(ctx, b.Link, note); ok {
+			ensureBlankLine(buf)
+			buf.WriteString(card + "\n")
+		} else {
+			buf.WriteString("[[" + relativeWikiTarget(ctx.sourceNotePath, note) + "]]\n")
+		}
+	} else if date := linkTargetDate(b.Link.TargetBlockID); date != "" {
+		buf.WriteString(date + "\n")
+	}
+	return false
+}
+
+// renderTableBlock writes a table; its rows and columns are rendered as
+// part of it.
+func renderTableBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	table := renderTable(ctx, b)
+	if table != "" {
+		buf.WriteString(table)
+		if !strings.HasSuffix(table, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+	return true
+}
+
+func renderDividerBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if divider := renderDivider(b.Div); divider != "" {
+		buf.WriteString(divider + "\n")
+	}
+	return false
+}
+
+func renderTOCBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	buf.WriteString(renderTableOfContents(ctx.byID, ctx.rootID, ctx.tocMode))
+	return false
+}
+
+func renderRelationContentBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	buf.WriteString(renderRelationBlock(ctx, b.Relation.Key))
+	return false
+}
+
+func renderChatContentBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int, numberedIndex int) bool {
+	if chat := renderChatBlock(b.Chat, ctx.objectNamesByID); chat != "" {
+		ensureBlankLine(buf)
+		buf.WriteString(chat)
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultCalloutTypes maps callout icons to the Obsidian callout type that
+// means the same. Icons are looked up without the emoji variation selector.
+var defaultCalloutTypes = map[string]string{
+	"⚠": "warning",
+	"🚧": "warning",
+	"💡": "tip",
+	"✨": "tip",
+	"❗": "important",
+	"‼": "important",
+	"📌": "important",
+	"ℹ": "info",
+	"📝": "note",
+	"✅": "success",
+	"❌": "failure",
+	"🚫": "danger",
+	"⛔": "danger",
+	"🔥": "danger",
+	"❓": "question",
+	"🐛": "bug",
+	"🐞": "bug",
+	"📋": "abstract",
+	"🧪": "example",
+	"💬": "quote",
+	"☑": "todo",
+}
+
+// resolveCalloutTypes parses "icon=type" pairs that extend or override the
+// default callout icon mapping.
+func resolveCalloutTypes(pairs []string) (map[string]string, error) {
+	types := map[string]string{}
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		icon, calloutType, ok := strings.Cut(pair, "=")
+		icon = normalizeCalloutIcon(icon)
+		calloutType = strings.ToLower(strings.TrimSpace(calloutType))
+		if !ok || icon == "" || calloutType == "" || strings.ContainsAny(calloutType, " []") {
+			return nil, fmt.Errorf("invalid callout type %q: expected icon=type, e.g. 🚀=tip", pair)
+		}
+		types[icon] = calloutType
+	}
+	return types, nil
+}
+
+func normalizeCalloutIcon(icon string) string {
+	return strings.ReplaceAll(strings.TrimSpace(icon), "\uFE0F", "")
+}
+
+// calloutType returns the Obsidian callout type for a callout icon, and
+// whether the icon was mapped. Unmapped callouts are notes.
+func calloutType(icon string, custom map[string]string) (string, bool) {
+	icon = normalizeCalloutIcon(icon)
+	if icon == "" {
+		return "note", false
+	}
+	if t, ok := custom[icon]; ok {
+		return t, true
+	}
+	if t, ok := defaultCalloutTypes[icon]; ok {
+		return t, true
+	}
+	return "note", false
+}
@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+func renderChatBlock(chat *anytypedomain.ChatBlock, objectNamesByID map[string]string) string {
+	if chat == nil || len(chat.Messages) == 0 {
+		return ""
+	}
+
+	messages := append([]anytypedomain.ChatMessage(nil), chat.Messages...)
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, _ := anytypedomain.ParseAnytypeTimestamp(messages[i].CreatedAt)
+		b, _ := anytypedomain.ParseAnytypeTimestamp(messages[j].CreatedAt)
+		return a.Before(b)
+	})
+
+	known := make(map[string]struct{}, len(messages))
+	for _, m := range messages {
+		known[m.ID] = struct{}{}
+	}
+	replies := map[string][]anytypedomain.ChatMessage{}
+	var roots []anytypedomain.ChatMessage
+	for _, m := range messages {
+		if _, ok := known[m.ReplyToMessageID]; ok && m.ReplyToMessageID != m.ID {
+			replies[m.ReplyToMessageID] = append(replies[m.ReplyToMessageID], m)
+			continue
+		}
+		roots = append(roots, m)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("## Discussion\n\n")
+	for i, m := range roots {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(prefixLines(renderChatThread(m, replies, objectNamesByID, map[string]bool{}), "> "))
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func renderChatThread(m anytypedomain.ChatMessage, replies map[string][]anytypedomain.ChatMessage, objectNamesByID map[string]string, visited map[string]bool) string {
+	visited[m.ID] = true
+
+	author := strings.TrimSpace(objectNamesByID[m.Creator])
+	if author == "" {
+		author = strings.TrimSpace(m.Creator)
+	}
+	if author == "" {
+		author = "Unknown"
+	}
+	header := "**" + author + "**"
+	if created, ok := anytypedomain.ParseAnytypeTimestamp(m.CreatedAt); ok {
+		header += " · " + created.Format("2006-01-02 15:04")
+	}
+
+	lines := []string{header}
+	if text := strings.TrimSpace(m.Message.Text); text != "" {
+		lines = append(lines, text)
+	}
+	for _, reply := range replies[m.ID] {
+		if visited[reply.ID] {
+			continue
+		}
+		lines = append(lines, "", prefixLines(renderChatThread(reply, replies, objectNamesByID, visited), "> "))
+	}
+	return strings.Join(lines, "\n")
+}
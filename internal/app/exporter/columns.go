@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func resolveColumnLayout(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "", "flatten":
+		return "flatten", nil
+	case "html", "multi-column":
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid column layout %q: expected flatten, html, or multi-column", mode)
+	}
+}
+
+// renderColumnLayout keeps a Row of Column blocks side by side. It reports
+// false when the row should be flattened like any other block.
+func renderColumnLayout(buf *bytes.Buffer, ctx bodyContext, row block, depth int) bool {
+	if ctx.columnLayout != "html" && ctx.columnLayout != "multi-column" {
+		return false
+	}
+
+	var columns []string
+	for _, id := range row.ChildrenID {
+		column, ok := ctx.byID[id]
+		if !ok || column.Layout == nil || column.Layout.Style != "Column" {
+			return false
+		}
+		var content bytes.Buffer
+		renderChildren(&content, ctx, column.ChildrenID, depth)
+		columns = append(columns, strings.Trim(content.String(), "\n"))
+	}
+	if len(columns) < 2 {
+		return false
+	}
+
+	ensureBlankLine(buf)
+	if ctx.columnLayout == "html" {
+		buf.WriteString("<div class=\"columns\">\n")
+		for _, column := range columns {
+			buf.WriteString("<div class=\"column\">\n\n")
+			if column != "" {
+				buf.WriteString(column + "\n\n")
+			}
+			buf.WriteString("</div>\n")
+		}
+		buf.WriteString("</div>\n\n")
+		return true
+	}
+
+	buf.WriteString("--- start-multi-column: " + row.ID + "\n")
+	buf.WriteString("```column-settings\nNumber of Columns: " + fmt.Sprint(len(columns)) + "\n```\n\n")
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteString("--- column-break ---\n\n")
+		}
+		if column != "" {
+			buf.WriteString(column + "\n\n")
+		}
+	}
+	buf.WriteString("--- end-multi-column\n\n")
+	return true
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	anytypeCoverTypeColor    = 2
+	anytypeCoverTypeGradient = 3
+
+	generatedCoversDir = "files/covers"
+)
+
+// anytypeCoverColors mirrors the solid cover palette of the Anytype client.
+var anytypeCoverColors = map[string]string{
+	"yellow":    "#ecd91b",
+	"orange":    "#ffb522",
+	"red":       "#f55522",
+	"pink":      "#e51ca0",
+	"purple":    "#ab50cc",
+	"blue":      "#3e58eb",
+	"ice":       "#2aa7ee",
+	"teal":      "#0fc8ba",
+	"green":     "#5dd400",
+	"lightgrey": "#b6b6b6",
+	"darkgrey":  "#8c8c8c",
+	"black":     "#2c2b27",
+}
+
+// anytypeCoverGradients holds the top and bottom stops of Anytype's named
+// gradient covers.
+var anytypeCoverGradients = map[string][2]string{
+	"pinkOrange":  {"#d8a4e1", "#fdb2a5"},
+	"bluePink":    {"#81a6f6", "#e8bbe6"},
+	"greenOrange": {"#8be099", "#f8e479"},
+	"sky":         {"#8ebeff", "#dbeaff"},
+	"yellow":      {"#f8e479", "#fffce0"},
+	"red":         {"#ff8383", "#ffe0e0"},
+	"blue":        {"#6585fe", "#c8d3ff"},
+	"teal":        {"#5ed8cf", "#cff5f1"},
+}
+
+type generatedCover struct {
+	relPath string
+	top     string
+	bottom  string
+}
+
+// generatedCoverFor returns the image standing in for a color or gradient
+// cover, or false when the object has another cover type or an unknown color.
+func generatedCoverFor(details map[string]any) (generatedCover, bool) {
+	coverType, ok := asFloat(details["coverType"])
+	if !ok {
+		return generatedCover{}, false
+	}
+	coverID := strings.TrimSpace(asString(details["coverId"]))
+	switch int(coverType) {
+	case anytypeCoverTypeColor:
+		if color, ok := anytypeCoverColors[coverID]; ok {
+			return generatedCover{relPath: path.Join(generatedCoversDir, "color-"+coverID+".svg"), top: color, bottom: color}, true
+		}
+	case anytypeCoverTypeGradient:
+		if stops, ok := anytypeCoverGradients[coverID]; ok {
+			return generatedCover{relPath: path.Join(generatedCoversDir, "gradient-"+coverID+".svg"), top: stops[0], bottom: stops[1]}, true
+		}
+	}
+	return generatedCover{}, false
+}
+
+func isGeneratedCoverType(details map[string]any) bool {
+	coverType, ok := asFloat(details["coverType"])
+	return ok && (int(coverType) == anytypeCoverTypeColor || int(coverType) == anytypeCoverTypeGradient)
+}
+
+// writeGeneratedCovers writes one SVG per distinct color or gradient cover
+// used by objects and returns the written vault paths.
+func writeGeneratedCovers(outputDir string, objects []objectInfo, vault *vaultFiles) ([]string, error) {
+	coversByPath := map[string]generatedCover{}
+	for _, obj := range objects {
+		if cover, ok := generatedCoverFor(obj.Details); ok {
+			coversByPath[cover.relPath] = cover
+		}
+	}
+
+	written := make([]string, 0, len(coversByPath))
+	for relPath := range coversByPath {
+		written = append(written, relPath)
+	}
+	sort.Strings(written)
+	for _, relPath := range written {
+		cover := coversByPath[relPath]
+		target := filepath.Join(outputDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		if err := vault.writeFile(target, []byte(coverSVG(cover.top, cover.bottom))); err != nil {
+			return nil, fmt.Errorf("write cover %s: %w", relPath, err)
+		}
+	}
+	return written, nil
+}
+
+// coverSVG draws a banner-shaped vertical gradient; equal stops give a solid
+// fill. preserveAspectRatio="none" lets banner plugins stretch it freely.
+func coverSVG(top string, bottom string) string {
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="1200" height="300" viewBox="0 0 1200 300" preserveAspectRatio="none">` + "\n")
+	b.WriteString(`  <defs><linearGradient id="cover" x1="0" y1="0" x2="0" y2="1">`)
+	b.WriteString(`<stop offset="0" stop-color="` + top + `"/>`)
+	b.WriteString(`<stop offset="1" stop-color="` + bottom + `"/>`)
+	b.WriteString("</linearGradient></defs>\n")
+	b.WriteString(`  <rect width="1200" height="300" fill="url(#cover)"/>` + "\n")
+	b.WriteString("</svg>\n")
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const duplicatesReportFileName = "duplicates.md"
+
+// duplicateBodyHash fingerprints a note body for duplicate detection. Case
+// and whitespace are ignored, so bodies that only differ in formatting
+// count as the same content.
+func duplicateBodyHash(body string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(strings.ToLower(body)), " ")))
+	return string(sum[:])
+}
+
+type duplicateGroup struct {
+	name string
+	// clusters holds the group's note paths by body: notes in a cluster of
+	// more than one have the same content.
+	clusters [][]string
+}
+
+// findDuplicates groups the exported notes by title, case-insensitively,
+// and keeps the titles used by more than one note.
+func findDuplicates(objects []objectInfo, notes map[string]string, bodyHashes map[string]string) []duplicateGroup {
+	byName := map[string][]objectInfo{}
+	for _, obj := range objects {
+		if notes[obj.ID] == "" {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(inferObjectTitle(obj)))
+		byName[key] = append(byName[key], obj)
+	}
+
+	var groups []duplicateGroup
+	for _, objs := range byName {
+		if len(objs) < 2 {
+			continue
+		}
+		sort.Slice(objs, func(i, j int) bool { return notes[objs[i].ID] < notes[objs[j].ID] })
+		group := duplicateGroup{name: strings.TrimSpace(inferObjectTitle(objs[0]))}
+		clusterByHash := map[string]int{}
+		for _, obj := range objs {
+			hash := bodyHashes[obj.ID]
+			if i, ok := clusterByHash[hash]; ok {
+				group.clusters[i] = append(group.clusters[i], notes[obj.ID])
+				continue
+			}
+			clusterByHash[hash] = len(group.clusters)
+			group.clusters = append(group.clusters, []string{notes[obj.ID]})
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if a, b := strings.ToLower(groups[i].name), strings.ToLower(groups[j].name); a != b {
+			return a < b
+		}
+		return groups[i].clusters[0][0] < groups[j].clusters[0][0]
+	})
+	return groups
+}
+
+func renderDuplicatesReport(groups []duplicateGroup) string {
+	var b strings.Builder
+	b.WriteString("# Duplicates\n\n")
+	if len(groups) == 0 {
+		b.WriteString("No notes share a name.\n")
+		return b.String()
+	}
+	b.WriteString("Notes that share a name. Notes listed together under \"Same content\" have identical bodies, ignoring case and whitespace, and are likely safe to merge.\n")
+	for _, group := range groups {
+		notes := 0
+		for _, cluster := range group.clusters {
+			notes += len(cluster)
+		}
+		b.WriteString("\n## " + group.name + " (" + strconv.Itoa(notes) + " notes)\n\n")
+		var distinct []string
+		for _, cluster := range group.clusters {
+			if len(cluster) == 1 {
+				distinct = append(distinct, cluster[0])
+				continue
+			}
+			b.WriteString("Same content:\n")
+			for _, note := range cluster {
+				b.WriteString("- [[" + relativeWikiTarget(duplicatesReportFileName, note) + "]]\n")
+			}
+			b.WriteString("\n")
+		}
+		if len(distinct) > 0 {
+			b.WriteString("Different content:\n")
+			for _, note := range distinct {
+				b.WriteString("- [[" + relativeWikiTarget(duplicatesReportFileName, note) + "]]\n")
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func writeDuplicatesReport(outputDir string, groups []duplicateGroup, vault *vaultFiles) error {
+	return vault.writeFile(filepath.Join(outputDir, duplicatesReportFileName), []byte(renderDuplicatesReport(groups)))
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import "strings"
+
+// isEmptyObject reports whether obj would export as a note with nothing in
+// it: no body beyond its title and layout blocks, and no property that
+// frontmatter would show apart from its type. Sets, collections and notes
+// with attachments, bookmarks or links always have content.
+func isEmptyObject(obj objectInfo, blocks []block, relations map[string]relationDef, typesByID map[string]typeDef, opts frontmatterOptions) bool {
+	for _, b := range blocks {
+		switch {
+		case b.ID == obj.ID, b.Layout != nil, b.Relation != nil, b.Div != nil, b.TOC != nil:
+		case b.Text != nil:
+			if b.Text.Style != "Title" && strings.TrimSpace(b.Text.Text) != "" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	if snippetNoteBody(obj.Details) != "" {
+		// The note falls back to the snippet.
+		return false
+	}
+
+	empty := true
+	forEachFrontmatterProperty(obj, relations, typesByID, opts, func(k string, rel relationDef, hasRel bool, dateByType bool) {
+		if k == "type" || isEmptyFrontmatterValue(obj.Details[k]) {
+			return
+		}
+		if b, ok := obj.Details[k].(bool); ok && !b {
+			return
+		}
+		empty = false
+	})
+	return empty
+}
@@ -1,41 +1,139 @@
 package exporter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/charmbracelet/bubbles/progress"
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
 	"github.com/sleroq/anytype-to-obsidian/internal/infra/anytypejson"
+	"github.com/sleroq/anytype-to-obsidian/internal/infra/exportfs"
 )
 
 type Exporter struct {
+	// Logger receives per-object decisions (debug/info) and export warnings.
+	// A nil Logger writes warnings to stderr.
+	Logger                    *slog.Logger
 	InputDir                  string
 	OutputDir                 string
+	OutputZip                 string
+	SplitBySpace              bool
+	Format                    string
+	HTMLEmbedImages           bool
+	Flavor                    string
+	MissingStubs              bool
 	DisableIconizeIcons       bool
 	DisablePrettyPropertyIcon bool
 	DisablePictureToCover     bool
 	EnableBasesKanban         bool
 	DisableCollectionFilters  bool
 	RunPrettier               bool
+	PrettierCommand           string
+	PrettierConfig            string
+	PrettierTargets           []string
+	Formatter                 string
+	Update                    string
+	GraphStats                bool
+	TOCMode                   string
+	CalloutTypes              []string
+	ToggleHeadings            string
+	ComplexTables             string
+	MultiValueLists           bool
 	FilenameEscaping          string
 	IncludeDynamicProperties  bool
 	IncludeArchivedObjects    bool
+	IncludeDeleted            bool
+	IncludeSystemObjects      bool
+	KeepSystemObjectTypes     []string
 	IncludeArchivedProperties bool
 	ExcludeEmptyProperties    bool
 	ExcludePropertyKeys       []string
 	ForceIncludePropertyKeys  []string
 	LinkAsNotePropertyKeys    []string
+	PropertyOrder             []string
+	PropertiesStyle           string
+	Properties                string
+	TagHierarchy              []string
+	TagsFromProperties        []string
+	NoMergeTags               bool
+	TypeAsTag                 bool
+	KanbanBoards              bool
+	ExportTablesDir           string
+	ExportTablesFormat        string
+	QueryTarget               string
+	TagColorsCSS              bool
+	ScaffoldEmptyNotes        bool
+	SkipEmptyNotes            bool
+	ReportDuplicates          bool
+	WebClippings              bool
+	ExportVCards              bool
+	ExportICS                 bool
+	Protect                   []string
+	TransliterateFilenames    bool
+	WriteDefaultTemplateMap   bool
+	VerifyLinks               string
+	StrictRelations           bool
+	ExportPeople              bool
+	ColumnLayout              string
+	LinkCards                 bool
+	RelationBlocks            string
+	Atomic                    bool
+	MergeStrategy             string
+	EmbedAnytypeMetadata      bool
+	FilenameScheme            string
+	MaxFilenameLength         int
+	ASCIIFilenames            bool
+	EmojiInFilename           bool
+	NFCFilenames              bool
+	IconStyle                 string
+	NoDynamicTimestamps       bool
+	FailFast                  bool
+	LowMemory                 bool
+	Profile                   bool
+	MaxImageDimension         int
+	ImageQuality              int
+	KeepOriginalImages        bool
+	// Progress, when set, is called on every phase change and processed
+	// item and replaces the built-in terminal progress bar, so callers
+	// present progress their own way.
+	Progress func(Event)
+
+	// input replaces InputDir when set; split-by-space runs use it to
+	// export one space's view of the export.
+	input fs.FS
+	// staticBaseLists writes sets and collections as markdown lists of
+	// their members instead of .base files, for the portable flavor.
+	staticBaseLists bool
+	// frontmatterOnly renders notes for a frontmatter update: attachments
+	// are not copied and no covers or plugin data are written.
+	frontmatterOnly bool
+	// notePaths pins objects to the vault paths a previous export gave
+	// them, so links are rendered against where the notes live.
+	notePaths map[string]string
 }
 type Stats struct {
-	Notes int
-	Files int
+	Notes      int
+	Bases      int
+	Templates  int
+	Files      int
+	Warnings   int
+	Elapsed    time.Duration
+	ReportPath string
+	// SkippedEmpty counts the objects SkipEmptyNotes left out.
+	SkippedEmpty int
+	// WarningMessages lists the warnings counted in Warnings, as written to
+	// the report.
+	WarningMessages []string
 }
 
 type block = anytypedomain.Block
@@ -46,32 +144,126 @@ type relationOption = anytypedomain.RelationOption
 type objectInfo = anytypedomain.ObjectInfo
 type templateInfo = anytypedomain.TemplateInfo
 
+// DefaultImageQuality is the JPEG quality resized images are written with
+// when ImageQuality is zero.
+const DefaultImageQuality = exportfs.DefaultImageQuality
+
 type indexFile struct {
 	Notes map[string]string `json:"notes"`
+	Files map[string]int64  `json:"files,omitempty"`
+}
+
+// defaultPrettierTargets are the vault folders formatted when no targets
+// are configured.
+var defaultPrettierTargets = []string{"notes", "people", "trash", "bases", "templates"}
+
+// prettierInvocation describes how the vault is formatted after export.
+type prettierInvocation struct {
+	// command is the formatter and its arguments; empty runs
+	// npx --yes prettier --write --ignore-unknown.
+	command []string
+	// config is "" to ignore prettier config files, "auto" to let the
+	// formatter find them, or the absolute path of a config file.
+	config  string
+	targets []string
 }
 
-var prettierCommandRunner = func(outputDir string) error {
-	targets := make([]string, 0, 3)
-	for _, dir := range []string{"notes", "bases", "templates"} {
-		abs := filepath.Join(outputDir, dir)
-		info, err := os.Stat(abs)
+const prettierConfigAuto = "auto"
+
+// resolvePrettierInvocation splits the formatter command line and makes a
+// config path absolute, since the formatter runs inside the vault.
+func resolvePrettierInvocation(command string, config string, targets []string) (prettierInvocation, error) {
+	inv := prettierInvocation{command: strings.Fields(command)}
+	config = strings.TrimSpace(config)
+	switch {
+	case config == "", strings.EqualFold(config, prettierConfigAuto):
+		inv.config = strings.ToLower(config)
+	default:
+		abs, err := filepath.Abs(config)
 		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return err
+			return prettierInvocation{}, fmt.Errorf("resolve prettier config: %w", err)
 		}
-		if info.IsDir() {
-			targets = append(targets, dir)
+		if _, err := os.Stat(abs); err != nil {
+			return prettierInvocation{}, fmt.Errorf("prettier config: %w", err)
 		}
+		inv.config = abs
 	}
+	for _, target := range targets {
+		if target = strings.Trim(strings.TrimSpace(target), "/"); target != "" {
+			inv.targets = append(inv.targets, target)
+		}
+	}
+	return inv, nil
+}
+
+// formatFiles returns the markdown files among written, the paths this run
+// wrote, that fall under the configured targets. Files the merge strategy
+// kept or that are protected were not written and so are never formatted.
+func formatFiles(inv prettierInvocation, written []string) []string {
+	targets := inv.targets
 	if len(targets) == 0 {
+		targets = defaultPrettierTargets
+	}
+	var files []string
+	seen := map[string]struct{}{}
+	for _, rel := range written {
+		if _, ok := seen[rel]; ok || path.Ext(rel) != ".md" {
+			continue
+		}
+		for _, target := range targets {
+			if isGlobTarget(target) && matchVaultGlobs(rel, []string{target}) || rel == target || strings.HasPrefix(rel, target+"/") {
+				seen[rel] = struct{}{}
+				files = append(files, rel)
+				break
+			}
+		}
+	}
+	return files
+}
+
+func isGlobTarget(target string) bool {
+	return strings.ContainsAny(target, "*?[{")
+}
+
+// prettierArgsMaxLength caps the file arguments of one formatter call so
+// large vaults stay below the Windows command line limit.
+const prettierArgsMaxLength = 8000
+
+// prettierCommands returns the command lines that format files, split into
+// batches of at most prettierArgsMaxLength bytes of file arguments, or nil
+// when there is nothing to format.
+func prettierCommands(inv prettierInvocation, files []string) [][]string {
+	if len(files) == 0 {
 		return nil
 	}
 
-	args := []string{"--yes", "prettier", "--no-config", "--write", "--ignore-unknown"}
-	args = append(args, targets...)
-	cmd := exec.Command("npx", args...)
+	base := append([]string(nil), inv.command...)
+	if len(base) == 0 {
+		base = []string{"npx", "--yes", "prettier", "--write", "--ignore-unknown"}
+		if inv.config == "" {
+			base = append(base, "--no-config")
+		}
+	}
+	if inv.config != "" && inv.config != prettierConfigAuto {
+		base = append(base, "--config", inv.config)
+	}
+
+	var commands [][]string
+	var args []string
+	length := 0
+	for _, file := range files {
+		if len(args) > 0 && length+len(file)+1 > prettierArgsMaxLength {
+			commands = append(commands, append(append([]string(nil), base...), args...))
+			args, length = nil, 0
+		}
+		args = append(args, file)
+		length += len(file) + 1
+	}
+	return append(commands, append(append([]string(nil), base...), args...))
+}
+
+var prettierCommandRunner = func(outputDir string, args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Dir = outputDir
 	out, err := cmd.CombinedOutput()
 	if err == nil {
@@ -144,90 +336,6 @@ var createdDateKeys = []string{"createdDate", "addedDate"}
 var changedDateKeys = []string{"changedDate"}
 var modifiedDateKeys = []string{"lastModifiedDate", "modifiedDate"}
 
-type exportProgressBar struct {
-	enabled         bool
-	total           int
-	current         int
-	lastRenderWidth int
-	label           string
-	bar             progress.Model
-}
-
-func newExportProgressBar(total int) exportProgressBar {
-	if total <= 0 {
-		total = 1
-	}
-	bar := progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
-	bar.Width = 36
-
-	if cols, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil && cols > 0 {
-		width := cols - 40
-		if width < 16 {
-			width = 16
-		}
-		if width > 64 {
-			width = 64
-		}
-		bar.Width = width
-	}
-
-	return exportProgressBar{
-		enabled: isTerminal(os.Stderr),
-		total:   total,
-		bar:     bar,
-	}
-}
-
-func (p *exportProgressBar) Advance(label string) {
-	if !p.enabled {
-		return
-	}
-	p.current++
-	if p.current > p.total {
-		p.current = p.total
-	}
-	p.label = label
-	p.render()
-}
-
-func (p *exportProgressBar) Finish(label string) {
-	if !p.enabled {
-		return
-	}
-	p.current = p.total
-	p.label = label
-	p.render()
-	fmt.Fprint(os.Stderr, "\n")
-	p.lastRenderWidth = 0
-}
-
-func (p *exportProgressBar) Close() {
-	if !p.enabled {
-		return
-	}
-	if p.lastRenderWidth > 0 {
-		fmt.Fprint(os.Stderr, "\n")
-		p.lastRenderWidth = 0
-	}
-}
-
-func (p *exportProgressBar) render() {
-	percent := float64(p.current) / float64(p.total)
-	if percent < 0 {
-		percent = 0
-	}
-	if percent > 1 {
-		percent = 1
-	}
-	line := fmt.Sprintf("%s %3.0f%% %d/%d %s", p.bar.ViewAs(percent), percent*100, p.current, p.total, strings.TrimSpace(p.label))
-	pad := ""
-	if p.lastRenderWidth > len(line) {
-		pad = strings.Repeat(" ", p.lastRenderWidth-len(line))
-	}
-	fmt.Fprintf(os.Stderr, "\r%s%s", line, pad)
-	p.lastRenderWidth = len(line)
-}
-
 func isTerminal(f *os.File) bool {
 	if f == nil {
 		return false
@@ -268,13 +376,16 @@ func (e Exporter) prepareExportDirs() (exportDirs, error) {
 	return dirs, nil
 }
 
-func writeAnytypeReadme(anytypeDir string) error {
+func writeAnytypeReadme(anytypeDir string, vault *vaultFiles) error {
 	rawReadme := strings.TrimSpace(`This folder stores exporter metadata for this vault.
 
 What is inside:
 - index.json with deterministic object ID -> note path mapping
 - raw/ with one JSON sidecar per exported object: <object-id>.json
-- each raw sidecar keeps original Anytype fields: id, sbType, details
+- report.json with export counts and warnings from the last run
+- partial.json, only present when the last run was interrupted, listing the objects it finished writing
+- profile/, only present after a run with -profile: cpu.pprof, heap.pprof and phases.json timings
+- each raw sidecar keeps original Anytype fields: id, sbType, details, and unsupportedBlocks with the blocks the exporter could not render
 
 Why it exists:
 - Preserves metadata that may not fit cleanly into Obsidian markdown/frontmatter
@@ -287,33 +398,82 @@ Can I delete this folder?
 	if err := os.MkdirAll(anytypeDir, 0o755); err != nil {
 		return err
 	}
-	if err := os.WriteFile(filepath.Join(anytypeDir, "README.md"), []byte(rawReadme), 0o644); err != nil {
+	if err := vault.writeFile(filepath.Join(anytypeDir, "README.md"), []byte(rawReadme)); err != nil {
 		return fmt.Errorf("write raw metadata readme: %w", err)
 	}
 	return nil
 }
 
-func buildNotePathIndex(allObjects []objectInfo, filenameEscaping string) map[string]string {
+func buildNotePathIndex(allObjects []objectInfo, naming filenameOptions, exportPeople bool) map[string]string {
 	notePathByID := make(map[string]string, len(allObjects))
 	used := map[string]int{}
 	for _, obj := range allObjects {
-		title := inferObjectTitle(obj)
-		base := sanitizeName(title, filenameEscaping)
-		if base == "" {
-			base = "Untitled"
+		dir := "notes"
+		switch {
+		case isDeletedObject(obj):
+			dir = "trash"
+		case exportPeople && isParticipantObject(obj):
+			dir = "people"
 		}
-		usedKey := filenameCollisionKey(base, filenameEscaping)
+		base := naming.noteBaseName(obj)
+		usedKey := dir + "/" + naming.collisionKey(base)
 		n := used[usedKey]
 		used[usedKey] = n + 1
 		if n > 0 {
 			base = base + "-" + strconv.Itoa(n+1)
 		}
-		notePathByID[obj.ID] = filepath.ToSlash(filepath.Join("notes", base+".md"))
+		base = naming.fit(base, ".md")
+		notePathByID[obj.ID] = filepath.ToSlash(filepath.Join(dir, base+".md"))
 	}
 	return notePathByID
 }
 
-func buildTemplatePathIndex(templates []templateInfo, typesByID map[string]typeDef, filenameEscaping string) map[string]string {
+func participantDisplayName(obj objectInfo) string {
+	for _, key := range []string{"name", "globalName", "identity"} {
+		if name := strings.TrimSpace(asString(obj.Details[key])); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func isParticipantObject(obj objectInfo) bool {
+	if obj.SbType == "Participant" {
+		return true
+	}
+	layout, ok := obj.Details["resolvedLayout"]
+	if !ok {
+		layout, ok = obj.Details["layout"]
+	}
+	return ok && asInt(layout) == anytypedomain.LayoutParticipant
+}
+
+// noteAliases returns the titles a note can be found by besides its file
+// name: the primary title when the filename no longer carries it verbatim,
+// plus any diverging name/title-block/title-detail variants.
+func noteAliases(obj objectInfo, noteRelPath string, naming filenameOptions) []string {
+	variants := objectTitleVariants(obj)
+	if len(variants) == 0 {
+		return nil
+	}
+	title := variants[0]
+	fileTitle := strings.TrimSuffix(filepath.Base(filepath.ToSlash(noteRelPath)), filepath.Ext(noteRelPath))
+
+	sanitized := naming.sanitize(title)
+	truncated := naming.fit(sanitized, ".md") != sanitized
+	renamed := naming.transliterate || naming.asciiSlug || naming.emojiPrefix || naming.scheme != filenameSchemeName || truncated
+
+	var aliases []string
+	for i, variant := range variants {
+		if variant == fileTitle || (i == 0 && !renamed) {
+			continue
+		}
+		aliases = append(aliases, variant)
+	}
+	return aliases
+}
+
+func buildTemplatePathIndex(templates []templateInfo, typesByID map[string]typeDef, naming filenameOptions) map[string]string {
 	templatePathByID := make(map[string]string, len(templates))
 	usedTemplateNames := map[string]int{}
 	for _, tmpl := range templates {
@@ -322,19 +482,20 @@ func buildTemplatePathIndex(templates []templateInfo, typesByID map[string]typeD
 		if strings.TrimSpace(templateName) == "" {
 			templateName = "Template"
 		}
-		base := sanitizeName(typeName+" - "+templateName, filenameEscaping)
+		base := naming.sanitize(typeName + " - " + templateName)
 		if base == "" {
-			base = sanitizeName(typeName+" - Template", filenameEscaping)
+			base = naming.sanitize(typeName + " - Template")
 		}
 		if base == "" {
 			base = "Template"
 		}
-		usedKey := filenameCollisionKey(base, filenameEscaping)
+		usedKey := naming.collisionKey(base)
 		n := usedTemplateNames[usedKey]
 		usedTemplateNames[usedKey] = n + 1
 		if n > 0 {
 			base = base + "-" + strconv.Itoa(n+1)
 		}
+		base = naming.fit(base, ".md")
 		templatePathByID[tmpl.ID] = filepath.ToSlash(filepath.Join("templates", base+".md"))
 	}
 	return templatePathByID
@@ -365,7 +526,7 @@ func filterOutBaseBackedNotes(notePathByID map[string]string, basePathByID map[s
 	return filtered
 }
 
-func buildObjectNameIndexes(allObjects []objectInfo, typesByID map[string]typeDef, optionsByID map[string]relationOption) (map[string]objectInfo, map[string]string, map[string]string) {
+func buildObjectNameIndexes(allObjects []objectInfo, knownObjects []objectInfo, typesByID map[string]typeDef, optionsByID map[string]relationOption) (map[string]objectInfo, map[string]string, map[string]string) {
 	idToObject := make(map[string]objectInfo, len(allObjects))
 	objectNamesByID := make(map[string]string, len(allObjects)+len(typesByID)+len(optionsByID))
 	for _, o := range allObjects {
@@ -374,6 +535,32 @@ func buildObjectNameIndexes(allObjects []objectInfo, typesByID map[string]typeDe
 			objectNamesByID[o.ID] = name
 		}
 	}
+	// Objects left out of the export (e.g. archived) still name relation targets.
+	for _, o := range knownObjects {
+		if _, exists := objectNamesByID[o.ID]; exists {
+			continue
+		}
+		if name := strings.TrimSpace(o.Name); name != "" {
+			objectNamesByID[o.ID] = name
+		}
+	}
+	for _, o := range append(append([]objectInfo{}, allObjects...), knownObjects...) {
+		if isParticipantObject(o) {
+			// creator/lastModifiedBy may hold the participant id or the bare identity.
+			name := participantDisplayName(o)
+			for _, id := range []string{o.ID, strings.TrimSpace(asString(o.Details["identity"]))} {
+				if _, exists := objectNamesByID[id]; id != "" && name != "" && !exists {
+					objectNamesByID[id] = name
+				}
+			}
+		}
+		if _, exists := objectNamesByID[o.ID]; exists {
+			continue
+		}
+		if snippet := objectSnippetName(o.Details); snippet != "" {
+			objectNamesByID[o.ID] = snippet
+		}
+	}
 	for id, typeInfo := range typesByID {
 		name := strings.TrimSpace(typeInfo.Name)
 		if name == "" {
@@ -400,10 +587,28 @@ func buildObjectNameIndexes(allObjects []objectInfo, typesByID map[string]typeDe
 	return idToObject, objectNamesByID, optionNamesByID
 }
 
+func objectSnippetName(details map[string]any) string {
+	snippet := strings.TrimSpace(asString(details["snippet"]))
+	if idx := strings.IndexByte(snippet, '\n'); idx >= 0 {
+		snippet = strings.TrimSpace(snippet[:idx])
+	}
+	const maxRunes = 60
+	if runes := []rune(snippet); len(runes) > maxRunes {
+		snippet = strings.TrimSpace(string(runes[:maxRunes])) + "…"
+	}
+	return snippet
+}
+
 func isArchivedObject(obj objectInfo) bool {
 	return asBool(anyMapGet(obj.Details, "isArchived", "is_archived", "archived"))
 }
 
+// isDeletedObject reports objects deleted from the bin, which exports may
+// still carry with their last content.
+func isDeletedObject(obj objectInfo) bool {
+	return asBool(anyMapGet(obj.Details, "isDeleted", "is_deleted", "deleted"))
+}
+
 func shouldExportBaseObject(obj objectInfo, includeRelationOptionDataviews bool) bool {
 	if includeRelationOptionDataviews {
 		return true
@@ -437,13 +642,44 @@ func isCollectionObject(obj objectInfo) bool {
 	return false
 }
 
-func filterExportableObjects(objects []objectInfo, includeArchivedObjects bool) []objectInfo {
-	if includeArchivedObjects {
+// dateFilterAnchor is the "now" relative date filters of a base resolve
+// against. NoDynamicTimestamps pins it to the base object's last
+// modification so identical input renders identical bases; without a
+// timestamp relative filters are dropped.
+func (e Exporter) dateFilterAnchor(obj objectInfo, started time.Time) time.Time {
+	if !e.NoDynamicTimestamps {
+		return started
+	}
+	if _, modified, ok := anytypeTimestamps(obj.Details); ok {
+		return modified
+	}
+	return time.Time{}
+}
+
+func missingFileObjects(outputDir string, fileObjects map[string]string) []string {
+	var missing []string
+	for id, relPath := range fileObjects {
+		if strings.TrimSpace(relPath) == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(relPath))); os.IsNotExist(err) {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func filterExportableObjects(objects []objectInfo, includeArchivedObjects bool, includeDeletedObjects bool) []objectInfo {
+	if includeArchivedObjects && includeDeletedObjects {
 		return objects
 	}
 	filtered := make([]objectInfo, 0, len(objects))
 	for _, obj := range objects {
-		if isArchivedObject(obj) {
+		if !includeArchivedObjects && isArchivedObject(obj) {
+			continue
+		}
+		if !includeDeletedObjects && isDeletedObject(obj) {
 			continue
 		}
 		filtered = append(filtered, obj)
@@ -451,10 +687,89 @@ func filterExportableObjects(objects []objectInfo, includeArchivedObjects bool)
 	return filtered
 }
 
+func (e Exporter) logger() *slog.Logger {
+	if e.Logger != nil {
+		return e.Logger
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
+
 func (e Exporter) Run() (Stats, error) {
+	return e.RunContext(context.Background())
+}
+
+// RunContext is Run with cancellation: once ctx is done the export stops at
+// the next object boundary, records what was written in _anytype/partial.json
+// and returns an error wrapping ctx.Err().
+func (e Exporter) RunContext(ctx context.Context) (Stats, error) {
+	started := time.Now()
+	log := e.logger()
+	var warnings []string
+
+	if e.MaxFilenameLength > 0 && e.MaxFilenameLength < minMaxFilenameBytes {
+		return Stats{}, fmt.Errorf("invalid max filename length %d: must be at least %d bytes, or -1 to disable the limit", e.MaxFilenameLength, minMaxFilenameBytes)
+	}
+	if e.ImageQuality != 0 && (e.ImageQuality < 1 || e.ImageQuality > 100) {
+		return Stats{}, fmt.Errorf("invalid image quality %d: expected 1-100", e.ImageQuality)
+	}
+	if e.OutputZip != "" {
+		if e.InputDir == "" {
+			return Stats{}, fmt.Errorf("input directory is required")
+		}
+		return e.runToZip(ctx)
+	}
 	if e.InputDir == "" || e.OutputDir == "" {
 		return Stats{}, fmt.Errorf("input and output directories are required")
 	}
+	// With an absolute output path the os package adds the \\?\ prefix
+	// on Windows, so deep attachment folders may exceed MAX_PATH.
+	if abs, err := filepath.Abs(e.OutputDir); err == nil {
+		e.OutputDir = abs
+	}
+	if e.SplitBySpace {
+		return e.runSplitBySpace(ctx)
+	}
+	outputFormat, err := resolveOutputFormat(e.Format)
+	if err != nil {
+		return Stats{}, err
+	}
+	flavor, err := resolveFlavor(e.Flavor)
+	if err != nil {
+		return Stats{}, err
+	}
+	if flavor != flavorObsidian && outputFormat != outputFormatMarkdown {
+		return Stats{}, fmt.Errorf("%s flavor cannot be combined with format %q", flavor, outputFormat)
+	}
+	switch flavor {
+	case flavorLogseq:
+		return e.runToLogseq(ctx)
+	case flavorPortable:
+		return e.runToPortable(ctx)
+	}
+	if outputFormat == outputFormatHTML {
+		return e.runToHTML(ctx)
+	}
+	if mode, err := resolveUpdateMode(e.Update); err != nil {
+		return Stats{}, err
+	} else if mode == updateFrontmatter {
+		if e.Atomic {
+			return Stats{}, fmt.Errorf("atomic export cannot be combined with a frontmatter update")
+		}
+		return e.runFrontmatterUpdate(ctx)
+	}
+	if e.Atomic {
+		if strategy, err := resolveMergeStrategy(e.MergeStrategy); err != nil {
+			return Stats{}, err
+		} else if strategy != mergeOverwrite {
+			return Stats{}, fmt.Errorf("atomic export cannot be combined with merge strategy %q", strategy)
+		}
+		if patterns, err := resolveProtectPatterns(e.Protect); err != nil {
+			return Stats{}, err
+		} else if len(patterns) > 0 {
+			return Stats{}, fmt.Errorf("atomic export replaces the whole vault and cannot be combined with protected paths")
+		}
+		return e.runAtomic(ctx)
+	}
 
 	if err := os.MkdirAll(e.OutputDir, 0o755); err != nil {
 		return Stats{}, fmt.Errorf("create output dir: %w", err)
@@ -464,11 +779,127 @@ func (e Exporter) Run() (Stats, error) {
 	if err != nil {
 		return Stats{}, err
 	}
+	filenameScheme, err := resolveFilenameScheme(e.FilenameScheme)
+	if err != nil {
+		return Stats{}, err
+	}
+	maxFilenameBytes := e.MaxFilenameLength
+	if maxFilenameBytes == 0 {
+		maxFilenameBytes = defaultMaxFilenameBytes
+	}
+	iconStyle, err := resolveIconStyle(e.IconStyle)
+	if err != nil {
+		return Stats{}, err
+	}
+	naming := filenameOptions{escaping: filenameEscaping, transliterate: e.TransliterateFilenames, scheme: filenameScheme, maxBytes: maxFilenameBytes, asciiSlug: e.ASCIIFilenames, emojiPrefix: e.EmojiInFilename || iconStyle == iconStyleFilename, foldCase: caseInsensitiveFilenames(e.FilenameEscaping, filenameEscaping, e.OutputDir), nfc: e.NFCFilenames}
+	verifyMode, err := resolveVerifyMode(e.VerifyLinks)
+	if err != nil {
+		return Stats{}, err
+	}
+	columnLayout, err := resolveColumnLayout(e.ColumnLayout)
+	if err != nil {
+		return Stats{}, err
+	}
+	relationBlocks, err := resolveRelationBlockStyle(e.RelationBlocks)
+	if err != nil {
+		return Stats{}, err
+	}
+	tocMode, err := resolveTOCMode(e.TOCMode)
+	if err != nil {
+		return Stats{}, err
+	}
+	calloutTypes, err := resolveCalloutTypes(e.CalloutTypes)
+	if err != nil {
+		return Stats{}, err
+	}
+	toggleHeadings, err := resolveToggleHeadings(e.ToggleHeadings)
+	if err != nil {
+		return Stats{}, err
+	}
+	complexTables, err := resolveComplexTables(e.ComplexTables)
+	if err != nil {
+		return Stats{}, err
+	}
+	mergeStrategy, err := resolveMergeStrategy(e.MergeStrategy)
+	if err != nil {
+		return Stats{}, err
+	}
+	protectPatterns, err := resolveProtectPatterns(e.Protect)
+	if err != nil {
+		return Stats{}, err
+	}
+	vault := &vaultFiles{root: e.OutputDir, protect: protectPatterns}
+	formatter, err := resolveFormatter(e.Formatter, e.RunPrettier)
+	if err != nil {
+		return Stats{}, err
+	}
+	prettier, err := resolvePrettierInvocation(e.PrettierCommand, e.PrettierConfig, e.PrettierTargets)
+	if err != nil {
+		return Stats{}, err
+	}
+	propertyOrder, err := resolvePropertyOrder(e.PropertyOrder)
+	if err != nil {
+		return Stats{}, err
+	}
+	propertiesStyle, err := resolvePropertiesStyle(e.PropertiesStyle)
+	if err != nil {
+		return Stats{}, err
+	}
+	nestedTags, err := resolveTagHierarchy(e.TagHierarchy)
+	if err != nil {
+		return Stats{}, err
+	}
+	tagSources, err := resolveTagSources(e.TagsFromProperties, e.NoMergeTags)
+	if err != nil {
+		return Stats{}, err
+	}
+	tableFormat, err := resolveTableFormat(e.ExportTablesFormat)
+	if err != nil {
+		return Stats{}, err
+	}
+	queryTarget, err := resolveQueryTarget(e.QueryTarget)
+	if err != nil {
+		return Stats{}, err
+	}
+	properties, err := resolveProperties(e.Properties)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var profiler *exportProfiler
+	if e.Profile {
+		profiler, err = startExportProfiler()
+		if err != nil {
+			return Stats{}, err
+		}
+		defer profiler.stop()
+	}
 
-	exportData, err := anytypejson.ReadExport(e.InputDir)
+	progressBar := newExportProgressBar(exportPhases)
+	if e.Progress != nil {
+		progressBar.enabled = false
+		progressBar.onProgress = e.Progress
+	}
+	defer progressBar.Close()
+
+	progressBar.StartPhase(PhaseReadExport, 0)
+	input, closeInput, err := e.openInput()
+	if err != nil {
+		return Stats{}, err
+	}
+	defer closeInput()
+	exportData, err := anytypejson.ReadExportFS(input, anytypejson.ReadOptions{FailFast: e.FailFast, LowMemory: e.LowMemory})
 	if err != nil {
 		return Stats{}, err
 	}
+	for _, problem := range exportData.Problems {
+		log.Warn("skipped unreadable snapshot", "path", problem.Path, "error", problem.Err)
+		warnings = append(warnings, fmt.Sprintf("skipped unreadable snapshot %s: %v", problem.Path, problem.Err))
+	}
+	log.Debug("read export", "format", exportData.Format, "objects", len(exportData.Objects))
+	if err := ctx.Err(); err != nil {
+		return Stats{}, fmt.Errorf("export interrupted: %w", err)
+	}
 	objects := exportData.Objects
 	relations := exportData.Relations
 	optionsByID := exportData.OptionsByID
@@ -480,44 +911,180 @@ func (e Exporter) Run() (Stats, error) {
 	if err != nil {
 		return Stats{}, err
 	}
-	if err := writeAnytypeReadme(dirs.anytypeDir); err != nil {
+	if err := writeAnytypeReadme(dirs.anytypeDir, vault); err != nil {
 		return Stats{}, err
 	}
 
-	copiedFiles, err := copyDir(filepath.Join(e.InputDir, "files"), filepath.Join(e.OutputDir, "files"))
+	partial := partialExport{}
+	interrupted := func(phase string) error {
+		partial.Phase = phase
+		if err := writePartialExportMarker(dirs.anytypeDir, partial, vault); err != nil {
+			return fmt.Errorf("export interrupted: %w (write partial marker: %v)", ctx.Err(), err)
+		}
+		log.Warn("export interrupted", "phase", phase, "notes", len(partial.Notes))
+		return fmt.Errorf("export interrupted: %w", ctx.Err())
+	}
+
+	isProtected := func(relPath string) bool {
+		return matchVaultGlobs(relPath, protectPatterns)
+	}
+	progressBar.StartPhase(PhaseCopyFiles, 0)
+	copiedFiles := 0
+	if !e.frontmatterOnly {
+		copiedFiles, err = copyDir(input, "files", filepath.Join(e.OutputDir, "files"), func(rel string) bool {
+			return isProtected("files/" + rel)
+		}, func(done, total int) {
+			progressBar.total = total
+			progressBar.Advance("")
+		})
+		if err != nil {
+			return Stats{}, err
+		}
+	}
+	// Without copies the attachments are only renamed in fileObjects, so
+	// links still point where the previous export put them.
+	escapedFiles, err := escapeAttachmentNames(input, e.OutputDir, fileObjects, naming, protectPatterns)
 	if err != nil {
-		return Stats{}, err
+		return Stats{}, fmt.Errorf("escape attachment names: %w", err)
 	}
-	if err := normalizeExportedFileObjectPaths(e.InputDir, e.OutputDir, fileObjects); err != nil {
+	for _, rename := range escapedFiles {
+		log.Debug("renamed attachment", "from", rename.from, "to", rename.to)
+	}
+	if err := normalizeExportedFileObjectPaths(input, e.OutputDir, fileObjects, isProtected); err != nil {
 		return Stats{}, err
 	}
+	if !e.frontmatterOnly {
+		resizedImages, err := shrinkImages(e.OutputDir, exportfs.ImageOptions{
+			MaxDimension:  e.MaxImageDimension,
+			Quality:       e.ImageQuality,
+			KeepOriginals: e.KeepOriginalImages,
+			Skip:          isProtected,
+		})
+		if err != nil {
+			return Stats{}, fmt.Errorf("resize images: %w", err)
+		}
+		for _, path := range resizedImages {
+			log.Debug("resized image", "path", path, "maxDimension", e.MaxImageDimension)
+		}
+	}
+	if ctx.Err() != nil {
+		return Stats{}, interrupted(PhaseCopyFiles)
+	}
+
+	if !e.frontmatterOnly {
+		for _, missing := range missingFileObjects(e.OutputDir, fileObjects) {
+			log.Warn("missing file", "id", missing, "path", fileObjects[missing])
+		}
+	}
 
-	objects = filterExportableObjects(objects, e.IncludeArchivedObjects)
+	objects = filterExportableObjects(objects, e.IncludeArchivedObjects, e.IncludeDeleted)
+	for _, obj := range exportData.Objects {
+		switch {
+		case !e.IncludeDeleted && isDeletedObject(obj):
+			log.Debug("skipped deleted object", "id", obj.ID, "name", obj.Name)
+		case !e.IncludeArchivedObjects && isArchivedObject(obj):
+			log.Debug("skipped archived object", "id", obj.ID, "name", obj.Name)
+		}
+	}
+	objects = filterSystemObjects(objects, e.IncludeSystemObjects, e.KeepSystemObjectTypes, func(obj objectInfo, kind string) {
+		log.Debug("skipped system object", "id", obj.ID, "name", obj.Name, "kind", kind)
+	})
+	if !e.frontmatterOnly {
+		generatedCovers, err := writeGeneratedCovers(e.OutputDir, objects, vault)
+		if err != nil {
+			return Stats{}, err
+		}
+		for _, cover := range generatedCovers {
+			log.Debug("generated cover", "path", cover)
+		}
+	}
 
 	filters := newPropertyFilters(e.ExcludePropertyKeys, e.ForceIncludePropertyKeys, e.LinkAsNotePropertyKeys, e.ExcludeEmptyProperties)
+	fmOptions := frontmatterOptions{
+		includeDynamicProperties:  e.IncludeDynamicProperties,
+		includeArchivedProperties: e.IncludeArchivedProperties,
+		filters:                   filters,
+		prettyPropertyIcon:        !e.DisablePrettyPropertyIcon,
+		pictureToCover:            !e.DisablePictureToCover,
+		embedAnytypeMetadata:      e.EmbedAnytypeMetadata,
+		iconStyle:                 iconStyle,
+		propertyOrder:             propertyOrder,
+		propertiesStyle:           propertiesStyle,
+		tagHierarchy:              nestedTags,
+		tagSources:                tagSources,
+		typeAsTag:                 e.TypeAsTag,
+		properties:                properties,
+		webClippings:              e.WebClippings,
+		multiValueLists:           e.MultiValueLists,
+	}
+	skippedEmpty := 0
+	if e.SkipEmptyNotes {
+		kept := objects[:0:0]
+		for _, obj := range objects {
+			blocks := obj.Blocks
+			if obj.BlocksPath != "" {
+				if blocks, err = anytypejson.ReadObjectBlocks(input, obj); err != nil {
+					return Stats{}, fmt.Errorf("read blocks %s: %w", obj.ID, err)
+				}
+			}
+			if isEmptyObject(obj, blocks, relations, typesByID, fmOptions) {
+				log.Debug("skipped empty object", "id", obj.ID, "name", obj.Name)
+				skippedEmpty++
+				continue
+			}
+			kept = append(kept, obj)
+		}
+		objects = kept
+		log.Info("skipped empty notes", "count", skippedEmpty)
+	}
 	syntheticObjects := buildSyntheticLinkObjects(objects, relations, optionsByID, typesByID, filters)
 
 	allObjects := make([]objectInfo, 0, len(objects)+len(syntheticObjects))
 	allObjects = append(allObjects, objects...)
 	allObjects = append(allObjects, syntheticObjects...)
 
-	progressBar := newExportProgressBar(len(objects) + len(templates) + len(allObjects) + 1)
-	if e.RunPrettier {
-		progressBar.total++
-	}
-	defer progressBar.Close()
-
-	notePathByID := buildNotePathIndex(allObjects, filenameEscaping)
-	templatePathByID := buildTemplatePathIndex(templates, typesByID, filenameEscaping)
-	idToObject, objectNamesByID, optionNamesByID := buildObjectNameIndexes(allObjects, typesByID, optionsByID)
+	notePathByID := buildNotePathIndex(allObjects, naming, e.ExportPeople)
+	templatePathByID := buildTemplatePathIndex(templates, typesByID, naming)
+	idToObject, objectNamesByID, optionNamesByID := buildObjectNameIndexes(allObjects, exportData.Objects, typesByID, optionsByID)
 
 	usedExcalidrawNames := map[string]int{}
 
+	merger, err := loadVaultMerger(e.OutputDir, mergeStrategy)
+	if err != nil {
+		return Stats{}, err
+	}
+	merger.stableOnly = e.NoDynamicTimestamps
+	merger.protect = protectPatterns
+	noteMergeOutcome := func(relPath string, written string) {
+		switch {
+		case written == relPath:
+		case written == "" && merger.protected(relPath):
+			warnings = append(warnings, fmt.Sprintf("protected: %s was not written", relPath))
+			log.Warn("skipped protected path", "path", relPath)
+		case written == "":
+			log.Info("kept existing file", "path", relPath, "strategy", mergeStrategy)
+		default:
+			warnings = append(warnings, fmt.Sprintf("conflict: %s was edited, export written to %s", relPath, written))
+			log.Warn("merge conflict", "path", relPath, "exported", written)
+		}
+	}
+
+	progressBar.StartPhase(PhaseRenderBases, len(objects))
 	basePathByID := map[string]string{}
 	usedBaseNames := map[string]int{}
+	var projects []projectsProject
 	for _, obj := range objects {
+		if ctx.Err() != nil {
+			return Stats{}, interrupted(PhaseRenderBases)
+		}
 		if !shouldExportBaseObject(obj, e.IncludeArchivedProperties) {
-			progressBar.Advance("exporting bases")
+			log.Debug("skipped relation option dataview", "id", obj.ID, "name", obj.Name)
+			progressBar.AdvanceObject(obj.ID)
+			continue
+		}
+		if isDeletedObject(obj) {
+			// Deleted sets are recovered as notes in trash/, not as live bases.
+			progressBar.AdvanceObject(obj.ID)
 			continue
 		}
 		baseContent, ok := renderBaseFile(
@@ -527,58 +1094,213 @@ func (e Exporter) Run() (Stats, error) {
 			notePathByID,
 			objectNamesByID,
 			fileObjects,
-			!e.DisablePictureToCover,
+			fmOptions.keys(),
 			e.EnableBasesKanban,
+			e.dateFilterAnchor(obj, started),
 		)
 		if !ok {
-			progressBar.Advance("exporting bases")
+			progressBar.AdvanceObject(obj.ID)
 			continue
 		}
 		title := inferObjectTitle(obj)
-		baseName := sanitizeName(title, filenameEscaping)
+		baseName := naming.sanitize(title)
 		if baseName == "" {
 			baseName = "Untitled"
 		}
-		usedKey := filenameCollisionKey(baseName, filenameEscaping)
+		usedKey := naming.collisionKey(baseName)
 		n := usedBaseNames[usedKey]
 		usedBaseNames[usedKey] = n + 1
 		if n > 0 {
 			baseName = baseName + "-" + strconv.Itoa(n+1)
 		}
-		basePathByID[obj.ID] = filepath.ToSlash(filepath.Join("bases", baseName+".base"))
-		basePath := filepath.Join(dirs.baseDir, baseName+".base")
-		if err := os.WriteFile(basePath, []byte(baseContent), 0o644); err != nil {
+		if queryTarget == queryTargetProjects {
+			// The set stays a regular note; Projects shows its members.
+			if project, ok := buildProject(obj, baseName, collectBaseMembers(obj, objects, notePathByID), relations, optionNamesByID, notePathByID, objectNamesByID, fileObjects, fmOptions.keys(), e.dateFilterAnchor(obj, started)); ok {
+				projects = append(projects, project)
+			}
+			progressBar.AdvanceObject(obj.ID)
+			continue
+		}
+		if e.staticBaseLists {
+			basePathByID[obj.ID] = path.Join("bases", naming.fit(baseName, ".md")+".md")
+			baseContent = renderBaseMemberList(title, basePathByID[obj.ID], collectBaseMembers(obj, objects, notePathByID))
+		} else {
+			baseName = naming.fit(baseName, ".base")
+			basePathByID[obj.ID] = filepath.ToSlash(filepath.Join("bases", baseName+".base"))
+			if err := validateBaseYAML(baseContent); err != nil {
+				return Stats{}, fmt.Errorf("base %s: %w", obj.ID, err)
+			}
+		}
+		partial.Bases = append(partial.Bases, obj.ID)
+		written, err := merger.write(basePathByID[obj.ID], obj.ID, []byte(baseContent), obj.Details)
+		if err != nil {
 			return Stats{}, fmt.Errorf("write base %s: %w", obj.ID, err)
 		}
-		if err := applyExportedFileTimes(basePath, obj.Details); err != nil {
-			return Stats{}, fmt.Errorf("apply base timestamps %s: %w", obj.ID, err)
+		noteMergeOutcome(basePathByID[obj.ID], written)
+		for _, calc := range untranslatedViewCalculations(obj, relations, fmOptions.keys()) {
+			warnings = append(warnings, fmt.Sprintf("base %s: no Bases summary for column calculation %s", basePathByID[obj.ID], calc))
+			log.Warn("untranslated column calculation", "base", basePathByID[obj.ID], "calculation", calc)
 		}
-		progressBar.Advance("exporting bases")
+		progressBar.AdvanceObject(obj.ID)
+	}
+
+	if err := writeProjectsData(e.OutputDir, projects, vault); err != nil {
+		return Stats{}, fmt.Errorf("write projects: %w", err)
 	}
 
 	exportedNotePathByID := filterOutBaseBackedNotes(notePathByID, basePathByID)
+	pinNotePaths(exportedNotePathByID, e.notePaths)
 	linkPathByID := buildLinkTargetIndex(exportedNotePathByID, basePathByID)
+	pinNotePaths(linkPathByID, e.notePaths)
+
+	if e.KanbanBoards {
+		usedBoardNames := map[string]int{}
+		for _, obj := range objects {
+			basePath, ok := basePathByID[obj.ID]
+			if !ok {
+				continue
+			}
+			for _, board := range buildKanbanBoards(obj, objects, exportedNotePathByID, relations, optionNamesByID, objectNamesByID, fileObjects) {
+				viewName := naming.sanitize(board.viewName)
+				if viewName == "" {
+					viewName = "Board"
+				}
+				boardName := strings.TrimSuffix(path.Base(basePath), ".base") + " - " + viewName
+				usedKey := naming.collisionKey(boardName)
+				n := usedBoardNames[usedKey]
+				usedBoardNames[usedKey] = n + 1
+				if n > 0 {
+					boardName = boardName + "-" + strconv.Itoa(n+1)
+				}
+				boardPath := path.Join("bases", naming.fit(boardName, ".md")+".md")
+				written, err := merger.write(boardPath, obj.ID, []byte(renderKanbanBoard(board, boardPath)), obj.Details)
+				if err != nil {
+					return Stats{}, fmt.Errorf("write kanban board %s: %w", obj.ID, err)
+				}
+				noteMergeOutcome(boardPath, written)
+				log.Debug("exported kanban board", "id", obj.ID, "path", boardPath)
+			}
+		}
+	}
 
+	if e.ExportTablesDir != "" {
+		for _, obj := range objects {
+			basePath, ok := basePathByID[obj.ID]
+			if !ok {
+				continue
+			}
+			name := strings.TrimSuffix(path.Base(basePath), path.Ext(basePath))
+			table := buildObjectTable(obj, objects, relations, optionNamesByID, objectNamesByID, fileObjects)
+			if err := writeObjectTable(e.ExportTablesDir, name, tableFormat, table, vault); err != nil {
+				return Stats{}, fmt.Errorf("write table %s: %w", obj.ID, err)
+			}
+			log.Debug("exported table", "id", obj.ID, "name", name, "rows", len(table.Rows))
+		}
+	}
+
+	if e.ExportVCards {
+		written, err := writeVCards(e.OutputDir, collectContacts(objects, relations, typesByID), naming, vault)
+		if err != nil {
+			return Stats{}, fmt.Errorf("write vcards: %w", err)
+		}
+		log.Info("exported vcards", "count", written, "dir", vcardDir)
+	}
+
+	if e.ExportICS {
+		events := collectEvents(objects, exportedNotePathByID, relations)
+		if err := writeICS(e.OutputDir, events, vault); err != nil {
+			return Stats{}, fmt.Errorf("write ics: %w", err)
+		}
+		log.Info("exported calendar", "events", len(events), "path", icsFileName)
+	}
+
+	if e.StrictRelations || log.Enabled(ctx, slog.LevelInfo) {
+		unresolved := collectUnresolvedRelationTargets(allObjects, exportedNotePathByID, linkPathByID, objectNamesByID, relations, typesByID, fmOptions)
+		if e.StrictRelations && len(unresolved) > 0 {
+			return Stats{}, fmt.Errorf("strict relations: %s", formatUnresolvedRelationTargets(unresolved))
+		}
+		for _, target := range unresolved {
+			log.Info("unresolved relation", "note", target.NotePath, "property", target.Property, "target", target.TargetID)
+		}
+	}
+
+	// Low-memory reads load each body here once more; a read error surfaces
+	// again when the note itself is rendered.
+	blocksOf := func(obj objectInfo) []block {
+		if obj.BlocksPath == "" {
+			return obj.Blocks
+		}
+		blocks, _ := anytypejson.ReadObjectBlocks(input, obj)
+		return blocks
+	}
+
+	if e.MissingStubs {
+		missing := collectMissingObjects(allObjects, blocksOf, exportedNotePathByID, linkPathByID, objectNamesByID, relations, typesByID, fmOptions)
+		stubPaths := buildMissingStubPaths(missing, naming)
+		ids := make([]string, 0, len(stubPaths))
+		for id := range stubPaths {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if len(ids) > 0 {
+			if err := os.MkdirAll(filepath.Join(e.OutputDir, missingStubDir), 0o755); err != nil {
+				return Stats{}, err
+			}
+		}
+		for _, id := range ids {
+			stubPath := stubPaths[id]
+			written, err := merger.write(stubPath, id, []byte(renderMissingStub(id)), nil)
+			if err != nil {
+				return Stats{}, fmt.Errorf("write missing stub %s: %w", id, err)
+			}
+			noteMergeOutcome(stubPath, written)
+			linkPathByID[id] = stubPath
+			log.Info("wrote stub for missing object", "id", id, "path", stubPath)
+		}
+	}
+
+	progressBar.StartPhase(PhaseRenderTemplates, len(templates))
 	for _, tmpl := range templates {
+		if ctx.Err() != nil {
+			return Stats{}, interrupted(PhaseRenderTemplates)
+		}
 		templateRelPath := templatePathByID[tmpl.ID]
 		templateAbsPath := filepath.Join(e.OutputDir, filepath.FromSlash(templateRelPath))
 		if err := os.MkdirAll(filepath.Dir(templateAbsPath), 0o755); err != nil {
 			return Stats{}, err
 		}
-		content := renderTemplate(tmpl, relations, idToObject, linkPathByID, fileObjects, !e.DisablePictureToCover)
-		if err := os.WriteFile(templateAbsPath, []byte(content), 0o644); err != nil {
+		content := renderTemplate(tmpl, templateRelPath, relations, optionNamesByID, objectNamesByID, idToObject, linkPathByID, fileObjects, fmOptions.keys(), tocMode)
+		if err := validateFrontmatterYAML(content); err != nil {
+			return Stats{}, fmt.Errorf("template %s: %w", tmpl.ID, err)
+		}
+		written, err := merger.write(templateRelPath, tmpl.ID, []byte(content), tmpl.Details)
+		if err != nil {
 			return Stats{}, fmt.Errorf("write template %s: %w", tmpl.ID, err)
 		}
-		if err := applyExportedFileTimes(templateAbsPath, tmpl.Details); err != nil {
-			return Stats{}, fmt.Errorf("apply template timestamps %s: %w", tmpl.ID, err)
+		noteMergeOutcome(templateRelPath, written)
+		partial.Templates = append(partial.Templates, tmpl.ID)
+		progressBar.AdvanceObject(tmpl.ID)
+	}
+
+	if e.WriteDefaultTemplateMap {
+		if err := writeDefaultTemplateMap(e.OutputDir, templates, typesByID, templatePathByID, vault); err != nil {
+			return Stats{}, fmt.Errorf("write default template map: %w", err)
 		}
-		progressBar.Advance("exporting templates")
 	}
 
+	// Links to a single block need a "^id" marker on the target block, so
+	// targets are collected before any note is written.
+	blockAnchors := collectBlockAnchors(allObjects, blocksOf)
+
+	bodyHashes := map[string]string{}
+	progressBar.StartPhase(PhaseRenderNotes, len(allObjects))
 	for _, obj := range allObjects {
+		if ctx.Err() != nil {
+			return Stats{}, interrupted(PhaseRenderNotes)
+		}
 		noteRelPath, ok := exportedNotePathByID[obj.ID]
 		if !ok || strings.TrimSpace(noteRelPath) == "" {
-			progressBar.Advance("exporting notes")
+			progressBar.AdvanceObject(obj.ID)
 			continue
 		}
 		noteAbsPath := filepath.Join(e.OutputDir, filepath.FromSlash(noteRelPath))
@@ -586,33 +1308,100 @@ func (e Exporter) Run() (Stats, error) {
 			return Stats{}, err
 		}
 
-		excalidrawEmbeds, err := exportExcalidrawDrawings(obj, noteRelPath, dirs.excalidrawDir, filenameEscaping, usedExcalidrawNames)
-		if err != nil {
-			return Stats{}, fmt.Errorf("export excalidraw %s: %w", obj.ID, err)
+		if obj.BlocksPath != "" {
+			// Low-memory reads keep only skeleton blocks; load the body for
+			// this note alone so it is released before the next one.
+			blocks, err := anytypejson.ReadObjectBlocks(input, obj)
+			if err != nil {
+				return Stats{}, fmt.Errorf("reload blocks %s: %w", obj.ID, err)
+			}
+			obj.Blocks = blocks
 		}
 
-		fm := renderFrontmatter(
-			obj,
-			relations,
-			typesByID,
-			optionNamesByID,
-			linkPathByID,
-			noteRelPath,
-			objectNamesByID,
-			fileObjects,
-			e.IncludeDynamicProperties,
-			e.IncludeArchivedProperties,
-			filters,
-			!e.DisablePrettyPropertyIcon,
-			!e.DisablePictureToCover,
-		)
-		body := renderBody(obj, idToObject, linkPathByID, noteRelPath, fileObjects, excalidrawEmbeds)
-		if err := os.WriteFile(noteAbsPath, []byte(fm+body), 0o644); err != nil {
-			return Stats{}, fmt.Errorf("write note %s: %w", obj.ID, err)
+		aliases := noteAliases(obj, noteRelPath, naming)
+		var fm, body string
+		var unsupportedBlocks []map[string]any
+		trivial := false
+		if len(blockAnchors[obj.ID]) == 0 {
+			fm, body, trivial = renderTrivialNote(obj, relations, optionNamesByID, linkPathByID, noteRelPath, objectNamesByID, fileObjects, aliases, fmOptions)
 		}
-		if err := applyExportedFileTimes(noteAbsPath, obj.Details); err != nil {
-			return Stats{}, fmt.Errorf("apply note timestamps %s: %w", obj.ID, err)
+		if !trivial {
+			excalidrawEmbeds, err := exportExcalidrawDrawings(obj, noteRelPath, dirs.excalidrawDir, naming, usedExcalidrawNames, vault)
+			if err != nil {
+				return Stats{}, fmt.Errorf("export excalidraw %s: %w", obj.ID, err)
+			}
+
+			entries := frontmatterEntries(
+				obj,
+				relations,
+				typesByID,
+				optionNamesByID,
+				linkPathByID,
+				noteRelPath,
+				objectNamesByID,
+				fileObjects,
+				aliases,
+				fmOptions,
+			)
+			fm = writeFrontmatter(entries, fmOptions)
+			body = renderBody(obj, bodyContext{
+				objects:          idToObject,
+				notes:            linkPathByID,
+				sourceNotePath:   noteRelPath,
+				fileObjects:      fileObjects,
+				excalidrawEmbeds: excalidrawEmbeds,
+				objectNamesByID:  objectNamesByID,
+				columnLayout:     columnLayout,
+				linkCards:        e.LinkCards,
+				relationBlocks:   relationBlocks,
+				tocMode:          tocMode,
+				calloutTypes:     calloutTypes,
+				toggleHeadings:   toggleHeadings,
+				complexTables:    complexTables,
+				relations:        relations,
+				optionNamesByID:  optionNamesByID,
+				blockAnchors:     blockAnchors[obj.ID],
+				transcripts:      exportData.FileTranscripts,
+				warn: func(msg string) {
+					warnings = append(warnings, fmt.Sprintf("%s: %s", noteRelPath, msg))
+					log.Warn("lossy note content", "path", noteRelPath, "problem", msg)
+				},
+				unsupportedBlock: func(b block, kind string) {
+					entry := map[string]any{"id": b.ID, "kind": kind, "content": b.Content}
+					if len(b.Fields) > 0 {
+						entry["fields"] = b.Fields
+					}
+					unsupportedBlocks = append(unsupportedBlocks, entry)
+					warnings = append(warnings, fmt.Sprintf("%s: unsupported %q block %s was not exported; raw content in %s", noteRelPath, kind, b.ID, rawSidecarPath(obj.ID)))
+					log.Warn("unsupported block", "path", noteRelPath, "kind", kind, "block", b.ID)
+				},
+			})
+			if strings.TrimSpace(body) == "" {
+				body = snippetNoteBody(obj.Details)
+			}
+			if e.WebClippings && isBookmarkObject(obj, typesByID) {
+				body = insertWebClipping(body, webClippingBody(obj, fileObjects, noteRelPath))
+			}
+			body = renderInlineProperties(entries, propertiesStyle) + body
+		}
+		if e.ScaffoldEmptyNotes && strings.TrimSpace(body) == "" {
+			body = scaffoldEmptyNoteBody(obj, typesByID)
+		}
+		if iconStyle == iconStyleHeading {
+			body = prependIconHeading(obj, body)
+		}
+		if e.ReportDuplicates {
+			bodyHashes[obj.ID] = duplicateBodyHash(body)
+		}
+		if err := validateFrontmatterYAML(fm); err != nil {
+			return Stats{}, fmt.Errorf("note %s: %w", obj.ID, err)
+		}
+		written, err := merger.write(noteRelPath, obj.ID, []byte(fm+body), obj.Details)
+		if err != nil {
+			return Stats{}, fmt.Errorf("write note %s: %w", obj.ID, err)
 		}
+		noteMergeOutcome(noteRelPath, written)
+		log.Debug("exported note", "id", obj.ID, "path", noteRelPath, "fastPath", trivial)
 
 		rawPath := filepath.Join(dirs.rawDir, obj.ID+".json")
 		rawPayload := map[string]any{
@@ -620,80 +1409,201 @@ func (e Exporter) Run() (Stats, error) {
 			"sbType":  obj.SbType,
 			"details": obj.Details,
 		}
+		if len(unsupportedBlocks) > 0 {
+			rawPayload["unsupportedBlocks"] = unsupportedBlocks
+		}
 		rawBytes, _ := json.MarshalIndent(rawPayload, "", "  ")
-		if err := os.WriteFile(rawPath, rawBytes, 0o644); err != nil {
+		if err := vault.writeFile(rawPath, rawBytes); err != nil {
 			return Stats{}, err
 		}
-		progressBar.Advance("exporting notes")
+		partial.Notes = append(partial.Notes, obj.ID)
+		progressBar.AdvanceObject(obj.ID)
+	}
+
+	if ctx.Err() != nil {
+		return Stats{}, interrupted(PhasePostProcess)
+	}
+
+	if e.ReportDuplicates {
+		duplicates := findDuplicates(allObjects, exportedNotePathByID, bodyHashes)
+		if err := writeDuplicatesReport(e.OutputDir, duplicates, vault); err != nil {
+			return Stats{}, fmt.Errorf("write duplicates report: %w", err)
+		}
+		log.Info("wrote duplicates report", "names", len(duplicates))
 	}
 
-	if !e.DisableIconizeIcons {
-		if err := exportIconizePluginData(e.InputDir, e.OutputDir, allObjects, exportedNotePathByID, fileObjects); err != nil {
-			return Stats{}, fmt.Errorf("export iconize plugin data: %w", err)
+	if e.GraphStats {
+		unresolved := collectUnresolvedRelationTargets(allObjects, exportedNotePathByID, linkPathByID, objectNamesByID, relations, typesByID, fmOptions)
+		graph, err := collectGraphStats(e.OutputDir, allObjects, exportedNotePathByID, typesByID, unresolved)
+		if err != nil {
+			return Stats{}, fmt.Errorf("collect graph stats: %w", err)
 		}
+		if err := writeGraphStats(e.OutputDir, graph, vault); err != nil {
+			return Stats{}, fmt.Errorf("write graph stats: %w", err)
+		}
+		log.Info("wrote graph stats", "notes", graph.notes, "links", graph.links, "orphans", len(graph.orphans))
 	}
 
-	if err := exportPrettyPropertiesPluginData(e.OutputDir, relations, optionsByID); err != nil {
-		return Stats{}, fmt.Errorf("export pretty properties plugin data: %w", err)
+	postProcessSteps := 2
+	if formatter != formatterNone {
+		postProcessSteps++
+	}
+	if verifyMode != "off" {
+		postProcessSteps++
 	}
+	progressBar.StartPhase(PhasePostProcess, postProcessSteps)
+
+	if !e.frontmatterOnly {
+		if !e.DisableIconizeIcons {
+			if err := exportIconizePluginData(input, e.OutputDir, allObjects, exportedNotePathByID, fileObjects, vault); err != nil {
+				return Stats{}, fmt.Errorf("export iconize plugin data: %w", err)
+			}
+		}
+
+		if err := exportPrettyPropertiesPluginData(e.OutputDir, relations, optionsByID, collectPrettyPropertiesMedia(allObjects, exportedNotePathByID, fileObjects, !e.DisablePictureToCover), fmOptions.keys(), vault); err != nil {
+			return Stats{}, fmt.Errorf("export pretty properties plugin data: %w", err)
+		}
+		if e.TagColorsCSS {
+			if err := writeTagColorsSnippet(e.OutputDir, collectTagColors(relations, optionsByID, fmOptions), vault); err != nil {
+				return Stats{}, fmt.Errorf("write tag colors snippet: %w", err)
+			}
+		}
 
-	idx := indexFile{Notes: linkPathByID}
+		propertyTypes := collectObsidianPropertyTypes(allObjects, exportedNotePathByID, relations, typesByID, fmOptions)
+		if err := writeObsidianPropertyTypes(e.OutputDir, propertyTypes, vault); err != nil {
+			return Stats{}, fmt.Errorf("write obsidian property types: %w", err)
+		}
+	}
+	progressBar.Advance("writing plugin data")
+
+	formatted := formatFiles(prettier, merger.updated)
+	switch formatter {
+	case formatterPrettier:
+		if err := tryRunPrettier(e.OutputDir, prettier, formatted); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to apply prettier to export: %v", err))
+			log.Warn("failed to apply prettier to export", "error", err)
+		}
+		progressBar.Advance("formatting with prettier")
+	case formatterBuiltin:
+		changed, err := runBuiltinFormatter(e.OutputDir, formatted)
+		if err != nil {
+			return Stats{}, fmt.Errorf("format markdown: %w", err)
+		}
+		log.Info("formatted markdown", "files", changed)
+		progressBar.Advance("formatting markdown")
+	}
+
+	// The index is written after prettier so the recorded mtimes match the
+	// files as they are left on disk.
+	fileState, err := merger.fileState()
+	if err != nil {
+		return Stats{}, fmt.Errorf("collect file state: %w", err)
+	}
+	idx := indexFile{Notes: linkPathByID, Files: fileState}
 	indexBytes, _ := json.MarshalIndent(idx, "", "  ")
 	if err := os.MkdirAll(dirs.anytypeDir, 0o755); err != nil {
 		return Stats{}, err
 	}
-	if err := os.WriteFile(filepath.Join(dirs.anytypeDir, "index.json"), indexBytes, 0o644); err != nil {
+	if err := vault.writeFile(filepath.Join(dirs.anytypeDir, "index.json"), indexBytes); err != nil {
 		return Stats{}, err
 	}
 	progressBar.Advance("writing index")
 
-	if e.RunPrettier {
-		if err := tryRunPrettier(e.OutputDir); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: failed to apply prettier to export: %v\n", err)
+	var brokenLinks []brokenLink
+	if verifyMode != "off" {
+		brokenLinks, err = verifyVaultLinks(e.OutputDir)
+		if err != nil {
+			return Stats{}, fmt.Errorf("verify links: %w", err)
 		}
-		progressBar.Advance("formatting with prettier")
+		if len(brokenLinks) > 0 && verifyMode == "fail" {
+			return Stats{}, fmt.Errorf("found %d broken links:\n%s", len(brokenLinks), formatBrokenLinks(brokenLinks))
+		}
+		progressBar.Advance("verifying links")
+	}
+
+	for _, link := range brokenLinks {
+		warnings = append(warnings, "broken link "+link.String())
+	}
+
+	if outputFormat == outputFormatBoth {
+		if err := writeHTMLSite(e.OutputDir, e.OutputDir, e.HTMLEmbedImages, vault); err != nil {
+			return Stats{}, fmt.Errorf("write html: %w", err)
+		}
+	}
+
+	if profiler != nil {
+		profileDir, err := profiler.write(dirs.anytypeDir, progressBar.Timings(), vault)
+		if err != nil {
+			return Stats{}, fmt.Errorf("write profile: %w", err)
+		}
+		log.Info("wrote profile", "dir", profileDir)
+	}
+
+	for _, relPath := range vault.skipped {
+		warnings = append(warnings, fmt.Sprintf("protected: %s was not written", relPath))
+		log.Warn("skipped protected path", "path", relPath)
 	}
 
+	stats := Stats{
+		Notes:     len(exportedNotePathByID),
+		Bases:     len(basePathByID),
+		Templates: len(templates),
+		Files:     copiedFiles,
+		Warnings:  len(warnings),
+		Elapsed:   time.Since(started),
+
+		SkippedEmpty: skippedEmpty,
+
+		WarningMessages: warnings,
+	}
+	if err := removePartialExportMarker(dirs.anytypeDir); err != nil {
+		return Stats{}, err
+	}
+	reportStats := stats
+	if e.NoDynamicTimestamps {
+		reportStats.Elapsed = 0
+	}
+	reportPath, err := writeExportReport(dirs.anytypeDir, reportStats, warnings, vault)
+	if err != nil {
+		return Stats{}, fmt.Errorf("write export report: %w", err)
+	}
+	stats.ReportPath = reportPath
+
 	progressBar.Finish("done")
+	for _, link := range brokenLinks {
+		log.Warn("broken link", "source", link.Source, "target", link.Target)
+	}
+	log.Info("export finished", "notes", stats.Notes, "bases", stats.Bases, "templates", stats.Templates, "files", stats.Files, "warnings", stats.Warnings, "elapsed", stats.Elapsed)
 
-	return Stats{Notes: len(exportedNotePathByID), Files: copiedFiles}, nil
+	return stats, nil
 }
 
-func tryRunPrettier(outputDir string) error {
-	if err := prettierCommandRunner(outputDir); err != nil {
-		return err
+func tryRunPrettier(outputDir string, inv prettierInvocation, files []string) error {
+	for _, args := range prettierCommands(inv, files) {
+		if err := prettierCommandRunner(outputDir, args); err != nil {
+			return err
+		}
 	}
-	return restoreCalloutBlockSeparation(outputDir)
+	return restoreCalloutBlockSeparation(outputDir, files)
 }
 
-func restoreCalloutBlockSeparation(outputDir string) error {
-	for _, dir := range []string{"notes", "templates"} {
-		root := filepath.Join(outputDir, dir)
-		if _, err := os.Stat(root); err != nil {
+// restoreCalloutBlockSeparation re-adds the blank line prettier removes
+// between adjacent callouts in the formatted files.
+func restoreCalloutBlockSeparation(outputDir string, files []string) error {
+	for _, rel := range files {
+		path := filepath.Join(outputDir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(path)
+		if err != nil {
 			if os.IsNotExist(err) {
 				continue
 			}
 			return err
 		}
-
-		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
-			if walkErr != nil {
-				return walkErr
-			}
-			if d.IsDir() || filepath.Ext(path) != ".md" {
-				return nil
-			}
-			data, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			fixed, changed := ensureCalloutBlockSeparation(string(data))
-			if !changed {
-				return nil
-			}
-			return os.WriteFile(path, []byte(fixed), 0o644)
-		})
-		if err != nil {
+		fixed, changed := ensureCalloutBlockSeparation(string(data))
+		if !changed {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(fixed), 0o644); err != nil {
 			return err
 		}
 	}
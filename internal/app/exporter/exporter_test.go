@@ -1,15 +1,28 @@
 package exporter
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
-	"runtime"
+	"strconv"
 	"strings"
-	"syscall"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+	"gopkg.in/yaml.v3"
 )
 
 func TestExporterPreservesRelationsAndFields(t *testing.T) {
@@ -371,7 +384,7 @@ func TestExporterIncludesDynamicPropertiesWhenEnabled(t *testing.T) {
 		t.Fatalf("read note: %v", err)
 	}
 	note := string(noteBytes)
-	if !strings.Contains(note, "backlinks:") || !strings.Contains(note, "- \"obj-2\"") {
+	if !strings.Contains(note, "backlinks:") || !strings.Contains(note, "- \"Unknown (obj-2)\"") {
 		t.Fatalf("expected backlinks to be included when enabled, got:\n%s", note)
 	}
 }
@@ -845,7 +858,7 @@ func TestExporterRendersObsidianCompatibleBlocks(t *testing.T) {
 		t.Fatalf("expected root title block to be rendered in note body, got:\n%s", note)
 	}
 
-	if !strings.Contains(note, "- [Heading One](#heading-one)") || !strings.Contains(note, "- [Heading Two](#heading-two)") {
+	if !strings.Contains(note, "- [[#Heading One|Heading One]]") || !strings.Contains(note, "- [[#Heading Two|Heading Two]]") {
 		t.Fatalf("expected generated table of contents, got:\n%s", note)
 	}
 	if !strings.Contains(note, "---") {
@@ -857,7 +870,7 @@ func TestExporterRendersObsidianCompatibleBlocks(t *testing.T) {
 	if !strings.Contains(note, "2026-02-04") {
 		t.Fatalf("expected date link target to render as date text, got:\n%s", note)
 	}
-	if !strings.Contains(note, "1. first\n2. second\n1. nested\n3. third") {
+	if !strings.Contains(note, "1. first\n2. second\n\t1. nested\n3. third") {
 		t.Fatalf("expected numbered list sequence with nested numbering, got:\n%s", note)
 	}
 	if !strings.Contains(note, "```jsx\nconsole.log('lol')\n```") {
@@ -1588,10 +1601,8 @@ func TestExporterAppliesFilesystemTimestampsFromAnytypeDetails(t *testing.T) {
 	if got := info.ModTime().UTC().Unix(); got != modifiedUnix {
 		t.Fatalf("expected note mtime %d, got %d", modifiedUnix, got)
 	}
-	if runtime.GOOS == "darwin" {
-		if got := int64(info.Sys().(*syscall.Stat_t).Birthtimespec.Sec); got != createdUnix {
-			t.Fatalf("expected note birthtime %d, got %d", createdUnix, got)
-		}
+	if got, ok := fileBirthTimeUnix(info); ok && got != createdUnix {
+		t.Fatalf("expected note birthtime %d, got %d", createdUnix, got)
 	}
 }
 
@@ -2037,6 +2048,75 @@ func TestExporterGeneratesTemplatesFromTemplateBlocks(t *testing.T) {
 	}
 }
 
+func TestExporterRendersTemplateDefaultValuesInFrontmatter(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "templates"))
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-status.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-status",
+		"relationKey":    "status",
+		"relationFormat": 3,
+		"name":           "Status",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": 100,
+		"name":           "Related",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-due.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-due",
+		"relationKey":    "dueDate",
+		"relationFormat": 4,
+		"name":           "Due date",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-todo.pb.json"), "STRelationOption", map[string]any{
+		"id":   "opt-todo",
+		"name": "To do",
+	}, nil)
+
+	writePBJSON(t, filepath.Join(input, "templates", "tmpl-1.pb.json"), "Template", map[string]any{
+		"id":      "tmpl-1",
+		"name":    "Task",
+		"status":  "opt-todo",
+		"related": []any{"obj-1"},
+		"dueDate": 1720000000,
+	}, []map[string]any{
+		{"id": "tmpl-1", "childrenIds": []string{"rel-status", "rel-related", "rel-due", "rel-missing"}},
+		{"id": "rel-status", "relation": map[string]any{"key": "status"}},
+		{"id": "rel-related", "relation": map[string]any{"key": "related"}},
+		{"id": "rel-due", "relation": map[string]any{"key": "dueDate"}},
+		{"id": "rel-missing", "relation": map[string]any{"key": "description"}},
+	})
+
+	_, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	templateBytes, err := os.ReadFile(filepath.Join(output, "templates", "Unknown Type - Task.md"))
+	if err != nil {
+		t.Fatalf("read template: %v", err)
+	}
+	template := string(templateBytes)
+	if !strings.Contains(template, "status: \"To do\"") {
+		t.Fatalf("expected prefilled status option to be resolved, got:\n%s", template)
+	}
+	if !strings.Contains(template, "related:\n  - \"[[../notes/Task One.md]]\"") {
+		t.Fatalf("expected prefilled object relation to link relative to template, got:\n%s", template)
+	}
+	if !strings.Contains(template, "dueDate: \"2024-07-03\"") {
+		t.Fatalf("expected prefilled date to be formatted, got:\n%s", template)
+	}
+	if !strings.Contains(template, "description: null") {
+		t.Fatalf("expected relation without default to stay null, got:\n%s", template)
+	}
+}
+
 func TestExporterTemplateFileNamesAvoidIDsAndUseNumericSuffixes(t *testing.T) {
 	root := t.TempDir()
 	input := filepath.Join(root, "Anytype-json")
@@ -2229,7 +2309,7 @@ func TestExporterResetsNumberedListAfterNonNumberedSiblingAndUsesTabIndent(t *te
 	if !strings.Contains(note, "1. first\nbreak\n1. second\n2. third") {
 		t.Fatalf("expected numbering reset after paragraph break, got:\n%s", note)
 	}
-	if !strings.Contains(note, "3. parent\n1. nested") {
+	if !strings.Contains(note, "3. parent\n\t1. nested") {
 		t.Fatalf("expected nested numbered item to keep independent numbering, got:\n%s", note)
 	}
 }
@@ -2481,7 +2561,7 @@ func TestParseDataviewViewsMapsGalleryToCards(t *testing.T) {
 				"name": "All",
 			},
 		},
-	}, nil, nil, nil, nil, nil, false, true)
+	}, nil, nil, nil, nil, nil, propertyKeys{}, true, time.Now())
 
 	if len(views) != 1 {
 		t.Fatalf("expected one view, got %d", len(views))
@@ -2502,12 +2582,12 @@ func TestParseDataviewViewsMapsKanbanToKanbanWhenEnabled(t *testing.T) {
 				"name": "Sprint",
 			},
 		},
-	}, nil, nil, nil, nil, nil, false, true)
+	}, nil, nil, nil, nil, nil, propertyKeys{}, true, time.Now())
 
 	if len(views) != 1 {
 		t.Fatalf("expected one view, got %d", len(views))
 	}
-	if views[0].Type != "kanban" {
+	if views[0].Type != "cumban" {
 		t.Fatalf("expected board view to map to kanban, got %q", views[0].Type)
 	}
 }
@@ -2520,7 +2600,7 @@ func TestParseDataviewViewsMapsKanbanToTableWhenDisabled(t *testing.T) {
 				"name": "Sprint",
 			},
 		},
-	}, nil, nil, nil, nil, nil, false, false)
+	}, nil, nil, nil, nil, nil, propertyKeys{}, false, time.Now())
 
 	if len(views) != 1 {
 		t.Fatalf("expected one view, got %d", len(views))
@@ -2555,7 +2635,7 @@ func TestParseDataviewViewsPreservesLocalCardOrder(t *testing.T) {
 		"obj-2": "notes/Weed Shop 3.md",
 		"obj-3": "notes/Should Be Skipped.md",
 		"obj-4": "notes/Miside.md",
-	}, nil, nil, false, true)
+	}, nil, nil, propertyKeys{}, true, time.Now())
 
 	if len(views) != 1 {
 		t.Fatalf("expected one view, got %d", len(views))
@@ -2587,7 +2667,7 @@ func TestRenderBaseFileAddsSetOfTypeFilter(t *testing.T) {
 		"type": {Key: "type", Name: "Type", Format: anytypedomain.RelationFormatObjectRef},
 	}
 
-	base, ok := renderBaseFile(obj, relations, nil, nil, map[string]string{"type-game": "Games"}, nil, false, true)
+	base, ok := renderBaseFile(obj, relations, nil, nil, map[string]string{"type-game": "Games"}, nil, propertyKeys{}, true, time.Now())
 	if !ok {
 		t.Fatalf("expected base to be rendered")
 	}
@@ -2622,7 +2702,7 @@ func TestRenderBaseFileWrapsSingleSetOfFilterInTopLevelAnd(t *testing.T) {
 		"type": {Key: "type", Name: "Type", Format: anytypedomain.RelationFormatObjectRef},
 	}
 
-	base, ok := renderBaseFile(obj, relations, nil, nil, map[string]string{"type-work-note": "Work Note"}, nil, false, true)
+	base, ok := renderBaseFile(obj, relations, nil, nil, map[string]string{"type-work-note": "Work Note"}, nil, propertyKeys{}, true, time.Now())
 	if !ok {
 		t.Fatalf("expected base to be rendered")
 	}
@@ -2674,8 +2754,9 @@ func TestRenderBaseFileMergesViewFiltersAndSetOfTypeIntoConciseExpressions(t *te
 		nil,
 		map[string]string{"type-task": "Task"},
 		nil,
-		false,
+		propertyKeys{},
 		true,
+		time.Now(),
 	)
 	if !ok {
 		t.Fatalf("expected base to be rendered")
@@ -2724,8 +2805,9 @@ func TestRenderBaseFileRendersLocalCardOrder(t *testing.T) {
 		map[string]string{"obj-1": "notes/Create Mod.md", "obj-2": "notes/Weed Shop 3.md"},
 		nil,
 		nil,
-		false,
+		propertyKeys{},
 		true,
+		time.Now(),
 	)
 	if !ok {
 		t.Fatalf("expected base to be rendered")
@@ -2766,8 +2848,9 @@ func TestRenderBaseFileOmitsLocalCardOrderWhenKanbanDisabled(t *testing.T) {
 		map[string]string{"obj-1": "notes/Create Mod.md", "obj-2": "notes/Weed Shop 3.md"},
 		nil,
 		nil,
+		propertyKeys{},
 		false,
-		false,
+		time.Now(),
 	)
 	if !ok {
 		t.Fatalf("expected base to be rendered")
@@ -2852,7 +2935,7 @@ func TestExporterRendersKanbanPluginViewWhenEnabled(t *testing.T) {
 	}
 	base := string(baseBytes)
 
-	if !strings.Contains(base, "views:\n  - type: kanban\n") {
+	if !strings.Contains(base, "views:\n  - type: cumban\n") {
 		t.Fatalf("expected board view to render as plugin kanban view when enabled, got:\n%s", base)
 	}
 }
@@ -2894,7 +2977,7 @@ func TestBuildFilterExpressionSupportsAllAnytypeConditions(t *testing.T) {
 			"condition":   condition,
 			"value":       value,
 			"format":      "status",
-		}, relations, optionsByID, nil, nil, nil, false)
+		}, relations, optionsByID, nil, nil, nil, propertyKeys{}, time.Now())
 		if strings.TrimSpace(expr) == "" {
 			t.Fatalf("expected non-empty expression for condition %s", condition)
 		}
@@ -2912,7 +2995,7 @@ func TestBuildFilterExpressionSkipsEmptyLikeFilters(t *testing.T) {
 			"condition":   condition,
 			"value":       "",
 			"format":      "text",
-		}, relations, nil, nil, nil, nil, false)
+		}, relations, nil, nil, nil, nil, propertyKeys{}, time.Now())
 		if strings.TrimSpace(expr) != "" {
 			t.Fatalf("expected empty expression for %s with empty value, got %q", condition, expr)
 		}
@@ -2941,7 +3024,7 @@ func TestConvertAnytypeFilterNodeDropsEmptyLikeFilter(t *testing.T) {
 				"format":      "tag",
 			},
 		},
-	}, relations, nil, nil, nil, nil, false)
+	}, relations, nil, nil, nil, nil, propertyKeys{}, time.Now())
 
 	if !ok {
 		t.Fatalf("expected filter node to be built")
@@ -2968,7 +3051,7 @@ func TestExporterRunsPrettierWhenEnabled(t *testing.T) {
 	called := false
 	callCount := 0
 	calledWithDir := ""
-	prettierCommandRunner = func(outputDir string) error {
+	prettierCommandRunner = func(outputDir string, _ []string) error {
 		called = true
 		callCount++
 		calledWithDir = outputDir
@@ -3021,7 +3104,7 @@ func TestExporterRestoresCalloutSpacingAfterPrettier(t *testing.T) {
 		prettierCommandRunner = originalRunner
 	})
 
-	prettierCommandRunner = func(outputDir string) error {
+	prettierCommandRunner = func(outputDir string, _ []string) error {
 		notePath := filepath.Join(outputDir, "notes", "Quote Callout Row.md")
 		data, err := os.ReadFile(notePath)
 		if err != nil {
@@ -3071,7 +3154,7 @@ func TestExporterIgnoresPrettierFailure(t *testing.T) {
 		prettierCommandRunner = originalRunner
 	})
 
-	prettierCommandRunner = func(string) error {
+	prettierCommandRunner = func(string, []string) error {
 		return os.ErrNotExist
 	}
 
@@ -3433,57 +3516,5055 @@ func TestExporterNormalizesExistingPrettyPropertiesTagColorKeys(t *testing.T) {
 	}
 }
 
-func prepareMinimalExportFixture(t *testing.T, input string) {
-	t.Helper()
-	mustMkdirAll(t, filepath.Join(input, "objects"))
-	mustMkdirAll(t, filepath.Join(input, "relations"))
-	mustMkdirAll(t, filepath.Join(input, "relationsOptions"))
-	mustMkdirAll(t, filepath.Join(input, "filesObjects"))
-	mustMkdirAll(t, filepath.Join(input, "files"))
+func TestExporterScaffoldsEmptyNotesFromTypeRecommendedLayout(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
 
-	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
-		"id":   "obj-1",
-		"name": "Task One",
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "types"))
+
+	writePBJSON(t, filepath.Join(input, "types", "type-task.pb.json"), "STType", map[string]any{
+		"id":                "type-task",
+		"name":              "Task",
+		"recommendedLayout": 2,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-empty.pb.json"), "Page", map[string]any{
+		"id":   "obj-empty",
+		"name": "Empty Task",
+		"type": "type-task",
 	}, []map[string]any{
-		{"id": "obj-1", "childrenIds": []string{"title"}},
-		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "obj-empty", "childrenIds": []string{}},
 	})
+
+	_, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Empty Task.md"))
+	if strings.Contains(note, "## Tasks") {
+		t.Fatalf("did not expect scaffold without opt-in, got:\n%s", note)
+	}
+
+	_, err = (Exporter{InputDir: input, OutputDir: output, ScaffoldEmptyNotes: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note = readFileString(t, filepath.Join(output, "notes", "Empty Task.md"))
+	if !strings.HasSuffix(note, "---\n\n## Tasks\n\n- [ ] \n") {
+		t.Fatalf("expected task scaffold for empty todo-layout note, got:\n%s", note)
+	}
+	other := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if strings.Contains(other, "## Tasks") {
+		t.Fatalf("did not expect scaffold for non-empty note, got:\n%s", other)
+	}
 }
 
-func writePBJSON(t *testing.T, path string, sbType string, details map[string]any, blocks []map[string]any) {
-	writePBJSONWithData(t, path, sbType, details, blocks, nil)
+func TestExporterTransliteratesFilenamesAndKeepsTitleAsAlias(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-ru.pb.json"), "Page", map[string]any{
+		"id":   "obj-ru",
+		"name": "Привет Мир",
+	}, []map[string]any{
+		{"id": "obj-ru", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Привет Мир", "style": "Title"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-jp.pb.json"), "Page", map[string]any{
+		"id":   "obj-jp",
+		"name": "すし Café",
+	}, nil)
+
+	_, err := (Exporter{InputDir: input, OutputDir: output, TransliterateFilenames: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Privet Mir.md"))
+	if !strings.Contains(note, "aliases:\n  - \"Привет Мир\"") {
+		t.Fatalf("expected original title alias, got:\n%s", note)
+	}
+	if !strings.Contains(note, "# Привет Мир") {
+		t.Fatalf("expected original title in body, got:\n%s", note)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "sushi Cafe.md")); err != nil {
+		t.Fatalf("expected kana and accented latin to be romanized: %v", err)
+	}
+	plain := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if strings.Contains(plain, "aliases:") {
+		t.Fatalf("did not expect alias for ascii title, got:\n%s", plain)
+	}
 }
 
-func writePBJSONWithData(t *testing.T, path string, sbType string, details map[string]any, blocks []map[string]any, dataExtras map[string]any) {
-	t.Helper()
-	if blocks == nil {
-		blocks = []map[string]any{}
+func TestExporterWritesDefaultTemplateMapWhenEnabled(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "types"))
+	mustMkdirAll(t, filepath.Join(input, "templates"))
+
+	writePBJSON(t, filepath.Join(input, "types", "type-human.pb.json"), "STType", map[string]any{
+		"id":                "type-human",
+		"name":              "Human",
+		"defaultTemplateId": "tmpl-contact",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "types", "type-task.pb.json"), "STType", map[string]any{
+		"id":                "type-task",
+		"name":              "Task",
+		"defaultTemplateId": "tmpl-missing",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "templates", "tmpl-contact.pb.json"), "Template", map[string]any{
+		"id":               "tmpl-contact",
+		"name":             "Contact",
+		"targetObjectType": "type-human",
+	}, nil)
+
+	_, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
 	}
-	data := map[string]any{
-		"blocks":  blocks,
-		"details": details,
+	if _, err := os.Stat(filepath.Join(output, "templates", "README.md")); err == nil {
+		t.Fatalf("did not expect default template map without opt-in")
 	}
-	for key, value := range dataExtras {
-		data[key] = value
+
+	_, err = (Exporter{InputDir: input, OutputDir: output, WriteDefaultTemplateMap: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
 	}
-	payload := map[string]any{
-		"sbType": sbType,
-		"snapshot": map[string]any{
-			"data": data,
+	readme := readFileString(t, filepath.Join(output, "templates", "README.md"))
+	if !strings.Contains(readme, "- Human: [[Human - Contact.md]]\n") {
+		t.Fatalf("expected type to default template link, got:\n%s", readme)
+	}
+	if strings.Contains(readme, "Task") {
+		t.Fatalf("did not expect unresolved default template entry, got:\n%s", readme)
+	}
+}
+
+func TestRenderTrivialNoteMatchesFullRendering(t *testing.T) {
+	relations := map[string]relationDef{
+		"type": {Key: "type", Name: "Object type", Format: anytypedomain.RelationFormatObjectRef},
+	}
+	objectNamesByID := map[string]string{"type-page": "Page"}
+	opts := frontmatterOptions{filters: newPropertyFilters(nil, nil, nil, false), prettyPropertyIcon: true, pictureToCover: true}
+
+	trivial := objectInfo{
+		ID: "obj-1",
+		Details: map[string]any{
+			"id":          "obj-1",
+			"name":        "Short",
+			"type":        "type-page",
+			"createdDate": 1720000000,
+			"backlinks":   []any{"obj-2"},
+		},
+		Blocks: []block{
+			{ID: "obj-1", ChildrenID: []string{"title", "p1", "p2"}},
+			{ID: "title", Text: &textBlock{Text: "Short", Style: "Title"}, Fields: map[string]any{"_detailsKey": []any{"name"}}},
+			{ID: "p1", Text: &textBlock{Text: "Hello", Style: "Paragraph"}},
+			{ID: "p2", Text: &textBlock{Text: "Section", Style: "Header2"}},
 		},
 	}
-	b, err := json.Marshal(payload)
+	fm, body, ok := renderTrivialNote(trivial, relations, nil, nil, "notes/Short.md", objectNamesByID, nil, nil, opts)
+	if !ok {
+		t.Fatalf("expected object to take the trivial fast path")
+	}
+	wantFM := renderFrontmatter(trivial, relations, nil, nil, nil, "notes/Short.md", objectNamesByID, nil, nil, opts)
+	wantBody := renderBody(trivial, bodyContext{sourceNotePath: "notes/Short.md"})
+	if fm != wantFM || body != wantBody {
+		t.Fatalf("fast path mismatch:\ngot:\n%s%s\nwant:\n%s%s", fm, body, wantFM, wantBody)
+	}
+
+	withRelation := trivial
+	withRelation.Details = map[string]any{"id": "obj-1", "status": "opt-1"}
+	if _, _, ok := renderTrivialNote(withRelation, relations, nil, nil, "notes/Short.md", objectNamesByID, nil, nil, opts); ok {
+		t.Fatalf("expected object with user relation to use full rendering")
+	}
+
+	withMarks := trivial
+	withMarks.Blocks = []block{
+		{ID: "obj-1", ChildrenID: []string{"p1"}},
+		{ID: "p1", Text: &textBlock{Text: "See", Marks: &anytypedomain.TextMarks{Marks: []anytypedomain.TextMark{{Type: "Mention", Param: "obj-2", Range: anytypedomain.TextMarkRange{From: 0, To: 3}}}}}},
+	}
+	if _, _, ok := renderTrivialNote(withMarks, relations, nil, nil, "notes/Short.md", objectNamesByID, nil, nil, opts); ok {
+		t.Fatalf("expected object with text marks to use full rendering")
+	}
+}
+
+func TestExporterVerifyLinksReportsBrokenTargets(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Linked",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"link-1"}},
+		{"id": "link-1", "link": map[string]any{"targetBlockId": "obj-1"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, VerifyLinks: "fail"}).Run(); err != nil {
+		t.Fatalf("expected valid links to pass verification: %v", err)
+	}
+
+	broken := "[[Task One]] ![[Missing.png]] [[notes/Task One.md#Heading|alias]] [ok](Linked.md) [bad](../files/none.pdf) [web](https://example.com)\n```\n[[Inside Fence]]\n```\n"
+	if err := os.WriteFile(filepath.Join(output, "notes", "Broken.md"), []byte(broken), 0o644); err != nil {
+		t.Fatalf("write broken note: %v", err)
+	}
+
+	links, err := verifyVaultLinks(output)
 	if err != nil {
-		t.Fatalf("marshal: %v", err)
+		t.Fatalf("verify links: %v", err)
 	}
-	if err := os.WriteFile(path, b, 0o644); err != nil {
-		t.Fatalf("write %s: %v", path, err)
+	var got []string
+	for _, link := range links {
+		got = append(got, link.String())
+	}
+	want := []string{"notes/Broken.md -> Missing.png", "notes/Broken.md -> ../files/none.pdf"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected broken links: got %v want %v", got, want)
+	}
+
+	_, err = (Exporter{InputDir: input, OutputDir: output, VerifyLinks: "warn"}).Run()
+	if err != nil {
+		t.Fatalf("expected warn mode not to fail: %v", err)
 	}
 }
 
-func mustMkdirAll(t *testing.T, path string) {
-	t.Helper()
-	if err := os.MkdirAll(path, 0o755); err != nil {
-		t.Fatalf("mkdir %s: %v", path, err)
+func TestVerifyVaultReportsMissingIndexedNotes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	problems, err := VerifyVault(output)
+	if err != nil {
+		t.Fatalf("verify vault: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("expected fresh export to verify cleanly, got %v", problems)
+	}
+
+	if err := os.Remove(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("remove note: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(output, "_anytype", "raw", "obj-1.json"), []byte("{"), 0o644); err != nil {
+		t.Fatalf("corrupt raw sidecar: %v", err)
+	}
+
+	problems, err = VerifyVault(output)
+	if err != nil {
+		t.Fatalf("verify vault: %v", err)
+	}
+	got := strings.Join(problems, "\n")
+	if !strings.Contains(got, "index entry obj-1: notes/Task One.md is missing") || !strings.Contains(got, "_anytype/raw/obj-1.json: invalid JSON") {
+		t.Fatalf("expected missing note and corrupt sidecar to be reported, got:\n%s", got)
+	}
+}
+
+func TestExporterFrontmatterRoundTripsThroughYAMLParser(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":           "obj-1",
+		"name":         "Task One",
+		"Status: done": "a\tb\x01c",
+		"true":         "yes",
+		"# priority":   "high",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	parts := strings.SplitN(note, "---\n", 3)
+	if len(parts) != 3 {
+		t.Fatalf("expected frontmatter block, got:\n%s", note)
+	}
+	var parsed map[string]any
+	if err := yaml.Unmarshal([]byte(parts[1]), &parsed); err != nil {
+		t.Fatalf("frontmatter is not valid YAML: %v\n%s", err, parts[1])
+	}
+	if parsed["Status: done"] != "a\tb\x01c" || parsed["true"] != "yes" || parsed["# priority"] != "high" {
+		t.Fatalf("unexpected parsed frontmatter: %#v", parsed)
+	}
+}
+
+func TestExporterWritesObsidianPropertyTypes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	formats := map[string]int{
+		"estimate": anytypedomain.RelationFormatNumber,
+		"done":     anytypedomain.RelationFormatCheckbox,
+		"due":      anytypedomain.RelationFormatDate,
+		"status":   anytypedomain.RelationFormatStatus,
+		"summary":  anytypedomain.RelationFormatLongText,
+	}
+	for key, format := range formats {
+		writePBJSON(t, filepath.Join(input, "relations", "rel-"+key+".pb.json"), "STRelation", map[string]any{
+			"id":             "rel-" + key,
+			"relationKey":    key,
+			"relationFormat": format,
+			"name":           key,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":       "obj-1",
+		"name":     "Task One",
+		"estimate": 3,
+		"done":     true,
+		"due":      1735689600,
+		"status":   []any{"opt-1"},
+		"summary":  "text",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	typesPath := filepath.Join(output, ".obsidian", "types.json")
+	mustMkdirAll(t, filepath.Dir(typesPath))
+	if err := os.WriteFile(typesPath, []byte(`{"types":{"summary":"multitext","custom":"number"}}`), 0o644); err != nil {
+		t.Fatalf("write existing types: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	var data struct {
+		Types map[string]string `json:"types"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(t, typesPath)), &data); err != nil {
+		t.Fatalf("decode types.json: %v", err)
+	}
+	want := map[string]string{
+		"estimate": "number",
+		"done":     "checkbox",
+		"due":      "date",
+		"status":   "multitext",
+		"summary":  "multitext",
+		"custom":   "number",
+	}
+	for key, propertyType := range want {
+		if data.Types[key] != propertyType {
+			t.Fatalf("expected %s to be %q, got types %#v", key, propertyType, data.Types)
+		}
+	}
+}
+
+func TestExporterFormatsNumberURLEmailAndPhoneRelations(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	formats := map[string]int{
+		"estimate": anytypedomain.RelationFormatNumber,
+		"site":     anytypedomain.RelationFormatURL,
+		"mail":     anytypedomain.RelationFormatEmail,
+		"phone":    anytypedomain.RelationFormatPhone,
+	}
+	for key, format := range formats {
+		writePBJSON(t, filepath.Join(input, "relations", "rel-"+key+".pb.json"), "STRelation", map[string]any{
+			"id":             "rel-" + key,
+			"relationKey":    key,
+			"relationFormat": format,
+			"name":           key,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":       "obj-1",
+		"name":     "Task One",
+		"estimate": " 3.5 ",
+		"site":     "example.com/path",
+		"mail":     "mailto:me@example.com",
+		"phone":    79991234567,
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	for _, want := range []string{
+		"estimate: 3.5\n",
+		"site: \"https://example.com/path\"\n",
+		"mail: \"me@example.com\"\n",
+		"phone: \"79991234567\"\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterResolvesUnexportedRelationTargetsByNameSnippetOrUnknown(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": anytypedomain.RelationFormatObjectRef,
+		"name":           "Related",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-archived.pb.json"), "Page", map[string]any{
+		"id":         "obj-archived",
+		"name":       "Archived Project",
+		"isArchived": true,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-note.pb.json"), "Page", map[string]any{
+		"id":         "obj-note",
+		"snippet":    "Quick thought about lunch\nsecond line",
+		"isArchived": true,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":      "obj-1",
+		"name":    "Task One",
+		"related": []any{"obj-archived", "obj-note", "obj-gone"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	for _, want := range []string{`- "Archived Project"`, `- "Quick thought about lunch"`, `- "Unknown (obj-gone)"`} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+
+	_, err := (Exporter{InputDir: input, OutputDir: filepath.Join(root, "strict"), StrictRelations: true}).Run()
+	if err == nil || !strings.Contains(err.Error(), "notes/Task One.md: related -> obj-gone") {
+		t.Fatalf("expected strict mode to report unresolved target, got %v", err)
+	}
+	if strings.Contains(err.Error(), "obj-archived") || strings.Contains(err.Error(), "obj-note") {
+		t.Fatalf("expected only unresolved targets to be reported, got %v", err)
+	}
+}
+
+func TestExporterExportsParticipantsAsPeopleNotes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-assignee.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-assignee",
+		"relationKey":    "assignee",
+		"relationFormat": anytypedomain.RelationFormatObjectRef,
+		"name":           "Assignee",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "participant-1.pb.json"), "Participant", map[string]any{
+		"id":             "_participant_space_alice",
+		"name":           "Alice",
+		"resolvedLayout": anytypedomain.LayoutParticipant,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":       "obj-1",
+		"name":     "Task One",
+		"assignee": []any{"_participant_space_alice"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ExportPeople: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(output, "people", "Alice.md")); err != nil {
+		t.Fatalf("expected participant note under people/: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Alice.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected participant to be moved out of notes/, got err=%v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(note, `- "[[../people/Alice.md]]"`) {
+		t.Fatalf("expected assignee to link to people note, got:\n%s", note)
+	}
+}
+
+func TestExporterResolvesCreatorIdentityToParticipantName(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	for _, key := range []string{"creator", "lastModifiedBy"} {
+		writePBJSON(t, filepath.Join(input, "relations", "rel-"+key+".pb.json"), "STRelation", map[string]any{
+			"id":             "rel-" + key,
+			"relationKey":    key,
+			"relationFormat": anytypedomain.RelationFormatObjectRef,
+			"name":           key,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "participant-1.pb.json"), "Participant", map[string]any{
+		"id":         "_participant_space_ident-1",
+		"globalName": "alice.any",
+		"identity":   "ident-1",
+		"isArchived": true,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":             "obj-1",
+		"name":           "Task One",
+		"creator":        "ident-1",
+		"lastModifiedBy": "_participant_space_ident-1",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	_, err := (Exporter{InputDir: input, OutputDir: output, ForceIncludePropertyKeys: []string{"creator", "lastModifiedBy"}}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(note, "creator: \"alice.any\"\n") || !strings.Contains(note, "lastModifiedBy: \"alice.any\"\n") {
+		t.Fatalf("expected creator fields to resolve to participant name, got:\n%s", note)
+	}
+}
+
+func TestExporterRendersChatBlockAsDiscussion(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "participant-1.pb.json"), "Participant", map[string]any{
+		"id":   "_participant_space_alice",
+		"name": "Alice",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "intro", "chat"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "intro", "text": map[string]any{"text": "Intro"}},
+		{"id": "chat", "chat": map[string]any{"messages": []map[string]any{
+			{"id": "m2", "creator": "bob-identity", "createdAt": "1704207600", "replyToMessageId": "m1", "message": map[string]any{"text": "Agreed"}},
+			{"id": "m1", "creator": "_participant_space_alice", "createdAt": 1704204000, "message": map[string]any{"text": "Ship it?\nToday"}},
+		}}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	want := "Intro\n\n## Discussion\n\n> **Alice** · 2024-01-02 14:00\n> Ship it?\n> Today\n>\n> > **bob-identity** · 2024-01-02 15:00\n> > Agreed\n"
+	if !strings.HasSuffix(note, want) {
+		t.Fatalf("expected discussion section %q, got:\n%s", want, note)
+	}
+}
+
+func TestExporterPreservesColumnLayouts(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "row"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "row", "childrenIds": []string{"col-1", "col-2"}, "layout": map[string]any{"style": "Row"}},
+		{"id": "col-1", "childrenIds": []string{"left"}, "layout": map[string]any{"style": "Column"}},
+		{"id": "col-2", "childrenIds": []string{"right"}, "layout": map[string]any{"style": "Column"}},
+		{"id": "left", "text": map[string]any{"text": "Left"}},
+		{"id": "right", "text": map[string]any{"text": "Right"}},
+	})
+
+	cases := map[string]string{
+		"flatten":      "Left\nRight\n",
+		"html":         "\n<div class=\"columns\">\n<div class=\"column\">\n\nLeft\n\n</div>\n<div class=\"column\">\n\nRight\n\n</div>\n</div>\n",
+		"multi-column": "\n--- start-multi-column: row\n```column-settings\nNumber of Columns: 2\n```\n\nLeft\n\n--- column-break ---\n\nRight\n\n--- end-multi-column\n",
+	}
+	for mode, want := range cases {
+		output := filepath.Join(root, "output-"+mode)
+		if _, err := (Exporter{InputDir: input, OutputDir: output, ColumnLayout: mode}).Run(); err != nil {
+			t.Fatalf("run exporter (%s): %v", mode, err)
+		}
+		note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+		if !strings.Contains(note, "# Task One\n"+want) {
+			t.Fatalf("unexpected %s column output, got:\n%s", mode, note)
+		}
+	}
+}
+
+func TestExporterRendersCardLinkBlocksAsCallouts(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "filesObjects", "cover-file.pb.json"), "FileObject", map[string]any{
+		"id":      "cover-file",
+		"name":    "cover",
+		"fileExt": "png",
+		"source":  "files/cover.png",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":          "obj-2",
+		"name":        "Project",
+		"description": "Roadmap and notes",
+		"coverId":     "cover-file",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "card", "plain"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "card", "link": map[string]any{"targetBlockId": "obj-2", "cardStyle": "Card", "description": "Added", "relations": []string{"cover", "type"}}},
+		{"id": "plain", "link": map[string]any{"targetBlockId": "obj-2"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, LinkCards: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	want := "# Task One\n\n> [!info] [[Project.md]]\n> Roadmap and notes\n> ![](../files/cover.png)\n\n[[Project.md]]\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected card callout followed by plain link, got:\n%s", note)
+	}
+}
+
+func TestExporterRendersInlineRelationBlocks(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-status.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-status",
+		"relationKey":    "status",
+		"relationFormat": anytypedomain.RelationFormatStatus,
+		"name":           "Status",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-done.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-done",
+		"relationKey": "status",
+		"name":        "Done",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":     "obj-1",
+		"name":   "Task One",
+		"status": []any{"opt-done"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "rel", "missing"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "rel", "relation": map[string]any{"key": "status"}},
+		{"id": "missing", "relation": map[string]any{"key": "description"}},
+	})
+
+	cases := map[string]string{
+		"dataview": "# Task One\nStatus:: Done\n",
+		"line":     "# Task One\n**Status**: Done\n",
+		"skip":     "# Task One\n",
+	}
+	for style, want := range cases {
+		output := filepath.Join(root, "output-"+style)
+		if _, err := (Exporter{InputDir: input, OutputDir: output, RelationBlocks: style}).Run(); err != nil {
+			t.Fatalf("run exporter (%s): %v", style, err)
+		}
+		note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+		if !strings.HasSuffix(note, want) {
+			t.Fatalf("unexpected %s relation block output, got:\n%s", style, note)
+		}
+	}
+}
+
+func TestExporterReturnsStatsAndWritesReport(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	prettierCommandRunner = func(outputDir string, _ []string) error {
+		return os.ErrNotExist
+	}
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, RunPrettier: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if stats.Notes != 1 || stats.Warnings != 1 || stats.Elapsed <= 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats.ReportPath != filepath.Join(output, "_anytype", "report.json") {
+		t.Fatalf("unexpected report path %q", stats.ReportPath)
+	}
+
+	var report struct {
+		Stats    map[string]any `json:"stats"`
+		Warnings []string       `json:"warnings"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(t, stats.ReportPath)), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Stats["notes"] != float64(1) || len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "prettier") {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestExportProgressBarStatusShowsPhaseRateAndETA(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newExportProgressBar(exportPhases)
+	p.enabled = false
+	p.now = func() time.Time { return now }
+
+	p.StartPhase(PhaseRenderNotes, 100)
+	for i := 0; i < 25; i++ {
+		p.Advance("")
+	}
+	now = now.Add(5 * time.Second)
+
+	if got, want := p.status(), "[5/6] rendering notes 25/100 · 5.0/s · ETA 15s"; got != want {
+		t.Fatalf("unexpected status\nwant: %q\ngot:  %q", want, got)
+	}
+
+	p.StartPhase(PhaseReadExport, 0)
+	if got, want := p.status(), "[1/6] reading export"; got != want {
+		t.Fatalf("unexpected status for unsized phase: %q", got)
+	}
+
+	p.StartPhase(PhasePostProcess, 3)
+	p.Advance("writing index")
+	if got := p.status(); !strings.HasPrefix(got, "[6/6] post-processing 1/3") || !strings.HasSuffix(got, "· writing index") {
+		t.Fatalf("unexpected post-processing status: %q", got)
+	}
+}
+
+func TestFormatProgressETA(t *testing.T) {
+	cases := map[time.Duration]string{
+		300 * time.Millisecond:        "<1s",
+		42 * time.Second:              "42s",
+		3*time.Minute + 5*time.Second: "3m05s",
+		2*time.Hour + 7*time.Minute:   "2h07m",
+	}
+	for d, want := range cases {
+		if got := formatProgressETA(d); got != want {
+			t.Fatalf("formatProgressETA(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestExporterLogsPerObjectDecisions(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": anytypedomain.RelationFormatObjectRef,
+		"name":           "Related",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-archived.pb.json"), "Page", map[string]any{
+		"id":         "obj-archived",
+		"name":       "Archived Project",
+		"isArchived": true,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":      "obj-1",
+		"name":    "Task One",
+		"related": []any{"obj-gone"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+	writePBJSON(t, filepath.Join(input, "filesObjects", "file-1.pb.json"), "FileObject", map[string]any{
+		"id":      "file-1",
+		"name":    "scan",
+		"source":  "files/scan.pdf",
+		"fileExt": "pdf",
+	}, nil)
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Logger: logger}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	got := logs.String()
+	for _, want := range []string{
+		`level=DEBUG msg="skipped archived object" id=obj-archived`,
+		`level=INFO msg="unresolved relation" note="notes/Task One.md" property=related target=obj-gone`,
+		`level=WARN msg="missing file" id=file-1 path=files/scan.pdf`,
+		`level=DEBUG msg="exported note" id=obj-1`,
+		`level=INFO msg="export finished" notes=1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in logs, got:\n%s", want, got)
+		}
+	}
+}
+
+type cancelAfterChecksContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *cancelAfterChecksContext) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func TestExporterRunContextStopsAtObjectBoundaryAndWritesPartialMarker(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Task Two",
+	}, nil)
+
+	// Read, copy and both base checks pass, then the first note is written
+	// before cancellation is observed.
+	ctx := &cancelAfterChecksContext{Context: context.Background(), remaining: 5}
+	_, err := (Exporter{InputDir: input, OutputDir: output}).RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	var partial struct {
+		Phase string   `json:"phase"`
+		Notes []string `json:"notes"`
+	}
+	markerPath := filepath.Join(output, "_anytype", "partial.json")
+	if err := json.Unmarshal([]byte(readFileString(t, markerPath)), &partial); err != nil {
+		t.Fatalf("decode partial marker: %v", err)
+	}
+	if partial.Phase != "rendering notes" || len(partial.Notes) != 1 {
+		t.Fatalf("unexpected partial marker: %+v", partial)
+	}
+	if _, err := os.Stat(filepath.Join(output, "_anytype", "report.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no report for interrupted run, got err=%v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(markerPath); !os.IsNotExist(err) {
+		t.Fatalf("expected partial marker to be removed after a full run, got err=%v", err)
+	}
+}
+
+func TestExporterAtomicSwapsVaultOnlyOnSuccess(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	mustMkdirAll(t, filepath.Join(output, ".obsidian"))
+	if err := os.WriteFile(filepath.Join(output, ".obsidian", "app.json"), []byte(`{"theme":"dark"}`), 0o644); err != nil {
+		t.Fatalf("write app.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(output, "stale.md"), []byte("old"), 0o644); err != nil {
+		t.Fatalf("write stale note: %v", err)
+	}
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, Atomic: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if stats.ReportPath != filepath.Join(output, "_anytype", "report.json") {
+		t.Fatalf("unexpected report path %q", stats.ReportPath)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("expected exported note in swapped vault: %v", err)
+	}
+	if got := readFileString(t, filepath.Join(output, "stale.md")); got != "old" {
+		t.Fatalf("expected a file the exporter never wrote to survive the swap, got %q", got)
+	}
+	if got := readFileString(t, filepath.Join(output, ".obsidian", "app.json")); got != `{"theme":"dark"}` {
+		t.Fatalf("expected .obsidian settings to survive the swap, got %q", got)
+	}
+	assertNoAtomicLeftovers(t, root)
+
+	_, err = (Exporter{InputDir: input, OutputDir: output, Atomic: true, ColumnLayout: "bogus"}).Run()
+	if err == nil {
+		t.Fatalf("expected invalid column layout to fail")
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("expected previous vault to stay untouched after failure: %v", err)
+	}
+	assertNoAtomicLeftovers(t, root)
+}
+
+func TestExporterAtomicKeepsForeignFilesAndSiblings(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Gone Soon",
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Atomic: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Gone Soon.md")); err != nil {
+		t.Fatalf("expected second note in the first export: %v", err)
+	}
+
+	foreign := map[string]string{
+		".git/HEAD":                 "ref: refs/heads/main\n",
+		"Daily/today.md":            "written by hand\n",
+		"../vault.old/keep.txt":     "user sibling\n",
+		"../vault.tmp/keep.txt":     "user sibling\n",
+		"../vault.old-123/keep.txt": "user sibling\n",
+	}
+	for rel, content := range foreign {
+		path := filepath.Join(output, filepath.FromSlash(rel))
+		mustMkdirAll(t, filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	if err := os.Remove(filepath.Join(input, "objects", "obj-2.pb.json")); err != nil {
+		t.Fatalf("remove object: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Atomic: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	for rel, want := range foreign {
+		if got := readFileString(t, filepath.Join(output, filepath.FromSlash(rel))); got != want {
+			t.Fatalf("expected %s to survive the atomic swap, got %q", rel, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Gone Soon.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected a note the previous export wrote to be replaced, got err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("expected exported note in swapped vault: %v", err)
+	}
+}
+
+// assertNoAtomicLeftovers fails when an atomic run left a temp or old vault
+// next to the output.
+func assertNoAtomicLeftovers(t *testing.T, root string) {
+	t.Helper()
+	for _, pattern := range []string{"vault.tmp-*", "vault.old-*"} {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			t.Fatalf("glob %s: %v", pattern, err)
+		}
+		if len(matches) > 0 {
+			t.Fatalf("expected atomic run to clean up, found %v", matches)
+		}
+	}
+}
+
+func TestExporterMergeStrategiesProtectEditedNotes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":               "obj-1",
+		"name":             "Task One",
+		"lastModifiedDate": 1700000000,
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "body"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "body", "text": map[string]any{"text": "from anytype"}},
+	})
+
+	run := func(strategy string) {
+		t.Helper()
+		if _, err := (Exporter{InputDir: input, OutputDir: output, MergeStrategy: strategy}).Run(); err != nil {
+			t.Fatalf("run exporter with %s: %v", strategy, err)
+		}
+	}
+	notePath := filepath.Join(output, "notes", "Task One.md")
+	conflictPath := filepath.Join(output, "notes", "Task One (conflict).md")
+	edit := func() {
+		t.Helper()
+		if err := os.WriteFile(notePath, []byte("edited in obsidian\n"), 0o644); err != nil {
+			t.Fatalf("edit note: %v", err)
+		}
+	}
+
+	run("")
+	if !strings.Contains(readFileString(t, notePath), "from anytype") {
+		t.Fatalf("expected initial export")
+	}
+
+	// An untouched note is refreshed even by the protective strategies.
+	run("keep-newer")
+	if !strings.Contains(readFileString(t, notePath), "from anytype") {
+		t.Fatalf("expected unedited note to be rewritten")
+	}
+
+	edit()
+	for _, strategy := range []string{"skip-existing", "keep-newer"} {
+		run(strategy)
+		if got := readFileString(t, notePath); got != "edited in obsidian\n" {
+			t.Fatalf("%s: expected user edit to be kept, got %q", strategy, got)
+		}
+	}
+
+	run("suffix-conflicts")
+	if got := readFileString(t, notePath); got != "edited in obsidian\n" {
+		t.Fatalf("suffix-conflicts: expected user edit to be kept, got %q", got)
+	}
+	if !strings.Contains(readFileString(t, conflictPath), "from anytype") {
+		t.Fatalf("expected export to be written to the conflict file")
+	}
+
+	run("overwrite")
+	if !strings.Contains(readFileString(t, notePath), "from anytype") {
+		t.Fatalf("expected overwrite to replace the edited note")
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, MergeStrategy: "newest"}).Run(); err == nil || !strings.Contains(err.Error(), "invalid merge strategy") {
+		t.Fatalf("expected invalid merge strategy error, got %v", err)
+	}
+}
+
+func TestExporterEmbedsAnytypeMetadataBlock(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":               "obj-1",
+		"name":             "Task One",
+		"spaceId":          "space-1",
+		"type":             "type-task",
+		"lastModifiedDate": 1700000000,
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, EmbedAnytypeMetadata: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	want := "anytype:\n  id: \"obj-1\"\n  spaceId: \"space-1\"\n  typeId: \"type-task\"\n  lastModified: \"2023-11-14T22:13:20Z\"\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected anytype metadata block, got:\n%s", note)
+	}
+
+	var fm struct {
+		Anytype map[string]string `yaml:"anytype"`
+	}
+	parts := strings.SplitN(note, "---\n", 3)
+	if err := yaml.Unmarshal([]byte(parts[1]), &fm); err != nil {
+		t.Fatalf("parse frontmatter: %v", err)
+	}
+	if fm.Anytype["id"] != "obj-1" || fm.Anytype["spaceId"] != "space-1" {
+		t.Fatalf("unexpected parsed metadata: %#v", fm.Anytype)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: filepath.Join(root, "plain")}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if plain := readFileString(t, filepath.Join(root, "plain", "notes", "Task One.md")); strings.Contains(plain, "anytype:") {
+		t.Fatalf("expected no metadata block by default, got:\n%s", plain)
+	}
+}
+
+func TestExporterFilenameSchemes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":          "bafyreiabcdef12345678",
+		"name":        "Task One",
+		"createdDate": 1700000000,
+	}, []map[string]any{
+		{"id": "bafyreiabcdef12345678", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	cases := map[string]string{
+		"name":           "Task One.md",
+		"id":             "bafyreiabcdef12345678.md",
+		"name-id-suffix": "Task One 12345678.md",
+		"zettel":         "202311142213 Task One.md",
+	}
+	for scheme, wantFile := range cases {
+		output := filepath.Join(root, scheme)
+		if _, err := (Exporter{InputDir: input, OutputDir: output, FilenameScheme: scheme}).Run(); err != nil {
+			t.Fatalf("run exporter with %s: %v", scheme, err)
+		}
+		note := readFileString(t, filepath.Join(output, "notes", wantFile))
+		hasAlias := strings.Contains(note, "aliases:\n  - \"Task One\"")
+		if scheme == "name" && hasAlias {
+			t.Fatalf("%s: did not expect title alias, got:\n%s", scheme, note)
+		}
+		if scheme != "name" && !hasAlias {
+			t.Fatalf("%s: expected title alias, got:\n%s", scheme, note)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: filepath.Join(root, "bad"), FilenameScheme: "uuid"}).Run(); err == nil || !strings.Contains(err.Error(), "invalid filename scheme") {
+		t.Fatalf("expected invalid filename scheme error, got %v", err)
+	}
+}
+
+func TestFilenameOptionsFitTruncatesWithHashAndKeepsExtension(t *testing.T) {
+	naming := filenameOptions{maxBytes: 40}
+	long := strings.Repeat("Привет ", 10)
+
+	got := naming.fit(long, ".md")
+	if len(got)+len(".md") > 40 {
+		t.Fatalf("expected at most 40 bytes, got %d: %q", len(got)+len(".md"), got)
+	}
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected truncation on a rune boundary, got %q", got)
+	}
+	if !strings.HasPrefix(got, "Привет") || !strings.Contains(got, "~") {
+		t.Fatalf("expected truncated prefix with hash suffix, got %q", got)
+	}
+	if other := naming.fit(long+"2", ".md"); other == got {
+		t.Fatalf("expected distinct names for distinct long inputs, both %q", got)
+	}
+	if short := naming.fit("Short", ".excalidraw.md"); short != "Short" {
+		t.Fatalf("expected short names to stay unchanged, got %q", short)
+	}
+	if unlimited := (filenameOptions{maxBytes: -1}).fit(long, ".md"); unlimited != long {
+		t.Fatalf("expected no truncation without a limit")
+	}
+}
+
+func TestExporterTruncatesLongNoteFilenames(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	longName := strings.Repeat("очень длинное название ", 20)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": longName,
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(output, "notes"))
+	if err != nil {
+		t.Fatalf("read notes dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one note, got %d", len(entries))
+	}
+	name := entries[0].Name()
+	if len(name) > 255 || !strings.HasSuffix(name, ".md") || !strings.Contains(name, "~") {
+		t.Fatalf("expected truncated note filename within 255 bytes, got %d bytes: %q", len(name), name)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", name))
+	if !strings.Contains(note, "aliases:\n  - \""+strings.TrimSpace(longName)+"\"") {
+		t.Fatalf("expected full title alias for truncated note, got:\n%s", note)
+	}
+}
+
+func TestExporterRejectsMaxFilenameLengthBelowHashSuffix(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": strings.Repeat("long name ", 5),
+	}, nil)
+
+	tooShort := Exporter{InputDir: input, OutputDir: filepath.Join(root, "short"), MaxFilenameLength: minMaxFilenameBytes - 1}
+	if _, err := tooShort.Run(); err == nil || !strings.Contains(err.Error(), "max filename length") {
+		t.Fatalf("expected a max filename length below %d to fail, got %v", minMaxFilenameBytes, err)
+	}
+
+	output := filepath.Join(root, "min")
+	if _, err := (Exporter{InputDir: input, OutputDir: output, MaxFilenameLength: minMaxFilenameBytes}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(output, "notes"))
+	if err != nil {
+		t.Fatalf("read notes dir: %v", err)
+	}
+	for _, entry := range entries {
+		if len(entry.Name()) > minMaxFilenameBytes {
+			t.Fatalf("expected filenames within %d bytes, got %d: %q", minMaxFilenameBytes, len(entry.Name()), entry.Name())
+		}
+	}
+	if got := (filenameOptions{maxBytes: minMaxFilenameBytes}).fit(strings.Repeat("x", 40), ".excalidraw.md"); len(got)+len(".excalidraw.md") > minMaxFilenameBytes {
+		t.Fatalf("expected an Excalidraw name within %d bytes, got %q", minMaxFilenameBytes, got)
+	}
+}
+
+func TestASCIISlug(t *testing.T) {
+	cases := map[string]string{
+		"Привет, мир!":    "privet-mir",
+		"Café Ölfen 2024": "cafe-olfen-2024",
+		"🚀 Launch plan 🚀": "launch-plan",
+		"漢字":              "u6f22u5b57",
+		"snake_case name": "snake_case-name",
+	}
+	for in, want := range cases {
+		if got := asciiSlug(in); got != want {
+			t.Fatalf("asciiSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExporterASCIIFilenamesKeepTitleAlias(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Заметка 📝 о встрече",
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ASCIIFilenames: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "zametka-o-vstreche.md"))
+	if !strings.Contains(note, "aliases:\n  - \"Заметка 📝 о встрече\"") {
+		t.Fatalf("expected original title alias, got:\n%s", note)
+	}
+
+	// ASCII slugs imply transliteration, so adding it changes nothing.
+	both := filepath.Join(root, "both")
+	if _, err := (Exporter{InputDir: input, OutputDir: both, ASCIIFilenames: true, TransliterateFilenames: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if got := readFileString(t, filepath.Join(both, "notes", "zametka-o-vstreche.md")); got != note {
+		t.Fatalf("expected -transliterate-filenames to add nothing to ASCII slugs, got:\n%s", got)
+	}
+}
+
+func TestExporterWritesDivergingTitleVariantsAsAliases(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":    "obj-1",
+		"name":  "Quarterly Plan",
+		"title": "Q3 Plan",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "body"}},
+		{"id": "title", "text": map[string]any{"text": "Plan for Q3", "style": "Title"}},
+		{"id": "body", "text": map[string]any{"text": "Goals"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Same Everywhere",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title-2"}},
+		{"id": "title-2", "text": map[string]any{"text": "Same Everywhere", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Quarterly Plan.md"))
+	if !strings.Contains(note, "aliases:\n  - \"Plan for Q3\"\n  - \"Q3 Plan\"\n") {
+		t.Fatalf("expected title block and title detail aliases, got:\n%s", note)
+	}
+	if same := readFileString(t, filepath.Join(output, "notes", "Same Everywhere.md")); strings.Contains(same, "aliases:") {
+		t.Fatalf("expected no aliases when all titles agree, got:\n%s", same)
+	}
+}
+
+func TestExporterEmojiInFilenameKeepsLinksConsistent(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":        "obj-1",
+		"name":      "Task One",
+		"iconEmoji": "✨",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Index",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"link"}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "obj-1"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, EmojiInFilename: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "✨ Task One.md"))
+	if !strings.Contains(note, "aliases:\n  - \"Task One\"") {
+		t.Fatalf("expected plain title alias, got:\n%s", note)
+	}
+	index := readFileString(t, filepath.Join(output, "notes", "Index.md"))
+	if !strings.Contains(index, "[[✨ Task One.md]]") {
+		t.Fatalf("expected link to emoji-prefixed note, got:\n%s", index)
+	}
+}
+
+func TestExporterCopiesNestedFilesAndLinksByRelativePath(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	mustMkdirAll(t, filepath.Join(input, "files", "trip", "day-1"))
+	if err := os.WriteFile(filepath.Join(input, "files", "trip", "day-1", "beach.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write nested file: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "beach.pb.json"), "FileObject", map[string]any{
+		"id":      "beach",
+		"name":    "beach",
+		"fileExt": "jpg",
+		"source":  "./files/trip/day-1/beach.jpg",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":      "obj-1",
+		"name":    "Task One",
+		"coverId": "beach",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"photo"}},
+		{"id": "photo", "file": map[string]any{"targetObjectId": "beach", "name": "beach.jpg", "type": "Image"}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if stats.Files != 1 {
+		t.Fatalf("expected nested file to be counted, got %d", stats.Files)
+	}
+	if got := readFileString(t, filepath.Join(output, "files", "trip", "day-1", "beach.jpg")); got != "jpg" {
+		t.Fatalf("expected nested file to be copied, got %q", got)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	for _, want := range []string{
+		`banner: "[[files/trip/day-1/beach.jpg]]"`,
+		"![beach.jpg](../files/trip/day-1/beach.jpg)",
+	} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterDetectsExtensionsForAllExtensionlessAttachments(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	png := []byte("\x89PNG\r\n\x1a\n0000IHDR")
+	pdf := []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	mustMkdirAll(t, filepath.Join(input, "files", "scans"))
+	for path, content := range map[string][]byte{
+		filepath.Join(input, "files", "diagram"):         png,
+		filepath.Join(input, "files", "scans", "report"): pdf,
+	} {
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "diagram.pb.json"), "FileObject", map[string]any{
+		"id":     "diagram",
+		"name":   "diagram",
+		"source": "files/diagram",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":        "obj-1",
+		"name":      "Task One",
+		"iconImage": "diagram",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"img"}},
+		{"id": "img", "file": map[string]any{"targetObjectId": "diagram", "name": "diagram", "type": "Image"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	for _, path := range []string{
+		filepath.Join(output, "files", "diagram.png"),
+		filepath.Join(output, "files", "scans", "report.pdf"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("expected renamed attachment %s: %v", path, err)
+		}
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	for _, want := range []string{`icon: "files/diagram.png"`, "](../files/diagram.png)"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterShrinksOversizedImagesAndKeepsOriginals(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+	var pngBytes, jpegBytes bytes.Buffer
+	if err := png.Encode(&pngBytes, src); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	if err := jpeg.Encode(&jpegBytes, src, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	small := image.NewRGBA(image.Rect(0, 0, 50, 20))
+	var smallBytes bytes.Buffer
+	if err := png.Encode(&smallBytes, small); err != nil {
+		t.Fatalf("encode small png: %v", err)
+	}
+	for name, content := range map[string][]byte{"big.png": pngBytes.Bytes(), "big.jpg": jpegBytes.Bytes(), "small.png": smallBytes.Bytes()} {
+		if err := os.WriteFile(filepath.Join(input, "files", name), content, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, MaxImageDimension: 100, ImageQuality: 80, KeepOriginalImages: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	decodeSize := func(path string) (int, int) {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open %s: %v", path, err)
+		}
+		defer f.Close()
+		cfg, _, err := image.DecodeConfig(f)
+		if err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		return cfg.Width, cfg.Height
+	}
+	for _, name := range []string{"big.png", "big.jpg"} {
+		if w, h := decodeSize(filepath.Join(output, "files", name)); w != 100 || h != 50 {
+			t.Fatalf("expected %s to be resized to 100x50, got %dx%d", name, w, h)
+		}
+		if w, h := decodeSize(filepath.Join(output, "files", "originals", name)); w != 400 || h != 200 {
+			t.Fatalf("expected original %s to be kept at 400x200, got %dx%d", name, w, h)
+		}
+	}
+	if w, h := decodeSize(filepath.Join(output, "files", "small.png")); w != 50 || h != 20 {
+		t.Fatalf("expected small image to stay 50x20, got %dx%d", w, h)
+	}
+	if _, err := os.Stat(filepath.Join(output, "files", "originals", "small.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected no original copy for untouched image, got err=%v", err)
+	}
+}
+
+func TestExporterRejectsImageQualityOutOfRange(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+
+	for _, quality := range []int{-1, 101} {
+		e := Exporter{InputDir: input, OutputDir: filepath.Join(root, "out"), MaxImageDimension: 100, ImageQuality: quality}
+		if _, err := e.Run(); err == nil || !strings.Contains(err.Error(), "image quality") {
+			t.Fatalf("expected image quality %d to be rejected, got %v", quality, err)
+		}
+	}
+	for _, quality := range []int{0, 1, 100} {
+		e := Exporter{InputDir: input, OutputDir: filepath.Join(root, "out"), MaxImageDimension: 100, ImageQuality: quality}
+		if _, err := e.Run(); err != nil {
+			t.Fatalf("expected image quality %d to be accepted: %v", quality, err)
+		}
+	}
+}
+
+func TestExporterTranslatesCoverPositionToBannerFields(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "filesObjects", "cover-file.pb.json"), "FileObject", map[string]any{
+		"id":     "cover-file",
+		"name":   "cover",
+		"source": "files/cover.jpg",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":         "obj-1",
+		"name":       "Task One",
+		"coverId":    "cover-file",
+		"coverType":  1,
+		"coverX":     -0.125,
+		"coverY":     -0.4,
+		"coverScale": 0.3,
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	want := "banner: \"[[cover.jpg]]\"\nbanner_x: 0.125\nbanner_y: 0.4\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected banner position fields, got:\n%s", note)
+	}
+	for _, hidden := range []string{"coverX", "coverY", "coverScale"} {
+		if strings.Contains(note, hidden) {
+			t.Fatalf("expected %s to stay hidden, got:\n%s", hidden, note)
+		}
+	}
+}
+
+func TestExporterGeneratesColorAndGradientCovers(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":        "obj-1",
+		"name":      "Task One",
+		"coverId":   "pinkOrange",
+		"coverType": 3,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":        "obj-2",
+		"name":      "Task Two",
+		"coverId":   "teal",
+		"coverType": 2,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-3.pb.json"), "Page", map[string]any{
+		"id":        "obj-3",
+		"name":      "Task Three",
+		"coverId":   "unknownGradient",
+		"coverType": 3,
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	gradient := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(gradient, "banner: \"[[files/covers/gradient-pinkOrange.svg]]\"\n") {
+		t.Fatalf("expected gradient banner, got:\n%s", gradient)
+	}
+	svg := readFileString(t, filepath.Join(output, "files", "covers", "gradient-pinkOrange.svg"))
+	if !strings.Contains(svg, `stop-color="#d8a4e1"`) || !strings.Contains(svg, `stop-color="#fdb2a5"`) {
+		t.Fatalf("expected pinkOrange gradient stops, got:\n%s", svg)
+	}
+
+	color := readFileString(t, filepath.Join(output, "notes", "Task Two.md"))
+	if !strings.Contains(color, "banner: \"[[files/covers/color-teal.svg]]\"\n") {
+		t.Fatalf("expected color banner, got:\n%s", color)
+	}
+	if svg := readFileString(t, filepath.Join(output, "files", "covers", "color-teal.svg")); strings.Count(svg, `stop-color="#0fc8ba"`) != 2 {
+		t.Fatalf("expected solid teal cover, got:\n%s", svg)
+	}
+
+	unknown := readFileString(t, filepath.Join(output, "notes", "Task Three.md"))
+	if strings.Contains(unknown, "banner:") {
+		t.Fatalf("expected no banner for unknown gradient, got:\n%s", unknown)
+	}
+}
+
+func TestExporterRegistersPrettyPropertiesBannerAndCoverSettings(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":        "obj-1",
+		"name":      "Task One",
+		"coverId":   "teal",
+		"coverType": 2,
+		"picture":   "cover-file",
+	}, nil)
+
+	dataPath := filepath.Join(output, ".obsidian", "plugins", "pretty-properties", "data.json")
+	mustMkdirAll(t, filepath.Dir(dataPath))
+	if err := os.WriteFile(dataPath, []byte(`{"bannerProperty":"hero"}`), 0o644); err != nil {
+		t.Fatalf("write existing pretty properties data: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(readFileString(t, dataPath)), &data); err != nil {
+		t.Fatalf("decode pretty properties data: %v", err)
+	}
+	if data["bannerProperty"] != "hero" {
+		t.Fatalf("expected existing bannerProperty to be kept, got %#v", data["bannerProperty"])
+	}
+	if data["enableBanner"] != true || data["bannersFolder"] != "files" {
+		t.Fatalf("expected banner settings, got %#v", data)
+	}
+	if data["enableCover"] != true || data["coverProperty"] != "cover" || data["coversFolder"] != "files" {
+		t.Fatalf("expected cover settings, got %#v", data)
+	}
+}
+
+func TestExporterSkipsPrettyPropertiesMediaSettingsWithoutImages(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, ".obsidian", "plugins", "pretty-properties", "data.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no pretty properties data without colors or images, got err=%v", err)
+	}
+}
+
+func TestExporterIconStyles(t *testing.T) {
+	cases := []struct {
+		style    string
+		notePath string
+		want     []string
+		reject   []string
+	}{
+		{style: "property", notePath: "Task One.md", want: []string{"icon: \"✨\"\n"}, reject: []string{"# ✨"}},
+		{style: "heading", notePath: "Task One.md", want: []string{"---\n\n# ✨ Task One\n\nHello\n"}, reject: []string{"icon:"}},
+		{style: "filename", notePath: "✨ Task One.md", want: []string{"Hello"}, reject: []string{"icon:", "# ✨"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.style, func(t *testing.T) {
+			root := t.TempDir()
+			input := filepath.Join(root, "input")
+			output := filepath.Join(root, "output")
+			prepareMinimalExportFixture(t, input)
+			writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+				"id":        "obj-1",
+				"name":      "Task One",
+				"iconEmoji": "✨",
+			}, []map[string]any{
+				{"id": "obj-1", "childrenIds": []string{"txt-1"}},
+				{"id": "txt-1", "text": map[string]any{"text": "Hello"}},
+			})
+
+			if _, err := (Exporter{InputDir: input, OutputDir: output, IconStyle: tc.style}).Run(); err != nil {
+				t.Fatalf("run exporter: %v", err)
+			}
+			note := readFileString(t, filepath.Join(output, "notes", tc.notePath))
+			for _, want := range tc.want {
+				if !strings.Contains(note, want) {
+					t.Fatalf("expected %q in note, got:\n%s", want, note)
+				}
+			}
+			for _, reject := range tc.reject {
+				if strings.Contains(note, reject) {
+					t.Fatalf("expected no %q in note, got:\n%s", reject, note)
+				}
+			}
+		})
+	}
+
+	if _, err := (Exporter{InputDir: t.TempDir(), OutputDir: t.TempDir(), IconStyle: "sidebar"}).Run(); err == nil || !strings.Contains(err.Error(), "invalid icon style") {
+		t.Fatalf("expected invalid icon style error, got %v", err)
+	}
+}
+
+func TestBuildFilterExpressionResolvesQuickOptionsAgainstAnchor(t *testing.T) {
+	relations := map[string]relationDef{
+		"dueDate": {Key: "dueDate", Name: "Due", Format: anytypedomain.RelationFormatDate},
+	}
+	filter := map[string]any{
+		"RelationKey": "dueDate",
+		"condition":   "Equal",
+		"quickOption": "Today",
+		"format":      "date",
+	}
+
+	anchor := time.Date(2024, time.March, 5, 15, 0, 0, 0, time.Local)
+	expr := buildFilterExpression(filter, relations, nil, nil, nil, nil, propertyKeys{}, anchor)
+	if !strings.Contains(expr, "2024-03-05") {
+		t.Fatalf("expected filter anchored to 2024-03-05, got %q", expr)
+	}
+	if again := buildFilterExpression(filter, relations, nil, nil, nil, nil, propertyKeys{}, anchor); again != expr {
+		t.Fatalf("expected identical expression for the same anchor, got %q and %q", expr, again)
+	}
+	if expr := buildFilterExpression(filter, relations, nil, nil, nil, nil, propertyKeys{}, time.Time{}); expr != "" {
+		t.Fatalf("expected relative filter without anchor to be dropped, got %q", expr)
+	}
+}
+
+func TestExporterNoDynamicTimestampsProducesIdenticalVaults(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-tag.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-tag",
+		"name":           "Tag",
+		"relationKey":    "tag",
+		"relationFormat": 11,
+	}, nil)
+	for _, option := range []struct{ id, name, color string }{{"opt-a", "Alpha", "red"}, {"opt-b", "Beta", "teal"}, {"opt-c", "Gamma", "blue"}} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", option.id+".pb.json"), "STRelationOption", map[string]any{
+			"id":                  option.id,
+			"name":                option.name,
+			"relationKey":         "tag",
+			"relationOptionColor": option.color,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":        "obj-2",
+		"name":      "Task Two",
+		"iconEmoji": "✨",
+		"tag":       []any{"opt-c", "opt-a", "opt-b"},
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "query.pb.json"), "Page", map[string]any{
+		"id":               "query-1",
+		"name":             "Due Today",
+		"lastModifiedDate": 1709650800,
+	}, []map[string]any{
+		{"id": "query-1", "childrenIds": []string{"dataview"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views": []any{map[string]any{
+				"id":   "view-1",
+				"type": "Table",
+				"name": "Today",
+				"filters": []any{map[string]any{
+					"RelationKey": "lastModifiedDate",
+					"condition":   "Equal",
+					"quickOption": "Today",
+					"format":      "date",
+				}},
+			}},
+		}},
+	})
+
+	readVault := func(output string) map[string]string {
+		files := map[string]string{}
+		err := filepath.WalkDir(output, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(output, p)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = readFileString(t, p)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walk %s: %v", output, err)
+		}
+		return files
+	}
+
+	var vaults []map[string]string
+	for _, name := range []string{"first", "second"} {
+		output := filepath.Join(root, name)
+		if _, err := (Exporter{InputDir: input, OutputDir: output, NoDynamicTimestamps: true}).Run(); err != nil {
+			t.Fatalf("run exporter: %v", err)
+		}
+		vaults = append(vaults, readVault(output))
+	}
+
+	if len(vaults[0]) != len(vaults[1]) {
+		t.Fatalf("expected the same files in both runs, got %d and %d", len(vaults[0]), len(vaults[1]))
+	}
+	for rel, content := range vaults[0] {
+		if vaults[1][rel] != content {
+			t.Fatalf("expected %s to be identical across runs, got:\n%s\n---\n%s", rel, content, vaults[1][rel])
+		}
+	}
+	if base := vaults[0]["bases/Due Today.base"]; !strings.Contains(base, "2024-03-05") {
+		t.Fatalf("expected date filter anchored to the base's modification day, got:\n%s", base)
+	}
+	if report := vaults[0]["_anytype/report.json"]; strings.Contains(report, "elapsedSeconds") {
+		t.Fatalf("expected report without elapsed time, got:\n%s", report)
+	}
+}
+
+func TestExporterReadsLegacyJSONExport(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	mustMkdirAll(t, filepath.Join(input, "objects"))
+
+	payload := `{
+  "sbType": 16,
+  "snapshot": {"data": {
+    "blocks": [{"id": "obj-1", "childrenIds": ["p1"]}, {"id": "p1", "text": {"text": "Old but gold"}}],
+    "details": {"id": "obj-1", "name": "Legacy Note", "rating": 4, "reviewed": true},
+    "extraRelations": [
+      {"key": "rating", "format": "number", "name": "Rating"},
+      {"key": "reviewed", "format": 6, "name": "Reviewed"}
+    ],
+    "relationLinks": [{"key": "rating", "format": "shorttext"}]
+  }}
+}`
+	if err := os.WriteFile(filepath.Join(input, "objects", "obj-1.pb.json"), []byte(payload), 0o644); err != nil {
+		t.Fatalf("write legacy snapshot: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Legacy Note.md"))
+	for _, want := range []string{"rating: 4\n", "reviewed: true\n", "Old but gold"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in legacy note, got:\n%s", want, note)
+		}
+	}
+	types := readFileString(t, filepath.Join(output, ".obsidian", "types.json"))
+	if !strings.Contains(types, `"rating": "number"`) || !strings.Contains(types, `"reviewed": "checkbox"`) {
+		t.Fatalf("expected legacy relation formats in types.json, got:\n%s", types)
+	}
+}
+
+func TestExporterRejectsUnsupportedExportFormats(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "markdown", file: "Note.md", want: "Markdown export"},
+		{name: "protobuf", file: "objects.pb", want: "Protobuf snapshots"},
+		{name: "empty", file: "", want: "no objects/ directory"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := t.TempDir()
+			if tc.file != "" {
+				if err := os.WriteFile(filepath.Join(input, tc.file), []byte("x"), 0o644); err != nil {
+					t.Fatalf("write %s: %v", tc.file, err)
+				}
+			}
+			_, err := (Exporter{InputDir: input, OutputDir: t.TempDir()}).Run()
+			if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), "supported: Anytype JSON export") {
+				t.Fatalf("expected unsupported format error mentioning %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestExporterSkipsCorruptSnapshots(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	prepareMinimalExportFixture(t, input)
+	if err := os.WriteFile(filepath.Join(input, "objects", "broken.pb.json"), []byte(`{"sbType": "Page", "snapshot": {`), 0o644); err != nil {
+		t.Fatalf("write broken snapshot: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(input, "relations", "broken-rel.pb.json"), []byte(`not json`), 0o644); err != nil {
+		t.Fatalf("write broken relation: %v", err)
+	}
+
+	output := filepath.Join(root, "output")
+	stats, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("expected corrupt snapshots to be skipped, got %v", err)
+	}
+	if stats.Notes != 1 || stats.Warnings != 2 {
+		t.Fatalf("expected 1 note and 2 warnings, got %+v", stats)
+	}
+	report := readFileString(t, filepath.Join(output, "_anytype", "report.json"))
+	for _, want := range []string{"skipped unreadable snapshot objects/broken.pb.json", "skipped unreadable snapshot relations/broken-rel.pb.json"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in report, got:\n%s", want, report)
+		}
+	}
+
+	_, err = (Exporter{InputDir: input, OutputDir: filepath.Join(root, "strict"), FailFast: true}).Run()
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("expected fail-fast error naming the broken snapshot, got %v", err)
+	}
+}
+
+func TestExporterLowMemoryMatchesDefaultOutput(t *testing.T) {
+	input := filepath.Join("testdata", "golden", "input")
+	defaultOut := t.TempDir()
+	lowMemoryOut := t.TempDir()
+
+	if _, err := (Exporter{InputDir: input, OutputDir: defaultOut, NoDynamicTimestamps: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := (Exporter{InputDir: input, OutputDir: lowMemoryOut, NoDynamicTimestamps: true, LowMemory: true}).Run(); err != nil {
+		t.Fatalf("run low-memory exporter: %v", err)
+	}
+
+	want := readGoldenTree(t, defaultOut)
+	got := readGoldenTree(t, lowMemoryOut)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d files, got %d", len(want), len(got))
+	}
+	for _, rel := range sortedGoldenPaths(want) {
+		if got[rel] != want[rel] {
+			t.Errorf("%s differs in low-memory mode:\n%s", rel, firstGoldenDifference(want[rel], got[rel]))
+		}
+	}
+}
+
+func TestExporterProfileWritesPprofAndPhaseTimings(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Profile: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	profileDir := filepath.Join(output, "_anytype", "profile")
+	for _, name := range []string{"cpu.pprof", "heap.pprof"} {
+		info, err := os.Stat(filepath.Join(profileDir, name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("expected %s to be non-empty", name)
+		}
+	}
+
+	var timings struct {
+		Phases []phaseTiming `json:"phases"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(profileDir, "phases.json"))), &timings); err != nil {
+		t.Fatalf("decode phases.json: %v", err)
+	}
+	var phases []string
+	for _, timing := range timings.Phases {
+		phases = append(phases, timing.Phase)
+	}
+	if strings.Join(phases, ",") != strings.Join(exportPhases, ",") {
+		t.Fatalf("expected timings for %v, got %v", exportPhases, phases)
+	}
+}
+
+func TestExporterProgressHookReportsPhasesAndObjects(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	var phases []string
+	var objectIDs []string
+	progress := func(event Event) {
+		if len(phases) == 0 || phases[len(phases)-1] != event.Phase {
+			phases = append(phases, event.Phase)
+		}
+		if event.ObjectID != "" {
+			objectIDs = append(objectIDs, event.ObjectID)
+		}
+	}
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Progress: progress}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	if strings.Join(phases, ",") != strings.Join(exportPhases, ",") {
+		t.Fatalf("expected phases %v, got %v", exportPhases, phases)
+	}
+	if len(objectIDs) == 0 {
+		t.Fatal("expected object ids in progress events")
+	}
+}
+
+func TestExporterReadsZipArchiveInput(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join("testdata", "golden", "input")
+	archivePath := filepath.Join(root, "Anytype.20240601.zip")
+	writeZipFromDir(t, input, "Anytype.20240601", archivePath)
+
+	fromDir := filepath.Join(root, "from-dir")
+	fromZip := filepath.Join(root, "from-zip")
+	if _, err := (Exporter{InputDir: input, OutputDir: fromDir, NoDynamicTimestamps: true}).Run(); err != nil {
+		t.Fatalf("run exporter on directory: %v", err)
+	}
+	stats, err := (Exporter{InputDir: archivePath, OutputDir: fromZip, NoDynamicTimestamps: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter on zip: %v", err)
+	}
+	if stats.Files == 0 {
+		t.Fatalf("expected files copied out of the archive, got %+v", stats)
+	}
+
+	want := readGoldenTree(t, fromDir)
+	got := readGoldenTree(t, fromZip)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d files from zip input, got %d", len(want), len(got))
+	}
+	for _, rel := range sortedGoldenPaths(want) {
+		if got[rel] != want[rel] {
+			t.Errorf("%s differs for zip input:\n%s", rel, firstGoldenDifference(want[rel], got[rel]))
+		}
+	}
+}
+
+func writeZipFromDir(t *testing.T, dir string, prefix string, archivePath string) {
+	t.Helper()
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	defer out.Close()
+	zw := zip.NewWriter(out)
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return walkErr
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(path.Join(prefix, filepath.ToSlash(rel)))
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+}
+
+func TestExporterWritesVaultToZipArchive(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join("testdata", "golden", "input")
+	archivePath := filepath.Join(root, "vault.zip")
+	fromDir := filepath.Join(root, "vault")
+
+	if _, err := (Exporter{InputDir: input, OutputDir: fromDir, NoDynamicTimestamps: true}).Run(); err != nil {
+		t.Fatalf("run exporter on directory: %v", err)
+	}
+	stats, err := (Exporter{InputDir: input, OutputZip: archivePath, NoDynamicTimestamps: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter to zip: %v", err)
+	}
+	if stats.Notes == 0 || stats.ReportPath != "" {
+		t.Fatalf("unexpected zip export stats: %+v", stats)
+	}
+	if _, err := os.Stat(archivePath + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover temp archive, got %v", err)
+	}
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer archive.Close()
+	got := map[string]string{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		var content bytes.Buffer
+		if _, err := content.ReadFrom(rc); err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		rc.Close()
+		got[f.Name] = content.String()
+	}
+
+	want := readGoldenTree(t, fromDir)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d archive entries, got %d", len(want), len(got))
+	}
+	for _, rel := range sortedGoldenPaths(want) {
+		if got[rel] != want[rel] {
+			t.Errorf("%s differs in archive:\n%s", rel, firstGoldenDifference(want[rel], got[rel]))
+		}
+	}
+}
+
+func TestExporterRejectsZipOutputWithMergeStrategy(t *testing.T) {
+	_, err := (Exporter{InputDir: t.TempDir(), OutputZip: filepath.Join(t.TempDir(), "vault.zip"), MergeStrategy: "keep-newer"}).Run()
+	if err == nil || !strings.Contains(err.Error(), "zip output") {
+		t.Fatalf("expected zip/merge conflict error, got %v", err)
+	}
+}
+
+func TestExporterEscapesAttachmentNamesAndUpdatesLinks(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "files", "Q1: plans"))
+
+	if err := os.WriteFile(filepath.Join(input, "files", "Q1: plans", "Report?.pdf"), []byte("pdf"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "file-1.pb.json"), "FileObject", map[string]any{
+		"id":     "file-1",
+		"name":   "Report?",
+		"source": "files/Q1: plans/Report?.pdf",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "file-page.pb.json"), "Page", map[string]any{
+		"id":   "file-page",
+		"name": "File Page",
+	}, []map[string]any{
+		{"id": "file-page", "childrenIds": []string{"title", "file-block"}},
+		{"id": "title", "text": map[string]any{"text": "File Page", "style": "Title"}},
+		{"id": "file-block", "file": map[string]any{"name": "Report?.pdf", "type": "File", "targetObjectId": "file-1"}},
+	})
+
+	for run := 0; run < 2; run++ {
+		if _, err := (Exporter{InputDir: input, OutputDir: output, FilenameEscaping: "windows"}).Run(); err != nil {
+			t.Fatalf("run exporter: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(output, "files"))
+	if err != nil {
+		t.Fatalf("read files dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "Q1- plans" {
+		t.Fatalf("expected only the escaped folder under files/, got %v", names)
+	}
+	copied, err := os.ReadDir(filepath.Join(output, "files", "Q1- plans"))
+	if err != nil || len(copied) != 1 || copied[0].Name() != "Report-.pdf" {
+		t.Fatalf("expected a single escaped attachment after repeated runs, got %v (%v)", copied, err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "File Page.md"))
+	if !strings.Contains(note, "Q1- plans/Report-.pdf") || strings.Contains(note, "Report?.pdf)") {
+		t.Fatalf("expected file link to follow the escaped attachment, got:\n%s", note)
+	}
+}
+
+func TestCaseInsensitiveFilenamesFollowsModeAndFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	if !caseInsensitiveFilenames("windows", "windows", dir) {
+		t.Fatal("expected windows escaping to fold case")
+	}
+	if caseInsensitiveFilenames("posix", "posix", dir) {
+		t.Fatal("expected explicit posix escaping to keep case")
+	}
+	probed, ok := probeCaseInsensitive(dir)
+	if !ok {
+		t.Fatal("expected the temp dir to be probeable")
+	}
+	if got := caseInsensitiveFilenames("auto", "posix", dir); got != probed {
+		t.Fatalf("expected auto mode to follow the probe (%t), got %t", probed, got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected the probe file to be removed, got %v", entries)
+	}
+}
+
+func TestBuildNotePathIndexSeparatesCaseVariantsWhenFoldingCase(t *testing.T) {
+	objects := []objectInfo{
+		{ID: "a", Name: "Readme"},
+		{ID: "b", Name: "README"},
+	}
+	folded := buildNotePathIndex(objects, filenameOptions{escaping: "posix", foldCase: true}, false)
+	if folded["a"] != "notes/Readme.md" || folded["b"] != "notes/README-2.md" {
+		t.Fatalf("expected case variants to get distinct files, got %v", folded)
+	}
+	sensitive := buildNotePathIndex(objects, filenameOptions{escaping: "posix"}, false)
+	if sensitive["b"] != "notes/README.md" {
+		t.Fatalf("expected case-sensitive naming to keep README.md, got %v", sensitive)
+	}
+}
+
+func TestExporterNormalizesFilenamesAndLinksToNFC(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	nfdName := "Cafe\u0301"
+	nfcName := "Caf\u00e9"
+	if err := os.WriteFile(filepath.Join(input, "files", nfdName+".png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "file-1.pb.json"), "FileObject", map[string]any{
+		"id":     "file-1",
+		"name":   nfdName,
+		"source": "files/" + nfdName + ".png",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "cafe.pb.json"), "Page", map[string]any{
+		"id":   "cafe",
+		"name": nfdName,
+	}, []map[string]any{
+		{"id": "cafe", "childrenIds": []string{"photo"}},
+		{"id": "photo", "file": map[string]any{"name": nfdName + ".png", "type": "Image", "targetObjectId": "file-1"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "linker.pb.json"), "Page", map[string]any{
+		"id":   "linker",
+		"name": "Linker",
+	}, []map[string]any{
+		{"id": "linker", "childrenIds": []string{"link"}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "cafe"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, NFCFilenames: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", nfcName+".md"))
+	if !strings.Contains(note, "files/"+nfcName+".png") {
+		t.Fatalf("expected image link to use the NFC attachment name, got:\n%s", note)
+	}
+	if _, err := os.Stat(filepath.Join(output, "files", nfcName+".png")); err != nil {
+		t.Fatalf("expected NFC attachment: %v", err)
+	}
+	linker := readFileString(t, filepath.Join(output, "notes", "Linker.md"))
+	if !strings.Contains(linker, nfcName+".md") || strings.Contains(linker, nfdName) {
+		t.Fatalf("expected link target in NFC, got:\n%s", linker)
+	}
+}
+
+func TestExporterPropertyOrderPinsKeysAroundWildcard(t *testing.T) {
+	output := t.TempDir()
+	input := filepath.Join("testdata", "golden", "input")
+
+	exp := Exporter{InputDir: input, OutputDir: output, NoDynamicTimestamps: true, PropertyOrder: []string{"Type", "tags", "*", "icon"}}
+	if _, err := exp.Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Ship the exporter.md"))
+	want := "---\ntype: \"Task\"\ntags:\n  - \"work\"\n  - \"big-idea\"\nstatus: \"To do\"\ndueDate: \"2024-06-11\"\n"
+	if !strings.HasPrefix(note, want) {
+		t.Fatalf("expected pinned keys first, got:\n%s", note)
+	}
+	if !strings.Contains(note, "source: \"https://example.com/roadmap\"\nicon: \"files/diagram.png\"\n---\n") {
+		t.Fatalf("expected keys after * to go last, got:\n%s", note)
+	}
+
+	plain := readFileString(t, filepath.Join(output, "notes", "Plain page.md"))
+	if !strings.HasPrefix(plain, "---\ntype: \"Page\"\n---\n") {
+		t.Fatalf("expected trivial note to keep its frontmatter, got:\n%s", plain)
+	}
+}
+
+func TestExporterRejectsRepeatedPropertyOrderWildcard(t *testing.T) {
+	exp := Exporter{InputDir: filepath.Join("testdata", "golden", "input"), OutputDir: t.TempDir(), PropertyOrder: []string{"*", "type", "*"}}
+	if _, err := exp.Run(); err == nil || !strings.Contains(err.Error(), "may appear only once") {
+		t.Fatalf("expected repeated wildcard error, got %v", err)
+	}
+}
+
+func TestExporterPropertiesStyleInlineMovesRelationsToBody(t *testing.T) {
+	input := filepath.Join("testdata", "golden", "input")
+
+	inlineOut := t.TempDir()
+	if _, err := (Exporter{InputDir: input, OutputDir: inlineOut, NoDynamicTimestamps: true, PropertiesStyle: "inline"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(inlineOut, "notes", "Ship the exporter.md"))
+	want := "---\nicon: \"files/diagram.png\"\n---\n\nstatus:: To do\ndueDate:: 2024-06-11\ntags:: work, big-idea\nassignee:: [[Ada Lovelace.md]]\ndone:: false\nestimate:: 3.5\nsource:: https://example.com/roadmap\ntype:: Task\n\n"
+	if !strings.HasPrefix(note, want) {
+		t.Fatalf("expected relations as inline fields, got:\n%s", note)
+	}
+	plain := readFileString(t, filepath.Join(inlineOut, "notes", "Plain page.md"))
+	if !strings.HasPrefix(plain, "type:: Page\n\nJust text.\n") {
+		t.Fatalf("expected trivial note without frontmatter, got:\n%s", plain)
+	}
+	if _, err := os.Stat(filepath.Join(inlineOut, ".obsidian", "types.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no property types for inline properties, got %v", err)
+	}
+
+	bothOut := t.TempDir()
+	if _, err := (Exporter{InputDir: input, OutputDir: bothOut, NoDynamicTimestamps: true, PropertiesStyle: "both"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	plain = readFileString(t, filepath.Join(bothOut, "notes", "Plain page.md"))
+	if !strings.HasPrefix(plain, "---\ntype: \"Page\"\n---\n\ntype:: Page\n\nJust text.\n") {
+		t.Fatalf("expected frontmatter and inline fields, got:\n%s", plain)
+	}
+}
+
+func TestExporterTagHierarchyNestsMappedRelations(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	for _, rel := range []struct{ id, key, name string }{
+		{"rel-tag", "tag", "Tag"},
+		{"rel-area", "area", "Area"},
+		{"rel-topic", "topic", "Topic"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relations", rel.id+".pb.json"), "STRelation", map[string]any{
+			"id":             rel.id,
+			"relationKey":    rel.key,
+			"relationFormat": 11,
+			"name":           rel.name,
+		}, nil)
+	}
+	for _, opt := range []struct{ id, name string }{
+		{"opt-go", "go"},
+		{"opt-work", "Work/Project X"},
+		{"opt-reading", "reading"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", opt.id+".pb.json"), "STRelationOption", map[string]any{
+			"id":   opt.id,
+			"name": opt.name,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":    "obj-1",
+		"name":  "Task One",
+		"tag":   []any{"opt-go"},
+		"area":  []any{"opt-work"},
+		"topic": []any{"opt-reading"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	exp := Exporter{InputDir: input, OutputDir: output, TagHierarchy: []string{"area", "topic=learning"}}
+	if _, err := exp.Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(note, "tags:\n  - \"Area/Work/Project-X\"\n  - \"go\"\n  - \"learning/reading\"\n") {
+		t.Fatalf("expected nested tags in one tags list, got:\n%s", note)
+	}
+	if strings.Contains(note, "area:") || strings.Contains(note, "topic:") {
+		t.Fatalf("expected mapped relations to leave their own properties, got:\n%s", note)
+	}
+}
+
+func TestExporterTagsFromPropertiesControlsTagsKey(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+
+	for _, rel := range []struct{ id, key, name string }{
+		{"rel-tag", "tag", "Tag"},
+		{"rel-topics", "topics", "Topics"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relations", rel.id+".pb.json"), "STRelation", map[string]any{
+			"id":             rel.id,
+			"relationKey":    rel.key,
+			"relationFormat": 11,
+			"name":           rel.name,
+		}, nil)
+	}
+	for _, opt := range []struct{ id, name string }{
+		{"opt-go", "go"},
+		{"opt-reading", "Deep Reading"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", opt.id+".pb.json"), "STRelationOption", map[string]any{
+			"id":   opt.id,
+			"name": opt.name,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":     "obj-1",
+		"name":   "Task One",
+		"tag":    []any{"opt-go"},
+		"topics": []any{"opt-reading", "opt-go"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+
+	merged := filepath.Join(root, "merged")
+	if _, err := (Exporter{InputDir: input, OutputDir: merged, TagsFromProperties: []string{"tag", "Topics"}}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(merged, "notes", "Task One.md"))
+	if !strings.Contains(note, "tags:\n  - \"go\"\n  - \"Deep-Reading\"\n") || strings.Contains(note, "topics:") {
+		t.Fatalf("expected tag and topics merged into tags, got:\n%s", note)
+	}
+
+	separate := filepath.Join(root, "separate")
+	if _, err := (Exporter{InputDir: input, OutputDir: separate, NoMergeTags: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note = readFileString(t, filepath.Join(separate, "notes", "Task One.md"))
+	if strings.Contains(note, "tags:") || !strings.Contains(note, "tag:\n  - \"go\"\n") || !strings.Contains(note, "topics:\n  - \"Deep Reading\"\n  - \"go\"\n") {
+		t.Fatalf("expected relations kept as their own properties, got:\n%s", note)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: filepath.Join(root, "both"), NoMergeTags: true, TagsFromProperties: []string{"topics"}}).Run(); err == nil {
+		t.Fatalf("expected conflicting tag options to fail")
+	}
+}
+
+func TestExporterKanbanBoardsFromBoardViews(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-status.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-status",
+		"relationKey":    "status",
+		"relationFormat": 3,
+		"name":           "Status",
+	}, nil)
+	for _, opt := range []struct{ id, name string }{
+		{"opt-todo", "To do"},
+		{"opt-done", "Done"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", opt.id+".pb.json"), "STRelationOption", map[string]any{
+			"id":   opt.id,
+			"name": opt.name,
+		}, nil)
+	}
+	for _, task := range []struct{ id, name, status string }{
+		{"task-a", "Alpha", "opt-todo"},
+		{"task-b", "Beta", "opt-todo"},
+		{"task-c", "Gamma", "opt-done"},
+		{"task-d", "Delta", ""},
+	} {
+		details := map[string]any{"id": task.id, "name": task.name, "type": "type-task"}
+		if task.status != "" {
+			details["status"] = task.status
+		}
+		writePBJSON(t, filepath.Join(input, "objects", task.id+".pb.json"), "Page", details, []map[string]any{
+			{"id": task.id, "childrenIds": []string{}},
+		})
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "set-tasks.pb.json"), "Page", map[string]any{
+		"id":     "set-tasks",
+		"name":   "Tasks",
+		"layout": 3,
+		"setOf":  []any{"type-task"},
+	}, []map[string]any{
+		{"id": "set-tasks", "childrenIds": []string{"dataview"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views": []any{
+				map[string]any{"id": "view-board", "name": "Board", "type": "Board", "groupRelationKey": "status"},
+				map[string]any{"id": "view-table", "name": "All", "type": "Table"},
+			},
+			"groupOrders": []any{
+				map[string]any{"viewId": "view-board", "viewGroups": []any{
+					map[string]any{"groupId": "opt-done", "index": 0},
+					map[string]any{"groupId": "opt-todo", "index": 1},
+				}},
+			},
+			"objectOrders": []any{
+				map[string]any{"viewId": "view-board", "groupId": "opt-todo", "objectIds": []any{"task-b", "task-a"}},
+			},
+		}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, KanbanBoards: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	board := readFileString(t, filepath.Join(output, "bases", "Tasks - Board.md"))
+	want := "---\n\nkanban-plugin: basic\n\n---\n\n" +
+		"## Done\n\n- [ ] [[../notes/Gamma.md]]\n\n" +
+		"## To do\n\n- [ ] [[../notes/Beta.md]]\n- [ ] [[../notes/Alpha.md]]\n\n" +
+		"## No Status\n\n- [ ] [[../notes/Delta.md]]\n\n"
+	if !strings.HasPrefix(board, want) {
+		t.Fatalf("unexpected kanban board:\n%s", board)
+	}
+	if _, err := os.Stat(filepath.Join(output, "bases", "Tasks - All.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected no board for table views, got %v", err)
+	}
+}
+
+func TestExporterIndentsNestedListsByListDepth(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "intro", "num-1", "num-2", "callout"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "intro", "text": map[string]any{"text": "Intro", "style": "Paragraph"}, "childrenIds": []string{"bullet"}},
+		{"id": "bullet", "text": map[string]any{"text": "under paragraph", "style": "Marked"}},
+		{"id": "num-1", "text": map[string]any{"text": "one", "style": "Numbered"}, "childrenIds": []string{"sub-1", "sub-2", "note"}},
+		{"id": "sub-1", "text": map[string]any{"text": "one.a", "style": "Numbered"}, "childrenIds": []string{"todo"}},
+		{"id": "todo", "text": map[string]any{"text": "check", "style": "Checkbox"}},
+		{"id": "sub-2", "text": map[string]any{"text": "one.b", "style": "Numbered"}},
+		{"id": "note", "text": map[string]any{"text": "continued", "style": "Paragraph"}},
+		{"id": "num-2", "text": map[string]any{"text": "two", "style": "Numbered"}},
+		{"id": "callout", "text": map[string]any{"text": "Tip", "style": "Callout"}, "childrenIds": []string{"callout-item"}},
+		{"id": "callout-item", "text": map[string]any{"text": "inside", "style": "Marked"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	want := "Intro\n- under paragraph\n1. one\n\t1. one.a\n\t\t- [ ] check\n\t2. one.b\n\n\tcontinued\n2. two\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected lists indented by list depth, got:\n%s", note)
+	}
+	if !strings.Contains(note, "> [!note] Tip\n> - inside") {
+		t.Fatalf("expected callout lists to start at the margin, got:\n%s", note)
+	}
+}
+
+func TestExporterLinksToSingleBlocksWithObsidianBlockIDs(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Target",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"para", "code"}},
+		{"id": "para", "text": map[string]any{"text": "Key point", "style": "Paragraph"}},
+		{"id": "code", "text": map[string]any{"text": "go test", "style": "Code"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"mention", "deep", "link"}},
+		{"id": "mention", "text": map[string]any{"text": "See Target", "style": "Paragraph", "marks": map[string]any{"marks": []any{
+			map[string]any{"range": map[string]any{"from": 4, "to": 10}, "type": "Mention", "param": "obj-2#para"},
+		}}}},
+		{"id": "deep", "text": map[string]any{"text": "the command", "style": "Paragraph", "marks": map[string]any{"marks": []any{
+			map[string]any{"range": map[string]any{"from": 4, "to": 11}, "type": "Link", "param": "anytype://object?objectId=obj-2&spaceId=space&blockId=code"},
+		}}}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "obj-2#para"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	source := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	for _, want := range []string{
+		"See [[Target.md#^para]]\n",
+		"the [[Target.md#^code|command]]\n",
+		"\n[[Target.md#^para]]\n",
+	} {
+		if !strings.Contains(source, want) {
+			t.Fatalf("expected %q in source note, got:\n%s", want, source)
+		}
+	}
+	target := readFileString(t, filepath.Join(output, "notes", "Target.md"))
+	if !strings.Contains(target, "Key point ^para\n") || !strings.Contains(target, "```\ngo test\n```\n\n^code\n") {
+		t.Fatalf("expected block ids on target blocks, got:\n%s", target)
+	}
+}
+
+func TestExporterEmbedsInlineSetTargetWithoutViewsAsNote(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "set-empty.pb.json"), "Page", map[string]any{
+		"id":   "set-empty",
+		"name": "Reading list",
+	}, []map[string]any{
+		{"id": "set-empty", "childrenIds": []string{"intro"}},
+		{"id": "intro", "text": map[string]any{"text": "Books to read", "style": "Paragraph"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"inline-set"}},
+		{"id": "inline-set", "dataview": map[string]any{"TargetObjectId": "set-empty"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(note, "![[Reading list.md]]\n") {
+		t.Fatalf("expected inline set to embed the target note, got:\n%s", note)
+	}
+}
+
+func TestExporterSplitBySpaceWritesOneVaultPerSpace(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":      "obj-1",
+		"name":    "Work note",
+		"spaceId": "space-work",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":      "obj-2",
+		"name":    "Home note",
+		"spaceId": "space-home",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "ws-work.pb.json"), "Workspace", map[string]any{
+		"id":      "ws-work",
+		"name":    "Work",
+		"spaceId": "space-work",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "filesObjects", "file-1.pb.json"), "FileObject", map[string]any{
+		"id":      "file-1",
+		"name":    "plan",
+		"fileExt": "pdf",
+		"source":  "files/plan.pdf",
+		"spaceId": "space-work",
+	}, nil)
+	if err := os.WriteFile(filepath.Join(input, "files", "plan.pdf"), []byte("pdf"), 0o644); err != nil {
+		t.Fatalf("write attachment: %v", err)
+	}
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, SplitBySpace: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	for _, rel := range []string{
+		"Work/notes/Work note.md",
+		"Work/files/plan.pdf",
+		"Work/_anytype/index.json",
+		"space-home/notes/Home note.md",
+	} {
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("expected %s: %v", rel, err)
+		}
+	}
+	for _, rel := range []string{
+		"Work/notes/Home note.md",
+		"space-home/notes/Work note.md",
+		"space-home/files/plan.pdf",
+		"notes",
+	} {
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(rel))); !os.IsNotExist(err) {
+			t.Errorf("expected no %s, got %v", rel, err)
+		}
+	}
+	if stats.Notes < 2 {
+		t.Fatalf("expected notes of both spaces in stats, got %+v", stats)
+	}
+}
+
+func TestExporterWritesStubsForMissingObjects(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "input")
+	output := filepath.Join(root, "output")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": 100,
+		"name":           "Related",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":      "obj-1",
+		"name":    "Task One",
+		"related": []any{"other-space-1"},
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title", "p1"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+		{"id": "p1", "text": map[string]any{
+			"text":  "See Roadmap",
+			"style": "Paragraph",
+			"marks": map[string]any{"marks": []any{map[string]any{"range": map[string]any{"from": 4, "to": 11}, "type": "Mention", "param": "other-space-2"}}},
+		}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, MissingStubs: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	stub := readFileString(t, filepath.Join(output, "Missing", "Roadmap.md"))
+	if !strings.Contains(stub, "anytype_missing: true\n") || !strings.Contains(stub, "anytype_id: \"other-space-2\"\n") {
+		t.Fatalf("unexpected stub:\n%s", stub)
+	}
+	if _, err := os.Stat(filepath.Join(output, "Missing", "other-space-1.md")); err != nil {
+		t.Fatalf("expected stub named after id: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task One.md"))
+	if !strings.Contains(note, "  - \"[[../Missing/other-space-1.md]]\"\n") {
+		t.Fatalf("expected relation to link to the stub, got:\n%s", note)
+	}
+	if !strings.Contains(note, "See [[../Missing/Roadmap.md]]") {
+		t.Fatalf("expected mention to link to the stub, got:\n%s", note)
+	}
+}
+
+func TestExporterWritesDeletedObjectsToTrashWhenIncludeDeletedEnabled(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	outputDefault := filepath.Join(root, "vault-default")
+	outputIncluded := filepath.Join(root, "vault-included")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "deleted-note.pb.json"), "Page", map[string]any{
+		"id":        "deleted-note-1",
+		"name":      "Deleted Note",
+		"isDeleted": true,
+	}, []map[string]any{
+		{"id": "deleted-note-1", "childrenIds": []string{"title", "p-1"}},
+		{"id": "title", "text": map[string]any{"text": "Deleted Note", "style": "Title"}},
+		{"id": "p-1", "text": map[string]any{"text": "Recover me", "style": "Paragraph"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: outputDefault, IncludeArchivedObjects: true}).Run(); err != nil {
+		t.Fatalf("run exporter default: %v", err)
+	}
+	for _, dir := range []string{"notes", "trash"} {
+		if _, err := os.Stat(filepath.Join(outputDefault, dir, "Deleted Note.md")); !os.IsNotExist(err) {
+			t.Fatalf("expected deleted note to be skipped by default, got %s: %v", dir, err)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: outputIncluded, IncludeDeleted: true}).Run(); err != nil {
+		t.Fatalf("run exporter include deleted: %v", err)
+	}
+	content := readFileString(t, filepath.Join(outputIncluded, "trash", "Deleted Note.md"))
+	if !strings.Contains(content, "Recover me") {
+		t.Fatalf("expected deleted note body in trash, got:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(outputIncluded, "notes", "Task One.md")); err != nil {
+		t.Fatalf("expected live notes to stay in notes/: %v", err)
+	}
+}
+
+func TestExporterSkipsSystemObjectsUnlessKept(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "home.pb.json"), "Home", map[string]any{
+		"id":   "home-1",
+		"name": "Home",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "workspace.pb.json"), "Workspace", map[string]any{
+		"id":   "workspace-1",
+		"name": "My Space",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "dashboard.pb.json"), "Page", map[string]any{
+		"id":             "dashboard-1",
+		"name":           "Dashboard",
+		"resolvedLayout": 7,
+	}, nil)
+
+	tests := []struct {
+		name    string
+		exp     Exporter
+		present []string
+	}{
+		{name: "default", present: []string{"Task One.md"}},
+		{name: "include all", exp: Exporter{IncludeSystemObjects: true}, present: []string{"Dashboard.md", "Home.md", "My Space.md", "Task One.md"}},
+		{name: "keep listed", exp: Exporter{KeepSystemObjectTypes: []string{"home", "Dashboard"}}, present: []string{"Dashboard.md", "Home.md", "Task One.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "vault")
+			exp := tt.exp
+			exp.InputDir = input
+			exp.OutputDir = output
+			if _, err := exp.Run(); err != nil {
+				t.Fatalf("run exporter: %v", err)
+			}
+			entries, err := os.ReadDir(filepath.Join(output, "notes"))
+			if err != nil {
+				t.Fatalf("read notes: %v", err)
+			}
+			var got []string
+			for _, ent := range entries {
+				got = append(got, ent.Name())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.present, ",") {
+				t.Fatalf("expected notes %v, got %v", tt.present, got)
+			}
+		})
+	}
+}
+
+func TestExporterWritesTagColorsSnippet(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-tag.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-tag",
+		"name":           "Tag",
+		"relationKey":    "tag",
+		"relationFormat": 11,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-area.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-area",
+		"name":           "Area",
+		"relationKey":    "area",
+		"relationFormat": 11,
+	}, nil)
+	for _, opt := range []struct{ id, name, key, color string }{
+		{"opt-backend", "Backend", "tag", "teal"},
+		{"opt-plain", "Plain", "tag", "grey"},
+		{"opt-home", "Home", "area", "red"},
+	} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", opt.id+".pb.json"), "STRelationOption", map[string]any{
+			"id":                  opt.id,
+			"name":                opt.name,
+			"relationKey":         opt.key,
+			"relationOptionColor": opt.color,
+		}, nil)
+	}
+	mustMkdirAll(t, filepath.Join(output, ".obsidian"))
+	if err := os.WriteFile(filepath.Join(output, ".obsidian", "appearance.json"), []byte(`{"theme":"obsidian","enabledCssSnippets":["mine"]}`), 0o644); err != nil {
+		t.Fatalf("seed appearance: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, TagColorsCSS: true, TagHierarchy: []string{"area"}}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+
+	css := readFileString(t, filepath.Join(output, ".obsidian", "snippets", "anytype-tag-colors.css"))
+	for _, want := range []string{
+		".tag[href=\"#Backend\"] {\n  --tag-color: var(--color-cyan);\n",
+		".tag[href=\"#Area/Home\"] {\n  --tag-color: var(--color-red);\n",
+	} {
+		if !strings.Contains(css, want) {
+			t.Fatalf("expected %q in snippet:\n%s", want, css)
+		}
+	}
+	if strings.Contains(css, "Plain") {
+		t.Fatalf("expected grey options to keep the theme color:\n%s", css)
+	}
+
+	var appearance map[string]any
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(output, ".obsidian", "appearance.json"))), &appearance); err != nil {
+		t.Fatalf("decode appearance: %v", err)
+	}
+	snippets := anyToStringSlice(appearance["enabledCssSnippets"])
+	if appearance["theme"] != "obsidian" || strings.Join(snippets, ",") != "mine,anytype-tag-colors" {
+		t.Fatalf("unexpected appearance settings: %v", appearance)
+	}
+}
+
+func TestRenderBaseFileKeepsColumnWidthsAndCardLayout(t *testing.T) {
+	obj := objectInfo{
+		ID: "query-1",
+		Blocks: []block{
+			{
+				ID: "dataview",
+				Dataview: map[string]any{
+					"views": []any{
+						map[string]any{"id": "view-1", "type": "Table", "name": "All", "relations": []any{
+							map[string]any{"key": "name", "isVisible": true, "width": 320},
+							map[string]any{"key": "status", "isVisible": true, "width": 140},
+							map[string]any{"key": "hidden", "isVisible": false, "width": 90},
+						}},
+						map[string]any{"id": "view-2", "type": "Gallery", "name": "Cards", "cardSize": "Large", "coverFit": true},
+					},
+				},
+			},
+		},
+	}
+	relations := map[string]relationDef{
+		"status": {Key: "status", Name: "Status", Format: anytypedomain.RelationFormatStatus},
+	}
+
+	base, ok := renderBaseFile(obj, relations, nil, nil, nil, nil, propertyKeys{}, false, time.Now())
+	if !ok {
+		t.Fatalf("expected base to be rendered")
+	}
+	for _, want := range []string{
+		"    columnSize:\n      file.name: 320\n      note.status: 140\n",
+		"  - type: cards\n    name: Cards\n    cardSize: 300\n    imageFit: contain\n",
+	} {
+		if !strings.Contains(base, want) {
+			t.Fatalf("expected %q in base, got:\n%s", want, base)
+		}
+	}
+	if strings.Contains(base, "hidden") {
+		t.Fatalf("expected hidden columns to have no width, got:\n%s", base)
+	}
+}
+
+func TestRenderBaseFileUsesGalleryCoverAsCardImage(t *testing.T) {
+	gallery := func(coverKey string) objectInfo {
+		return objectInfo{
+			ID: "query-1",
+			Blocks: []block{{
+				ID: "dataview",
+				Dataview: map[string]any{
+					"views": []any{map[string]any{"id": "view-1", "type": "Gallery", "name": "Cards", "coverRelationKey": coverKey}},
+				},
+			}},
+		}
+	}
+	relations := map[string]relationDef{
+		"picture": {Key: "picture", Name: "Picture", Format: anytypedomain.RelationFormatFile},
+		"poster":  {Key: "poster", Name: "Poster", Format: anytypedomain.RelationFormatFile},
+	}
+
+	tests := []struct {
+		coverKey string
+		keys     propertyKeys
+		want     string
+	}{
+		{coverKey: "pageCover", want: "    image: note.banner\n"},
+		{coverKey: "poster", want: "    image: note.poster\n"},
+		{coverKey: "picture", keys: propertyKeys{pictureToCover: true}, want: "    image: note.cover\n"},
+		{coverKey: "", want: ""},
+	}
+	for _, tt := range tests {
+		base, ok := renderBaseFile(gallery(tt.coverKey), relations, nil, nil, nil, nil, tt.keys, false, time.Now())
+		if !ok {
+			t.Fatalf("expected base for cover %q", tt.coverKey)
+		}
+		if tt.want == "" {
+			if strings.Contains(base, "image:") {
+				t.Fatalf("expected no image for empty cover key, got:\n%s", base)
+			}
+			continue
+		}
+		if !strings.Contains(base, tt.want) {
+			t.Fatalf("expected %q for cover %q, got:\n%s", tt.want, tt.coverKey, base)
+		}
+	}
+}
+
+func TestExporterWritesColumnCalculationsAsBaseSummaries(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-price.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-price",
+		"relationKey":    "price",
+		"relationFormat": 2,
+		"name":           "Price",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "query.pb.json"), "Page", map[string]any{
+		"id":   "query-1",
+		"name": "Purchases",
+	}, []map[string]any{
+		{"id": "query-1", "childrenIds": []string{"title", "dataview"}},
+		{"id": "title", "text": map[string]any{"text": "Purchases", "style": "Title"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views": []any{map[string]any{"id": "view-1", "type": "Table", "name": "All", "relations": []any{
+				map[string]any{"key": "name", "isVisible": true, "formulaType": "Count"},
+				map[string]any{"key": "price", "isVisible": true, "formulaType": "MathSum"},
+			}}},
+		}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	base := readFileString(t, filepath.Join(output, "bases", "Purchases.base"))
+	if !strings.Contains(base, "    summaries:\n      note.price: Sum\n") {
+		t.Fatalf("expected sum summary, got:\n%s", base)
+	}
+	if strings.Contains(base, "file.name:") {
+		t.Fatalf("expected no summary for the row count, got:\n%s", base)
+	}
+	want := "base bases/Purchases.base: no Bases summary for column calculation All: file.name (count)"
+	found := false
+	for _, msg := range stats.WarningMessages {
+		found = found || msg == want
+	}
+	if !found {
+		t.Fatalf("expected warning %q, got %v", want, stats.WarningMessages)
+	}
+}
+
+func TestExporterWritesHTMLSite(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+
+	if err := os.WriteFile(filepath.Join(input, "files", "beach.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "beach.pb.json"), "FileObject", map[string]any{
+		"id":      "beach",
+		"name":    "beach",
+		"fileExt": "jpg",
+		"source":  "files/beach.jpg",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Trip",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title", "intro", "photo", "link"}},
+		{"id": "title", "text": map[string]any{"text": "Trip", "style": "Title"}},
+		{"id": "intro", "text": map[string]any{"text": "Sun & sand", "style": "Paragraph"}},
+		{"id": "photo", "file": map[string]any{"targetObjectId": "beach", "name": "beach.jpg", "type": "Image"}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "obj-1"}},
+	})
+
+	both := filepath.Join(root, "both")
+	if _, err := (Exporter{InputDir: input, OutputDir: both, Format: "both"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(both, "notes", "Trip.md")); err != nil {
+		t.Fatalf("expected markdown note next to html: %v", err)
+	}
+	page := readFileString(t, filepath.Join(both, "notes", "Trip.html"))
+	for _, want := range []string{
+		"<title>Trip</title>",
+		"<p>Sun &amp; sand<br>",
+		`<img src="../files/beach.jpg" alt="beach.jpg">`,
+		`href="Task%20One.html"`,
+		`<a href="../index.html">Index</a>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected %q in page, got:\n%s", want, page)
+		}
+	}
+	if index := readFileString(t, filepath.Join(both, "index.html")); !strings.Contains(index, `<a href="notes/Trip.html">Trip</a>`) {
+		t.Fatalf("expected index to list the page, got:\n%s", index)
+	}
+
+	site := filepath.Join(root, "site")
+	if _, err := (Exporter{InputDir: input, OutputDir: site, Format: "html", HTMLEmbedImages: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(site, "notes", "Trip.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected no markdown in html output, got stat err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(site, "_anytype")); !os.IsNotExist(err) {
+		t.Fatalf("expected no _anytype folder in html output, got stat err: %v", err)
+	}
+	page = readFileString(t, filepath.Join(site, "notes", "Trip.html"))
+	if !strings.Contains(page, `<img src="data:image/jpeg;base64,anBn" alt="beach.jpg">`) {
+		t.Fatalf("expected embedded image, got:\n%s", page)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: site, Format: "pdf"}).Run(); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Fatalf("expected invalid format error, got %v", err)
+	}
+}
+
+func TestExporterWritesLogseqGraph(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "graph")
+	prepareMinimalExportFixture(t, input)
+
+	if err := os.WriteFile(filepath.Join(input, "files", "beach.jpg"), []byte("jpg"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "filesObjects", "beach.pb.json"), "FileObject", map[string]any{
+		"id":      "beach",
+		"name":    "beach",
+		"fileExt": "jpg",
+		"source":  "files/beach.jpg",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "date.pb.json"), "Date", map[string]any{
+		"id":   "_date_2026-02-04",
+		"name": "04 Feb 2026",
+	}, []map[string]any{
+		{"id": "_date_2026-02-04", "childrenIds": []string{"note"}},
+		{"id": "note", "text": map[string]any{"text": "Went to the beach", "style": "Paragraph"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Trip",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title", "plan", "todo", "done", "photo", "link"}},
+		{"id": "title", "text": map[string]any{"text": "Trip", "style": "Title"}},
+		{"id": "plan", "text": map[string]any{"text": "Plan", "style": "Header2"}},
+		{"id": "todo", "text": map[string]any{"text": "Pack", "style": "Checkbox"}},
+		{"id": "done", "text": map[string]any{"text": "Book", "style": "Checkbox", "checked": true}},
+		{"id": "photo", "file": map[string]any{"targetObjectId": "beach", "name": "beach.jpg", "type": "Image"}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "obj-1"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Flavor: "logseq"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	page := readFileString(t, filepath.Join(output, "pages", "Trip.md"))
+	for _, want := range []string{
+		"- # Trip\n\t- ## Plan\n",
+		"\t\t- TODO Pack\n\t\t- DONE Book\n",
+		"![beach.jpg](../assets/beach.jpg)",
+		"[[Task One]]",
+	} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("expected %q in page, got:\n%s", want, page)
+		}
+	}
+	if journal := readFileString(t, filepath.Join(output, "journals", "2026_02_04.md")); !strings.Contains(journal, "- Went to the beach\n") {
+		t.Fatalf("expected date object as journal, got:\n%s", journal)
+	}
+	if got := readFileString(t, filepath.Join(output, "assets", "beach.jpg")); got != "jpg" {
+		t.Fatalf("expected asset to be copied, got %q", got)
+	}
+	if config := readFileString(t, filepath.Join(output, "logseq", "config.edn")); !strings.Contains(config, `:journal/page-title-format "yyyy-MM-dd"`) {
+		t.Fatalf("expected journal title format in config, got:\n%s", config)
+	}
+	for _, rel := range []string{"notes", ".obsidian", "_anytype"} {
+		if _, err := os.Stat(filepath.Join(output, rel)); !os.IsNotExist(err) {
+			t.Fatalf("expected no %s in logseq graph, got stat err: %v", rel, err)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Flavor: "logseq", Format: "html"}).Run(); err == nil {
+		t.Fatalf("expected logseq flavor with html format to fail")
+	}
+}
+
+func TestLogseqGraphConvertsPropertiesAndCallouts(t *testing.T) {
+	graph := logseqGraph{
+		vault: vaultIndex{
+			files:  []string{"notes/A.md", "notes/B.md"},
+			exists: map[string]struct{}{"notes/A.md": {}, "notes/B.md": {}},
+			byName: map[string]string{"A.md": "notes/A.md", "B.md": "notes/B.md"},
+		},
+		pageNames:  map[string]string{"notes/A.md": "A", "notes/B.md": "B"},
+		pageFiles:  map[string]string{"notes/A.md": "pages/A.md", "notes/B.md": "pages/B.md"},
+		assetFiles: map[string]string{},
+	}
+	page := graph.renderPage("notes/A.md", "---\nStatus: Done\naliases:\n  - Alpha\nRelated:\n  - \"[[B|Bee]]\"\n---\n\n> [!warning] Careful\n> body\n\nline one\nline two ^abc\n")
+	want := "status:: Done\nalias:: Alpha\nrelated:: [Bee]([[B]])\n\n- #+BEGIN_WARNING\n  **Careful**\n  body\n  #+END_WARNING\n- line one\n  line two\n"
+	if page != want {
+		t.Fatalf("unexpected page:\n%s\nwant:\n%s", page, want)
+	}
+}
+
+func TestExporterWritesPortableMarkdown(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "portable")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "query.pb.json"), "Page", map[string]any{
+		"id":   "query-1",
+		"name": "Backlog",
+	}, []map[string]any{
+		{"id": "query-1", "childrenIds": []string{"title", "dataview"}},
+		{"id": "title", "text": map[string]any{"text": "Backlog", "style": "Title"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views":        []any{map[string]any{"id": "view-1", "type": "Table", "name": "All"}},
+			"objectOrders": []any{map[string]any{"viewId": "view-1", "objectIds": []any{"obj-1"}}},
+		}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Plan",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title", "callout", "link", "base-link"}},
+		{"id": "title", "text": map[string]any{"text": "Plan", "style": "Title"}},
+		{"id": "callout", "text": map[string]any{"text": "Heads up", "style": "Callout"}},
+		{"id": "link", "link": map[string]any{"targetBlockId": "obj-1"}},
+		{"id": "base-link", "link": map[string]any{"targetBlockId": "query-1"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Flavor: "portable"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "bases", "Backlog.base")); !os.IsNotExist(err) {
+		t.Fatalf("expected no .base file, got stat err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, ".obsidian")); !os.IsNotExist(err) {
+		t.Fatalf("expected no .obsidian folder, got stat err: %v", err)
+	}
+	if list := readFileString(t, filepath.Join(output, "bases", "Backlog.md")); list != "# Backlog\n\n- [Task One](../notes/Task%20One.md)\n" {
+		t.Fatalf("unexpected member list:\n%s", list)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Plan.md"))
+	for _, want := range []string{"[Task One](Task%20One.md)", "[Backlog](../bases/Backlog.md)", "> **"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+	for _, unwanted := range []string{"[[", "> [!"} {
+		if strings.Contains(note, unwanted) {
+			t.Fatalf("expected no %q in note, got:\n%s", unwanted, note)
+		}
+	}
+}
+
+func TestExporterTypeAsTagAddsTypeTag(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "types"))
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-tag.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-tag",
+		"relationKey":    "tag",
+		"relationFormat": 11,
+		"name":           "Tag",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-vip.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-vip",
+		"relationKey": "tag",
+		"name":        "vip",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "types", "type-human.pb.json"), "STType", map[string]any{
+		"id":   "type-human",
+		"name": "Human Being",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Dan Brown",
+		"type": "type-human",
+		"tag":  []any{"opt-vip"},
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Dan Brown", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, TypeAsTag: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Dan Brown.md"))
+	if !strings.Contains(note, "tags:\n  - \"vip\"\n  - \"type/Human-Being\"\n") {
+		t.Fatalf("expected type tag after the note's tags, got:\n%s", note)
+	}
+	if other := readFileString(t, filepath.Join(output, "notes", "Task One.md")); strings.Contains(other, "type/") {
+		t.Fatalf("expected no type tag for an object without a type, got:\n%s", other)
+	}
+}
+
+func TestExporterWritesSetTables(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	tables := filepath.Join(root, "tables")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-status.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-status",
+		"relationKey":    "status",
+		"relationFormat": 3,
+		"name":           "Status",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-done.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-done",
+		"relationKey": "status",
+		"name":        "Done, really",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":     "obj-2",
+		"name":   "Another Task",
+		"status": "opt-done",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Another Task", "style": "Title"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "query.pb.json"), "Page", map[string]any{
+		"id":   "query-1",
+		"name": "Backlog",
+	}, []map[string]any{
+		{"id": "query-1", "childrenIds": []string{"title", "dataview"}},
+		{"id": "title", "text": map[string]any{"text": "Backlog", "style": "Title"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views": []any{map[string]any{"id": "view-1", "type": "Table", "name": "All", "relations": []any{
+				map[string]any{"key": "name", "isVisible": true},
+				map[string]any{"key": "status", "isVisible": true},
+				map[string]any{"key": "done", "isVisible": false},
+			}}},
+			"objectOrders": []any{map[string]any{"viewId": "view-1", "objectIds": []any{"obj-1", "obj-2"}}},
+		}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ExportTablesDir: tables}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if got, want := readFileString(t, filepath.Join(tables, "Backlog.csv")), "Name,Status\nAnother Task,\"Done, really\"\nTask One,\n"; got != want {
+		t.Fatalf("unexpected csv:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ExportTablesDir: tables, ExportTablesFormat: "json"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(tables, "Backlog.json"))), &rows); err != nil {
+		t.Fatalf("decode json table: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["Name"] != "Another Task" || rows[0]["Status"] != "Done, really" || rows[1]["Status"] != nil {
+		t.Fatalf("unexpected json rows: %#v", rows)
+	}
+}
+
+func TestExporterWritesProjectsQueryTarget(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-status.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-status",
+		"relationKey":    "status",
+		"relationFormat": 3,
+		"name":           "Status",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "query.pb.json"), "Page", map[string]any{
+		"id":   "query-1",
+		"name": "Backlog",
+	}, []map[string]any{
+		{"id": "query-1", "childrenIds": []string{"title", "dataview"}},
+		{"id": "title", "text": map[string]any{"text": "Backlog", "style": "Title"}},
+		{"id": "dataview", "dataview": map[string]any{
+			"views": []any{
+				map[string]any{"id": "view-1", "type": "Table", "name": "All", "relations": []any{
+					map[string]any{"key": "name", "isVisible": true, "width": 240},
+					map[string]any{"key": "status", "isVisible": true},
+				}, "sorts": []any{map[string]any{"relationKey": "status", "type": "Desc"}}},
+				map[string]any{"id": "view-2", "type": "Kanban", "name": "Board", "groupRelationKey": "status"},
+			},
+			"objectOrders": []any{map[string]any{"viewId": "view-1", "objectIds": []any{"obj-1"}}},
+		}},
+	})
+	mustMkdirAll(t, filepath.Join(output, ".obsidian", "plugins", "obsidian-projects"))
+	if err := os.WriteFile(filepath.Join(output, ".obsidian", "plugins", "obsidian-projects", "data.json"), []byte(`{"version":2,"projects":[{"id":"mine","name":"Mine"}],"preferences":{"projectSizeLimit":1000}}`), 0o644); err != nil {
+		t.Fatalf("write plugin data: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := (Exporter{InputDir: input, OutputDir: output, QueryTarget: "projects"}).Run(); err != nil {
+			t.Fatalf("run exporter: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "bases", "Backlog.base")); !os.IsNotExist(err) {
+		t.Fatalf("expected no .base file, stat err: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Backlog.md")); err != nil {
+		t.Fatalf("expected set to stay a note: %v", err)
+	}
+
+	var data struct {
+		Preferences map[string]any   `json:"preferences"`
+		Projects    []map[string]any `json:"projects"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(output, ".obsidian", "plugins", "obsidian-projects", "data.json"))), &data); err != nil {
+		t.Fatalf("decode plugin data: %v", err)
+	}
+	if data.Preferences["projectSizeLimit"] != float64(1000) {
+		t.Fatalf("expected user preferences to be kept, got %#v", data.Preferences)
+	}
+	if len(data.Projects) != 2 || data.Projects[0]["id"] != "mine" {
+		t.Fatalf("expected the user's project and one exported project, got %#v", data.Projects)
+	}
+	project := data.Projects[1]
+	if project["name"] != "Backlog" || project["id"] != "anytype-query-1" {
+		t.Fatalf("unexpected project: %#v", project)
+	}
+	source, _ := project["dataSource"].(map[string]any)
+	config, _ := source["config"].(map[string]any)
+	if source["kind"] != "dataview" || config["query"] != `TABLE WHERE contains(list("notes/Task One.md"), file.path)` {
+		t.Fatalf("unexpected data source: %#v", source)
+	}
+	views, _ := project["views"].([]any)
+	if len(views) != 2 {
+		t.Fatalf("expected two views, got %#v", views)
+	}
+	table, _ := views[0].(map[string]any)
+	tableConfig, _ := table["config"].(map[string]any)
+	sortCriteria, _ := table["sort"].(map[string]any)["criteria"].([]any)
+	if table["type"] != "table" || len(tableConfig["orderFields"].([]any)) != 2 || len(sortCriteria) != 1 || sortCriteria[0].(map[string]any)["order"] != "desc" {
+		t.Fatalf("unexpected table view: %#v", table)
+	}
+	board, _ := views[1].(map[string]any)
+	if board["type"] != "board" || board["config"].(map[string]any)["groupByField"] != "status" {
+		t.Fatalf("unexpected board view: %#v", board)
+	}
+}
+
+func TestExporterEmitsSingleValueRelationsAsScalars(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-priority.pb.json"), "STRelation", map[string]any{
+		"id":               "rel-priority",
+		"relationKey":      "priority",
+		"relationFormat":   11,
+		"relationMaxCount": 1,
+		"name":             "Priority",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-labels.pb.json"), "STRelation", map[string]any{
+		"id":               "rel-labels",
+		"relationKey":      "labels",
+		"relationFormat":   11,
+		"relationMaxCount": 0,
+		"name":             "Labels",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-due.pb.json"), "STRelation", map[string]any{
+		"id":               "rel-due",
+		"relationKey":      "due",
+		"relationFormat":   4,
+		"relationMaxCount": 1,
+		"name":             "Due",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-high.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-high",
+		"relationKey": "priority",
+		"name":        "High",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-red.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-red",
+		"relationKey": "labels",
+		"name":        "Red",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":       "obj-2",
+		"name":     "Wrapped",
+		"priority": []any{"opt-high"},
+		"labels":   []any{"opt-red"},
+		"due":      []any{float64(1718064000)},
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Wrapped", "style": "Title"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Wrapped.md"))
+	for _, want := range []string{"priority: \"High\"\n", "labels:\n  - \"Red\"\n", "due: \"2024-06-11\"\n"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterFeaturedProperties(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+
+	for _, rel := range []struct{ id, key, name string }{
+		{"rel-type", "type", "type"},
+		{"rel-contact", "contact", "Contact"},
+		{"rel-priority", "priority", "Priority"},
+		{"rel-mood", "mood", "Mood"},
+	} {
+		format := 1
+		if rel.key == "type" {
+			format = 100
+		}
+		writePBJSON(t, filepath.Join(input, "relations", rel.id+".pb.json"), "STRelation", map[string]any{
+			"id":             rel.id,
+			"relationKey":    rel.key,
+			"relationFormat": format,
+			"name":           rel.name,
+		}, nil)
+	}
+	mustMkdirAll(t, filepath.Join(input, "types"))
+	writePBJSON(t, filepath.Join(input, "types", "type-human.pb.json"), "STType", map[string]any{
+		"id":                   "type-human",
+		"name":                 "Human",
+		"recommendedRelations": []string{"rel-contact"},
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":                "obj-2",
+		"name":              "Ada",
+		"type":              "type-human",
+		"contact":           "ada@example.com",
+		"priority":          "high",
+		"mood":              "calm",
+		"featuredRelations": []any{"type", "priority"},
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Ada", "style": "Title"}},
+	})
+
+	output := filepath.Join(root, "featured")
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Properties: "featured"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Ada.md"))
+	if !strings.Contains(note, "featured:\n  - \"type\"\n  - \"priority\"\n") || !strings.Contains(note, "mood: \"calm\"\n") {
+		t.Fatalf("expected featured list next to all properties, got:\n%s", note)
+	}
+
+	output = filepath.Join(root, "featured-only")
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Properties: "featured-only"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note = readFileString(t, filepath.Join(output, "notes", "Ada.md"))
+	for _, want := range []string{"type: \"Human\"\n", "contact: \"ada@example.com\"\n", "priority: \"high\"\n"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note:\n%s", want, note)
+		}
+	}
+	if strings.Contains(note, "mood:") || strings.Contains(note, "featured:") {
+		t.Fatalf("expected only key properties, got:\n%s", note)
+	}
+}
+
+func TestExporterSkipEmptyNotes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "stub.pb.json"), "Page", map[string]any{
+		"id":        "stub-1",
+		"name":      "Stub",
+		"iconEmoji": "📄",
+	}, []map[string]any{
+		{"id": "stub-1", "childrenIds": []string{"header", "blank"}},
+		{"id": "header", "childrenIds": []string{"title"}, "layout": map[string]any{"style": "Header"}},
+		{"id": "title", "text": map[string]any{"text": "Stub", "style": "Title"}},
+		{"id": "blank", "text": map[string]any{"text": "  "}},
+	})
+	writePBJSON(t, filepath.Join(input, "relations", "rel-mood.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-mood",
+		"relationKey":    "mood",
+		"relationFormat": 1,
+		"name":           "Mood",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "props.pb.json"), "Page", map[string]any{
+		"id":   "props-1",
+		"name": "Only Properties",
+		"mood": "calm",
+	}, []map[string]any{
+		{"id": "props-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Only Properties", "style": "Title"}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, SkipEmptyNotes: true}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	// Task One from the fixture has nothing but its title either.
+	if stats.SkippedEmpty != 2 {
+		t.Fatalf("expected two skipped notes, got %d", stats.SkippedEmpty)
+	}
+	for _, name := range []string{"Stub.md", "Task One.md"} {
+		if _, err := os.Stat(filepath.Join(output, "notes", name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be skipped, stat err: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Only Properties.md")); err != nil {
+		t.Fatalf("expected note with properties to be exported: %v", err)
+	}
+	if !strings.Contains(readFileString(t, stats.ReportPath), `"skippedEmpty": 2`) {
+		t.Fatalf("expected skipped count in report")
+	}
+}
+
+func TestExporterReportsDuplicates(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	for _, dup := range []struct{ id, name, text string }{
+		{"dup-1", "Meeting", "Agenda and notes"},
+		{"dup-2", "meeting", "agenda  and\nNOTES"},
+		{"dup-3", "Meeting", "Something else"},
+	} {
+		writePBJSON(t, filepath.Join(input, "objects", dup.id+".pb.json"), "Page", map[string]any{
+			"id":   dup.id,
+			"name": dup.name,
+		}, []map[string]any{
+			{"id": dup.id, "childrenIds": []string{"body"}},
+			{"id": "body", "text": map[string]any{"text": dup.text}},
+		})
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, FilenameEscaping: "posix", ReportDuplicates: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	report := readFileString(t, filepath.Join(output, "duplicates.md"))
+	want := "## Meeting (3 notes)\n\nSame content:\n- [[notes/Meeting.md]]\n- [[notes/meeting.md]]\n\nDifferent content:\n- [[notes/Meeting-2.md]]\n"
+	if !strings.Contains(report, want) || strings.Contains(report, "Task One") {
+		t.Fatalf("unexpected duplicates report:\n%s", report)
+	}
+}
+
+func TestExporterFallsBackToSnippetWithoutBlocks(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":      "obj-2",
+		"name":    "Lost Body",
+		"snippet": "First line of text\nSecond line ",
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Lost Body.md"))
+	if !strings.HasSuffix(note, "---\n\nFirst line of text\n\nSecond line\n") {
+		t.Fatalf("expected snippet as body, got:\n%s", note)
+	}
+}
+
+func TestExporterFallsBackToSnippetForRootOnlyNote(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":      "obj-2",
+		"name":    "Root Only",
+		"snippet": "Kept in the snippet",
+	}, []map[string]any{
+		{"id": "obj-2"},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, SkipEmptyNotes: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Root Only.md"))
+	if !strings.HasSuffix(note, "---\n\nKept in the snippet\n") {
+		t.Fatalf("expected snippet as body of the fast-path note, got:\n%s", note)
+	}
+}
+
+func TestExporterWritesBookmarksAsWebClippings(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "filesObjects", "preview.pb.json"), "FileObject", map[string]any{
+		"id":      "preview",
+		"name":    "preview",
+		"fileExt": "png",
+		"source":  "files/preview.png",
+	}, nil)
+	if err := os.WriteFile(filepath.Join(input, "files", "preview.png"), []byte("png"), 0o644); err != nil {
+		t.Fatalf("write preview: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "bookmark.pb.json"), "Page", map[string]any{
+		"id":             "bm-1",
+		"name":           "Go Blog",
+		"resolvedLayout": 11,
+		"description":    "News from the Go team",
+		"picture":        "preview",
+	}, []map[string]any{
+		{"id": "bm-1", "childrenIds": []string{"title", "bookmark"}},
+		{"id": "title", "text": map[string]any{"text": "Go Blog", "style": "Title"}},
+		{"id": "bookmark", "bookmark": map[string]any{"url": "https://go.dev/blog", "title": "The Go Blog"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, WebClippings: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Go Blog.md"))
+	if !strings.Contains(note, "source: \"https://go.dev/blog\"\n") {
+		t.Fatalf("expected source property, got:\n%s", note)
+	}
+	if !strings.Contains(note, "---\n\n# Go Blog\n\n> News from the Go team\n\n![[../files/preview.png]]\n\n[The Go Blog](https://go.dev/blog)\n") {
+		t.Fatalf("expected web clipping layout, got:\n%s", note)
+	}
+}
+
+func TestExporterWritesVCards(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-email.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-email",
+		"relationKey":    "email",
+		"relationFormat": 8,
+		"name":           "Email",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-phone.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-phone",
+		"relationKey":    "phone",
+		"relationFormat": 9,
+		"name":           "Phone",
+	}, nil)
+	mustMkdirAll(t, filepath.Join(input, "types"))
+	writePBJSON(t, filepath.Join(input, "types", "type-human.pb.json"), "STType", map[string]any{
+		"id":   "type-human",
+		"name": "Human",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "ada.pb.json"), "Page", map[string]any{
+		"id":          "ada",
+		"name":        "Ada Lovelace",
+		"type":        "type-human",
+		"email":       "ada@example.com",
+		"phone":       "+44 20 0000",
+		"description": "Analyst; first programmer",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "nobody.pb.json"), "Page", map[string]any{
+		"id":   "nobody",
+		"name": "No Contact",
+		"type": "type-human",
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ExportVCards: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	card := readFileString(t, filepath.Join(output, "contacts", "Ada Lovelace.vcf"))
+	want := "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:anytype-ada\r\nFN:Ada Lovelace\r\nN:Lovelace;Ada;;;\r\nEMAIL;TYPE=INTERNET:ada@example.com\r\nTEL:+44 20 0000\r\nNOTE:Analyst\\; first programmer\r\nEND:VCARD\r\n"
+	if card != want {
+		t.Fatalf("unexpected vcard:\n%q\nwant:\n%q", card, want)
+	}
+	if _, err := os.Stat(filepath.Join(output, "contacts", "No Contact.vcf")); !os.IsNotExist(err) {
+		t.Fatalf("expected no vcard without email or phone, stat err: %v", err)
+	}
+}
+
+func TestExporterWritesICSEvents(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-due.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-due",
+		"relationKey":    "dueDate",
+		"relationFormat": 4,
+		"name":           "Due date",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-modified.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-modified",
+		"relationKey":    "lastModifiedDate",
+		"relationFormat": 4,
+		"name":           "Last modified date",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":               "obj-2",
+		"name":             "Ship release",
+		"dueDate":          float64(1718064000),
+		"lastModifiedDate": float64(1717000000),
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ExportICS: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	ics := readFileString(t, filepath.Join(output, "anytype-events.ics"))
+	want := "BEGIN:VEVENT\r\nUID:obj-2-dueDate@anytype\r\nDTSTAMP:20240529T162640Z\r\nDTSTART;VALUE=DATE:20240611\r\nDTEND;VALUE=DATE:20240612\r\nSUMMARY:Ship release (Due date)\r\nDESCRIPTION:Obsidian note: notes/Ship release.md\r\nEND:VEVENT\r\n"
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.Contains(ics, want) || strings.Count(ics, "BEGIN:VEVENT") != 1 {
+		t.Fatalf("unexpected calendar:\n%q", ics)
+	}
+}
+
+func TestExporterRendersAudioTranscript(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "filesObjects", "memo.pb.json"), "FileObject", map[string]any{
+		"id":         "memo",
+		"name":       "memo",
+		"fileExt":    "m4a",
+		"source":     "files/memo.m4a",
+		"transcript": "Buy milk.\nCall Ada.",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":   "obj-2",
+		"name": "Voice Notes",
+	}, []map[string]any{
+		{"id": "obj-2", "childrenIds": []string{"audio"}},
+		{"id": "audio", "file": map[string]any{"name": "memo.m4a", "type": "Audio", "targetObjectId": "memo"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Voice Notes.md"))
+	if !strings.Contains(note, "![memo.m4a](../files/memo.m4a)\n\n> [!quote]- Transcript\n> Buy milk.\n> Call Ada.\n") {
+		t.Fatalf("expected audio embed with transcript, got:\n%s", note)
+	}
+}
+
+func TestExporterNeverWritesProtectedPaths(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	notePath := filepath.Join(output, "notes", "Task One.md")
+	mustMkdirAll(t, filepath.Dir(notePath))
+	if err := os.WriteFile(notePath, []byte("my own notes\n"), 0o644); err != nil {
+		t.Fatalf("write existing note: %v", err)
+	}
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, Protect: []string{"Daily/**", "notes/**"}}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if got := readFileString(t, notePath); got != "my own notes\n" {
+		t.Fatalf("expected protected note to be left alone, got:\n%s", got)
+	}
+	found := false
+	for _, warning := range stats.WarningMessages {
+		if strings.Contains(warning, "protected: notes/Task One.md") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a protected path warning, got %v", stats.WarningMessages)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Atomic: true, Protect: []string{"Inbox.md"}}).Run(); err == nil {
+		t.Fatalf("expected atomic export with protected paths to fail")
+	}
+}
+
+func TestMatchVaultGlobs(t *testing.T) {
+	patterns := []string{"Daily/**", "Inbox.md", "*/drafts/*.md"}
+	for relPath, want := range map[string]bool{
+		"Daily/2024/01.md":       true,
+		"Daily/today.md":         true,
+		"Inbox.md":               true,
+		"notes/Inbox.md":         false,
+		"notes/drafts/a.md":      true,
+		"notes/drafts/deep/a.md": false,
+		"notes/Task One.md":      false,
+	} {
+		if got := matchVaultGlobs(relPath, patterns); got != want {
+			t.Errorf("matchVaultGlobs(%q) = %v, want %v", relPath, got, want)
+		}
+	}
+}
+
+func TestExporterRunsConfiguredPrettierCommand(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	configPath := filepath.Join(root, ".prettierrc")
+	if err := os.WriteFile(configPath, []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("write prettier config: %v", err)
+	}
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	var calledWith []string
+	prettierCommandRunner = func(_ string, args []string) error {
+		calledWith = args
+		return nil
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, RunPrettier: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if got, want := strings.Join(calledWith, " "), "npx --yes prettier --write --ignore-unknown --no-config notes/Task One.md"; got != want {
+		t.Fatalf("expected default command %q, got %q", want, got)
+	}
+
+	_, err := (Exporter{
+		InputDir:        input,
+		OutputDir:       output,
+		RunPrettier:     true,
+		PrettierCommand: "dprint fmt",
+		PrettierConfig:  configPath,
+		PrettierTargets: []string{"notes/**/*.md", "missing", "templates/"},
+	}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if got, want := strings.Join(calledWith, " "), "dprint fmt --config "+configPath+" notes/Task One.md"; got != want {
+		t.Fatalf("expected configured command %q, got %q", want, got)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, RunPrettier: true, PrettierConfig: filepath.Join(root, "missing.json")}).Run(); err == nil {
+		t.Fatalf("expected a missing prettier config to fail")
+	}
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	input := "---\ntags:\n  - a\n---\n\n\n# Title   \n* one\n  + two\n\n\n\n> * quoted\n\n* * *\n\nhard  \nbreak\t\n\n```\n* kept  \n\n\n```\n$$\n+ x\n$$\n\n\n"
+	want := "---\ntags:\n  - a\n---\n\n# Title\n- one\n  - two\n\n> - quoted\n\n* * *\n\nhard  \nbreak\n\n```\n* kept  \n\n\n```\n$$\n+ x\n$$\n"
+	if got := formatMarkdown(input); got != want {
+		t.Fatalf("unexpected formatted markdown:\n%q\nwant:\n%q", got, want)
+	}
+	if got := formatMarkdown(want); got != want {
+		t.Fatalf("expected formatting to be idempotent, got:\n%q", got)
+	}
+}
+
+func TestExporterBuiltinFormatterRunsWithoutPrettier(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "spaced.pb.json"), "Page", map[string]any{
+		"id":   "spaced-1",
+		"name": "Spaced",
+	}, []map[string]any{
+		{"id": "spaced-1", "childrenIds": []string{"text"}},
+		{"id": "text", "text": map[string]any{"text": "trailing   \n\n\n\nafter", "style": "Paragraph"}},
+	})
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	prettierCommandRunner = func(string, []string) error {
+		t.Fatalf("prettier must not run with the builtin formatter")
+		return nil
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, RunPrettier: true, Formatter: "builtin"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	got := readFileString(t, filepath.Join(output, "notes", "Spaced.md"))
+	if !strings.Contains(got, "trailing\n\nafter\n") || strings.Contains(got, "\n\n\n") {
+		t.Fatalf("expected builtin formatter to normalize the note, got:\n%s", got)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, Formatter: "gofmt"}).Run(); err == nil {
+		t.Fatalf("expected an unknown formatter to fail")
+	}
+}
+
+func TestExporterUpdatesOnlyFrontmatter(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-mood.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-mood",
+		"relationKey":    "mood",
+		"relationFormat": 1,
+		"name":           "Mood",
+	}, nil)
+	writeNote := func(mood string) {
+		writePBJSON(t, filepath.Join(input, "objects", "journal.pb.json"), "Page", map[string]any{
+			"id":   "journal-1",
+			"name": "Journal",
+			"mood": mood,
+		}, []map[string]any{
+			{"id": "journal-1", "childrenIds": []string{"text"}},
+			{"id": "text", "text": map[string]any{"text": "from anytype", "style": "Paragraph"}},
+		})
+	}
+	writeNote("calm")
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	notePath := filepath.Join(output, "notes", "Journal.md")
+	fm, _ := splitMarkdownFrontmatter(readFileString(t, notePath))
+	if err := os.WriteFile(notePath, []byte("---\n"+fm+"---\nedited in obsidian\n"), 0o644); err != nil {
+		t.Fatalf("edit note: %v", err)
+	}
+	if err := os.Remove(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("remove note: %v", err)
+	}
+
+	writeNote("happy")
+	stats, err := (Exporter{InputDir: input, OutputDir: output, Update: "frontmatter"}).Run()
+	if err != nil {
+		t.Fatalf("run frontmatter update: %v", err)
+	}
+	if stats.Notes != 1 {
+		t.Fatalf("expected one updated note, got %d", stats.Notes)
+	}
+	got := readFileString(t, notePath)
+	if !strings.Contains(got, "mood: \"happy\"\n") || !strings.HasSuffix(got, "---\nedited in obsidian\n") {
+		t.Fatalf("expected new frontmatter above the edited body, got:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Task One.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected a frontmatter update not to recreate deleted notes, got %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: filepath.Join(root, "empty"), Update: "frontmatter"}).Run(); err == nil {
+		t.Fatalf("expected a frontmatter update without a previous export to fail")
+	}
+}
+
+func TestExporterWritesGraphStats(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": 100,
+		"name":           "Related",
+	}, nil)
+	for _, obj := range []map[string]any{
+		{"id": "hub-1", "name": "Hub"},
+		{"id": "spoke-1", "name": "Spoke", "related": []string{"hub-1"}},
+		{"id": "other-1", "name": "Other", "related": []string{"hub-1", "gone-1"}},
+	} {
+		writePBJSON(t, filepath.Join(input, "objects", obj["id"].(string)+".pb.json"), "Page", obj, nil)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, GraphStats: true, FilenameEscaping: "posix"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	got := readFileString(t, filepath.Join(output, "graph-stats.md"))
+	for _, want := range []string{
+		"4 notes, 2 links between them.\n",
+		"| No type | 4 |\n",
+		"- [[notes/Hub.md]] (2 incoming links)\n",
+		"## Orphan notes\n\nNotes with no links to or from other notes.\n\n- [[notes/Task One.md]]\n\n",
+		"- [[notes/Other.md]]: `related` → `gone-1`\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected graph stats to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderTableOfContentsUsesObsidianHeadingAnchors(t *testing.T) {
+	byID := map[string]block{
+		"root": {ID: "root", ChildrenID: []string{"h1", "h2", "h3"}},
+		"h1":   {ID: "h1", Text: &textBlock{Text: "Step 1: Overview", Style: "Header1"}},
+		"h2":   {ID: "h2", Text: &textBlock{Text: "Привет, мир!", Style: "Header2"}},
+		"h3":   {ID: "h3", Text: &textBlock{Text: "C# [draft] | notes", Style: "Header3"}},
+	}
+	got := renderTableOfContents(byID, "root", tocWikilinkAnchors)
+	want := "- [[#Step 1 Overview|Step 1: Overview]]\n" +
+		"\t- [[#Привет, мир!|Привет, мир!]]\n" +
+		"\t\t- [[#C draft notes|C# draft | notes]]\n"
+	if got != want {
+		t.Fatalf("unexpected table of contents:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = renderTableOfContents(byID, "root", tocLinks)
+	want = "- [Step 1: Overview](#Step%201%20Overview)\n" +
+		"\t- [Привет, мир!](#%D0%9F%D1%80%D0%B8%D0%B2%D0%B5%D1%82%2C%20%D0%BC%D0%B8%D1%80%21)\n" +
+		"\t\t- [C# \\[draft\\] | notes](#C%20draft%20notes)\n"
+	if got != want {
+		t.Fatalf("unexpected table of contents links:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := renderTableOfContents(byID, "root", tocSkip); got != "" {
+		t.Fatalf("expected skip mode to drop the table of contents, got:\n%s", got)
+	}
+}
+
+func TestExporterMapsCalloutIconsToTypes(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "callouts.pb.json"), "Page", map[string]any{
+		"id":   "callouts-1",
+		"name": "Callouts",
+	}, []map[string]any{
+		{"id": "callouts-1", "childrenIds": []string{"warn", "custom", "unmapped", "plain"}},
+		{"id": "warn", "text": map[string]any{"text": "Careful", "style": "Callout", "iconEmoji": "⚠️"}},
+		{"id": "custom", "text": map[string]any{"text": "Launch", "style": "Callout", "iconEmoji": "🚀"}},
+		{"id": "unmapped", "text": map[string]any{"text": "Cake", "style": "Callout", "iconEmoji": "🍰"}},
+		{"id": "plain", "text": map[string]any{"text": "Plain", "style": "Callout"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, CalloutTypes: []string{"🚀=Abstract"}}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Callouts.md"))
+	for _, want := range []string{"> [!warning] Careful\n", "> [!abstract] Launch\n", "> [!note] 🍰 Cake\n", "> [!note] Plain\n"} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected note to contain %q, got:\n%s", want, note)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, CalloutTypes: []string{"🚀"}}).Run(); err == nil {
+		t.Fatalf("expected a callout type without =type to fail")
+	}
+}
+
+func TestExporterKeepsQuoteAttributionInsideQuote(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "quotes.pb.json"), "Page", map[string]any{
+		"id":   "quotes-1",
+		"name": "Quotes",
+	}, []map[string]any{
+		{"id": "quotes-1", "childrenIds": []string{"q1", "q2", "after"}},
+		{"id": "q1", "text": map[string]any{"text": "Simplicity is prerequisite for reliability.", "style": "Quote"}, "childrenIds": []string{"q1-author"}},
+		{"id": "q1-author", "text": map[string]any{"text": "Edsger Dijkstra", "style": "Paragraph"}},
+		{"id": "q2", "text": map[string]any{"text": "Talk is cheap.", "style": "Quote"}, "childrenIds": []string{"q2-more", "q2-author"}},
+		{"id": "q2-more", "text": map[string]any{"text": "Show me the code.", "style": "Paragraph"}},
+		{"id": "q2-author", "text": map[string]any{"text": "— Linus Torvalds", "style": "Paragraph"}},
+		{"id": "after", "text": map[string]any{"text": "Outside.", "style": "Paragraph"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Quotes.md"))
+	for _, want := range []string{
+		"> Simplicity is prerequisite for reliability.\n> — Edsger Dijkstra\n",
+		"> Talk is cheap.\n>\n> Show me the code.\n> — Linus Torvalds\n",
+		"\nOutside.\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected note to contain %q, got:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterFoldsToggleHeadingContent(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "objects", "toggles.pb.json"), "Page", map[string]any{
+		"id":   "toggles-1",
+		"name": "Toggles",
+	}, []map[string]any{
+		{"id": "toggles-1", "childrenIds": []string{"section", "after"}},
+		{"id": "section", "text": map[string]any{"text": "Details", "style": "ToggleHeader2"}, "childrenIds": []string{"inner", "item"}},
+		{"id": "inner", "text": map[string]any{"text": "Hidden text", "style": "Paragraph"}},
+		{"id": "item", "text": map[string]any{"text": "Hidden item", "style": "Marked"}},
+		{"id": "after", "text": map[string]any{"text": "Visible", "style": "Paragraph"}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Toggles.md"))
+	if !strings.Contains(note, "## Details\n") || strings.Contains(note, "> ") || !strings.Contains(note, "Hidden text") {
+		t.Fatalf("expected toggle heading content below a plain heading, got:\n%s", note)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ToggleHeadings: "callout"}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note = readFileString(t, filepath.Join(output, "notes", "Toggles.md"))
+	if !strings.Contains(note, "## Details\n\n> [!note]-\n> Hidden text\n> - Hidden item\n\nVisible\n") {
+		t.Fatalf("expected toggle heading content folded into a callout, got:\n%s", note)
+	}
+}
+
+func TestExporterHonorsTableRowHeadersAlignmentAndMergedCells(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "grid.pb.json"), "Page", map[string]any{
+		"id":   "grid",
+		"name": "Grid",
+	}, []map[string]any{
+		{"id": "grid", "childrenIds": []string{"table-1", "table-2"}},
+		{"id": "table-1", "table": map[string]any{}, "childrenIds": []string{"cols-1", "rows-1"}},
+		{"id": "cols-1", "layout": map[string]any{"style": "TableColumns"}, "childrenIds": []string{"ca", "cb", "cc"}},
+		{"id": "ca", "tableColumn": map[string]any{}},
+		{"id": "cb", "tableColumn": map[string]any{}, "align": "AlignRight"},
+		{"id": "cc", "tableColumn": map[string]any{}},
+		{"id": "rows-1", "layout": map[string]any{"style": "TableRows"}, "childrenIds": []string{"r1", "r2", "r3"}},
+		{"id": "r1", "tableRow": map[string]any{"isHeader": true}, "childrenIds": []string{"r1-ca", "r1-cb", "r1-cc"}},
+		{"id": "r1-ca", "text": map[string]any{"text": "Name"}},
+		{"id": "r1-cb", "text": map[string]any{"text": "Price"}},
+		{"id": "r1-cc", "text": map[string]any{"text": "Note"}, "align": "AlignCenter"},
+		{"id": "r2", "tableRow": map[string]any{}, "childrenIds": []string{"r2-cb", "r2-cc"}},
+		{"id": "r2-cb", "text": map[string]any{"text": "3"}},
+		{"id": "r2-cc", "text": map[string]any{"text": "fresh"}, "align": "AlignCenter"},
+		{"id": "r3", "tableRow": map[string]any{}, "childrenIds": []string{"r3-ca", "r3-cb"}},
+		{"id": "r3-ca", "text": map[string]any{"text": "Total"}, "fields": map[string]any{"colSpan": 2}},
+		{"id": "r3-cb", "text": map[string]any{"text": "hidden"}},
+		{"id": "table-2", "table": map[string]any{}, "childrenIds": []string{"cols-2", "rows-2"}},
+		{"id": "cols-2", "layout": map[string]any{"style": "TableColumns"}, "childrenIds": []string{"cx"}},
+		{"id": "cx", "tableColumn": map[string]any{}},
+		{"id": "rows-2", "layout": map[string]any{"style": "TableRows"}, "childrenIds": []string{"s1"}},
+		{"id": "s1", "tableRow": map[string]any{}, "childrenIds": []string{"s1-cx"}},
+		{"id": "s1-cx", "text": map[string]any{"text": "plain"}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Grid.md"))
+	for _, want := range []string{
+		"| Name | Price | Note |\n| --- | ---: | :---: |\n|  | 3 | fresh |\n| Total |  |  |\n",
+		"|  |\n| --- |\n| plain |\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+	if strings.Contains(note, "hidden") {
+		t.Fatalf("expected merged-over cell to be dropped, got:\n%s", note)
+	}
+	found := false
+	for _, msg := range stats.WarningMessages {
+		found = found || strings.Contains(msg, "notes/Grid.md: table has merged cells")
+	}
+	if !found {
+		t.Fatalf("expected merged cell warning, got %v", stats.WarningMessages)
+	}
+}
+
+func TestExporterKeepsInlineMarkdownInTableCells(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "rich.pb.json"), "Page", map[string]any{
+		"id":   "rich",
+		"name": "Rich",
+	}, []map[string]any{
+		{"id": "rich", "childrenIds": []string{"table"}},
+		{"id": "table", "table": map[string]any{}, "childrenIds": []string{"cols", "rows"}},
+		{"id": "cols", "layout": map[string]any{"style": "TableColumns"}, "childrenIds": []string{"ca", "cb"}},
+		{"id": "rows", "layout": map[string]any{"style": "TableRows"}, "childrenIds": []string{"r1", "r2"}},
+		{"id": "r1", "childrenIds": []string{"r1-ca", "r1-cb"}},
+		{"id": "r1-ca", "text": map[string]any{"text": "Item"}},
+		{"id": "r1-cb", "text": map[string]any{"text": "Done"}},
+		{"id": "r2", "childrenIds": []string{"r2-ca", "r2-cb"}},
+		{"id": "r2-ca", "text": map[string]any{
+			"text": "see Task One or a|b now",
+			"marks": map[string]any{"marks": []map[string]any{
+				{"range": map[string]any{"from": 4, "to": 12}, "type": "Mention", "param": "obj-1"},
+				{"range": map[string]any{"from": 16, "to": 19}, "type": "Link", "param": "https://example.com"},
+				{"range": map[string]any{"from": 0, "to": 12}, "type": "Bold"},
+				{"range": map[string]any{"from": 19, "to": 23}, "type": "Italic"},
+			}},
+		}, "childrenIds": []string{"r2-ca-more"}},
+		{"id": "r2-ca-more", "text": map[string]any{"text": "second line"}},
+		{"id": "r2-cb", "text": map[string]any{"text": "shipped", "style": "Checkbox", "checked": true}},
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Rich.md"))
+	want := "| **see [[Task One.md]]** or [a\\|b](https://example.com) *now*<br>second line | ✅ shipped |\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected %q in note, got:\n%s", want, note)
+	}
+}
+
+func TestExporterWritesComplexTablesAsHTML(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "plan.pb.json"), "Page", map[string]any{
+		"id":   "plan",
+		"name": "Plan",
+	}, []map[string]any{
+		{"id": "plan", "childrenIds": []string{"complex", "after", "simple"}},
+		{"id": "complex", "table": map[string]any{}, "childrenIds": []string{"cols-1", "rows-1"}},
+		{"id": "cols-1", "layout": map[string]any{"style": "TableColumns"}, "childrenIds": []string{"ca", "cb"}},
+		{"id": "cb", "align": "AlignRight"},
+		{"id": "rows-1", "layout": map[string]any{"style": "TableRows"}, "childrenIds": []string{"r1", "r2", "r3"}},
+		{"id": "r1", "tableRow": map[string]any{"isHeader": true}, "childrenIds": []string{"r1-ca", "r1-cb"}},
+		{"id": "r1-ca", "text": map[string]any{"text": "Step"}},
+		{"id": "r1-cb", "text": map[string]any{"text": "Cost"}},
+		{"id": "r2", "tableRow": map[string]any{}, "childrenIds": []string{"r2-ca", "r2-cb"}},
+		{"id": "r2-ca", "text": map[string]any{
+			"text":  "Buy <wood>",
+			"marks": map[string]any{"marks": []map[string]any{{"range": map[string]any{"from": 0, "to": 3}, "type": "Bold"}}},
+		}, "childrenIds": []string{"r2-ca-more"}},
+		{"id": "r2-ca-more", "text": map[string]any{
+			"text":  "see Task One",
+			"marks": map[string]any{"marks": []map[string]any{{"range": map[string]any{"from": 4, "to": 12}, "type": "Mention", "param": "obj-1"}}},
+		}},
+		{"id": "r2-cb", "text": map[string]any{"text": "5"}},
+		{"id": "r3", "tableRow": map[string]any{}, "childrenIds": []string{"r3-ca"}},
+		{"id": "r3-ca", "text": map[string]any{"text": "Total 5"}, "fields": map[string]any{"colSpan": 2}},
+		{"id": "after", "text": map[string]any{"text": "After the table"}},
+		{"id": "simple", "table": map[string]any{}, "childrenIds": []string{"cols-2", "rows-2"}},
+		{"id": "cols-2", "layout": map[string]any{"style": "TableColumns"}, "childrenIds": []string{"cx"}},
+		{"id": "rows-2", "layout": map[string]any{"style": "TableRows"}, "childrenIds": []string{"s1", "s2"}},
+		{"id": "s1", "childrenIds": []string{"s1-cx"}},
+		{"id": "s1-cx", "text": map[string]any{"text": "Name"}},
+		{"id": "s2", "childrenIds": []string{"s2-cx"}},
+		{"id": "s2-cx", "text": map[string]any{"text": "plain"}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output, ComplexTables: "html"}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Plan.md"))
+	want := "<table>\n" +
+		"<thead>\n<tr><th>Step</th><th style=\"text-align: right\">Cost</th></tr>\n</thead>\n" +
+		"<tbody>\n" +
+		"<tr><td><strong>Buy</strong> &lt;wood&gt;<br>see <a class=\"internal-link\" data-href=\"Task One.md\" href=\"Task One.md\">Task One</a></td><td style=\"text-align: right\">5</td></tr>\n" +
+		"<tr><td colspan=\"2\">Total 5</td></tr>\n" +
+		"</tbody>\n</table>\n\nAfter the table\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected HTML table in note, got:\n%s", note)
+	}
+	if !strings.Contains(note, "| Name |\n| --- |\n| plain |\n") {
+		t.Fatalf("expected simple table to stay markdown, got:\n%s", note)
+	}
+	for _, msg := range stats.WarningMessages {
+		if strings.Contains(msg, "merged cells") {
+			t.Fatalf("expected no merged cell warning for HTML tables, got %q", msg)
+		}
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, ComplexTables: "latex"}).Run(); err == nil {
+		t.Fatalf("expected invalid complex tables mode to fail")
+	}
+}
+
+func TestExporterWritesMultiValueRelationsAsLists(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	for _, rel := range []struct {
+		key    string
+		format int
+		max    int
+	}{
+		{key: "topic", format: 11, max: 0},
+		{key: "status", format: 3, max: 1},
+		{key: "related", format: 100, max: 0},
+		{key: "owner", format: 100, max: 1},
+	} {
+		writePBJSON(t, filepath.Join(input, "relations", "rel-"+rel.key+".pb.json"), "STRelation", map[string]any{
+			"id":               "rel-" + rel.key,
+			"name":             rel.key,
+			"relationKey":      rel.key,
+			"relationFormat":   rel.format,
+			"relationMaxCount": rel.max,
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-topic.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-topic",
+		"name":        "Infra",
+		"relationKey": "topic",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-status.pb.json"), "STRelationOption", map[string]any{
+		"id":          "opt-status",
+		"name":        "Done",
+		"relationKey": "status",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-2.pb.json"), "Page", map[string]any{
+		"id":      "obj-2",
+		"name":    "Task Two",
+		"topic":   "opt-topic",
+		"status":  "opt-status",
+		"related": "obj-1",
+		"owner":   "obj-1",
+	}, nil)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Task Two.md"))
+	if !strings.Contains(note, "topic: \"Infra\"\n") {
+		t.Fatalf("expected scalar topic without -multi-value-lists, got:\n%s", note)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, MultiValueLists: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note = readFileString(t, filepath.Join(output, "notes", "Task Two.md"))
+	for _, want := range []string{
+		"topic:\n  - \"Infra\"\n",
+		"related:\n  - \"[[Task One.md]]\"\n",
+		"status: \"Done\"\n",
+		"owner: \"[[Task One.md]]\"\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Fatalf("expected %q in note, got:\n%s", want, note)
+		}
+	}
+}
+
+func TestExporterUsesRegisteredBlockRenderers(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "custom.pb.json"), "Page", map[string]any{
+		"id":   "custom",
+		"name": "Custom",
+	}, []map[string]any{
+		{"id": "custom", "childrenIds": []string{"widget", "bookmark", "text"}},
+		{"id": "widget", "widget": map[string]any{"layout": 2}, "childrenIds": []string{"widget-text"}},
+		{"id": "widget-text", "text": map[string]any{"text": "inside"}},
+		{"id": "bookmark", "bookmark": map[string]any{"url": "https://example.com", "title": "Example"}},
+		{"id": "text", "text": map[string]any{"text": "kept"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "plain.pb.json"), "Page", map[string]any{
+		"id":   "plain",
+		"name": "Plain",
+	}, []map[string]any{
+		{"id": "plain", "childrenIds": []string{"plain-text"}},
+		{"id": "plain-text", "text": map[string]any{"text": "only text"}},
+	})
+
+	var seen BlockInfo
+	RegisterBlockRenderer("widget", func(b BlockInfo) (string, bool) {
+		seen = b
+		return "> widget " + string(b.Content) + "\n" + b.RenderChildren(), true
+	})
+	RegisterBlockRenderer("bookmark", func(b BlockInfo) (string, bool) {
+		return "", false
+	})
+	RegisterBlockRenderer("text", func(b BlockInfo) (string, bool) {
+		if b.ID != "text" && b.ID != "plain-text" {
+			return "", false
+		}
+		return "custom text", true
+	})
+	t.Cleanup(func() {
+		RegisterBlockRenderer("widget", nil)
+		RegisterBlockRenderer("bookmark", nil)
+		RegisterBlockRenderer("text", nil)
+	})
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Custom.md"))
+	want := "> widget {\"layout\":2}\ninside\n[Example](https://example.com)\ncustom text\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected %q in note, got:\n%s", want, note)
+	}
+	if note := readFileString(t, filepath.Join(output, "notes", "Plain.md")); !strings.Contains(note, "custom text\n") {
+		t.Fatalf("expected registered text renderer in a text-only note, got:\n%s", note)
+	}
+	if seen.Kind != "widget" || seen.ObjectID != "custom" || seen.NotePath != "notes/Custom.md" || seen.Depth != 0 {
+		t.Fatalf("unexpected block info: %+v", seen)
+	}
+}
+
+type stagePropertyConverter struct {
+	seen []PropertyInfo
+}
+
+func (c *stagePropertyConverter) BeforeConvert(p PropertyInfo) (any, bool) {
+	c.seen = append(c.seen, p)
+	if p.Value == "opt-lost" {
+		return "closed", true
+	}
+	return nil, false
+}
+
+func (c *stagePropertyConverter) AfterConvert(p PropertyInfo, converted any) any {
+	if s, ok := converted.(string); ok {
+		return "pipeline/" + strings.ToLower(s)
+	}
+	return converted
+}
+
+func TestExporterAppliesRegisteredPropertyConverters(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "relations", "rel-stage.pb.json"), "STRelation", map[string]any{
+		"id":               "rel-stage",
+		"name":             "Stage",
+		"relationKey":      "stage",
+		"relationFormat":   3,
+		"relationMaxCount": 1,
+	}, nil)
+	for id, name := range map[string]string{"opt-won": "Won", "opt-lost": "Lost"} {
+		writePBJSON(t, filepath.Join(input, "relationsOptions", id+".pb.json"), "STRelationOption", map[string]any{
+			"id":          id,
+			"name":        name,
+			"relationKey": "stage",
+		}, nil)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "deal-1.pb.json"), "Page", map[string]any{
+		"id":    "deal-1",
+		"name":  "Deal One",
+		"stage": "opt-won",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "deal-2.pb.json"), "Page", map[string]any{
+		"id":    "deal-2",
+		"name":  "Deal Two",
+		"stage": "opt-lost",
+	}, nil)
+
+	conv := &stagePropertyConverter{}
+	RegisterPropertyConverter("stage", conv)
+	t.Cleanup(func() { RegisterPropertyConverter("stage", nil) })
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if note := readFileString(t, filepath.Join(output, "notes", "Deal One.md")); !strings.Contains(note, "stage: \"pipeline/won\"\n") {
+		t.Fatalf("expected converted stage, got:\n%s", note)
+	}
+	if note := readFileString(t, filepath.Join(output, "notes", "Deal Two.md")); !strings.Contains(note, "stage: \"pipeline/closed\"\n") {
+		t.Fatalf("expected replaced stage, got:\n%s", note)
+	}
+	var info PropertyInfo
+	for _, p := range conv.seen {
+		if p.NotePath == "notes/Deal One.md" {
+			info = p
+		}
+	}
+	if info.Key != "stage" || info.Value != "opt-won" || info.Name != "Stage" || info.Format != 3 || !info.HasRelation {
+		t.Fatalf("unexpected property info: %+v", info)
+	}
+}
+
+func TestExporterMarksUnsupportedBlocks(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	writePBJSON(t, filepath.Join(input, "objects", "embeds.pb.json"), "Page", map[string]any{
+		"id":   "embeds",
+		"name": "Embeds",
+	}, []map[string]any{
+		{"id": "embeds", "smartblock": map[string]any{}, "childrenIds": []string{"featured", "embed", "after"}},
+		{"id": "featured", "featuredRelations": map[string]any{}},
+		{"id": "embed", "embed": map[string]any{"processor": 21, "text": "<iframe src=\"https://example.com\"></iframe>"}, "fields": map[string]any{"width": 0.5}, "childrenIds": []string{"embed-caption"}},
+		{"id": "embed-caption", "text": map[string]any{"text": "Caption"}},
+		{"id": "after", "text": map[string]any{"text": "After"}},
+	})
+
+	stats, err := (Exporter{InputDir: input, OutputDir: output}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	note := readFileString(t, filepath.Join(output, "notes", "Embeds.md"))
+	want := "<!-- Unsupported Anytype block \"embed\" (embed) was not exported; its raw content is in _anytype/raw/embeds.json -->\nCaption\nAfter\n"
+	if !strings.Contains(note, want) {
+		t.Fatalf("expected %q in note, got:\n%s", want, note)
+	}
+	if strings.Count(note, "Unsupported") != 1 {
+		t.Fatalf("expected only the embed block to be marked, got:\n%s", note)
+	}
+
+	found := false
+	for _, msg := range stats.WarningMessages {
+		found = found || msg == `notes/Embeds.md: unsupported "embed" block embed was not exported; raw content in _anytype/raw/embeds.json`
+	}
+	if !found {
+		t.Fatalf("expected unsupported block warning, got %v", stats.WarningMessages)
+	}
+
+	var raw struct {
+		UnsupportedBlocks []struct {
+			ID      string         `json:"id"`
+			Kind    string         `json:"kind"`
+			Content map[string]any `json:"content"`
+			Fields  map[string]any `json:"fields"`
+		} `json:"unsupportedBlocks"`
+	}
+	if err := json.Unmarshal([]byte(readFileString(t, filepath.Join(output, "_anytype", "raw", "embeds.json"))), &raw); err != nil {
+		t.Fatalf("decode raw sidecar: %v", err)
+	}
+	if len(raw.UnsupportedBlocks) != 1 {
+		t.Fatalf("expected one unsupported block in sidecar, got %+v", raw.UnsupportedBlocks)
+	}
+	got := raw.UnsupportedBlocks[0]
+	if got.ID != "embed" || got.Kind != "embed" || asInt(got.Content["processor"]) != 21 || got.Fields["width"] != 0.5 {
+		t.Fatalf("unexpected unsupported block in sidecar: %+v", got)
+	}
+}
+
+func TestExporterFormattersAndAttachmentsSkipProtectedPaths(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+	if err := os.WriteFile(filepath.Join(input, "files", "photo.png"), []byte("from anytype"), 0o644); err != nil {
+		t.Fatalf("write attachment: %v", err)
+	}
+
+	protected := map[string]string{
+		"notes/Inbox.md":          "* messy   \n\n\n\n> [!note]\n> a\n> [!tip]\n> b\n",
+		"files/photo.png":         "my own picture",
+		"_anytype/raw/obj-1.json": "{\"mine\": true}\n",
+	}
+	for rel, content := range protected {
+		path := filepath.Join(output, filepath.FromSlash(rel))
+		mustMkdirAll(t, filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	protect := []string{"notes/Inbox.md", "files/photo.png", "_anytype/raw/obj-1.json"}
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	var prettierArgs []string
+	prettierCommandRunner = func(_ string, args []string) error {
+		prettierArgs = args
+		return nil
+	}
+
+	for _, formatter := range []string{formatterBuiltin, formatterPrettier} {
+		if _, err := (Exporter{InputDir: input, OutputDir: output, Formatter: formatter, Protect: protect}).Run(); err != nil {
+			t.Fatalf("run exporter with %s formatter: %v", formatter, err)
+		}
+		for rel, want := range protected {
+			if got := readFileString(t, filepath.Join(output, filepath.FromSlash(rel))); got != want {
+				t.Fatalf("expected %s to stay byte-identical after %s formatting, got:\n%q", rel, formatter, got)
+			}
+		}
+	}
+	if strings.Contains(strings.Join(prettierArgs, " "), "Inbox.md") {
+		t.Fatalf("expected prettier to leave the protected note out, got %v", prettierArgs)
+	}
+}
+
+func TestExporterFormatsOnlyFilesWrittenInThisRun(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	prepareMinimalExportFixture(t, input)
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	notePath := filepath.Join(output, "notes", "Task One.md")
+	edited := "* edited in obsidian   \n\n\n\n> [!note]\n> a\n> [!tip]\n> b\n"
+	if err := os.WriteFile(notePath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("edit note: %v", err)
+	}
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	var calls [][]string
+	prettierCommandRunner = func(_ string, args []string) error {
+		calls = append(calls, args)
+		return nil
+	}
+
+	for _, formatter := range []string{formatterBuiltin, formatterPrettier} {
+		if _, err := (Exporter{InputDir: input, OutputDir: output, Formatter: formatter, MergeStrategy: mergeSkipExisting}).Run(); err != nil {
+			t.Fatalf("run exporter with %s formatter: %v", formatter, err)
+		}
+		if got := readFileString(t, notePath); got != edited {
+			t.Fatalf("expected %s formatter to leave the kept note alone, got:\n%q", formatter, got)
+		}
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected no prettier call when nothing was written, got %v", calls)
+	}
+}
+
+func TestPrettierCommandsBatchFiles(t *testing.T) {
+	var files []string
+	for i := 0; i < 1000; i++ {
+		files = append(files, "notes/Note "+strconv.Itoa(i)+".md")
+	}
+	commands := prettierCommands(prettierInvocation{command: []string{"prettier", "--write"}}, files)
+	if len(commands) < 2 {
+		t.Fatalf("expected the files to be split into several calls, got %d", len(commands))
+	}
+	var got []string
+	for _, args := range commands {
+		if args[0] != "prettier" || args[1] != "--write" {
+			t.Fatalf("expected every batch to repeat the command, got %v", args[:2])
+		}
+		if length := len(strings.Join(args[2:], " ")); length > prettierArgsMaxLength {
+			t.Fatalf("batch of %d bytes exceeds the limit", length)
+		}
+		got = append(got, args[2:]...)
+	}
+	if strings.Join(got, "\n") != strings.Join(files, "\n") {
+		t.Fatalf("expected every file exactly once, in order")
+	}
+	if prettierCommands(prettierInvocation{}, nil) != nil {
+		t.Fatalf("expected no command without files")
+	}
+}
+
+func TestExporterTrivialFastPathMatchesFullRenderingAcrossOptions(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+	mustMkdirAll(t, filepath.Join(input, "types"))
+	writePBJSON(t, filepath.Join(input, "types", "type-page.pb.json"), "STType", map[string]any{
+		"id":   "type-page",
+		"name": "Page",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "short.pb.json"), "Page", map[string]any{
+		"id":               "short",
+		"name":             "Short",
+		"type":             "type-page",
+		"createdDate":      1720000000,
+		"lastModifiedDate": 1720000000,
+		"backlinks":        []any{"obj-1"},
+	}, []map[string]any{
+		{"id": "short", "childrenIds": []string{"title", "p1", "h2"}},
+		{"id": "title", "text": map[string]any{"text": "Short", "style": "Title"}, "fields": map[string]any{"_detailsKey": []any{"name"}}},
+		{"id": "p1", "text": map[string]any{"text": "Hello", "style": "Paragraph"}},
+		{"id": "h2", "text": map[string]any{"text": "Section", "style": "Header2"}},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "snippet.pb.json"), "Page", map[string]any{
+		"id":      "snippet",
+		"name":    "Snippet Only",
+		"type":    "type-page",
+		"snippet": "Kept in the snippet",
+	}, []map[string]any{
+		{"id": "snippet"},
+	})
+	writePBJSON(t, filepath.Join(input, "objects", "blank.pb.json"), "Page", map[string]any{
+		"id":   "blank",
+		"name": "Blank",
+	}, []map[string]any{
+		{"id": "blank", "childrenIds": []string{"empty"}},
+		{"id": "empty", "text": map[string]any{"text": "", "style": "Paragraph"}},
+	})
+
+	options := map[string]Exporter{
+		"default":           {},
+		"snippet and empty": {SkipEmptyNotes: true},
+		"scaffold empty":    {ScaffoldEmptyNotes: true},
+		"featured":          {Properties: propertiesFeatured},
+		"inline properties": {PropertiesStyle: propertiesStyleInline},
+		"both properties":   {PropertiesStyle: propertiesStyleBoth},
+		"type as tag":       {TypeAsTag: true},
+		"metadata":          {EmbedAnytypeMetadata: true},
+		"property order":    {PropertyOrder: []string{"type", "*"}},
+		"tag hierarchy":     {TagHierarchy: []string{"tag"}},
+		"web clippings":     {WebClippings: true},
+		"multi-value lists": {MultiValueLists: true},
+		"dynamic":           {IncludeDynamicProperties: true},
+		"exclude empty":     {ExcludeEmptyProperties: true},
+		"exclude keys":      {ExcludePropertyKeys: []string{"type"}},
+		"force include":     {ForceIncludePropertyKeys: []string{"backlinks"}},
+		"link as note":      {LinkAsNotePropertyKeys: []string{"type"}},
+		"icon heading":      {IconStyle: iconStyleHeading},
+		"no pretty icons":   {DisablePrettyPropertyIcon: true, DisablePictureToCover: true},
+		"builtin formatter": {Formatter: formatterBuiltin},
+	}
+	t.Cleanup(func() {
+		trivialFastPath = true
+	})
+	export := func(name string, opts Exporter, fastPath bool) map[string]string {
+		t.Helper()
+		trivialFastPath = fastPath
+		opts.InputDir = input
+		opts.OutputDir = filepath.Join(root, name, strconv.FormatBool(fastPath))
+		opts.NoDynamicTimestamps = true
+		if _, err := opts.Run(); err != nil {
+			t.Fatalf("%s: run exporter: %v", name, err)
+		}
+		notes := map[string]string{}
+		entries, err := os.ReadDir(filepath.Join(opts.OutputDir, "notes"))
+		if err != nil {
+			t.Fatalf("%s: read notes: %v", name, err)
+		}
+		for _, entry := range entries {
+			notes[entry.Name()] = readFileString(t, filepath.Join(opts.OutputDir, "notes", entry.Name()))
+		}
+		return notes
+	}
+
+	for name, opts := range options {
+		fast := export(name, opts, true)
+		full := export(name, opts, false)
+		if len(fast) != len(full) {
+			t.Fatalf("%s: fast path wrote %d notes, full rendering %d", name, len(fast), len(full))
+		}
+		for file, want := range full {
+			if got := fast[file]; got != want {
+				t.Errorf("%s: fast path mismatch for %s:\ngot:\n%s\nwant:\n%s", name, file, got, want)
+			}
+		}
+	}
+}
+
+func TestRenderBodySeparatesParagraphAfterNestedListItem(t *testing.T) {
+	obj := objectInfo{
+		ID: "obj-1",
+		Blocks: []block{
+			{ID: "obj-1", ChildrenID: []string{"item", "after"}},
+			{ID: "item", Text: &textBlock{Text: "parent", Style: "Marked"}, ChildrenID: []string{"sub", "para", "more"}},
+			{ID: "sub", Text: &textBlock{Text: "child", Style: "Marked"}},
+			{ID: "para", Text: &textBlock{Text: "belongs to parent", Style: "Paragraph"}},
+			{ID: "more", Text: &textBlock{Text: "still parent", Style: "Paragraph"}},
+			{ID: "after", Text: &textBlock{Text: "next", Style: "Marked"}},
+		},
+	}
+	want := "- parent\n\t- child\n\n\tbelongs to parent\n\tstill parent\n- next\n"
+	if got := renderBody(obj, bodyContext{}); got != want {
+		t.Fatalf("expected paragraph after the sub-item to start a new paragraph of the parent:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestRenderBodyIndentsCodeAndQuotesNestedInListItems(t *testing.T) {
+	obj := objectInfo{
+		ID: "obj-1",
+		Blocks: []block{
+			{ID: "obj-1", ChildrenID: []string{"item", "after"}},
+			{ID: "item", Text: &textBlock{Text: "parent", Style: "Marked"}, ChildrenID: []string{"code", "sub"}},
+			{ID: "code", Text: &textBlock{Text: "go run .\nexit", Style: "Code"}},
+			{ID: "sub", Text: &textBlock{Text: "child", Style: "Numbered"}, ChildrenID: []string{"quote"}},
+			{ID: "quote", Text: &textBlock{Text: "quoted\nagain", Style: "Quote"}},
+			{ID: "after", Text: &textBlock{Text: "next", Style: "Marked"}},
+		},
+	}
+	want := "- parent\n\t```\n\tgo run .\n\texit\n\t```\n\t1. child\n\t\t> quoted\n\t\t> again\n- next\n"
+	if got := renderBody(obj, bodyContext{}); got != want {
+		t.Fatalf("expected blocks nested in list items to stay inside the item:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestExporterLeavesProtectedObsidianAndMetadataFilesAlone(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-tag.pb.json"), "STRelation", map[string]any{
+		"id":               "rel-tag",
+		"name":             "Tag",
+		"relationKey":      "tag",
+		"relationFormat":   11,
+		"relationMaxCount": 0,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relationsOptions", "opt-tag.pb.json"), "STRelationOption", map[string]any{
+		"id":                  "opt-tag",
+		"name":                "Alpha",
+		"relationKey":         "tag",
+		"relationOptionColor": "teal",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":     "obj-1",
+		"name":   "Task One",
+		"tag":    []any{"opt-tag"},
+		"rating": 3,
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-rating.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-rating",
+		"name":           "Rating",
+		"relationKey":    "rating",
+		"relationFormat": 2,
+	}, nil)
+
+	guarded := []string{
+		".obsidian/types.json",
+		".obsidian/appearance.json",
+		".obsidian/snippets/anytype-tag-colors.css",
+		".obsidian/plugins/pretty-properties/data.json",
+		"_anytype/README.md",
+		"_anytype/index.json",
+		"_anytype/raw/obj-1.json",
+	}
+
+	// Without protection every guarded file is written by the export.
+	unprotected := filepath.Join(root, "plain")
+	if _, err := (Exporter{InputDir: input, OutputDir: unprotected, TagColorsCSS: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	for _, rel := range guarded {
+		if _, err := os.Stat(filepath.Join(unprotected, filepath.FromSlash(rel))); err != nil {
+			t.Fatalf("expected export to write %s: %v", rel, err)
+		}
+	}
+
+	output := filepath.Join(root, "vault")
+	for _, rel := range guarded {
+		path := filepath.Join(output, filepath.FromSlash(rel))
+		mustMkdirAll(t, filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(`{"mine":"`+rel+`"}`), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	stats, err := (Exporter{InputDir: input, OutputDir: output, TagColorsCSS: true, Protect: []string{".obsidian/**", "_anytype/**"}}).Run()
+	if err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	for _, rel := range guarded {
+		if got := readFileString(t, filepath.Join(output, filepath.FromSlash(rel))); got != `{"mine":"`+rel+`"}` {
+			t.Fatalf("expected protected %s to stay unchanged, got:\n%s", rel, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "notes", "Task One.md")); err != nil {
+		t.Fatalf("expected unprotected note to be written: %v", err)
+	}
+	found := false
+	for _, warning := range stats.WarningMessages {
+		if warning == "protected: .obsidian/types.json was not written" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a protected path warning, got %v", stats.WarningMessages)
+	}
+}
+
+func TestExporterFrontmatterUpdateRendersOnlyNotesAtTheirVaultPaths(t *testing.T) {
+	root := t.TempDir()
+	input := filepath.Join(root, "Anytype-json")
+	output := filepath.Join(root, "vault")
+	tables := filepath.Join(root, "tables")
+	prepareMinimalExportFixture(t, input)
+	writePBJSON(t, filepath.Join(input, "relations", "rel-related.pb.json"), "STRelation", map[string]any{
+		"id":             "rel-related",
+		"relationKey":    "related",
+		"relationFormat": 100,
+		"name":           "Related",
+	}, nil)
+	writePBJSON(t, filepath.Join(input, "objects", "journal.pb.json"), "Page", map[string]any{
+		"id":      "journal-1",
+		"name":    "Journal",
+		"related": []any{"obj-1"},
+	}, nil)
+	if err := os.WriteFile(filepath.Join(input, "files", "photo.txt"), []byte("attachment"), 0o644); err != nil {
+		t.Fatalf("write attachment: %v", err)
+	}
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	if err := os.Remove(filepath.Join(output, "files", "photo.txt")); err != nil {
+		t.Fatalf("remove attachment: %v", err)
+	}
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task Renamed",
+	}, nil)
+
+	originalRunner := prettierCommandRunner
+	t.Cleanup(func() {
+		prettierCommandRunner = originalRunner
+	})
+	prettierCommandRunner = func(string, []string) error {
+		t.Fatalf("the formatter must not run in a frontmatter update")
+		return nil
+	}
+	update := Exporter{InputDir: input, OutputDir: output, Update: "frontmatter", RunPrettier: true, ExportTablesDir: tables, ExportICS: true}
+	if _, err := update.Run(); err != nil {
+		t.Fatalf("run frontmatter update: %v", err)
+	}
+	got := readFileString(t, filepath.Join(output, "notes", "Journal.md"))
+	if !strings.Contains(got, "Task One") || strings.Contains(got, "Task Renamed") {
+		t.Fatalf("expected links to follow the note's path in the vault, got:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(output, "files", "photo.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected a frontmatter update not to copy attachments, got %v", err)
+	}
+	if _, err := os.Stat(tables); !os.IsNotExist(err) {
+		t.Fatalf("expected a frontmatter update not to export tables, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, icsFileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected a frontmatter update not to write a calendar, got %v", err)
+	}
+
+	for _, style := range []string{"inline", "both"} {
+		update.PropertiesStyle = style
+		if _, err := update.Run(); err == nil {
+			t.Fatalf("expected a frontmatter update with %s properties to fail", style)
+		}
+	}
+}
+
+func prepareMinimalExportFixture(t *testing.T, input string) {
+	t.Helper()
+	mustMkdirAll(t, filepath.Join(input, "objects"))
+	mustMkdirAll(t, filepath.Join(input, "relations"))
+	mustMkdirAll(t, filepath.Join(input, "relationsOptions"))
+	mustMkdirAll(t, filepath.Join(input, "filesObjects"))
+	mustMkdirAll(t, filepath.Join(input, "files"))
+
+	writePBJSON(t, filepath.Join(input, "objects", "obj-1.pb.json"), "Page", map[string]any{
+		"id":   "obj-1",
+		"name": "Task One",
+	}, []map[string]any{
+		{"id": "obj-1", "childrenIds": []string{"title"}},
+		{"id": "title", "text": map[string]any{"text": "Task One", "style": "Title"}},
+	})
+}
+
+func writePBJSON(t *testing.T, path string, sbType string, details map[string]any, blocks []map[string]any) {
+	writePBJSONWithData(t, path, sbType, details, blocks, nil)
+}
+
+func writePBJSONWithData(t *testing.T, path string, sbType string, details map[string]any, blocks []map[string]any, dataExtras map[string]any) {
+	t.Helper()
+	if blocks == nil {
+		blocks = []map[string]any{}
+	}
+	data := map[string]any{
+		"blocks":  blocks,
+		"details": details,
+	}
+	for key, value := range dataExtras {
+		data[key] = value
+	}
+	payload := map[string]any{
+		"sbType": sbType,
+		"snapshot": map[string]any{
+			"data": data,
+		},
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func mustMkdirAll(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+}
+
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
 	}
+	return string(b)
 }
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	propertiesAll          = "all"
+	propertiesFeatured     = "featured"
+	propertiesFeaturedOnly = "featured-only"
+)
+
+func resolveProperties(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "", propertiesAll:
+		return propertiesAll, nil
+	case propertiesFeatured, propertiesFeaturedOnly:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid properties %q: expected all, featured, or featured-only", mode)
+	}
+}
+
+// featuredDetailKeys returns the detail keys of the relations obj features
+// in its header (its featuredRelations).
+func featuredDetailKeys(obj objectInfo, relations map[string]relationDef) []string {
+	var keys []string
+	seen := map[string]struct{}{}
+	for _, ref := range anyToStringSlice(obj.Details["featuredRelations"]) {
+		key := resolveTypeRelationRefToDetailKey(ref, obj.Details, relations)
+		if _, dup := seen[key]; dup || key == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// keyPropertyKeys is what -properties featured-only keeps: the object's
+// featured relations and the ones its type recommends.
+func keyPropertyKeys(obj objectInfo, relations map[string]relationDef, typesByID map[string]typeDef) map[string]struct{} {
+	keys := map[string]struct{}{}
+	for _, key := range featuredDetailKeys(obj, relations) {
+		keys[key] = struct{}{}
+	}
+	if typeInfo, ok := typesByID[asString(obj.Details["type"])]; ok {
+		for _, refs := range [][]string{typeInfo.Featured, typeInfo.Recommended, typeInfo.RecommendedFile} {
+			for _, ref := range refs {
+				if key := resolveTypeRelationRefToDetailKey(ref, obj.Details, relations); key != "" {
+					keys[key] = struct{}{}
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// isKeyProperty reports whether k survives -properties featured-only; a nil
+// keys set keeps everything. Force-included properties are always kept.
+func isKeyProperty(k string, rel relationDef, hasRel bool, keys map[string]struct{}, filters propertyFilters) bool {
+	if keys == nil || filters.hasForceInclude(k, rel, hasRel) {
+		return true
+	}
+	_, ok := keys[k]
+	return ok
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	filenameSchemeName         = "name"
+	filenameSchemeID           = "id"
+	filenameSchemeNameIDSuffix = "name-id-suffix"
+	filenameSchemeZettel       = "zettel"
+
+	filenameIDSuffixLength = 8
+	zettelTimestampLayout  = "200601021504"
+)
+
+func resolveFilenameScheme(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return filenameSchemeName, nil
+	case filenameSchemeName, filenameSchemeID, filenameSchemeNameIDSuffix, filenameSchemeZettel:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid filename scheme %q: expected name, id, name-id-suffix, or zettel", mode)
+	}
+}
+
+// noteBaseName returns the note filename (without extension) for obj under
+// the configured scheme. Collisions are still resolved by the caller.
+func (o filenameOptions) noteBaseName(obj objectInfo) string {
+	title := o.sanitize(inferObjectTitle(obj))
+	if title == "" {
+		title = "Untitled"
+	}
+	sep := " "
+	if o.asciiSlug {
+		sep = "-"
+	}
+	if o.emojiPrefix && !o.asciiSlug {
+		if emoji := o.sanitize(asString(obj.Details["iconEmoji"])); emoji != "" {
+			title = emoji + " " + title
+		}
+	}
+	switch o.scheme {
+	case filenameSchemeID:
+		if id := o.sanitize(obj.ID); id != "" {
+			return id
+		}
+	case filenameSchemeNameIDSuffix:
+		if suffix := o.sanitize(shortObjectID(obj.ID)); suffix != "" {
+			return title + sep + suffix
+		}
+	case filenameSchemeZettel:
+		if created, _, ok := anytypeTimestamps(obj.Details); ok {
+			return created.UTC().Format(zettelTimestampLayout) + sep + title
+		}
+	}
+	return title
+}
+
+func shortObjectID(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) <= filenameIDSuffixLength {
+		return id
+	}
+	return id[len(id)-filenameIDSuffixLength:]
+}
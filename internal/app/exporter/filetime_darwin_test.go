@@ -0,0 +1,16 @@
+//go:build darwin
+
+package exporter
+
+import (
+	"os"
+	"syscall"
+)
+
+func fileBirthTimeUnix(info os.FileInfo) (int64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int64(stat.Birthtimespec.Sec), true
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+
+package exporter
+
+import "os"
+
+func fileBirthTimeUnix(_ os.FileInfo) (int64, bool) {
+	return 0, false
+}
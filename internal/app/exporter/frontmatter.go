@@ -2,8 +2,13 @@ package exporter
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/fs"
+	"math"
+	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
@@ -11,47 +16,148 @@ import (
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
 	"github.com/sleroq/anytype-to-obsidian/internal/infra/exportfs"
+	"golang.org/x/text/unicode/norm"
+	"gopkg.in/yaml.v3"
 )
 
-func renderFrontmatter(obj objectInfo, relations map[string]relationDef, typesByID map[string]typeDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, includeDynamicProperties bool, includeArchivedProperties bool, filters propertyFilters, prettyPropertyIcon bool, pictureToCover bool) string {
+type frontmatterOptions struct {
+	includeDynamicProperties  bool
+	includeArchivedProperties bool
+	filters                   propertyFilters
+	prettyPropertyIcon        bool
+	pictureToCover            bool
+	embedAnytypeMetadata      bool
+	iconStyle                 string
+	propertyOrder             []string
+	propertiesStyle           string
+	tagHierarchy              tagHierarchy
+	tagSources                map[string]struct{}
+	typeAsTag                 bool
+	properties                string
+	webClippings              bool
+	multiValueLists           bool
+}
+
+func (o frontmatterOptions) keys() propertyKeys {
+	return propertyKeys{pictureToCover: o.pictureToCover, tagSources: o.tagSources}
+}
+
+func renderFrontmatter(obj objectInfo, relations map[string]relationDef, typesByID map[string]typeDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, aliases []string, opts frontmatterOptions) string {
+	return writeFrontmatter(frontmatterEntries(obj, relations, typesByID, optionsByID, notes, sourceNotePath, objectNamesByID, fileObjects, aliases, opts), opts)
+}
+
+// frontmatterEntries renders each note property on its own, in the default
+// order. Entries from object relations are marked so the properties style can
+// move them into the note body.
+func frontmatterEntries(obj objectInfo, relations map[string]relationDef, typesByID map[string]typeDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, aliases []string, opts frontmatterOptions) []frontmatterEntry {
 	keys, includeByType, dateByType := orderedFrontmatterKeys(obj, relations, typesByID)
+	filters := opts.filters
 
-	var buf bytes.Buffer
-	buf.WriteString("---\n")
-	includeAnytypeID := shouldIncludeFrontmatterProperty("anytype_id", relationDef{}, false, false, includeDynamicProperties, includeArchivedProperties, filters)
+	var entries []frontmatterEntry
+	emit := func(key string, write func(*bytes.Buffer)) {
+		var b bytes.Buffer
+		write(&b)
+		entries = append(entries, frontmatterEntry{key: key, text: b.String()})
+	}
+	emitValue := func(key string, value any) {
+		emit(key, func(b *bytes.Buffer) { writeYAMLKeyValue(b, key, value) })
+	}
+
+	includeAnytypeID := shouldIncludeFrontmatterProperty("anytype_id", relationDef{}, false, false, opts.includeDynamicProperties, opts.includeArchivedProperties, filters)
 	if includeAnytypeID {
-		buf.WriteString("anytype_id: ")
-		writeYAMLString(&buf, obj.ID)
-		buf.WriteString("\n")
+		emit("anytype_id", func(b *bytes.Buffer) {
+			b.WriteString("anytype_id: ")
+			writeYAMLString(b, obj.ID)
+			b.WriteString("\n")
+		})
 	}
 
 	usedKeys := map[string]struct{}{}
 	if includeAnytypeID {
 		usedKeys["anytype_id"] = struct{}{}
 	}
-	if prettyPropertyIcon {
+	if opts.embedAnytypeMetadata {
+		emit("anytype", func(b *bytes.Buffer) { writeAnytypeMetadataBlock(b, obj) })
+		usedKeys["anytype"] = struct{}{}
+	}
+	if len(aliases) > 0 {
+		emitValue("aliases", aliases)
+		usedKeys["aliases"] = struct{}{}
+	}
+	// Heading and filename icon styles carry the emoji elsewhere; image icons
+	// still need the property.
+	if opts.prettyPropertyIcon && (opts.iconStyle == iconStyleProperty || opts.iconStyle == "" || objectIconEmoji(obj) == "") {
 		if iconValue, ok := prettyPropertyIconValue(obj.Details, fileObjects, sourceNotePath); ok {
-			writeYAMLKeyValue(&buf, "icon", iconValue)
+			emitValue("icon", iconValue)
 			usedKeys["icon"] = struct{}{}
 		}
 	}
+	// With -tag-hierarchy, -tags-from-properties or -type-as-tag, every
+	// relation feeding tags shares one tags entry, placed where the first of
+	// them would appear.
+	tagsEntry := -1
+	var nestedTags []string
+	seenTags := map[string]struct{}{}
+	addNestedTags := func(tags []string) {
+		for _, tag := range tags {
+			if _, seen := seenTags[tag]; seen {
+				continue
+			}
+			seenTags[tag] = struct{}{}
+			nestedTags = append(nestedTags, tag)
+		}
+		if len(nestedTags) == 0 {
+			return
+		}
+		if tagsEntry < 0 {
+			usedKeys["tags"] = struct{}{}
+			entries = append(entries, frontmatterEntry{key: "tags", relation: true})
+			tagsEntry = len(entries) - 1
+		}
+		var b bytes.Buffer
+		writeYAMLKeyValue(&b, "tags", nestedTags)
+		entries[tagsEntry].text = b.String()
+		entries[tagsEntry].value = append([]string(nil), nestedTags...)
+	}
+	var keyProperties map[string]struct{}
+	if opts.properties == propertiesFeaturedOnly {
+		keyProperties = keyPropertyKeys(obj, relations, typesByID)
+	}
+	emittedKeys := map[string]string{}
 	for _, k := range keys {
 		rel, hasRel := relations[k]
-		if prettyPropertyIcon && isAnytypeIconProperty(k, rel, hasRel) {
+		if opts.prettyPropertyIcon && isAnytypeIconProperty(k, rel, hasRel) {
 			continue
 		}
-		if !shouldIncludeFrontmatterProperty(k, rel, hasRel, includeByType[k], includeDynamicProperties, includeArchivedProperties, filters) {
+		if !shouldIncludeFrontmatterProperty(k, rel, hasRel, includeByType[k], opts.includeDynamicProperties, opts.includeArchivedProperties, filters) {
+			continue
+		}
+		if !isKeyProperty(k, rel, hasRel, keyProperties, filters) {
 			continue
 		}
 		v := obj.Details[k]
 		converted := convertPropertyValue(k, v, relations, optionsByID, notes, sourceNotePath, objectNamesByID, fileObjects, dateByType[k], filters.hasLinkAsNote(k, rel, hasRel))
-		outKey := frontmatterKey(k, rel, hasRel, pictureToCover)
+		outKey := frontmatterKey(k, rel, hasRel, opts.keys())
+		if prefix, ok := opts.tagHierarchy.prefix(k, rel, hasRel); ok {
+			addNestedTags(hierarchyTags(prefix, converted))
+			emittedKeys[k] = "tags"
+			continue
+		}
+		if outKey == "tags" && (len(opts.tagHierarchy) > 0 || opts.tagSources != nil || opts.typeAsTag) {
+			addNestedTags(hierarchyTags("", converted))
+			emittedKeys[k] = "tags"
+			continue
+		}
 		if outKey == "tags" {
 			converted = sanitizeObsidianTagValue(converted)
 		}
+		if opts.multiValueLists && allowsMultipleValues(rel, hasRel) {
+			converted = asListValue(converted)
+		}
 		if filters.excludeEmpty && isEmptyFrontmatterValue(converted) {
 			continue
 		}
@@ -59,21 +165,205 @@ func renderFrontmatter(obj objectInfo, relations map[string]relationDef, typesBy
 			outKey = k
 		}
 		usedKeys[outKey] = struct{}{}
-		writeYAMLKeyValue(&buf, outKey, converted)
+		emittedKeys[k] = outKey
+		emitValue(outKey, converted)
+		entries[len(entries)-1].value = converted
+		entries[len(entries)-1].relation = true
+	}
+
+	if opts.properties == propertiesFeatured {
+		var featured []string
+		seenFeatured := map[string]struct{}{}
+		for _, k := range featuredDetailKeys(obj, relations) {
+			outKey, ok := emittedKeys[k]
+			if _, dup := seenFeatured[outKey]; !ok || dup {
+				continue
+			}
+			seenFeatured[outKey] = struct{}{}
+			featured = append(featured, outKey)
+		}
+		if _, exists := usedKeys["featured"]; !exists && len(featured) > 0 {
+			usedKeys["featured"] = struct{}{}
+			emitValue("featured", featured)
+		}
+	}
+
+	// Web clippings keep the page URL in source, where Obsidian Web Clipper
+	// puts it, even when it only lives in a bookmark block.
+	if opts.webClippings && isBookmarkObject(obj, typesByID) {
+		if _, exists := usedKeys["source"]; !exists {
+			if url := bookmarkSourceURL(obj); url != "" {
+				usedKeys["source"] = struct{}{}
+				emitValue("source", url)
+			}
+		}
+	}
+
+	if opts.typeAsTag {
+		if tag := typeTag(obj, typesByID); tag != "" {
+			addNestedTags([]string{tag})
+		}
 	}
 
 	if banner, ok := coverBannerValue(obj.Details, fileObjects); ok {
 		if _, exists := usedKeys["banner"]; !exists {
 			usedKeys["banner"] = struct{}{}
-			writeYAMLKeyValue(&buf, "banner", banner)
+			emitValue("banner", banner)
+			for _, pos := range coverBannerPosition(obj.Details) {
+				if _, exists := usedKeys[pos.key]; !exists {
+					usedKeys[pos.key] = struct{}{}
+					emitValue(pos.key, pos.value)
+				}
+			}
 		}
 	}
 
+	return orderFrontmatterEntries(entries, opts.propertyOrder)
+}
+
+// writeFrontmatter joins entries into the YAML block. With the inline
+// properties style, relation entries are left to the note body and a block
+// that ends up empty is omitted.
+func writeFrontmatter(entries []frontmatterEntry, opts frontmatterOptions) string {
+	inline := opts.propertiesStyle == propertiesStyleInline
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	written := 0
+	for _, entry := range entries {
+		if entry.relation && inline {
+			continue
+		}
+		buf.WriteString(entry.text)
+		written++
+	}
+	if inline && written == 0 {
+		return ""
+	}
 	buf.WriteString("---\n\n")
 	return buf.String()
 }
 
+type frontmatterEntry struct {
+	key      string
+	text     string
+	value    any
+	relation bool
+}
+
+// orderFrontmatterEntries moves the properties named in order (matched
+// case-insensitively against the output keys) to the front, in that order.
+// A "*" entry stands for every other property in its default order; without
+// it the others follow the pinned ones.
+func orderFrontmatterEntries(entries []frontmatterEntry, order []string) []frontmatterEntry {
+	if len(order) == 0 {
+		return entries
+	}
+	rank := map[string]int{}
+	rest := len(order)
+	for i, key := range order {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "*" {
+			rest = i
+			continue
+		}
+		if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	position := func(entry frontmatterEntry) int {
+		if i, ok := rank[strings.ToLower(entry.key)]; ok {
+			return i
+		}
+		return rest
+	}
+	ordered := append([]frontmatterEntry(nil), entries...)
+	sort.SliceStable(ordered, func(i, j int) bool { return position(ordered[i]) < position(ordered[j]) })
+	return ordered
+}
+
+// writeAnytypeMetadataBlock writes the nested anytype: map a reverse importer
+// needs to match a note back to its object without reading _anytype/raw.
+func writeAnytypeMetadataBlock(buf *bytes.Buffer, obj objectInfo) {
+	buf.WriteString("anytype:\n")
+	writeField := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		buf.WriteString("  " + key + ": ")
+		writeYAMLString(buf, value)
+		buf.WriteString("\n")
+	}
+	writeField("id", obj.ID)
+	writeField("spaceId", strings.TrimSpace(asString(obj.Details["spaceId"])))
+	if typeIDs := anyToStringSlice(obj.Details["type"]); len(typeIDs) > 0 {
+		writeField("typeId", typeIDs[0])
+	}
+	if _, modified, ok := anytypeTimestamps(obj.Details); ok {
+		writeField("lastModified", modified.UTC().Format(time.RFC3339))
+	}
+}
+
+// forEachFrontmatterProperty visits the detail keys renderFrontmatter would
+// consider for obj, after visibility filters but before value conversion.
+func forEachFrontmatterProperty(obj objectInfo, relations map[string]relationDef, typesByID map[string]typeDef, opts frontmatterOptions, fn func(k string, rel relationDef, hasRel bool, dateByType bool)) {
+	keys, includeByType, dateByType := orderedFrontmatterKeys(obj, relations, typesByID)
+	var keyProperties map[string]struct{}
+	if opts.properties == propertiesFeaturedOnly {
+		keyProperties = keyPropertyKeys(obj, relations, typesByID)
+	}
+	for _, k := range keys {
+		rel, hasRel := relations[k]
+		if opts.prettyPropertyIcon && isAnytypeIconProperty(k, rel, hasRel) {
+			continue
+		}
+		if !shouldIncludeFrontmatterProperty(k, rel, hasRel, includeByType[k], opts.includeDynamicProperties, opts.includeArchivedProperties, opts.filters) {
+			continue
+		}
+		if !isKeyProperty(k, rel, hasRel, keyProperties, opts.filters) {
+			continue
+		}
+		fn(k, rel, hasRel, dateByType[k])
+	}
+}
+
+type bannerPosition struct {
+	key   string
+	value float64
+}
+
+// coverBannerPosition maps Anytype's cover offsets to the Banners plugin's
+// banner_x/banner_y focus point. Anytype stores coverX/coverY as the
+// negative offset of the image inside its frame as a fraction of the
+// image size, so 0 is the top/left edge and -1 the bottom/right.
+// coverScale has no Banners equivalent and is not exported.
+func coverBannerPosition(details map[string]any) []bannerPosition {
+	var positions []bannerPosition
+	for _, axis := range []struct{ detail, key string }{{"coverX", "banner_x"}, {"coverY", "banner_y"}} {
+		raw, ok := details[axis.detail]
+		if !ok {
+			continue
+		}
+		offset, ok := asFloat(raw)
+		if !ok {
+			continue
+		}
+		value := math.Abs(offset)
+		if value > 1 {
+			value = 1
+		}
+		positions = append(positions, bannerPosition{key: axis.key, value: math.Round(value*1000) / 1000})
+	}
+	return positions
+}
+
 func coverBannerValue(details map[string]any, fileObjects map[string]string) (string, bool) {
+	if isGeneratedCoverType(details) {
+		if cover, ok := generatedCoverFor(details); ok {
+			return "[[" + cover.relPath + "]]", true
+		}
+		return "", false
+	}
+
 	coverID := strings.TrimSpace(asString(details["coverId"]))
 	if coverID == "" {
 		return "", false
@@ -84,7 +374,12 @@ func coverBannerValue(details map[string]any, fileObjects map[string]string) (st
 		return "", false
 	}
 
-	banner := strings.TrimSpace(filepath.Base(filepath.ToSlash(coverSource)))
+	// Top-level attachments resolve by name; nested ones need their path.
+	coverSource = filepath.ToSlash(coverSource)
+	banner := strings.TrimSpace(filepath.Base(coverSource))
+	if dir := filepath.ToSlash(filepath.Dir(coverSource)); dir != "files" && dir != "." {
+		banner = coverSource
+	}
 	if banner == "" {
 		return "", false
 	}
@@ -292,11 +587,32 @@ func shouldIncludeFrontmatterProperty(rawKey string, rel relationDef, hasRel boo
 	return true
 }
 
-func frontmatterKey(rawKey string, rel relationDef, hasRel bool, pictureToCover bool) string {
-	if pictureToCover && isPictureProperty(rawKey, rel, hasRel) {
+// propertyKeys holds the options that decide which frontmatter key a
+// relation is written under.
+type propertyKeys struct {
+	pictureToCover bool
+	// tagSources lists the normalized relation keys or names merged into
+	// tags; nil keeps the default of the relation called "tag".
+	tagSources map[string]struct{}
+}
+
+func (p propertyKeys) isTags(rawKey string, rel relationDef, hasRel bool) bool {
+	if p.tagSources == nil {
+		return isTagProperty(rawKey, rel, hasRel)
+	}
+	for _, candidate := range propertyCandidates(rawKey, rel, hasRel) {
+		if _, ok := p.tagSources[normalizePropertyKey(candidate)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func frontmatterKey(rawKey string, rel relationDef, hasRel bool, keys propertyKeys) string {
+	if keys.pictureToCover && isPictureProperty(rawKey, rel, hasRel) {
 		return "cover"
 	}
-	if isTagProperty(rawKey, rel, hasRel) {
+	if keys.isTags(rawKey, rel, hasRel) {
 		return "tags"
 	}
 	if !hasRel {
@@ -403,7 +719,58 @@ func isLikelyCIDKey(s string) bool {
 	return true
 }
 
+// allowsMultipleValues reports whether a relation can hold more than one
+// value: object, tag and file relations without a relationMaxCount of 1,
+// and status relations that explicitly allow more.
+func allowsMultipleValues(rel relationDef, hasRel bool) bool {
+	if !hasRel {
+		return false
+	}
+	switch rel.Format {
+	case anytypedomain.RelationFormatObjectRef, anytypedomain.RelationFormatTag, anytypedomain.RelationFormatFile:
+		return rel.Max != 1
+	case anytypedomain.RelationFormatStatus:
+		return rel.Max > 1
+	default:
+		return false
+	}
+}
+
+// asListValue wraps a converted scalar in a list, so a property has the
+// same YAML shape in every note. Empty values become an empty list.
+func asListValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return []string{}
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return []string{}
+		}
+		return []string{v}
+	case []string, []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// convertPropertyValue converts a relation value for the vault, passing it
+// through the PropertyConverter registered for key, if any.
 func convertPropertyValue(key string, value any, relations map[string]relationDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, dateByType bool, linkAsNote bool) any {
+	conv, hooked := propertyConverter(key)
+	if !hooked {
+		return convertBuiltinPropertyValue(key, value, relations, optionsByID, notes, sourceNotePath, objectNamesByID, fileObjects, dateByType, linkAsNote)
+	}
+	rel, hasRel := relations[key]
+	info := PropertyInfo{Key: key, Value: value, Name: rel.Name, Format: rel.Format, HasRelation: hasRel, NotePath: sourceNotePath}
+	converted, replaced := conv.BeforeConvert(info)
+	if !replaced {
+		converted = convertBuiltinPropertyValue(key, value, relations, optionsByID, notes, sourceNotePath, objectNamesByID, fileObjects, dateByType, linkAsNote)
+	}
+	return conv.AfterConvert(info, converted)
+}
+
+func convertBuiltinPropertyValue(key string, value any, relations map[string]relationDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, dateByType bool, linkAsNote bool) any {
 	return anytypedomain.ConvertPropertyValue(
 		key,
 		value,
@@ -645,11 +1012,25 @@ func writeYAMLValue(buf *bytes.Buffer, value any, indent int) {
 }
 
 func writeYAMLString(buf *bytes.Buffer, s string) {
-	escaped := strings.ReplaceAll(s, "\\", "\\\\")
-	escaped = strings.ReplaceAll(escaped, "\"", "\\\"")
-	escaped = strings.ReplaceAll(escaped, "\n", "\\n")
 	buf.WriteString("\"")
-	buf.WriteString(escaped)
+	for _, r := range strings.ToValidUTF8(s, "\uFFFD") {
+		switch {
+		case r == '\\':
+			buf.WriteString("\\\\")
+		case r == '"':
+			buf.WriteString("\\\"")
+		case r == '\n':
+			buf.WriteString("\\n")
+		case r == '\r':
+			buf.WriteString("\\r")
+		case r == '\t':
+			buf.WriteString("\\t")
+		case r < 0x20 || (r >= 0x7f && r <= 0x9f && r != 0x85) || r == 0xfeff || r == 0xfffe || r == 0xffff:
+			fmt.Fprintf(buf, "\\u%04X", r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
 	buf.WriteString("\"")
 }
 
@@ -658,9 +1039,81 @@ func sanitizeYAMLKey(s string) string {
 	if s == "" {
 		return "field"
 	}
+	if yamlKeyNeedsQuoting(s) {
+		var buf bytes.Buffer
+		writeYAMLString(&buf, s)
+		return buf.String()
+	}
 	return s
 }
 
+// A key can stay plain only if YAML reads it back as the same string.
+func yamlKeyNeedsQuoting(key string) bool {
+	if strings.HasSuffix(key, ":") || strings.ContainsAny(key, "\n\r\t") {
+		return true
+	}
+	if isPlainYAMLWord(key) {
+		return false
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(key), &node); err != nil {
+		return true
+	}
+	if node.Kind != yaml.DocumentNode || len(node.Content) != 1 {
+		return true
+	}
+	scalar := node.Content[0]
+	return scalar.Kind != yaml.ScalarNode || scalar.Tag != "!!str" || scalar.Style != 0 || scalar.Value != key
+}
+
+func isPlainYAMLWord(key string) bool {
+	switch strings.ToLower(key) {
+	case "true", "false", "null":
+		return false
+	}
+	for i, r := range key {
+		if unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '-' || r == ' ')) {
+			continue
+		}
+		return false
+	}
+	return !strings.HasSuffix(key, " ")
+}
+
+// validateFrontmatterYAML parses the leading frontmatter block of a markdown
+// document and fails if it is not a YAML mapping with unique keys.
+func validateFrontmatterYAML(content string) error {
+	if !strings.HasPrefix(content, "---\n") {
+		return nil
+	}
+	rest := content[len("---\n"):]
+	var raw string
+	switch {
+	case strings.HasPrefix(rest, "---\n") || rest == "---":
+		return nil
+	case strings.Contains(rest, "\n---\n"):
+		raw = rest[:strings.Index(rest, "\n---\n")]
+	case strings.HasSuffix(rest, "\n---"):
+		raw = strings.TrimSuffix(rest, "\n---")
+	default:
+		return fmt.Errorf("unterminated frontmatter")
+	}
+
+	var parsed map[string]any
+	if err := yaml.Unmarshal([]byte(raw), &parsed); err != nil {
+		return fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	return nil
+}
+
+func validateBaseYAML(content string) error {
+	var parsed map[string]any
+	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+		return fmt.Errorf("invalid base YAML: %w", err)
+	}
+	return nil
+}
+
 func sanitizeObsidianTagValue(value any) any {
 	sanitizeSlice := func(items []string) []string {
 		out := make([]string, 0, len(items))
@@ -764,6 +1217,70 @@ func sanitizeObsidianTagPart(part string) string {
 	return strings.Trim(b.String(), "-")
 }
 
+const (
+	defaultMaxFilenameBytes = 255
+	filenameHashLength      = 8
+	// minMaxFilenameBytes fits the "~<hash>" suffix of a truncated name
+	// after the longest extension the exporter writes.
+	minMaxFilenameBytes = len(".excalidraw.md") + 1 + filenameHashLength
+)
+
+type filenameOptions struct {
+	escaping      string
+	transliterate bool
+	scheme        string
+	maxBytes      int
+	// asciiSlug transliterates and then slugs names; it takes precedence
+	// over transliterate, which it implies.
+	asciiSlug   bool
+	emojiPrefix bool
+	foldCase    bool
+	nfc         bool
+}
+
+func (o filenameOptions) sanitize(s string) string {
+	if o.nfc {
+		s = norm.NFC.String(s)
+	}
+	if o.asciiSlug {
+		s = asciiSlug(s)
+	} else if o.transliterate {
+		s = transliterateToASCII(s)
+	}
+	return sanitizeName(s, o.escaping)
+}
+
+// fit shortens base so that base+ext stays within maxBytes. Truncated names
+// end in "~" plus a hash of the full name, so two long names that share a
+// prefix still get distinct files.
+func (o filenameOptions) fit(base string, ext string) string {
+	if o.maxBytes <= 0 || len(base)+len(ext) <= o.maxBytes {
+		return base
+	}
+	sum := sha1.Sum([]byte(base))
+	suffix := "~" + hex.EncodeToString(sum[:])[:filenameHashLength]
+	budget := o.maxBytes - len(ext) - len(suffix)
+	if budget <= 0 {
+		return strings.TrimPrefix(suffix, "~")
+	}
+	cut := 0
+	for i, r := range base {
+		if i+utf8.RuneLen(r) > budget {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	truncated := strings.TrimRight(base[:cut], " .-")
+	return truncated + suffix
+}
+
+func (o filenameOptions) collisionKey(name string) string {
+	if o.foldCase {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
 func sanitizeName(s string, mode string) string {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -795,36 +1312,69 @@ func sanitizeName(s string, mode string) string {
 }
 
 func inferObjectTitle(obj objectInfo) string {
-	if name := strings.TrimSpace(obj.Name); name != "" {
-		return name
+	if variants := objectTitleVariants(obj); len(variants) > 0 {
+		return variants[0]
 	}
+	return ""
+}
+
+// objectTitleVariants lists the distinct non-empty titles an object carries,
+// in filename priority order: its name, the title block text, then the
+// title detail.
+func objectTitleVariants(obj objectInfo) []string {
+	var variants []string
+	add := func(title string) {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return
+		}
+		for _, existing := range variants {
+			if existing == title {
+				return
+			}
+		}
+		variants = append(variants, title)
+	}
+
+	add(obj.Name)
 
 	byID := make(map[string]block, len(obj.Blocks))
 	for _, b := range obj.Blocks {
 		byID[b.ID] = b
 	}
-
 	if root, ok := byID[obj.ID]; ok {
 		for _, childID := range root.ChildrenID {
 			child, exists := byID[childID]
-			if !exists || child.Text == nil {
-				continue
-			}
-			if child.Text.Style != "Title" {
+			if !exists || child.Text == nil || child.Text.Style != "Title" {
 				continue
 			}
-			title := strings.TrimSpace(child.Text.Text)
-			if title != "" {
-				return title
+			if title := strings.TrimSpace(child.Text.Text); title != "" {
+				add(title)
+				break
 			}
 		}
 	}
 
-	if title := strings.TrimSpace(asString(obj.Details["title"])); title != "" {
-		return title
-	}
+	add(asString(obj.Details["title"]))
+	return variants
+}
 
-	return ""
+// typeTag is the type/<Name> tag -type-as-tag adds for the object's type,
+// or "" when the type is not part of the export.
+func typeTag(obj objectInfo, typesByID map[string]typeDef) string {
+	typeIDs := anyToStringSlice(obj.Details["type"])
+	if len(typeIDs) == 0 {
+		return ""
+	}
+	t, ok := typesByID[strings.TrimSpace(typeIDs[0])]
+	if !ok {
+		return ""
+	}
+	name := sanitizeObsidianTagPart(strings.TrimSpace(t.Name))
+	if name == "" {
+		return ""
+	}
+	return "type/" + name
 }
 
 func inferTemplateTypeName(typeID string, typesByID map[string]typeDef) string {
@@ -887,11 +1437,40 @@ func resolveFilenameEscaping(mode string) (string, error) {
 	return "", fmt.Errorf("invalid filename escaping mode %q: expected auto, posix, or windows", mode)
 }
 
-func filenameCollisionKey(name string, mode string) string {
-	if mode == "windows" {
-		return strings.ToLower(name)
+// caseInsensitiveFilenames reports whether "Readme.md" and "README.md" name
+// the same file in outputDir. Windows escaping always folds case; in auto
+// mode the output filesystem is probed, falling back to the platform default
+// (case-insensitive on darwin and windows) when probing fails.
+func caseInsensitiveFilenames(requestedMode string, escaping string, outputDir string) bool {
+	if escaping == "windows" {
+		return true
 	}
-	return name
+	mode := strings.TrimSpace(strings.ToLower(requestedMode))
+	if mode != "" && mode != "auto" {
+		return false
+	}
+	if insensitive, ok := probeCaseInsensitive(outputDir); ok {
+		return insensitive
+	}
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+func probeCaseInsensitive(dir string) (bool, bool) {
+	probe, err := os.CreateTemp(dir, ".case-probe-")
+	if err != nil {
+		return false, false
+	}
+	name := probe.Name()
+	_ = probe.Close()
+	defer os.Remove(name)
+
+	upper := filepath.Join(filepath.Dir(name), strings.ToUpper(filepath.Base(name)))
+	if _, err := os.Stat(upper); err == nil {
+		return true, true
+	} else if os.IsNotExist(err) {
+		return false, true
+	}
+	return false, false
 }
 
 func isForbiddenFileNameRune(r rune, mode string) bool {
@@ -927,10 +1506,35 @@ func isWindowsReservedName(name string) bool {
 	}
 }
 
-func copyDir(src, dst string) (int, error) {
-	return exportfs.CopyDir(src, dst)
+func copyDir(input fs.FS, src, dst string, skip func(rel string) bool, onFile func(done, total int)) (int, error) {
+	return exportfs.CopyDir(input, src, dst, skip, onFile)
+}
+
+func shrinkImages(outputDir string, opts exportfs.ImageOptions) ([]string, error) {
+	return exportfs.ShrinkImages(outputDir, opts)
 }
 
-func normalizeExportedFileObjectPaths(inputDir, outputDir string, fileObjects map[string]string) error {
-	return exportfs.NormalizeExportedFileObjectPaths(inputDir, outputDir, fileObjects)
+func normalizeExportedFileObjectPaths(input fs.FS, outputDir string, fileObjects map[string]string, skip func(relPath string) bool) error {
+	return exportfs.NormalizeExportedFileObjectPaths(input, outputDir, fileObjects, skip)
+}
+
+// resolvePropertyOrder trims the configured property order, drops blank
+// entries and rejects a repeated wildcard.
+func resolvePropertyOrder(order []string) ([]string, error) {
+	var resolved []string
+	wildcards := 0
+	for _, key := range order {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if key == "*" {
+			wildcards++
+			if wildcards > 1 {
+				return nil, fmt.Errorf("invalid property order %q: \"*\" may appear only once", strings.Join(order, ","))
+			}
+		}
+		resolved = append(resolved, key)
+	}
+	return resolved, nil
 }
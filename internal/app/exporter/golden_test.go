@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden/vault from the current exporter output")
+
+// TestExporterGoldenVault runs the whole pipeline over testdata/golden/input
+// and compares every exported file with testdata/golden/vault. After an
+// intended output change, regenerate the goldens with
+//
+//	go test ./internal/app/exporter -run '^TestExporterGoldenVault$' -update
+//
+// and review the diff like any other change.
+func TestExporterGoldenVault(t *testing.T) {
+	input := filepath.Join("testdata", "golden", "input")
+	goldenDir := filepath.Join("testdata", "golden", "vault")
+	output := t.TempDir()
+
+	if _, err := (Exporter{InputDir: input, OutputDir: output, NoDynamicTimestamps: true}).Run(); err != nil {
+		t.Fatalf("run exporter: %v", err)
+	}
+	got := readGoldenTree(t, output)
+
+	if *updateGolden {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatalf("remove golden vault: %v", err)
+		}
+		for rel, content := range got {
+			target := filepath.Join(goldenDir, filepath.FromSlash(rel))
+			mustMkdirAll(t, filepath.Dir(target))
+			if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+				t.Fatalf("write golden %s: %v", rel, err)
+			}
+		}
+		return
+	}
+
+	want := readGoldenTree(t, goldenDir)
+	for _, rel := range sortedGoldenPaths(want) {
+		content, ok := got[rel]
+		if !ok {
+			t.Errorf("missing exported file %s", rel)
+			continue
+		}
+		if content != want[rel] {
+			t.Errorf("%s differs from golden:\n%s", rel, firstGoldenDifference(want[rel], content))
+		}
+	}
+	for _, rel := range sortedGoldenPaths(got) {
+		if _, ok := want[rel]; !ok {
+			t.Errorf("unexpected exported file %s", rel)
+		}
+	}
+	if t.Failed() {
+		t.Log("run with -update to accept the new output")
+	}
+}
+
+func readGoldenTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read %s: %v", root, err)
+	}
+	return files
+}
+
+func sortedGoldenPaths(files map[string]string) []string {
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func firstGoldenDifference(want string, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return "line " + strconv.Itoa(i+1) + ":\n  want: " + w + "\n  got:  " + g
+		}
+	}
+	return "(no line difference)"
+}
@@ -0,0 +1,169 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	graphStatsFileName = "graph-stats.md"
+	// graphStatsMostLinked caps the most-linked list.
+	graphStatsMostLinked = 10
+)
+
+type graphStats struct {
+	notes      int
+	links      int
+	byType     []graphTypeCount
+	mostLinked []graphLinkCount
+	orphans    []string
+	unresolved []unresolvedRelationTarget
+}
+
+type graphTypeCount struct {
+	name  string
+	notes int
+}
+
+type graphLinkCount struct {
+	note     string
+	incoming int
+}
+
+// collectGraphStats reads the notes written to outputDir and counts the
+// links between them. A link counts once per pair of notes; links to
+// attachments, bases and a note's own headings are ignored.
+func collectGraphStats(outputDir string, objects []objectInfo, notes map[string]string, typesByID map[string]typeDef, unresolved []unresolvedRelationTarget) (graphStats, error) {
+	vault, err := indexVault(outputDir)
+	if err != nil {
+		return graphStats{}, err
+	}
+	isNote := make(map[string]struct{}, len(notes))
+	for _, notePath := range notes {
+		isNote[notePath] = struct{}{}
+	}
+
+	stats := graphStats{notes: len(isNote), unresolved: unresolved}
+	outgoing := map[string]int{}
+	incoming := map[string]map[string]struct{}{}
+	for notePath := range isNote {
+		content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(notePath)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return graphStats{}, err
+		}
+		for _, target := range extractLinkTargets(string(content)) {
+			resolved, ok := vault.resolve(notePath, target)
+			if _, note := isNote[resolved]; !ok || !note || resolved == notePath {
+				continue
+			}
+			if incoming[resolved] == nil {
+				incoming[resolved] = map[string]struct{}{}
+			}
+			if _, seen := incoming[resolved][notePath]; seen {
+				continue
+			}
+			incoming[resolved][notePath] = struct{}{}
+			outgoing[notePath]++
+			stats.links++
+		}
+	}
+
+	for notePath := range isNote {
+		if outgoing[notePath] == 0 && len(incoming[notePath]) == 0 {
+			stats.orphans = append(stats.orphans, notePath)
+		}
+		if n := len(incoming[notePath]); n > 0 {
+			stats.mostLinked = append(stats.mostLinked, graphLinkCount{note: notePath, incoming: n})
+		}
+	}
+	sort.Strings(stats.orphans)
+	sort.Slice(stats.mostLinked, func(i, j int) bool {
+		if stats.mostLinked[i].incoming != stats.mostLinked[j].incoming {
+			return stats.mostLinked[i].incoming > stats.mostLinked[j].incoming
+		}
+		return stats.mostLinked[i].note < stats.mostLinked[j].note
+	})
+	if len(stats.mostLinked) > graphStatsMostLinked {
+		stats.mostLinked = stats.mostLinked[:graphStatsMostLinked]
+	}
+
+	countByType := map[string]int{}
+	for _, obj := range objects {
+		if _, ok := notes[obj.ID]; !ok {
+			continue
+		}
+		name := "No type"
+		if typeInfo, ok := typesByID[asString(obj.Details["type"])]; ok && strings.TrimSpace(typeInfo.Name) != "" {
+			name = strings.TrimSpace(typeInfo.Name)
+		}
+		countByType[name]++
+	}
+	for name, n := range countByType {
+		stats.byType = append(stats.byType, graphTypeCount{name: name, notes: n})
+	}
+	sort.Slice(stats.byType, func(i, j int) bool {
+		if stats.byType[i].notes != stats.byType[j].notes {
+			return stats.byType[i].notes > stats.byType[j].notes
+		}
+		return stats.byType[i].name < stats.byType[j].name
+	})
+	return stats, nil
+}
+
+func renderGraphStats(stats graphStats) string {
+	link := func(notePath string) string {
+		return "[[" + relativeWikiTarget(graphStatsFileName, notePath) + "]]"
+	}
+	var b strings.Builder
+	b.WriteString("# Graph stats\n\n")
+	b.WriteString(strconv.Itoa(stats.notes) + " notes, " + strconv.Itoa(stats.links) + " links between them.\n")
+
+	b.WriteString("\n## Notes per type\n\n")
+	b.WriteString("| Type | Notes |\n| --- | ---: |\n")
+	for _, count := range stats.byType {
+		b.WriteString("| " + strings.ReplaceAll(count.name, "|", `\|`) + " | " + strconv.Itoa(count.notes) + " |\n")
+	}
+
+	b.WriteString("\n## Most linked notes\n\n")
+	if len(stats.mostLinked) == 0 {
+		b.WriteString("No note links to another.\n")
+	}
+	for _, count := range stats.mostLinked {
+		suffix := " incoming links"
+		if count.incoming == 1 {
+			suffix = " incoming link"
+		}
+		b.WriteString("- " + link(count.note) + " (" + strconv.Itoa(count.incoming) + suffix + ")\n")
+	}
+
+	b.WriteString("\n## Orphan notes\n\n")
+	if len(stats.orphans) == 0 {
+		b.WriteString("Every note links to or from another note.\n")
+	} else {
+		b.WriteString("Notes with no links to or from other notes.\n\n")
+	}
+	for _, orphan := range stats.orphans {
+		b.WriteString("- " + link(orphan) + "\n")
+	}
+
+	b.WriteString("\n## Broken relation targets\n\n")
+	if len(stats.unresolved) == 0 {
+		b.WriteString("Every relation points at an exported object.\n")
+	} else {
+		b.WriteString("Relations that point at objects missing from the export.\n\n")
+	}
+	for _, target := range stats.unresolved {
+		b.WriteString("- " + link(target.NotePath) + ": `" + target.Property + "` → `" + target.TargetID + "`\n")
+	}
+	return b.String()
+}
+
+func writeGraphStats(outputDir string, stats graphStats, vault *vaultFiles) error {
+	return vault.writeFile(filepath.Join(outputDir, graphStatsFileName), []byte(renderGraphStats(stats)))
+}
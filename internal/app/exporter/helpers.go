@@ -33,6 +33,20 @@ func asInt(v any) int {
 	}
 }
 
+func asFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
 func anyToStringSlice(v any) []string {
 	switch t := v.(type) {
 	case []string:
@@ -0,0 +1,613 @@
+package exporter
+
+import (
+	"encoding/base64"
+	"html"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The HTML renderer understands the markdown this exporter writes (notes,
+// boards and stubs), not CommonMark at large.
+
+var (
+	markdownHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	markdownListItemPattern = regexp.MustCompile(`^([\t ]*)([-*+]|\d+[.)])\s+(.*)$`)
+	markdownTaskPattern     = regexp.MustCompile(`^\[([ xX])\]\s?(.*)$`)
+	htmlTableSepPattern     = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	calloutPattern          = regexp.MustCompile(`^\[!([A-Za-z0-9_-]+)\][+-]?\s*(.*)$`)
+	blockAnchorPattern      = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)$`)
+)
+
+var htmlImageExts = map[string]struct{}{".png": {}, ".jpg": {}, ".jpeg": {}, ".gif": {}, ".webp": {}, ".svg": {}, ".bmp": {}, ".avif": {}}
+var htmlAudioExts = map[string]struct{}{".mp3": {}, ".wav": {}, ".ogg": {}, ".m4a": {}, ".flac": {}, ".webm": {}}
+var htmlVideoExts = map[string]struct{}{".mp4": {}, ".mov": {}, ".mkv": {}, ".ogv": {}}
+
+// htmlLinks resolves the links of one note against the vault being
+// rendered.
+type htmlLinks struct {
+	source      string
+	vaultDir    string
+	vault       vaultIndex
+	embedImages bool
+}
+
+// href links to a vault file from the current page; notes point at their
+// rendered .html page.
+func (l htmlLinks) href(rel string) string {
+	if strings.HasSuffix(rel, ".md") {
+		rel = strings.TrimSuffix(rel, ".md") + ".html"
+	}
+	return htmlEscapeURLPath(relativePathTarget(l.source, rel))
+}
+
+// assetSrc is the src of an embedded file: a data: URI when images are
+// embedded, a relative URL otherwise.
+func (l htmlLinks) assetSrc(rel string) string {
+	if l.embedImages {
+		if _, ok := htmlImageExts[strings.ToLower(path.Ext(rel))]; ok {
+			if data, err := os.ReadFile(filepath.Join(l.vaultDir, filepath.FromSlash(rel))); err == nil {
+				mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(rel)))
+				if mimeType == "" {
+					mimeType = "application/octet-stream"
+				}
+				return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+			}
+		}
+	}
+	return htmlEscapeURLPath(relativePathTarget(l.source, rel))
+}
+
+func htmlEscapeURLPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// htmlFragment turns an Obsidian link fragment into the id the rendered
+// page uses: block ids become block-<id>, headings their slug.
+func htmlFragment(fragment string) string {
+	fragment = strings.TrimSpace(fragment)
+	if fragment == "" {
+		return ""
+	}
+	if strings.HasPrefix(fragment, "^") {
+		return "#block-" + fragment[1:]
+	}
+	return "#" + htmlSlug(fragment)
+}
+
+func htmlSlug(s string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r == ' ' || r == '-' || r == '_':
+			if !lastDash && b.Len() > 0 {
+				b.WriteRune('-')
+				lastDash = true
+			}
+		case strings.ContainsRune(`!"#$%&'()*+,./:;<=>?@[\]^{|}~`+"`", r):
+		default:
+			b.WriteRune(r)
+			lastDash = false
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// splitMarkdownFrontmatter separates the YAML frontmatter from the body.
+func splitMarkdownFrontmatter(markdown string) (string, string) {
+	if !strings.HasPrefix(markdown, "---\n") {
+		return "", markdown
+	}
+	if strings.HasPrefix(markdown[4:], "---\n") {
+		return "", markdown[8:]
+	}
+	end := strings.Index(markdown[4:], "\n---\n")
+	if end < 0 {
+		if strings.HasSuffix(markdown, "\n---") {
+			return markdown[4 : len(markdown)-4], ""
+		}
+		return "", markdown
+	}
+	return markdown[4 : 4+end+1], markdown[4+end+5:]
+}
+
+// renderHTMLProperties shows frontmatter as a two-column table, in file
+// order, with links in values resolved.
+func renderHTMLProperties(frontmatter string, links htmlLinks) string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return ""
+	}
+	mapping := doc.Content[0]
+	var b strings.Builder
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		b.WriteString("<tr><th>" + html.EscapeString(key.Value) + "</th><td>" + renderHTMLPropertyValue(value, links) + "</td></tr>\n")
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "<table class=\"properties\">\n" + b.String() + "</table>\n"
+}
+
+func renderHTMLPropertyValue(node *yaml.Node, links htmlLinks) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return renderHTMLInline(node.Value, links)
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			items = append(items, renderHTMLPropertyValue(item, links))
+		}
+		return strings.Join(items, ", ")
+	default:
+		out, err := yaml.Marshal(node)
+		if err != nil {
+			return ""
+		}
+		return "<code>" + html.EscapeString(strings.TrimSpace(string(out))) + "</code>"
+	}
+}
+
+// splitBlockAnchorSuffix strips a trailing "^id" block marker.
+func splitBlockAnchorSuffix(line string) (string, string) {
+	m := blockAnchorPattern.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line, ""
+	}
+	return strings.TrimRight(line[:m[0]], " \t"), line[m[2]:m[3]]
+}
+
+func htmlIDAttr(anchor string) string {
+	if anchor == "" {
+		return ""
+	}
+	return ` id="block-` + html.EscapeString(anchor) + `"`
+}
+
+func renderMarkdownHTML(markdown string, links htmlLinks) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	renderHTMLBlocks(&b, lines, links)
+	return b.String()
+}
+
+func renderHTMLBlocks(b *strings.Builder, lines []string, links htmlLinks) {
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		last, anchor := splitBlockAnchorSuffix(para[len(para)-1])
+		para[len(para)-1] = last
+		rendered := make([]string, len(para))
+		for i, line := range para {
+			rendered[i] = renderHTMLInline(strings.TrimSpace(line), links)
+		}
+		b.WriteString("<p" + htmlIDAttr(anchor) + ">" + strings.Join(rendered, "<br>\n") + "</p>\n")
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), fence) {
+				j++
+			}
+			class := ""
+			if lang != "" {
+				class = ` class="language-` + html.EscapeString(lang) + `"`
+			}
+			b.WriteString("<pre><code" + class + ">" + html.EscapeString(strings.Join(lines[i+1:min(j, len(lines))], "\n")) + "</code></pre>\n")
+			i = j
+		case trimmed == "$$":
+			flush()
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "$$" {
+				j++
+			}
+			b.WriteString("<div class=\"math\">" + html.EscapeString(strings.Join(lines[i+1:min(j, len(lines))], "\n")) + "</div>\n")
+			i = j
+		case strings.HasPrefix(trimmed, "%%"):
+			// Obsidian comments, e.g. the Kanban plugin settings.
+			flush()
+			if len(trimmed) > 2 && strings.HasSuffix(trimmed, "%%") {
+				continue
+			}
+			for i+1 < len(lines) && !strings.HasSuffix(strings.TrimSpace(lines[i+1]), "%%") {
+				i++
+			}
+			i++
+		case markdownHeadingPattern.MatchString(trimmed):
+			flush()
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			text, anchor := splitBlockAnchorSuffix(m[2])
+			id := ` id="` + html.EscapeString(htmlSlug(text)) + `"`
+			if anchor != "" {
+				id = htmlIDAttr(anchor)
+			}
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + id + ">" + renderHTMLInline(text, links) + "</h" + level + ">\n")
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flush()
+			b.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			renderHTMLQuote(b, quoted, links)
+		case markdownListItemPattern.MatchString(line):
+			flush()
+			end := i
+			for end+1 < len(lines) {
+				next := lines[end+1]
+				if markdownListItemPattern.MatchString(next) || (strings.TrimSpace(next) != "" && (strings.HasPrefix(next, "\t") || strings.HasPrefix(next, "  "))) {
+					end++
+					continue
+				}
+				if strings.TrimSpace(next) == "" && end+2 < len(lines) && markdownListItemPattern.MatchString(lines[end+2]) {
+					end++
+					continue
+				}
+				break
+			}
+			renderHTMLList(b, parseMarkdownListItems(lines[i:end+1]), links)
+			i = end
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && htmlTableSepPattern.MatchString(strings.TrimSpace(lines[i+1])):
+			flush()
+			j := i + 2
+			for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|") {
+				j++
+			}
+			renderHTMLTable(b, lines[i], lines[i+2:j], links)
+			i = j - 1
+		case strings.HasPrefix(trimmed, "<"):
+			// Raw HTML the exporter writes, e.g. column layouts.
+			flush()
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				b.WriteString(lines[i] + "\n")
+			}
+		case strings.HasPrefix(trimmed, "^") && blockAnchorPattern.MatchString(trimmed) && !strings.Contains(trimmed, " "):
+			flush()
+			b.WriteString("<a" + htmlIDAttr(trimmed[1:]) + "></a>\n")
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+}
+
+func renderHTMLQuote(b *strings.Builder, lines []string, links htmlLinks) {
+	if len(lines) > 0 {
+		if m := calloutPattern.FindStringSubmatch(strings.TrimSpace(lines[0])); m != nil {
+			kind := strings.ToLower(m[1])
+			title := strings.TrimSpace(m[2])
+			if title == "" {
+				title = strings.ToUpper(kind[:1]) + kind[1:]
+			}
+			b.WriteString(`<div class="callout" data-callout="` + html.EscapeString(kind) + `">` + "\n")
+			b.WriteString(`<div class="callout-title">` + renderHTMLInline(title, links) + "</div>\n")
+			renderHTMLBlocks(b, lines[1:], links)
+			b.WriteString("</div>\n")
+			return
+		}
+	}
+	b.WriteString("<blockquote>\n")
+	renderHTMLBlocks(b, lines, links)
+	b.WriteString("</blockquote>\n")
+}
+
+type markdownListItem struct {
+	depth   int
+	ordered bool
+	start   int
+	task    bool
+	checked bool
+	text    string
+	extra   []string
+}
+
+func parseMarkdownListItems(lines []string) []markdownListItem {
+	var items []markdownListItem
+	for _, line := range lines {
+		m := markdownListItemPattern.FindStringSubmatch(line)
+		if m == nil {
+			if len(items) > 0 && strings.TrimSpace(line) != "" {
+				last := &items[len(items)-1]
+				last.extra = append(last.extra, strings.TrimSpace(line))
+			}
+			continue
+		}
+		indent := strings.Count(m[1], "\t") + strings.Count(m[1], " ")/4
+		item := markdownListItem{depth: indent, text: m[3]}
+		if marker := m[2]; marker[0] >= '0' && marker[0] <= '9' {
+			item.ordered = true
+			item.start, _ = strconv.Atoi(strings.TrimRight(marker, ".)"))
+		}
+		if t := markdownTaskPattern.FindStringSubmatch(item.text); t != nil && !item.ordered {
+			item.task = true
+			item.checked = t[1] != " "
+			item.text = t[2]
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func renderHTMLList(b *strings.Builder, items []markdownListItem, links htmlLinks) {
+	for i := 0; i < len(items); {
+		depth, ordered := items[i].depth, items[i].ordered
+		tag := "ul"
+		open := "<ul>"
+		if ordered {
+			tag = "ol"
+			open = "<ol>"
+			if items[i].start > 1 {
+				open = `<ol start="` + strconv.Itoa(items[i].start) + `">`
+			}
+		} else if items[i].task {
+			open = `<ul class="tasks">`
+		}
+		b.WriteString(open + "\n")
+		for i < len(items) && items[i].depth == depth && items[i].ordered == ordered {
+			item := items[i]
+			i++
+			text, anchor := splitBlockAnchorSuffix(item.text)
+			b.WriteString("<li" + htmlIDAttr(anchor) + ">")
+			if item.task {
+				checked := ""
+				if item.checked {
+					checked = " checked"
+				}
+				b.WriteString(`<input type="checkbox" disabled` + checked + `> `)
+			}
+			b.WriteString(renderHTMLInline(text, links))
+			for _, extra := range item.extra {
+				b.WriteString("<br>\n" + renderHTMLInline(extra, links))
+			}
+			j := i
+			for j < len(items) && items[j].depth > depth {
+				j++
+			}
+			if j > i {
+				b.WriteString("\n")
+				renderHTMLList(b, items[i:j], links)
+				i = j
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</" + tag + ">\n")
+	}
+}
+
+func splitHTMLTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func renderHTMLTable(b *strings.Builder, header string, rows []string, links htmlLinks) {
+	b.WriteString("<table>\n<thead><tr>")
+	for _, cell := range splitHTMLTableRow(header) {
+		b.WriteString("<th>" + renderHTMLInline(cell, links) + "</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range splitHTMLTableRow(row) {
+			b.WriteString("<td>" + renderHTMLInline(cell, links) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+}
+
+// renderHTMLInline renders code spans, wikilinks and embeds, markdown links
+// and images, and bold, italic, strikethrough and highlight markers.
+func renderHTMLInline(s string, links htmlLinks) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune(`\`+"`"+`*_[]()#+-.!|~=<>^$`, rune(rest[1])):
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				b.WriteString("<code>" + html.EscapeString(rest[1:1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "![["), strings.HasPrefix(rest, "[["):
+			embed := rest[0] == '!'
+			open := 2
+			if embed {
+				open = 3
+			}
+			if end := strings.Index(rest[open:], "]]"); end >= 0 {
+				b.WriteString(renderHTMLWikiLink(rest[open:open+end], embed, links))
+				i += open + end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "!["), rest[0] == '[':
+			if out, n, ok := renderHTMLMarkdownLink(rest, links); ok {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+		case strings.HasPrefix(rest, "**"), strings.HasPrefix(rest, "~~"), strings.HasPrefix(rest, "=="):
+			marker := rest[:2]
+			if end := strings.Index(rest[2:], marker); end > 0 {
+				tag := map[string]string{"**": "strong", "~~": "del", "==": "mark"}[marker]
+				b.WriteString("<" + tag + ">" + renderHTMLInline(rest[2:2+end], links) + "</" + tag + ">")
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_':
+			marker := rest[:1]
+			wordStart := i == 0 || !isHTMLWordByte(s[i-1])
+			if len(rest) > 1 && rest[1] != ' ' && (marker == "*" || wordStart) {
+				if end := strings.Index(rest[1:], marker); end > 0 && rest[end] != ' ' {
+					b.WriteString("<em>" + renderHTMLInline(rest[1:1+end], links) + "</em>")
+					i += end + 2
+					continue
+				}
+			}
+		}
+		b.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+	return b.String()
+}
+
+func isHTMLWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func renderHTMLWikiLink(inner string, embed bool, links htmlLinks) string {
+	target, label, _ := strings.Cut(inner, "|")
+	target = strings.ReplaceAll(target, `\`, "")
+	label = strings.ReplaceAll(label, `\`, "")
+	target, fragment, _ := strings.Cut(target, "#")
+	target = strings.TrimSpace(target)
+	if label == "" {
+		label = strings.TrimSuffix(path.Base(target), ".md")
+		if target == "" {
+			label = strings.TrimPrefix(fragment, "^")
+		}
+	}
+	if target == "" {
+		return `<a href="` + html.EscapeString(htmlFragment(fragment)) + `">` + html.EscapeString(label) + "</a>"
+	}
+	rel, ok := links.vault.resolve(links.source, target)
+	if !ok {
+		return `<span class="unresolved">` + html.EscapeString(label) + "</span>"
+	}
+	if embed {
+		if out, ok := renderHTMLEmbed(rel, label, links); ok {
+			return out
+		}
+	}
+	if !strings.HasSuffix(rel, ".md") && strings.HasSuffix(rel, ".base") {
+		return `<span class="base">` + html.EscapeString(label) + "</span>"
+	}
+	href := links.href(rel)
+	if strings.HasSuffix(rel, ".md") {
+		href += htmlFragment(fragment)
+	}
+	return `<a href="` + html.EscapeString(href) + `">` + html.EscapeString(label) + "</a>"
+}
+
+func renderHTMLEmbed(rel string, label string, links htmlLinks) (string, bool) {
+	ext := strings.ToLower(path.Ext(rel))
+	if _, ok := htmlImageExts[ext]; ok {
+		return `<img src="` + html.EscapeString(links.assetSrc(rel)) + `" alt="` + html.EscapeString(label) + `">`, true
+	}
+	if _, ok := htmlAudioExts[ext]; ok {
+		return `<audio controls src="` + html.EscapeString(links.assetSrc(rel)) + `"></audio>`, true
+	}
+	if _, ok := htmlVideoExts[ext]; ok {
+		return `<video controls src="` + html.EscapeString(links.assetSrc(rel)) + `"></video>`, true
+	}
+	return "", false
+}
+
+// renderHTMLMarkdownLink renders a [label](url) link or ![alt](url) image
+// at the start of s and reports how many bytes it used.
+func renderHTMLMarkdownLink(s string, links htmlLinks) (string, int, bool) {
+	image := s[0] == '!'
+	start := 1
+	if image {
+		start = 2
+	}
+	depth := 1
+	closeLabel := -1
+	for i := start; i < len(s) && closeLabel < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeLabel = i
+			}
+		}
+	}
+	if closeLabel < 0 || closeLabel+1 >= len(s) || s[closeLabel+1] != '(' {
+		return "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeURL < 0 {
+		return "", 0, false
+	}
+	label := s[start:closeLabel]
+	target := strings.TrimSpace(s[closeLabel+2 : closeLabel+2+closeURL])
+	n := closeLabel + 3 + closeURL
+
+	href := target
+	if !urlSchemePattern.MatchString(target) && !strings.HasPrefix(target, "#") {
+		file, fragment, _ := strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(file); err == nil {
+			file = unescaped
+		}
+		if rel, ok := links.vault.resolve(links.source, file); ok {
+			if image {
+				href = links.assetSrc(rel)
+			} else {
+				href = links.href(rel)
+				if fragment != "" && strings.HasSuffix(rel, ".md") {
+					href += htmlFragment(fragment)
+				}
+			}
+		}
+	}
+	if image {
+		return `<img src="` + html.EscapeString(href) + `" alt="` + html.EscapeString(label) + `">`, n, true
+	}
+	return `<a href="` + html.EscapeString(href) + `">` + renderHTMLInline(label, links) + "</a>", n, true
+}
@@ -0,0 +1,205 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	outputFormatMarkdown = "markdown"
+	outputFormatHTML     = "html"
+	outputFormatBoth     = "both"
+)
+
+func resolveOutputFormat(format string) (string, error) {
+	format = strings.TrimSpace(strings.ToLower(format))
+	switch format {
+	case "", "md", outputFormatMarkdown:
+		return outputFormatMarkdown, nil
+	case outputFormatHTML, outputFormatBoth:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format %q: expected markdown, html, or both", format)
+	}
+}
+
+const htmlPageStyle = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",sans-serif;max-width:46rem;margin:2rem auto;padding:0 1rem;line-height:1.6;color:#222}
+nav{font-size:.9em;margin-bottom:1.5rem}a{color:#5a3fc0}img,video{max-width:100%}
+pre{background:#f5f5f5;padding:.75rem;overflow-x:auto}code{background:#f5f5f5;padding:0 .2em}
+table{border-collapse:collapse}th,td{border:1px solid #ddd;padding:.3rem .6rem;text-align:left;vertical-align:top}
+table.properties{margin-bottom:1.5rem;font-size:.9em}table.properties th{background:#fafafa;font-weight:600}
+blockquote{border-left:3px solid #ccc;margin-left:0;padding-left:1rem;color:#555}
+.callout{border-left:3px solid #5a3fc0;background:#f7f5fd;padding:.5rem 1rem;margin:1rem 0}.callout-title{font-weight:600}
+ul.tasks{list-style:none;padding-left:1.2rem}.unresolved{color:#999}.math{font-family:monospace;white-space:pre}`
+
+// runToHTML renders the markdown vault into a temporary directory and
+// turns it into a static site in e.OutputDir. The vault itself is not kept.
+func (e Exporter) runToHTML(ctx context.Context) (Stats, error) {
+	if strategy, err := resolveMergeStrategy(e.MergeStrategy); err != nil {
+		return Stats{}, err
+	} else if strategy != mergeOverwrite {
+		return Stats{}, fmt.Errorf("html output cannot be combined with merge strategy %q", strategy)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "anytype-to-obsidian-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.Format = outputFormatMarkdown
+	staged.Atomic = false
+	stats, err := staged.RunContext(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	protectPatterns, err := resolveProtectPatterns(e.Protect)
+	if err != nil {
+		return Stats{}, err
+	}
+	site := &vaultFiles{root: e.OutputDir, protect: protectPatterns}
+	if err := writeHTMLSite(tmpDir, e.OutputDir, e.HTMLEmbedImages, site); err != nil {
+		return Stats{}, fmt.Errorf("write html: %w", err)
+	}
+	// The report only exists in the discarded vault.
+	stats.ReportPath = ""
+	return stats, nil
+}
+
+// writeHTMLSite renders every note of the vault in vaultDir to a standalone
+// page in siteDir, next to an index.html listing them. Attachments are
+// copied unless vaultDir and siteDir are the same directory; .base files
+// and the Excalidraw drawings have no HTML counterpart and are skipped.
+// Protected paths of site are left alone.
+func writeHTMLSite(vaultDir string, siteDir string, embedImages bool, site *vaultFiles) error {
+	vault, err := indexVault(vaultDir)
+	if err != nil {
+		return err
+	}
+	var notes []string
+	var assets []string
+	for _, rel := range vault.files {
+		switch {
+		case strings.HasSuffix(rel, ".excalidraw.md"), path.Ext(rel) == ".base", path.Ext(rel) == ".html":
+		case path.Ext(rel) == ".md":
+			notes = append(notes, rel)
+		default:
+			assets = append(assets, rel)
+		}
+	}
+
+	sameDir := filepath.Clean(vaultDir) == filepath.Clean(siteDir)
+	if !sameDir {
+		for _, rel := range assets {
+			if site.skip(filepath.Join(siteDir, filepath.FromSlash(rel))) {
+				continue
+			}
+			if err := copyVaultFile(filepath.Join(vaultDir, filepath.FromSlash(rel)), filepath.Join(siteDir, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, rel := range notes {
+		content, err := os.ReadFile(filepath.Join(vaultDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		links := htmlLinks{source: rel, vaultDir: vaultDir, vault: vault, embedImages: embedImages}
+		page := renderHTMLPage(rel, string(content), links)
+		target := filepath.Join(siteDir, filepath.FromSlash(strings.TrimSuffix(rel, ".md")+".html"))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := site.writeFile(target, []byte(page)); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(siteDir, 0o755); err != nil {
+		return err
+	}
+	return site.writeFile(filepath.Join(siteDir, "index.html"), []byte(renderHTMLIndex(notes)))
+}
+
+func copyVaultFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func writeHTMLDocument(b *strings.Builder, title string, indexHref string) {
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<style>\n" + htmlPageStyle + "\n</style>\n</head>\n<body>\n")
+	if indexHref != "" {
+		b.WriteString("<nav><a href=\"" + html.EscapeString(indexHref) + "\">Index</a></nav>\n")
+	}
+}
+
+func renderHTMLPage(rel string, markdown string, links htmlLinks) string {
+	title := strings.TrimSuffix(path.Base(rel), ".md")
+	frontmatter, body := splitMarkdownFrontmatter(markdown)
+
+	var b strings.Builder
+	writeHTMLDocument(&b, title, relativePathTarget(rel, "index.html"))
+	b.WriteString("<main>\n")
+	// Notes usually open with their own title heading.
+	if !strings.HasPrefix(strings.TrimLeft(body, "\n"), "# ") {
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	}
+	b.WriteString(renderHTMLProperties(frontmatter, links))
+	b.WriteString(renderMarkdownHTML(body, links))
+	b.WriteString("</main>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// renderHTMLIndex lists every page, grouped by the folder it lives in.
+func renderHTMLIndex(notes []string) string {
+	var b strings.Builder
+	writeHTMLDocument(&b, "Index", "")
+	b.WriteString("<main>\n<h1>Index</h1>\n")
+	dir := "\x00"
+	for _, rel := range notes {
+		if d := path.Dir(rel); d != dir {
+			if dir != "\x00" {
+				b.WriteString("</ul>\n")
+			}
+			dir = d
+			if d != "." {
+				b.WriteString("<h2>" + html.EscapeString(d) + "</h2>\n")
+			}
+			b.WriteString("<ul>\n")
+		}
+		href := htmlEscapeURLPath(strings.TrimSuffix(rel, ".md") + ".html")
+		b.WriteString("<li><a href=\"" + html.EscapeString(href) + "\">" + html.EscapeString(strings.TrimSuffix(path.Base(rel), ".md")) + "</a></li>\n")
+	}
+	if dir != "\x00" {
+		b.WriteString("</ul>\n")
+	}
+	b.WriteString("</main>\n</body>\n</html>\n")
+	return b.String()
+}
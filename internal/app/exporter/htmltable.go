@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	complexTablesMarkdown = "markdown"
+	complexTablesHTML     = "html"
+
+	// complexTableMaxColumns is the widest table still written as markdown
+	// under -complex-tables=html.
+	complexTableMaxColumns = 8
+)
+
+func resolveComplexTables(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return complexTablesMarkdown, nil
+	case complexTablesMarkdown, complexTablesHTML:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid complex tables mode %q: expected markdown or html", mode)
+	}
+}
+
+// isComplexTable reports whether a markdown table would lose part of t:
+// merged cells, cells holding several blocks or lines, or more columns than
+// fit on a line.
+func isComplexTable(ctx bodyContext, t anytypeTable) bool {
+	if len(t.spans) > 0 || t.cols > complexTableMaxColumns {
+		return true
+	}
+	for _, row := range t.rows {
+		for _, cellID := range row.cells {
+			if cellID != "" && strings.Contains(renderTableCell(ctx, cellID, false), "\n") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderHTMLTableBlock writes t as an HTML table that keeps merged cells
+// and the blocks of each cell. Header rows use <th>, the first one in
+// <thead>. The table ends with a blank line so markdown after it is not
+// read as part of the HTML block.
+func renderHTMLTableBlock(ctx bodyContext, t anytypeTable) string {
+	var b strings.Builder
+	writeRow := func(r int, header bool) {
+		tag := "td"
+		if header {
+			tag = "th"
+		}
+		b.WriteString("<tr>")
+		for c, cellID := range t.rows[r].cells {
+			if _, covered := t.covered[[2]int{r, c}]; covered {
+				continue
+			}
+			b.WriteString("<" + tag)
+			if span, ok := t.spans[[2]int{r, c}]; ok {
+				if rowSpan := min(span[0], len(t.rows)-r); rowSpan > 1 {
+					b.WriteString(` rowspan="` + strconv.Itoa(rowSpan) + `"`)
+				}
+				if colSpan := min(span[1], t.cols-c); colSpan > 1 {
+					b.WriteString(` colspan="` + strconv.Itoa(colSpan) + `"`)
+				}
+			}
+			switch t.aligns[c] {
+			case "AlignCenter":
+				b.WriteString(` style="text-align: center"`)
+			case "AlignRight":
+				b.WriteString(` style="text-align: right"`)
+			}
+			b.WriteString(">")
+			if cellID != "" {
+				b.WriteString(renderTableCell(ctx, cellID, true))
+			}
+			b.WriteString("</" + tag + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("<table>\n")
+	first := 0
+	if t.headerRow() {
+		b.WriteString("<thead>\n")
+		writeRow(0, true)
+		b.WriteString("</thead>\n")
+		first = 1
+	}
+	if first < len(t.rows) {
+		b.WriteString("<tbody>\n")
+		for r := first; r < len(t.rows); r++ {
+			writeRow(r, t.rows[r].header)
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n\n")
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	iconStyleProperty = "property"
+	iconStyleHeading  = "heading"
+	iconStyleFilename = "filename"
+)
+
+func resolveIconStyle(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return iconStyleProperty, nil
+	case iconStyleProperty, iconStyleHeading, iconStyleFilename:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid icon style %q: expected property, heading, or filename", mode)
+	}
+}
+
+// objectIconEmoji returns the emoji icon of obj, or "" when it has none or
+// uses an image icon instead.
+func objectIconEmoji(obj objectInfo) string {
+	if strings.TrimSpace(asString(obj.Details["iconImage"])) != "" {
+		return ""
+	}
+	return strings.TrimSpace(asString(obj.Details["iconEmoji"]))
+}
+
+// prependIconHeading starts body with "# <emoji> <title>" for the heading
+// icon style. Notes without an emoji icon are returned unchanged.
+func prependIconHeading(obj objectInfo, body string) string {
+	emoji := objectIconEmoji(obj)
+	if emoji == "" {
+		return body
+	}
+	heading := "# " + emoji
+	if title := inferObjectTitle(obj); title != "" {
+		heading += " " + title
+	}
+	if strings.TrimSpace(body) == "" {
+		return heading + "\n"
+	}
+	return heading + "\n\n" + body
+}
@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+const icsFileName = "anytype-events.ics"
+
+type icsEvent struct {
+	uid     string
+	summary string
+	start   time.Time
+	stamp   time.Time
+	note    string
+}
+
+// isEventDateKey reports whether a date relation is one the user set, such
+// as a due or event date, rather than one Anytype keeps for itself.
+func isEventDateKey(key string) bool {
+	if _, dynamic := dynamicPropertyKeys[key]; dynamic {
+		return false
+	}
+	if _, hidden := defaultHiddenPropertyKeys[key]; hidden {
+		return false
+	}
+	for _, keys := range [][]string{createdDateKeys, changedDateKeys, modifiedDateKeys} {
+		for _, k := range keys {
+			if k == key {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collectEvents returns one event per user date relation set on an
+// exported note, named after the note and the relation.
+func collectEvents(objects []objectInfo, notes map[string]string, relations map[string]relationDef) []icsEvent {
+	var events []icsEvent
+	for _, obj := range objects {
+		notePath := notes[obj.ID]
+		if notePath == "" {
+			continue
+		}
+		keys := make([]string, 0, len(obj.Details))
+		for k := range obj.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		_, modified, hasModified := anytypeTimestamps(obj.Details)
+		for _, k := range keys {
+			rel, ok := relations[k]
+			if !ok || rel.Format != anytypedomain.RelationFormatDate || !isEventDateKey(k) {
+				continue
+			}
+			start, ok := parseAnytypeTimestamp(obj.Details[k])
+			if !ok || start.Unix() == 0 {
+				continue
+			}
+			name := strings.TrimSpace(rel.Name)
+			if name == "" {
+				name = k
+			}
+			stamp := start
+			if hasModified {
+				stamp = modified
+			}
+			events = append(events, icsEvent{
+				uid:     obj.ID + "-" + k + "@anytype",
+				summary: inferObjectTitle(obj) + " (" + name + ")",
+				start:   start,
+				stamp:   stamp,
+				note:    notePath,
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].start.Before(events[j].start) })
+	return events
+}
+
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold breaks a content line into 75-octet pieces as RFC 5545 requires,
+// without splitting a UTF-8 sequence.
+func icsFold(line string) string {
+	var b strings.Builder
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+// renderICS writes events as a calendar. Dates without a time of day become
+// all-day events.
+func renderICS(events []icsEvent) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(icsFold(s))
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//anytype-to-obsidian//EN")
+	line("CALSCALE:GREGORIAN")
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + icsEscape(event.uid))
+		line("DTSTAMP:" + event.stamp.UTC().Format("20060102T150405Z"))
+		start := event.start.UTC()
+		if start.Hour() == 0 && start.Minute() == 0 && start.Second() == 0 {
+			line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			line("DTSTART:" + start.Format("20060102T150405Z"))
+		}
+		line("SUMMARY:" + icsEscape(event.summary))
+		line("DESCRIPTION:" + icsEscape("Obsidian note: "+event.note))
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+func writeICS(outputDir string, events []icsEvent, vault *vaultFiles) error {
+	return vault.writeFile(filepath.Join(outputDir, icsFileName), []byte(renderICS(events)))
+}
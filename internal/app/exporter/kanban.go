@@ -0,0 +1,212 @@
+package exporter
+
+import (
+	"sort"
+	"strings"
+)
+
+type kanbanLane struct {
+	name  string
+	cards []string
+}
+
+type kanbanBoard struct {
+	viewName string
+	lanes    []kanbanLane
+}
+
+// buildKanbanBoards turns the Board views of a set or collection into lanes
+// of note paths for the Obsidian Kanban plugin. Members are the exported
+// notes whose type is in setOf, or that were created in the collection, plus
+// any object the view ordered by hand. View filters are not evaluated.
+func buildKanbanBoards(base objectInfo, objects []objectInfo, notes map[string]string, relations map[string]relationDef, optionNamesByID map[string]string, objectNamesByID map[string]string, fileObjects map[string]string) []kanbanBoard {
+	var boards []kanbanBoard
+	for _, b := range base.Blocks {
+		if len(b.Dataview) == 0 {
+			continue
+		}
+		targetID := strings.TrimSpace(asString(anyMapGet(b.Dataview, "TargetObjectId", "targetObjectId")))
+		if targetID != "" && targetID != base.ID {
+			continue
+		}
+		for _, viewRaw := range asAnySlice(anyMapGet(b.Dataview, "views", "Views")) {
+			viewMap, ok := viewRaw.(map[string]any)
+			if !ok {
+				continue
+			}
+			viewType := strings.ToLower(strings.TrimSpace(asString(anyMapGet(viewMap, "type", "Type"))))
+			groupKey := strings.TrimSpace(asString(anyMapGet(viewMap, "groupRelationKey", "GroupRelationKey")))
+			if (viewType != "board" && viewType != "kanban") || groupKey == "" {
+				continue
+			}
+			viewID := strings.TrimSpace(asString(anyMapGet(viewMap, "id", "Id")))
+			name := strings.TrimSpace(asString(anyMapGet(viewMap, "name", "Name")))
+			if name == "" {
+				name = "Board"
+			}
+			lanes := buildKanbanLanes(base, objects, b.Dataview, viewID, groupKey, notes, relations, optionNamesByID, objectNamesByID, fileObjects)
+			if len(lanes) == 0 {
+				continue
+			}
+			boards = append(boards, kanbanBoard{viewName: name, lanes: lanes})
+		}
+	}
+	return boards
+}
+
+func buildKanbanLanes(base objectInfo, objects []objectInfo, dataview map[string]any, viewID string, groupKey string, notes map[string]string, relations map[string]relationDef, optionNamesByID map[string]string, objectNamesByID map[string]string, fileObjects map[string]string) []kanbanLane {
+	groupName := func(id string) string {
+		return resolveDataviewGroupName(groupKey, id, relations, optionNamesByID, notes, objectNamesByID, fileObjects)
+	}
+
+	// Lane order: the view's saved group order, then groups that have a
+	// manual card order, then the rest alphabetically.
+	var laneOrder []string
+	laneSeen := map[string]struct{}{}
+	addLane := func(name string) {
+		if _, ok := laneSeen[name]; ok {
+			return
+		}
+		laneSeen[name] = struct{}{}
+		laneOrder = append(laneOrder, name)
+	}
+	for _, orderRaw := range asAnySlice(anyMapGet(dataview, "groupOrders", "GroupOrders")) {
+		orderMap, ok := orderRaw.(map[string]any)
+		if !ok || strings.TrimSpace(asString(anyMapGet(orderMap, "viewId", "ViewId"))) != viewID {
+			continue
+		}
+		for _, groupRaw := range asAnySlice(anyMapGet(orderMap, "viewGroups", "ViewGroups")) {
+			groupMap, ok := groupRaw.(map[string]any)
+			if !ok || asBool(anyMapGet(groupMap, "isHidden", "IsHidden")) {
+				continue
+			}
+			groupID := strings.TrimSpace(asString(anyMapGet(groupMap, "groupId", "GroupId")))
+			if groupID != "" && groupID != "empty" {
+				addLane(groupName(groupID))
+			}
+		}
+	}
+
+	cardRank := map[string]int{}
+	members := map[string]struct{}{}
+	for _, orderRaw := range asAnySlice(anyMapGet(dataview, "objectOrders", "ObjectOrders")) {
+		orderMap, ok := orderRaw.(map[string]any)
+		if !ok || strings.TrimSpace(asString(anyMapGet(orderMap, "viewId", "ViewId"))) != viewID {
+			continue
+		}
+		groupID := strings.TrimSpace(asString(anyMapGet(orderMap, "groupId", "GroupId")))
+		if groupID != "" && groupID != "empty" {
+			addLane(groupName(groupID))
+		}
+		for i, id := range anyToStringSlice(anyMapGet(orderMap, "objectIds", "ObjectIds")) {
+			members[id] = struct{}{}
+			if _, ok := cardRank[id]; !ok {
+				cardRank[id] = i
+			}
+		}
+	}
+
+	setOf := map[string]struct{}{}
+	for _, id := range anyToStringSlice(base.Details["setOf"]) {
+		setOf[id] = struct{}{}
+	}
+	collection := isCollectionObject(base)
+
+	emptyLane := "No " + groupKey
+	if rel, ok := relations[groupKey]; ok && strings.TrimSpace(rel.Name) != "" {
+		emptyLane = "No " + strings.TrimSpace(rel.Name)
+	}
+	cardsByLane := map[string][]string{}
+	rankByPath := map[string]int{}
+	for _, obj := range objects {
+		notePath := notes[obj.ID]
+		if notePath == "" || obj.ID == base.ID {
+			continue
+		}
+		_, member := members[obj.ID]
+		if !member && !isKanbanMember(obj, base.ID, setOf, collection) {
+			continue
+		}
+		if rank, ok := cardRank[obj.ID]; ok {
+			rankByPath[notePath] = rank
+		}
+		var laneNames []string
+		for _, value := range anyToStringSlice(obj.Details[groupKey]) {
+			if name := strings.TrimSpace(groupName(value)); name != "" {
+				laneNames = append(laneNames, name)
+			}
+		}
+		if len(laneNames) == 0 {
+			laneNames = []string{emptyLane}
+		}
+		for _, name := range laneNames {
+			cardsByLane[name] = append(cardsByLane[name], notePath)
+		}
+	}
+	if len(cardsByLane) == 0 {
+		return nil
+	}
+
+	var rest []string
+	for name := range cardsByLane {
+		if _, ok := laneSeen[name]; !ok && name != emptyLane {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	laneOrder = append(laneOrder, rest...)
+	if _, ok := cardsByLane[emptyLane]; ok {
+		addLane(emptyLane)
+	}
+
+	lanes := make([]kanbanLane, 0, len(laneOrder))
+	for _, name := range laneOrder {
+		cards := cardsByLane[name]
+		sort.SliceStable(cards, func(i, j int) bool {
+			ri, iRanked := rankByPath[cards[i]]
+			rj, jRanked := rankByPath[cards[j]]
+			switch {
+			case iRanked && jRanked:
+				return ri < rj
+			case iRanked != jRanked:
+				return iRanked
+			default:
+				return cards[i] < cards[j]
+			}
+		})
+		lanes = append(lanes, kanbanLane{name: name, cards: cards})
+	}
+	return lanes
+}
+
+func isKanbanMember(obj objectInfo, baseID string, setOf map[string]struct{}, collection bool) bool {
+	if collection {
+		for _, id := range anyToStringSlice(obj.Details["createdInContext"]) {
+			if id == baseID {
+				return true
+			}
+		}
+	}
+	for _, typeID := range anyToStringSlice(obj.Details["type"]) {
+		if _, ok := setOf[typeID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderKanbanBoard writes a board in the Obsidian Kanban plugin format:
+// one "## Lane" heading per group with a task-list card per note.
+func renderKanbanBoard(board kanbanBoard, boardRelPath string) string {
+	var b strings.Builder
+	b.WriteString("---\n\nkanban-plugin: basic\n\n---\n\n")
+	for _, lane := range board.lanes {
+		b.WriteString("## " + lane.name + "\n\n")
+		for _, card := range lane.cards {
+			b.WriteString("- [ ] [[" + relativeWikiTarget(boardRelPath, card) + "]]\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n%% kanban:settings\n```\n{\"kanban-plugin\":\"basic\"}\n```\n%%\n")
+	return b.String()
+}
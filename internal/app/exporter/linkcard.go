@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+// Anytype LinkBlock enums may be exported either by name or by number.
+func linkCardStyle(link *anytypedomain.LinkBlock) string {
+	switch v := link.CardStyle.(type) {
+	case string:
+		return v
+	case float64:
+		return [...]string{"Text", "Card", "Inline"}[min(max(int(v), 0), 2)]
+	default:
+		return "Text"
+	}
+}
+
+func linkDescriptionMode(link *anytypedomain.LinkBlock) string {
+	switch v := link.Description.(type) {
+	case string:
+		return v
+	case float64:
+		return [...]string{"None", "Added", "Content"}[min(max(int(v), 0), 2)]
+	default:
+		return "None"
+	}
+}
+
+func renderLinkCard(ctx bodyContext, link *anytypedomain.LinkBlock, notePath string) (string, bool) {
+	if !ctx.linkCards || linkCardStyle(link) != "Card" {
+		return "", false
+	}
+	target, ok := ctx.objects[link.TargetBlockID]
+	if !ok {
+		return "", false
+	}
+
+	lines := []string{"> [!info] [[" + relativeWikiTarget(ctx.sourceNotePath, notePath) + "]]"}
+	var description string
+	switch linkDescriptionMode(link) {
+	case "Added":
+		description = asString(target.Details["description"])
+	case "Content":
+		description = asString(target.Details["snippet"])
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		lines = append(lines, prefixLines(description, "> "))
+	}
+	for _, rel := range link.Relations {
+		if rel != "cover" {
+			continue
+		}
+		coverID := strings.TrimSpace(asString(target.Details["coverId"]))
+		if cover := ctx.fileObjects[coverID]; cover != "" {
+			lines = append(lines, "> ![]("+relativePathTarget(ctx.sourceNotePath, cover)+")")
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", true
+}
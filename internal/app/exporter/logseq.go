@@ -0,0 +1,477 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	flavorObsidian = "obsidian"
+	flavorLogseq   = "logseq"
+)
+
+func resolveFlavor(flavor string) (string, error) {
+	flavor = strings.TrimSpace(strings.ToLower(flavor))
+	switch flavor {
+	case "", flavorObsidian:
+		return flavorObsidian, nil
+	case flavorLogseq, flavorPortable:
+		return flavor, nil
+	default:
+		return "", fmt.Errorf("invalid flavor %q: expected obsidian, logseq, or portable", flavor)
+	}
+}
+
+// logseqConfig pins the journal title format so that date links written as
+// [[2006-01-02]] resolve to the journal pages.
+const logseqConfig = `{:preferred-format :markdown
+ :file/name-format :triple-lowbar
+ :journal/page-title-format "yyyy-MM-dd"
+ :journal/file-name-format "yyyy_MM_dd"}
+`
+
+var (
+	logseqMarkdownLinkPattern = regexp.MustCompile(`(!?)\[((?:[^\]\n\\]|\\.)*)\]\(([^)\n]+)\)`)
+	logseqDatePattern         = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})`)
+)
+
+// runToLogseq renders the Obsidian vault into a temporary directory and
+// rewrites it as a Logseq graph in e.OutputDir.
+func (e Exporter) runToLogseq(ctx context.Context) (Stats, error) {
+	if strategy, err := resolveMergeStrategy(e.MergeStrategy); err != nil {
+		return Stats{}, err
+	} else if strategy != mergeOverwrite {
+		return Stats{}, fmt.Errorf("logseq output cannot be combined with merge strategy %q", strategy)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "anytype-to-obsidian-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.Flavor = flavorObsidian
+	staged.Atomic = false
+	stats, err := staged.RunContext(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := writeLogseqGraph(tmpDir, e.OutputDir); err != nil {
+		return Stats{}, fmt.Errorf("write logseq graph: %w", err)
+	}
+	// The report only exists in the discarded vault.
+	stats.ReportPath = ""
+	return stats, nil
+}
+
+// logseqGraph maps the files of an Obsidian vault to their place in a
+// Logseq graph: notes become pages/<name>.md, date objects
+// journals/yyyy_MM_dd.md and attachments assets/<name>.
+type logseqGraph struct {
+	vault      vaultIndex
+	pageNames  map[string]string
+	pageFiles  map[string]string
+	assetFiles map[string]string
+}
+
+// writeLogseqGraph converts the vault in vaultDir into a Logseq graph in
+// graphDir. Page names are note basenames, so notes with the same name in
+// different folders get a numbered suffix; bases have no Logseq
+// counterpart and are dropped.
+func writeLogseqGraph(vaultDir string, graphDir string) error {
+	journalDates, err := logseqJournalDates(vaultDir)
+	if err != nil {
+		return err
+	}
+
+	vault, err := indexVault(vaultDir)
+	if err != nil {
+		return err
+	}
+	graph := logseqGraph{
+		vault:      vault,
+		pageNames:  map[string]string{},
+		pageFiles:  map[string]string{},
+		assetFiles: map[string]string{},
+	}
+	var notes, assets []string
+	for _, rel := range vault.files {
+		switch {
+		case path.Ext(rel) == ".base":
+		case path.Ext(rel) == ".md" && !strings.HasSuffix(rel, ".excalidraw.md"):
+			notes = append(notes, rel)
+		default:
+			assets = append(assets, rel)
+		}
+	}
+
+	usedPages := map[string]int{}
+	for _, rel := range notes {
+		if date, ok := journalDates[rel]; ok {
+			graph.pageNames[rel] = date
+			graph.pageFiles[rel] = path.Join("journals", strings.ReplaceAll(date, "-", "_")+".md")
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(rel), ".md")
+		key := strings.ToLower(name)
+		n := usedPages[key]
+		usedPages[key] = n + 1
+		if n > 0 {
+			name = name + "-" + strconv.Itoa(n+1)
+		}
+		graph.pageNames[rel] = name
+		graph.pageFiles[rel] = path.Join("pages", strings.ReplaceAll(name, "/", "___")+".md")
+	}
+	usedAssets := map[string]int{}
+	for _, rel := range assets {
+		name := path.Base(rel)
+		key := strings.ToLower(name)
+		n := usedAssets[key]
+		usedAssets[key] = n + 1
+		if n > 0 {
+			ext := path.Ext(name)
+			name = strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(n+1) + ext
+		}
+		graph.assetFiles[rel] = path.Join("assets", name)
+	}
+
+	for _, rel := range assets {
+		if err := copyVaultFile(filepath.Join(vaultDir, filepath.FromSlash(rel)), filepath.Join(graphDir, filepath.FromSlash(graph.assetFiles[rel]))); err != nil {
+			return err
+		}
+	}
+	for _, rel := range notes {
+		content, err := os.ReadFile(filepath.Join(vaultDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(graphDir, filepath.FromSlash(graph.pageFiles[rel]))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(graph.renderPage(rel, string(content))), 0o644); err != nil {
+			return err
+		}
+	}
+
+	configPath := filepath.Join(graphDir, "logseq", "config.edn")
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(configPath, []byte(logseqConfig), 0o644)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// logseqJournalDates finds the notes of Anytype date objects through the
+// ids recorded in _anytype/index.json and returns their dates.
+func logseqJournalDates(vaultDir string) (map[string]string, error) {
+	dates := map[string]string{}
+	raw, err := os.ReadFile(filepath.Join(vaultDir, "_anytype", "index.json"))
+	if os.IsNotExist(err) {
+		return dates, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var idx indexFile
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, fmt.Errorf("decode index: %w", err)
+	}
+	for id, rel := range idx.Notes {
+		if m := logseqDatePattern.FindString(linkTargetDate(id)); m != "" && strings.HasSuffix(rel, ".md") {
+			dates[rel] = m
+		}
+	}
+	return dates, nil
+}
+
+func (g logseqGraph) renderPage(rel string, markdown string) string {
+	frontmatter, body := splitMarkdownFrontmatter(markdown)
+	var b strings.Builder
+	b.WriteString(g.renderProperties(rel, frontmatter))
+	blocks := g.renderBlocks(rel, body)
+	if b.Len() > 0 && blocks != "" {
+		b.WriteString("\n")
+	}
+	b.WriteString(blocks)
+	return b.String()
+}
+
+// renderProperties turns the frontmatter into the key:: value lines Logseq
+// reads as page properties.
+func (g logseqGraph) renderProperties(rel string, frontmatter string) string {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(frontmatter), &doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return ""
+	}
+	mapping := doc.Content[0]
+	var b strings.Builder
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := strings.ToLower(strings.Join(strings.Fields(mapping.Content[i].Value), "-"))
+		if key == "aliases" {
+			key = "alias"
+		}
+		value := g.renderPropertyValue(rel, mapping.Content[i+1])
+		if key == "" || value == "" {
+			continue
+		}
+		b.WriteString(key + ":: " + value + "\n")
+	}
+	return b.String()
+}
+
+func (g logseqGraph) renderPropertyValue(rel string, node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return ""
+		}
+		return g.convertInline(rel, strings.Join(strings.Fields(node.Value), " "))
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if value := g.renderPropertyValue(rel, item); value != "" {
+				items = append(items, value)
+			}
+		}
+		return strings.Join(items, ", ")
+	default:
+		node.Style = yaml.FlowStyle
+		out, err := yaml.Marshal(node)
+		if err != nil {
+			return ""
+		}
+		return strings.Join(strings.Fields(string(out)), " ")
+	}
+}
+
+// renderBlocks rewrites a note body as a Logseq outline: every paragraph,
+// code block, table or quote becomes one bullet, list items keep their
+// nesting, and content below a heading is indented under it.
+func (g logseqGraph) renderBlocks(rel string, body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	var headings []int
+	emit := func(depth int, block []string, convert bool) {
+		indent := strings.Repeat("\t", depth)
+		for i, line := range block {
+			if convert {
+				line = g.convertInline(rel, line)
+			}
+			if i == 0 {
+				b.WriteString(indent + "- " + line + "\n")
+			} else {
+				b.WriteString(indent + "  " + line + "\n")
+			}
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		depth := len(headings)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") || trimmed == "$$":
+			closing := trimmed[:min(3, len(trimmed))]
+			if trimmed == "$$" {
+				closing = "$$"
+			}
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), closing) {
+				j++
+			}
+			emit(depth, lines[i:min(j+1, len(lines))], false)
+			i = j
+		case strings.HasPrefix(trimmed, "%%"):
+			if len(trimmed) > 2 && strings.HasSuffix(trimmed, "%%") {
+				continue
+			}
+			for i+1 < len(lines) && !strings.HasSuffix(strings.TrimSpace(lines[i+1]), "%%") {
+				i++
+			}
+			i++
+		case markdownHeadingPattern.MatchString(trimmed):
+			level := len(markdownHeadingPattern.FindStringSubmatch(trimmed)[1])
+			for len(headings) > 0 && headings[len(headings)-1] >= level {
+				headings = headings[:len(headings)-1]
+			}
+			emit(len(headings), []string{trimmed}, true)
+			headings = append(headings, level)
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimSpace(lines[i]))
+			}
+			i--
+			emit(depth, logseqQuote(quoted), true)
+		case markdownListItemPattern.MatchString(line):
+			for _, item := range parseMarkdownListItems(lines[i:logseqListEnd(lines, i)]) {
+				text := item.text
+				if item.task {
+					text = "TODO " + text
+					if item.checked {
+						text = "DONE " + item.text
+					}
+				}
+				block := append([]string{text}, item.extra...)
+				if item.ordered {
+					block = append(block, "logseq.order-list-type:: number")
+				}
+				emit(depth+item.depth, block, true)
+			}
+			i = logseqListEnd(lines, i) - 1
+		case strings.HasPrefix(trimmed, "|"), strings.HasPrefix(trimmed, "<"):
+			j := i
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "" {
+				j++
+			}
+			emit(depth, lines[i:j], true)
+			i = j - 1
+		case strings.HasPrefix(trimmed, "^") && !strings.Contains(trimmed, " "):
+			// Obsidian block ids; Logseq block refs need uuids instead.
+		default:
+			j := i
+			for j < len(lines) && strings.TrimSpace(lines[j]) != "" && (j == i || !logseqStartsBlock(lines[j])) {
+				j++
+			}
+			block := make([]string, 0, j-i)
+			for _, l := range lines[i:j] {
+				block = append(block, strings.TrimSpace(l))
+			}
+			emit(depth, block, true)
+			i = j - 1
+		}
+	}
+	return b.String()
+}
+
+func logseqStartsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return markdownHeadingPattern.MatchString(trimmed) || markdownListItemPattern.MatchString(line) ||
+		strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") || trimmed == "$$" ||
+		strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "%%")
+}
+
+// logseqListEnd returns the index after the list that starts at lines[start].
+func logseqListEnd(lines []string, start int) int {
+	end := start + 1
+	for end < len(lines) {
+		next := lines[end]
+		if markdownListItemPattern.MatchString(next) || (strings.TrimSpace(next) != "" && (strings.HasPrefix(next, "\t") || strings.HasPrefix(next, "  "))) {
+			end++
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// logseqQuote keeps quotes as they are and turns callouts into the
+// matching #+BEGIN_ block Logseq renders as an admonition.
+func logseqQuote(lines []string) []string {
+	m := calloutPattern.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(lines[0], ">")))
+	if m == nil {
+		return lines
+	}
+	kind := strings.ToUpper(m[1])
+	switch kind {
+	case "NOTE", "TIP", "IMPORTANT", "CAUTION", "WARNING":
+	case "INFO", "TODO", "ABSTRACT", "SUMMARY", "EXAMPLE":
+		kind = "NOTE"
+	case "DANGER", "ERROR", "BUG", "FAILURE":
+		kind = "CAUTION"
+	default:
+		kind = "QUOTE"
+	}
+	out := []string{"#+BEGIN_" + kind}
+	if title := strings.TrimSpace(m[2]); title != "" {
+		out = append(out, "**"+title+"**")
+	}
+	for _, line := range lines[1:] {
+		out = append(out, strings.TrimPrefix(strings.TrimPrefix(line, ">"), " "))
+	}
+	return append(out, "#+END_"+kind)
+}
+
+// convertInline rewrites the links of one line for the graph layout and
+// drops Obsidian block ids.
+func (g logseqGraph) convertInline(rel string, line string) string {
+	line, _ = splitBlockAnchorSuffix(line)
+	line = wikiLinkPattern.ReplaceAllStringFunc(line, func(match string) string {
+		embed := strings.HasPrefix(match, "!")
+		inner := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(match, "!"), "[["), "]]")
+		target, label, _ := strings.Cut(inner, "|")
+		target = strings.ReplaceAll(target, `\`, "")
+		label = strings.ReplaceAll(label, `\`, "")
+		target, _, _ = strings.Cut(target, "#")
+		if strings.TrimSpace(target) == "" {
+			return label
+		}
+		return g.link(rel, target, label, embed)
+	})
+	return logseqMarkdownLinkPattern.ReplaceAllStringFunc(line, func(match string) string {
+		m := logseqMarkdownLinkPattern.FindStringSubmatch(match)
+		target := strings.TrimSpace(m[3])
+		// [label]([[Page]]) is a page link written by the pass above.
+		if urlSchemePattern.MatchString(target) || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "[[") {
+			return match
+		}
+		target, _, _ = strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		return g.link(rel, target, m[2], m[1] == "!")
+	})
+}
+
+func (g logseqGraph) link(rel string, target string, label string, embed bool) string {
+	resolved, ok := g.vault.resolve(rel, strings.TrimSpace(target))
+	if !ok {
+		name := strings.TrimSuffix(path.Base(strings.TrimSpace(target)), ".md")
+		return logseqPageLink(name, label)
+	}
+	if page, ok := g.pageNames[resolved]; ok {
+		if embed {
+			return "{{embed [[" + page + "]]}}"
+		}
+		return logseqPageLink(page, label)
+	}
+	if asset, ok := g.assetFiles[resolved]; ok {
+		if label == "" {
+			label = path.Base(asset)
+		}
+		prefix := ""
+		if embed {
+			prefix = "!"
+		}
+		return prefix + "[" + label + "](../" + htmlEscapeURLPath(asset) + ")"
+	}
+	// Bases have no Logseq counterpart.
+	if label == "" {
+		label = strings.TrimSuffix(path.Base(resolved), path.Ext(resolved))
+	}
+	return label
+}
+
+func logseqPageLink(page string, label string) string {
+	if label == "" || label == page {
+		return "[[" + page + "]]"
+	}
+	return "[" + label + "]([[" + page + "]])"
+}
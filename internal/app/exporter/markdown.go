@@ -6,14 +6,17 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
 )
@@ -21,7 +24,45 @@ import (
 const iconizeAnytypePackName = "anytype"
 const iconizeAnytypePackPrefix = "An"
 
-func renderBody(obj objectInfo, objects map[string]objectInfo, notes map[string]string, sourceNotePath string, fileObjects map[string]string, excalidrawEmbeds map[string]string) string {
+// bodyContext carries the lookups block renderers need. byID and rootID are
+// filled in by renderBody for the object being rendered.
+type bodyContext struct {
+	objects          map[string]objectInfo
+	notes            map[string]string
+	sourceNotePath   string
+	fileObjects      map[string]string
+	excalidrawEmbeds map[string]string
+	objectNamesByID  map[string]string
+	columnLayout     string
+	linkCards        bool
+	relationBlocks   string
+	tocMode          string
+	relations        map[string]relationDef
+	optionNamesByID  map[string]string
+	blockAnchors     map[string]struct{}
+	// transcripts holds audio transcripts by file object ID.
+	transcripts map[string]string
+	// calloutTypes extends the callout icon to callout type mapping.
+	calloutTypes map[string]string
+	// toggleHeadings is heading, or callout to fold what a toggle heading
+	// holds into a collapsed callout.
+	toggleHeadings string
+	// warn reports a problem with the note's content; it may be nil.
+	warn func(string)
+	// complexTables is markdown, or html to write tables markdown would
+	// flatten as HTML.
+	complexTables string
+	// unsupportedBlock records a block of a kind no renderer handles so its
+	// content can be kept in the raw sidecar; it may be nil.
+	unsupportedBlock func(b block, kind string)
+
+	byID      map[string]block
+	rootID    string
+	details   map[string]any
+	listDepth int
+}
+
+func renderBody(obj objectInfo, ctx bodyContext) string {
 	byID := make(map[string]block, len(obj.Blocks))
 	for _, b := range obj.Blocks {
 		byID[b.ID] = b
@@ -31,35 +72,46 @@ func renderBody(obj objectInfo, objects map[string]objectInfo, notes map[string]
 	if !ok {
 		return ""
 	}
+	ctx.byID = byID
+	ctx.rootID = obj.ID
+	ctx.details = obj.Details
 
 	var buf bytes.Buffer
-	renderChildren(&buf, byID, root.ChildrenID, notes, sourceNotePath, fileObjects, excalidrawEmbeds, 0, obj.ID)
+	renderChildren(&buf, ctx, root.ChildrenID, 0)
 	return strings.TrimLeft(buf.String(), "\n")
 }
 
-func renderChildren(buf *bytes.Buffer, byID map[string]block, children []string, notes map[string]string, sourceNotePath string, fileObjects map[string]string, excalidrawEmbeds map[string]string, depth int, rootID string) {
+func renderChildren(buf *bytes.Buffer, ctx bodyContext, children []string, depth int) {
 	numberedIndex := 0
+	afterListItem := false
 	for _, id := range children {
-		b, ok := byID[id]
+		b, ok := ctx.byID[id]
 		if ok && b.Text != nil && b.Text.Style == "Numbered" {
 			numberedIndex++
 		} else {
 			numberedIndex = 0
 		}
-		renderBlock(buf, byID, id, notes, sourceNotePath, fileObjects, excalidrawEmbeds, depth, rootID, numberedIndex)
+		isText := ok && b.Text != nil
+		// Inside a list item, a paragraph after a sub-item would read as a
+		// lazy continuation of that sub-item without a blank line first.
+		if ctx.listDepth > 0 && afterListItem && isText && !isListStyle(b.Text.Style) && strings.TrimSpace(b.Text.Text) != "" {
+			buf.WriteString("\n")
+		}
+		afterListItem = isText && isListStyle(b.Text.Style)
+		renderBlock(buf, ctx, id, depth, numberedIndex)
 	}
 }
 
-func renderTemplate(tmpl templateInfo, relations map[string]relationDef, objects map[string]objectInfo, notes map[string]string, fileObjects map[string]string, pictureToCover bool) string {
-	keys := collectTemplateRelationKeys(tmpl)
+func renderTemplate(tmpl templateInfo, templateRelPath string, relations map[string]relationDef, optionsByID map[string]string, objectNamesByID map[string]string, objects map[string]objectInfo, notes map[string]string, fileObjects map[string]string, keys propertyKeys, tocMode string) string {
+	relationKeys := collectTemplateRelationKeys(tmpl)
 
 	var buf bytes.Buffer
 	buf.WriteString("---\n")
 
 	used := map[string]struct{}{}
-	for _, raw := range keys {
+	for _, raw := range relationKeys {
 		rel, hasRel := relations[raw]
-		outKey := frontmatterKey(raw, rel, hasRel, pictureToCover)
+		outKey := frontmatterKey(raw, rel, hasRel, keys)
 		if outKey == "" {
 			outKey = raw
 		}
@@ -67,15 +119,84 @@ func renderTemplate(tmpl templateInfo, relations map[string]relationDef, objects
 			continue
 		}
 		used[outKey] = struct{}{}
-		writeYAMLKeyValue(&buf, outKey, nil)
+		writeYAMLKeyValue(&buf, outKey, templateDefaultValue(raw, tmpl.Details, templateRelPath, relations, optionsByID, objectNamesByID, notes, fileObjects, keys))
 	}
 	buf.WriteString("---\n\n")
 
-	body := renderBody(objectInfo{ID: tmpl.ID, Name: tmpl.Name, Details: tmpl.Details, Blocks: tmpl.Blocks}, objects, notes, "", fileObjects, nil)
+	body := renderBody(objectInfo{ID: tmpl.ID, Name: tmpl.Name, Details: tmpl.Details, Blocks: tmpl.Blocks}, bodyContext{
+		objects:         objects,
+		notes:           notes,
+		fileObjects:     fileObjects,
+		objectNamesByID: objectNamesByID,
+		tocMode:         tocMode,
+	})
 	buf.WriteString(body)
 	return buf.String()
 }
 
+func templateDefaultValue(key string, details map[string]any, templateRelPath string, relations map[string]relationDef, optionsByID map[string]string, objectNamesByID map[string]string, notes map[string]string, fileObjects map[string]string, keys propertyKeys) any {
+	value, ok := details[key]
+	if !ok || isEmptyFrontmatterValue(value) {
+		return nil
+	}
+	rel, hasRel := relations[key]
+	converted := convertPropertyValue(key, value, relations, optionsByID, notes, templateRelPath, objectNamesByID, fileObjects, false, false)
+	if keys.isTags(key, rel, hasRel) {
+		converted = sanitizeObsidianTagValue(converted)
+	}
+	return converted
+}
+
+func writeDefaultTemplateMap(outputDir string, templates []templateInfo, typesByID map[string]typeDef, templatePathByID map[string]string, vault *vaultFiles) error {
+	exported := make(map[string]struct{}, len(templates))
+	for _, tmpl := range templates {
+		exported[tmpl.ID] = struct{}{}
+	}
+
+	type typeTemplate struct {
+		typeName     string
+		templatePath string
+	}
+	entries := make([]typeTemplate, 0)
+	for typeID, typeInfo := range typesByID {
+		templateID := strings.TrimSpace(asString(typeInfo.Details["defaultTemplateId"]))
+		if templateID == "" {
+			continue
+		}
+		if _, ok := exported[templateID]; !ok {
+			continue
+		}
+		templatePath := strings.TrimSpace(templatePathByID[templateID])
+		if templatePath == "" {
+			continue
+		}
+		entries = append(entries, typeTemplate{typeName: inferTemplateTypeName(typeID, typesByID), templatePath: templatePath})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].typeName == entries[j].typeName {
+			return entries[i].templatePath < entries[j].templatePath
+		}
+		return entries[i].typeName < entries[j].typeName
+	})
+
+	readmeRelPath := filepath.ToSlash(filepath.Join("templates", "README.md"))
+	var buf bytes.Buffer
+	buf.WriteString("# Default templates\n\n")
+	buf.WriteString("Default Anytype template for each type. Use it with the Templates or Templater plugin when creating a note of that type.\n\n")
+	for _, entry := range entries {
+		buf.WriteString("- " + entry.typeName + ": [[" + relativeWikiTarget(readmeRelPath, entry.templatePath) + "]]\n")
+	}
+
+	readmePath := filepath.Join(outputDir, filepath.FromSlash(readmeRelPath))
+	if err := os.MkdirAll(filepath.Dir(readmePath), 0o755); err != nil {
+		return err
+	}
+	return vault.writeFile(readmePath, buf.Bytes())
+}
+
 func collectTemplateRelationKeys(tmpl templateInfo) []string {
 	byID := make(map[string]block, len(tmpl.Blocks))
 	for _, b := range tmpl.Blocks {
@@ -114,8 +235,12 @@ func collectTemplateRelationKeys(tmpl templateInfo) []string {
 	return ordered
 }
 
-func renderBlock(buf *bytes.Buffer, byID map[string]block, id string, notes map[string]string, sourceNotePath string, fileObjects map[string]string, excalidrawEmbeds map[string]string, depth int, rootID string, numberedIndex int) {
-	b, ok := byID[id]
+// renderBlock writes block id and its children. A renderer registered with
+// RegisterBlockRenderer gets the block first, then the built-in renderer of
+// its kind; blocks of unknown kinds leave a comment and have their children
+// rendered.
+func renderBlock(buf *bytes.Buffer, ctx bodyContext, id string, depth int, numberedIndex int) {
+	b, ok := ctx.byID[id]
 	if !ok {
 		return
 	}
@@ -124,83 +249,68 @@ func renderBlock(buf *bytes.Buffer, byID map[string]block, id string, notes map[
 		return
 	}
 
-	if b.Text != nil && (b.Text.Style == "Callout" || b.Text.Style == "Toggle") {
-		renderCalloutBlock(buf, byID, b, notes, sourceNotePath, fileObjects, excalidrawEmbeds, depth, rootID)
+	kind := blockKind(b)
+	if render, ok := customBlockRenderer(kind); ok && renderCustomBlock(buf, ctx, b, kind, depth, render) {
 		return
 	}
-
-	if b.Text != nil {
-		line := renderTextBlock(*b.Text, depth, b.Fields, notes, sourceNotePath, numberedIndex)
-		if line != "" {
-			buf.WriteString(line)
-			if !strings.HasSuffix(line, "\n") {
-				buf.WriteString("\n")
-			}
-		}
-	} else if b.File != nil {
-		path := fileObjects[b.File.TargetObjectID]
-		if path == "" {
-			path = filepath.ToSlash(filepath.Join("files", sanitizeName(strings.TrimSpace(b.File.Name), "posix")))
-		}
-		path = relativePathTarget(sourceNotePath, path)
-		if strings.EqualFold(b.File.Type, "image") {
-			buf.WriteString("![" + escapeBrackets(b.File.Name) + "](" + path + ")\n")
-		} else {
-			title := b.File.Name
-			if title == "" {
-				title = filepath.Base(path)
-			}
-			buf.WriteString("[" + escapeBrackets(title) + "](" + path + ")\n")
-		}
-	} else if b.Bookmark != nil {
-		title := strings.TrimSpace(b.Bookmark.Title)
-		if title == "" {
-			title = b.Bookmark.URL
-		}
-		if b.Bookmark.URL != "" {
-			buf.WriteString("[" + escapeBrackets(title) + "](" + b.Bookmark.URL + ")\n")
-		}
-	} else if b.Latex != nil {
-		if embedTarget, ok := excalidrawEmbeds[b.ID]; ok && embedTarget != "" {
-			buf.WriteString("![[" + embedTarget + "]]\n")
-		} else if strings.TrimSpace(b.Latex.Text) != "" {
-			buf.WriteString("$$\n" + b.Latex.Text + "\n$$\n")
-		}
-	} else if len(b.Dataview) > 0 {
-		dataviewTargetID := rootID
-		if target := strings.TrimSpace(asString(anyMapGet(b.Dataview, "TargetObjectId", "targetObjectId"))); target != "" {
-			dataviewTargetID = target
-		}
-		if note, ok := notes[dataviewTargetID]; ok && strings.HasPrefix(filepath.ToSlash(strings.TrimSpace(note)), "bases/") {
-			buf.WriteString("![[" + relativeWikiTarget(sourceNotePath, note) + "]]\n")
-		}
-	} else if b.Link != nil {
-		if note, ok := notes[b.Link.TargetBlockID]; ok {
-			buf.WriteString("[[" + relativeWikiTarget(sourceNotePath, note) + "]]\n")
-		} else if date := linkTargetDate(b.Link.TargetBlockID); date != "" {
-			buf.WriteString(date + "\n")
-		}
-	} else if b.Table != nil {
-		table := renderTable(byID, b)
-		if table != "" {
-			buf.WriteString(table)
-			if !strings.HasSuffix(table, "\n") {
-				buf.WriteString("\n")
-			}
-		}
+	render, builtin := builtinBlockRenderers[kind]
+	if builtin && render(buf, ctx, b, depth, numberedIndex) {
 		return
-	} else if b.Div != nil {
-		if divider := renderDivider(b.Div); divider != "" {
-			buf.WriteString(divider + "\n")
-		}
-	} else if b.TOC != nil {
-		toc := renderTableOfContents(byID, rootID)
-		if toc != "" {
-			buf.WriteString(toc)
+	}
+	if !builtin {
+		renderUnsupportedBlock(buf, ctx, b, kind)
+	}
+	renderChildren(buf, ctx, b.ChildrenID, depth+1)
+}
+
+func isListStyle(style string) bool {
+	return style == "Marked" || style == "Numbered" || style == "Checkbox"
+}
+
+func scaffoldEmptyNoteBody(obj objectInfo, typesByID map[string]typeDef) string {
+	layout, ok := recommendedLayoutForObject(obj, typesByID)
+	if !ok {
+		return ""
+	}
+	switch layout {
+	case anytypedomain.LayoutTodo:
+		return "## Tasks\n\n- [ ] \n"
+	case anytypedomain.LayoutProfile:
+		return "## About\n\n## Contacts\n"
+	case anytypedomain.LayoutBookmark:
+		return "## Summary\n\n## Highlights\n"
+	default:
+		return ""
+	}
+}
+
+// snippetNoteBody stands in for the body of an object whose blocks are
+// missing from the export: the snippet Anytype keeps of its text, one
+// paragraph per line.
+func snippetNoteBody(details map[string]any) string {
+	var paragraphs []string
+	for _, line := range strings.Split(asString(details["snippet"]), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paragraphs = append(paragraphs, line)
 		}
 	}
+	if len(paragraphs) == 0 {
+		return ""
+	}
+	return strings.Join(paragraphs, "\n\n") + "\n"
+}
 
-	renderChildren(buf, byID, b.ChildrenID, notes, sourceNotePath, fileObjects, excalidrawEmbeds, depth+1, rootID)
+func recommendedLayoutForObject(obj objectInfo, typesByID map[string]typeDef) (int, bool) {
+	typeID := strings.TrimSpace(asString(obj.Details["type"]))
+	if typeInfo, ok := typesByID[typeID]; ok {
+		if raw, exists := typeInfo.Details["recommendedLayout"]; exists {
+			return asInt(raw), true
+		}
+	}
+	if raw := anyMapGet(obj.Details, "resolvedLayout", "layout"); raw != nil {
+		return asInt(raw), true
+	}
+	return 0, false
 }
 
 func isSystemTitleBlock(b block) bool {
@@ -215,11 +325,12 @@ func isSystemTitleBlock(b block) bool {
 	return false
 }
 
-func renderTextBlock(t textBlock, depth int, fields map[string]any, notes map[string]string, sourceNotePath string, numberedIndex int) string {
+// renderTextBlock renders one text block at the left margin; blocks nested
+// under a list item are indented by the item that renders them.
+func renderTextBlock(t textBlock, fields map[string]any, notes map[string]string, sourceNotePath string, numberedIndex int) string {
 	text := strings.TrimRight(t.Text, "\n")
 	text = applyTextMarks(text, t.Marks, notes, sourceNotePath)
 	style := t.Style
-	indent := strings.Repeat("\t", max(0, depth-1))
 
 	switch style {
 	case "Title", "Header1", "ToggleHeader1":
@@ -232,16 +343,16 @@ func renderTextBlock(t textBlock, depth int, fields map[string]any, notes map[st
 		return "#### " + text + "\n"
 	case "Checkbox":
 		if t.Checked {
-			return indent + "- [x] " + text + "\n"
+			return "- [x] " + text + "\n"
 		}
-		return indent + "- [ ] " + text + "\n"
+		return "- [ ] " + text + "\n"
 	case "Marked":
-		return indent + "- " + text + "\n"
+		return "- " + text + "\n"
 	case "Numbered":
 		if numberedIndex <= 0 {
 			numberedIndex = 1
 		}
-		return indent + strconv.Itoa(numberedIndex) + ". " + text + "\n"
+		return strconv.Itoa(numberedIndex) + ". " + text + "\n"
 	case "Code":
 		code := strings.TrimLeft(text, "\n")
 		lang := strings.TrimSpace(asString(fields["lang"]))
@@ -264,50 +375,80 @@ func applyTextMarks(text string, marks *anytypedomain.TextMarks, notes map[strin
 		return text
 	}
 
-	type replacementMark struct {
-		from int
-		to   int
-		repl string
+	runes := []rune(text)
+	replacements := textMarkReplacements(runes, marks, notes, sourceNotePath)
+	if len(replacements) == 0 {
+		return text
 	}
 
-	runes := []rune(text)
-	replacements := make([]replacementMark, 0, len(marks.Marks))
+	var out strings.Builder
+	cursor := 0
+	for _, replacement := range replacements {
+		out.WriteString(string(runes[cursor:replacement.from]))
+		out.WriteString(replacement.repl)
+		cursor = replacement.to
+	}
+	out.WriteString(string(runes[cursor:]))
+	return out.String()
+}
+
+// textReplacement swaps the runes [from, to) of a text for repl, a link to
+// href. wiki marks href as a wikilink target; label is the link text when
+// the mark sets one.
+type textReplacement struct {
+	from  int
+	to    int
+	repl  string
+	href  string
+	label string
+	wiki  bool
+}
+
+// textMarkReplacements turns the mention and link marks of a text into
+// wikilinks and markdown links, ordered by position and without overlaps.
+func textMarkReplacements(runes []rune, marks *anytypedomain.TextMarks, notes map[string]string, sourceNotePath string) []textReplacement {
+	if marks == nil {
+		return nil
+	}
+	replacements := make([]textReplacement, 0, len(marks.Marks))
 	for _, mark := range marks.Marks {
-		from := mark.Range.From
-		to := mark.Range.To
-		if from < 0 {
-			from = 0
-		}
-		if to > len(runes) {
-			to = len(runes)
-		}
-		if to <= from {
+		from, to, ok := clampMarkRange(mark.Range, len(runes))
+		if !ok {
 			continue
 		}
 
 		markType := strings.ToLower(strings.TrimSpace(mark.Type))
 		switch markType {
 		case "mention":
-			note := notes[strings.TrimSpace(mark.Param)]
-			if note == "" {
-				continue
+			target, ok := blockLinkTarget(mark.Param, notes, sourceNotePath)
+			if !ok {
+				note := notes[strings.TrimSpace(mark.Param)]
+				if note == "" {
+					continue
+				}
+				target = relativeWikiTarget(sourceNotePath, note)
 			}
-			replacements = append(replacements, replacementMark{from: from, to: to, repl: "[[" + relativeWikiTarget(sourceNotePath, note) + "]]"})
+			replacements = append(replacements, textReplacement{from: from, to: to, repl: "[[" + target + "]]", href: target, wiki: true})
 		case "link":
 			url := strings.TrimSpace(mark.Param)
 			if url == "" {
 				continue
 			}
 			label := strings.TrimSpace(string(runes[from:to]))
+			if target, ok := blockLinkTarget(url, notes, sourceNotePath); ok {
+				repl := target
+				if label != "" {
+					repl += "|" + escapeBrackets(label)
+				}
+				replacements = append(replacements, textReplacement{from: from, to: to, repl: "[[" + repl + "]]", href: target, label: label, wiki: true})
+				continue
+			}
 			if label == "" {
 				label = url
 			}
-			replacements = append(replacements, replacementMark{from: from, to: to, repl: "[" + escapeBrackets(label) + "](" + url + ")"})
+			replacements = append(replacements, textReplacement{from: from, to: to, repl: "[" + escapeBrackets(label) + "](" + url + ")", href: url, label: label})
 		}
 	}
-	if len(replacements) == 0 {
-		return text
-	}
 
 	sort.Slice(replacements, func(i, j int) bool {
 		if replacements[i].from == replacements[j].from {
@@ -315,40 +456,53 @@ func applyTextMarks(text string, marks *anytypedomain.TextMarks, notes map[strin
 		}
 		return replacements[i].from < replacements[j].from
 	})
-
-	var out strings.Builder
+	kept := replacements[:0]
 	cursor := 0
 	for _, replacement := range replacements {
 		if replacement.from < cursor {
 			continue
 		}
-		out.WriteString(string(runes[cursor:replacement.from]))
-		out.WriteString(replacement.repl)
+		kept = append(kept, replacement)
 		cursor = replacement.to
 	}
-	out.WriteString(string(runes[cursor:]))
-	return out.String()
+	return kept
+}
+
+// clampMarkRange fits a mark range into a text of n runes and reports
+// whether anything of it is left.
+func clampMarkRange(r anytypedomain.TextMarkRange, n int) (int, int, bool) {
+	from := max(r.From, 0)
+	to := min(r.To, n)
+	return from, to, to > from
 }
 
-func renderCalloutBlock(buf *bytes.Buffer, byID map[string]block, b block, notes map[string]string, sourceNotePath string, fileObjects map[string]string, excalidrawEmbeds map[string]string, depth int, rootID string) {
+func renderCalloutBlock(buf *bytes.Buffer, ctx bodyContext, b block, depth int) {
 	if b.Text == nil {
 		return
 	}
 	if depth == 0 && buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) {
 		buf.WriteString("\n")
 	}
-	marker := "> [!note]"
+	kind, mapped := calloutType(b.Text.IconEmoji, ctx.calloutTypes)
+	marker := "> [!" + kind + "]"
 	if b.Text.Style == "Toggle" {
 		marker += "-"
 	}
 	title := strings.TrimSpace(b.Text.Text)
+	if icon := strings.TrimSpace(b.Text.IconEmoji); icon != "" && !mapped {
+		// Keep an icon Obsidian has no callout type for in the title.
+		title = strings.TrimSpace(icon + " " + title)
+	}
 	if title != "" {
 		marker += " " + title
 	}
 	buf.WriteString(marker + "\n")
 
+	// The callout starts a new quote block, so lists inside it start over
+	// at the left margin.
+	ctx.listDepth = 0
 	var child bytes.Buffer
-	renderChildren(&child, byID, b.ChildrenID, notes, sourceNotePath, fileObjects, excalidrawEmbeds, depth+1, rootID)
+	renderChildren(&child, ctx, b.ChildrenID, depth+1)
 	body := strings.TrimRight(child.String(), "\n")
 	if body == "" {
 		buf.WriteString("\n")
@@ -358,11 +512,90 @@ func renderCalloutBlock(buf *bytes.Buffer, byID map[string]block, b block, notes
 	buf.WriteString("\n\n")
 }
 
-func exportExcalidrawDrawings(obj objectInfo, noteRelPath string, excalidrawDir string, filenameEscaping string, usedNames map[string]int) (map[string]string, error) {
+const (
+	toggleHeadingsHeading = "heading"
+	toggleHeadingsCallout = "callout"
+)
+
+func resolveToggleHeadings(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return toggleHeadingsHeading, nil
+	case toggleHeadingsHeading, toggleHeadingsCallout:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid toggle headings mode %q: expected heading or callout", mode)
+	}
+}
+
+func isToggleHeading(style string) bool {
+	return style == "ToggleHeader1" || style == "ToggleHeader2" || style == "ToggleHeader3"
+}
+
+// renderFoldedChildren puts the blocks under a toggle heading into a
+// collapsed callout below it, so they start folded as they do in Anytype.
+func renderFoldedChildren(buf *bytes.Buffer, ctx bodyContext, b block, depth int) {
+	ctx.listDepth = 0
+	var child bytes.Buffer
+	renderChildren(&child, ctx, b.ChildrenID, depth+1)
+	body := strings.TrimRight(child.String(), "\n")
+	if body == "" {
+		return
+	}
+	buf.WriteString("\n> [!note]-\n" + prefixLines(body, "> ") + "\n\n")
+}
+
+// renderQuoteChildren keeps the blocks nested under a quote inside it. A
+// last child that reads as an attribution becomes the quote's
+// "— Author" line.
+func renderQuoteChildren(buf *bytes.Buffer, ctx bodyContext, b block, depth int) {
+	children := b.ChildrenID
+	caption := ""
+	if n := len(children); n > 0 {
+		if text, ok := quoteCaption(ctx, children[n-1], n == 1); ok {
+			caption = text
+			children = children[:n-1]
+		}
+	}
+	ctx.listDepth = 0
+	var child bytes.Buffer
+	renderChildren(&child, ctx, children, depth+1)
+	if body := strings.TrimRight(child.String(), "\n"); body != "" {
+		buf.WriteString(">\n" + prefixLines(body, "> ") + "\n")
+	}
+	if caption != "" {
+		buf.WriteString("> — " + caption + "\n")
+	}
+}
+
+// quoteCaption reports whether the quote child id is an attribution: a
+// single-line paragraph that starts with a dash, or a short paragraph that
+// is the quote's only child. It returns the text without the dash.
+func quoteCaption(ctx bodyContext, id string, only bool) (string, bool) {
+	c, ok := ctx.byID[id]
+	if !ok || c.Text == nil || len(c.ChildrenID) > 0 || (c.Text.Style != "" && c.Text.Style != "Paragraph") {
+		return "", false
+	}
+	text := strings.TrimSpace(applyTextMarks(c.Text.Text, c.Text.Marks, ctx.notes, ctx.sourceNotePath))
+	if text == "" || strings.Contains(text, "\n") {
+		return "", false
+	}
+	if author := strings.TrimSpace(strings.TrimLeft(text, "—–-~")); author != text {
+		return author, author != ""
+	}
+	return text, only && utf8.RuneCountInString(text) <= quoteCaptionMaxRunes
+}
+
+// quoteCaptionMaxRunes bounds how long an undashed only child of a quote
+// may be to still read as its author rather than more quoted text.
+const quoteCaptionMaxRunes = 80
+
+func exportExcalidrawDrawings(obj objectInfo, noteRelPath string, excalidrawDir string, naming filenameOptions, usedNames map[string]int, vault *vaultFiles) (map[string]string, error) {
 	embeds := map[string]string{}
 	noteBase := strings.TrimSpace(strings.TrimSuffix(filepath.Base(noteRelPath), filepath.Ext(noteRelPath)))
 	if noteBase == "" {
-		noteBase = sanitizeName(obj.ID, filenameEscaping)
+		noteBase = naming.sanitize(obj.ID)
 	}
 	drawingIndex := 0
 
@@ -384,9 +617,9 @@ func exportExcalidrawDrawings(obj objectInfo, noteRelPath string, excalidrawDir
 		}
 
 		drawingIndex++
-		baseName := sanitizeName(noteBase+" drawing", filenameEscaping)
+		baseName := sanitizeName(noteBase+" drawing", naming.escaping)
 		if baseName == "" {
-			baseName = sanitizeName(obj.ID+" drawing", filenameEscaping)
+			baseName = sanitizeName(obj.ID+" drawing", naming.escaping)
 		}
 		if baseName == "" {
 			baseName = "drawing"
@@ -395,16 +628,17 @@ func exportExcalidrawDrawings(obj objectInfo, noteRelPath string, excalidrawDir
 			baseName = baseName + "-" + strconv.Itoa(drawingIndex)
 		}
 
-		usedKey := filenameCollisionKey(baseName, filenameEscaping)
+		usedKey := naming.collisionKey(baseName)
 		n := usedNames[usedKey]
 		usedNames[usedKey] = n + 1
 		if n > 0 {
 			baseName = baseName + "-" + strconv.Itoa(n+1)
 		}
 
+		baseName = naming.fit(baseName, ".excalidraw.md")
 		drawingFilename := baseName + ".excalidraw.md"
 		drawingPath := filepath.Join(excalidrawDir, drawingFilename)
-		if err := os.WriteFile(drawingPath, []byte(drawingContent), 0o644); err != nil {
+		if err := vault.writeFile(drawingPath, []byte(drawingContent)); err != nil {
 			return nil, err
 		}
 		if err := applyExportedFileTimes(drawingPath, obj.Details); err != nil {
@@ -485,6 +719,12 @@ func normalizeExcalidrawPayload(payload map[string]any) {
 	}
 }
 
+func ensureBlankLine(buf *bytes.Buffer) {
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) {
+		buf.WriteString("\n")
+	}
+}
+
 func prefixLines(s string, prefix string) string {
 	lines := strings.Split(s, "\n")
 	for i, line := range lines {
@@ -508,9 +748,31 @@ func renderDivider(div map[string]any) string {
 	}
 }
 
-func renderTableOfContents(byID map[string]block, rootID string) string {
+const (
+	tocWikilinkAnchors = "wikilink-anchors"
+	tocLinks           = "links"
+	tocSkip            = "skip"
+)
+
+func resolveTOCMode(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return tocWikilinkAnchors, nil
+	case tocWikilinkAnchors, tocLinks, tocSkip:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid toc mode %q: expected wikilink-anchors, links, or skip", mode)
+	}
+}
+
+// renderTableOfContents lists the headings under rootID as [[#Heading]]
+// wikilinks, or as markdown links to the URL-encoded heading with the
+// links mode. The skip mode drops the list and leaves navigation to
+// Obsidian's outline pane.
+func renderTableOfContents(byID map[string]block, rootID string, mode string) string {
 	root, ok := byID[rootID]
-	if !ok {
+	if !ok || mode == tocSkip {
 		return ""
 	}
 
@@ -547,12 +809,17 @@ func renderTableOfContents(byID map[string]block, rootID string) string {
 
 	var buf bytes.Buffer
 	for _, h := range headings {
-		slug := headingSlug(h.text)
-		if slug == "" {
+		anchor := headingAnchor(h.text)
+		if anchor == "" {
 			continue
 		}
 		indent := strings.Repeat("\t", max(0, h.level-1))
-		buf.WriteString(indent + "- [" + escapeBrackets(h.text) + "](#" + slug + ")\n")
+		if mode == tocLinks {
+			buf.WriteString(indent + "- [" + escapeBrackets(h.text) + "](#" + url.PathEscape(anchor) + ")\n")
+			continue
+		}
+		label := strings.NewReplacer("[", "", "]", "").Replace(h.text)
+		buf.WriteString(indent + "- [[#" + anchor + "|" + label + "]]\n")
 	}
 	return buf.String()
 }
@@ -572,26 +839,12 @@ func headingLevel(style string) int {
 	}
 }
 
-func headingSlug(s string) string {
-	s = strings.ToLower(strings.TrimSpace(s))
-	if s == "" {
-		return ""
-	}
-	var b strings.Builder
-	lastDash := false
-	for _, r := range s {
-		switch {
-		case unicode.IsLetter(r) || unicode.IsDigit(r):
-			b.WriteRune(r)
-			lastDash = false
-		case r == ' ' || r == '-':
-			if !lastDash {
-				b.WriteRune('-')
-				lastDash = true
-			}
-		}
-	}
-	return strings.Trim(b.String(), "-")
+// headingAnchor returns the heading reference Obsidian links to for text:
+// the characters it does not allow in links (# ^ | : [ ] \) become spaces
+// and runs of whitespace collapse, so [[#anchor]] finds the heading.
+func headingAnchor(text string) string {
+	text = strings.NewReplacer("#", " ", "^", " ", "|", " ", ":", " ", "[", " ", "]", " ", `\`, " ").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
 }
 
 func linkTargetDate(target string) string {
@@ -602,7 +855,34 @@ func linkTargetDate(target string) string {
 	return ""
 }
 
-func renderTable(byID map[string]block, tableBlock block) string {
+// anytypeTable is a table block read into a grid. Cells hold block IDs;
+// spans maps the position of a merged cell to its row and column span, and
+// covered holds the positions merged cells reach over.
+type anytypeTable struct {
+	cols       int
+	aligns     []string
+	rows       []anytypeTableRow
+	spans      map[[2]int][2]int
+	covered    map[[2]int]struct{}
+	hasRowMeta bool
+}
+
+type anytypeTableRow struct {
+	cells  []string
+	header bool
+}
+
+// headerRow reports whether the first row is the table header: the first
+// row of tables from exports without row metadata, otherwise a row Anytype
+// flags as a header.
+func (t anytypeTable) headerRow() bool {
+	return !t.hasRowMeta || t.rows[0].header
+}
+
+// readTable reads the columns and rows of an Anytype table block. Anytype
+// names cells <row>-<column> and leaves empty cells out; older exports list
+// every cell in column order.
+func readTable(byID map[string]block, tableBlock block) (anytypeTable, bool) {
 	var colsBlock block
 	var rowsBlock block
 	foundCols := false
@@ -624,58 +904,169 @@ func renderTable(byID map[string]block, tableBlock block) string {
 	}
 
 	if !foundCols || !foundRows {
-		return ""
+		return anytypeTable{}, false
 	}
 
 	colCount := len(colsBlock.ChildrenID)
 	if colCount == 0 {
-		return ""
+		return anytypeTable{}, false
+	}
+	colIndex := make(map[string]int, colCount)
+	for i, colID := range colsBlock.ChildrenID {
+		colIndex[colID] = i
 	}
 
-	rows := make([][]string, 0, len(rowsBlock.ChildrenID))
+	t := anytypeTable{
+		cols:    colCount,
+		spans:   map[[2]int][2]int{},
+		covered: map[[2]int]struct{}{},
+	}
+	cellAligns := make([]map[string]int, colCount)
 	for _, rid := range rowsBlock.ChildrenID {
 		rb, ok := byID[rid]
 		if !ok {
 			continue
 		}
-		row := make([]string, colCount)
-		for i := 0; i < colCount; i++ {
-			if i < len(rb.ChildrenID) {
-				row[i] = extractPlainText(byID, rb.ChildrenID[i])
-			} else {
-				row[i] = ""
+		row := anytypeTableRow{cells: make([]string, colCount)}
+		if rb.TableRow != nil {
+			t.hasRowMeta = true
+			row.header = rb.TableRow.IsHeader
+		}
+		for pos, cellID := range rb.ChildrenID {
+			col := pos
+			if colID, ok := strings.CutPrefix(cellID, rid+"-"); ok {
+				if idx, known := colIndex[colID]; known {
+					col = idx
+				}
+			}
+			if col >= colCount {
+				continue
 			}
-			if row[i] == "" {
-				row[i] = " "
+			row.cells[col] = cellID
+			cell := byID[cellID]
+			if align := strings.TrimSpace(cell.Align); align != "" {
+				if cellAligns[col] == nil {
+					cellAligns[col] = map[string]int{}
+				}
+				cellAligns[col][align]++
+			}
+			colSpan := max(1, asInt(anyMapGet(cell.Fields, "colSpan", "colspan")))
+			rowSpan := max(1, asInt(anyMapGet(cell.Fields, "rowSpan", "rowspan")))
+			if colSpan > 1 || rowSpan > 1 {
+				t.spans[[2]int{len(t.rows), col}] = [2]int{rowSpan, colSpan}
+				for r := 0; r < rowSpan; r++ {
+					for c := 0; c < colSpan; c++ {
+						if r > 0 || c > 0 {
+							t.covered[[2]int{len(t.rows) + r, col + c}] = struct{}{}
+						}
+					}
+				}
 			}
 		}
-		rows = append(rows, row)
+		t.rows = append(t.rows, row)
+	}
+	if len(t.rows) == 0 {
+		return anytypeTable{}, false
+	}
+	for pos := range t.covered {
+		if pos[0] < len(t.rows) && pos[1] < colCount {
+			t.rows[pos[0]].cells[pos[1]] = ""
+		}
 	}
 
-	if len(rows) == 0 {
+	t.aligns = make([]string, colCount)
+	for i := range t.aligns {
+		if colBlock, ok := byID[colsBlock.ChildrenID[i]]; ok {
+			t.aligns[i] = strings.TrimSpace(colBlock.Align)
+		}
+		if t.aligns[i] == "" && len(cellAligns[i]) == 1 {
+			for a := range cellAligns[i] {
+				t.aligns[i] = a
+			}
+		}
+	}
+	return t, true
+}
+
+// renderTable writes an Anytype table as a markdown table, or as an HTML
+// table when ctx.complexTables asks for one and markdown would lose part of
+// it.
+func renderTable(ctx bodyContext, tableBlock block) string {
+	t, ok := readTable(ctx.byID, tableBlock)
+	if !ok {
 		return ""
 	}
+	if ctx.complexTables == complexTablesHTML && isComplexTable(ctx, t) {
+		return renderHTMLTableBlock(ctx, t)
+	}
+	return renderMarkdownTable(ctx, t)
+}
+
+// renderMarkdownTable writes t as a markdown table. Rows flagged as header
+// rows in Anytype keep header semantics: the first becomes the markdown
+// header, later ones are bolded; a table without one gets an empty header
+// row. Tables from exports without row metadata use their first row as the
+// header. Column alignment comes from the column, or from its cells when
+// they agree. Merged cells cannot be expressed, so the cells they span are
+// left empty and ctx.warn is told.
+func renderMarkdownTable(ctx bodyContext, t anytypeTable) string {
+	if len(t.spans) > 0 && ctx.warn != nil {
+		ctx.warn("table has merged cells, which markdown tables cannot show; the cells they span are left empty")
+	}
+	renderRow := func(row anytypeTableRow) []string {
+		cells := make([]string, t.cols)
+		for i, cellID := range row.cells {
+			if cellID != "" {
+				cells[i] = renderTableCell(ctx, cellID, false)
+			}
+		}
+		return cells
+	}
+
+	header := make([]string, t.cols)
+	body := t.rows
+	if t.headerRow() {
+		header, body = renderRow(t.rows[0]), t.rows[1:]
+	}
 
 	var buf bytes.Buffer
-	header := rows[0]
 	writeMarkdownTableRow(&buf, header)
-	sep := make([]string, len(header))
-	for i := range sep {
-		sep[i] = "---"
+	sep := make([]string, t.cols)
+	for i, align := range t.aligns {
+		sep[i] = markdownTableAlignment(align)
 	}
 	writeMarkdownTableRow(&buf, sep)
-	for i := 1; i < len(rows); i++ {
-		writeMarkdownTableRow(&buf, rows[i])
+	for _, row := range body {
+		cells := renderRow(row)
+		if row.header {
+			for i, cell := range cells {
+				if strings.TrimSpace(cell) != "" {
+					cells[i] = "**" + cell + "**"
+				}
+			}
+		}
+		writeMarkdownTableRow(&buf, cells)
 	}
 	return buf.String()
 }
 
+func markdownTableAlignment(align string) string {
+	switch align {
+	case "AlignCenter":
+		return ":---:"
+	case "AlignRight":
+		return "---:"
+	default:
+		return "---"
+	}
+}
+
 func writeMarkdownTableRow(buf *bytes.Buffer, row []string) {
 	buf.WriteString("|")
 	for _, c := range row {
 		cell := strings.ReplaceAll(c, "|", "\\|")
-		cell = strings.ReplaceAll(cell, "\n", " ")
-		buf.WriteString(" " + strings.TrimSpace(cell) + " |")
+		cell = strings.ReplaceAll(strings.TrimSpace(cell), "\n", "<br>")
+		buf.WriteString(" " + cell + " |")
 	}
 	buf.WriteString("\n")
 }
@@ -707,7 +1098,48 @@ func extractPlainText(byID map[string]block, id string) string {
 	return strings.TrimSpace(strings.Join(parts, " "))
 }
 
-func exportPrettyPropertiesPluginData(outputDir string, relations map[string]relationDef, optionsByID map[string]relationOption) error {
+// prettyPropertiesMedia records which image properties the export emitted,
+// so the matching Pretty Properties banner/cover settings can be enabled.
+type prettyPropertiesMedia struct {
+	banner bool
+	cover  bool
+}
+
+func (m prettyPropertiesMedia) settings() map[string]any {
+	settings := map[string]any{}
+	if m.banner {
+		settings["enableBanner"] = true
+		settings["bannerProperty"] = "banner"
+		settings["bannersFolder"] = "files"
+	}
+	if m.cover {
+		settings["enableCover"] = true
+		settings["coverProperty"] = "cover"
+		settings["coversFolder"] = "files"
+	}
+	return settings
+}
+
+func collectPrettyPropertiesMedia(objects []objectInfo, notePathByID map[string]string, fileObjects map[string]string, pictureToCover bool) prettyPropertiesMedia {
+	var media prettyPropertiesMedia
+	for _, obj := range objects {
+		if _, exported := notePathByID[obj.ID]; !exported {
+			continue
+		}
+		if !media.banner {
+			_, media.banner = coverBannerValue(obj.Details, fileObjects)
+		}
+		if !media.cover && pictureToCover {
+			media.cover = len(anyToStringSlice(obj.Details["picture"])) > 0
+		}
+		if media.banner && media.cover {
+			break
+		}
+	}
+	return media
+}
+
+func exportPrettyPropertiesPluginData(outputDir string, relations map[string]relationDef, optionsByID map[string]relationOption, media prettyPropertiesMedia, keys propertyKeys, vault *vaultFiles) error {
 	colorByList := map[string]map[string]string{
 		"tagColors":              {},
 		"propertyPillColors":     {},
@@ -726,7 +1158,7 @@ func exportPrettyPropertiesPluginData(outputDir string, relations map[string]rel
 
 		relationKey := strings.TrimSpace(asString(option.Details["relationKey"]))
 		rel, hasRel := relations[relationKey]
-		listKey := prettyPropertiesColorListForOption(relationKey, rel, hasRel)
+		listKey := prettyPropertiesColorListForOption(relationKey, rel, hasRel, keys)
 		if listKey == "" {
 			continue
 		}
@@ -743,7 +1175,8 @@ func exportPrettyPropertiesPluginData(outputDir string, relations map[string]rel
 	for _, values := range colorByList {
 		totalColors += len(values)
 	}
-	if totalColors == 0 {
+	mediaSettings := media.settings()
+	if totalColors == 0 && len(mediaSettings) == 0 {
 		return nil
 	}
 
@@ -773,6 +1206,13 @@ func exportPrettyPropertiesPluginData(outputDir string, relations map[string]rel
 			}
 		}
 	}
+	// Settings the user already chose in the vault win over ours.
+	for key, value := range mediaSettings {
+		if _, exists := data[key]; !exists {
+			data[key] = value
+			changed = true
+		}
+	}
 
 	if !changed {
 		return nil
@@ -783,7 +1223,7 @@ func exportPrettyPropertiesPluginData(outputDir string, relations map[string]rel
 		return err
 	}
 
-	return os.WriteFile(dataPath, encoded, 0o644)
+	return vault.writeFile(dataPath, encoded)
 }
 
 func normalizePrettyPropertiesTagColorKeys(data map[string]any) bool {
@@ -871,8 +1311,8 @@ func mapAnytypePrettyPropertiesColor(raw string) (string, bool) {
 	}
 }
 
-func prettyPropertiesColorListForOption(rawRelationKey string, rel relationDef, hasRel bool) string {
-	if isTagProperty(rawRelationKey, rel, hasRel) {
+func prettyPropertiesColorListForOption(rawRelationKey string, rel relationDef, hasRel bool, keys propertyKeys) string {
+	if keys.isTags(rawRelationKey, rel, hasRel) {
 		return "tagColors"
 	}
 	if hasRel {
@@ -883,7 +1323,7 @@ func prettyPropertiesColorListForOption(rawRelationKey string, rel relationDef,
 	return "propertyPillColors"
 }
 
-func exportIconizePluginData(inputDir string, outputDir string, objects []objectInfo, notePathByID map[string]string, fileObjects map[string]string) error {
+func exportIconizePluginData(input fs.FS, outputDir string, objects []objectInfo, notePathByID map[string]string, fileObjects map[string]string, vault *vaultFiles) error {
 	iconByPath := make(map[string]string)
 	imageIconRefs := make(map[string]string)
 
@@ -897,7 +1337,7 @@ func exportIconizePluginData(inputDir string, outputDir string, objects []object
 		if iconValue == "" {
 			imageID := strings.TrimSpace(asString(obj.Details["iconImage"]))
 			if imageID != "" {
-				imageIcon, err := ensureIconizeImageIcon(inputDir, outputDir, imageID, fileObjects, imageIconRefs)
+				imageIcon, err := ensureIconizeImageIcon(input, outputDir, imageID, fileObjects, imageIconRefs, vault)
 				if err != nil {
 					return err
 				}
@@ -942,10 +1382,10 @@ func exportIconizePluginData(inputDir string, outputDir string, objects []object
 		return err
 	}
 
-	return os.WriteFile(dataPath, encoded, 0o644)
+	return vault.writeFile(dataPath, encoded)
 }
 
-func ensureIconizeImageIcon(inputDir string, outputDir string, imageID string, fileObjects map[string]string, refs map[string]string) (string, error) {
+func ensureIconizeImageIcon(input fs.FS, outputDir string, imageID string, fileObjects map[string]string, refs map[string]string, vault *vaultFiles) (string, error) {
 	if existing := strings.TrimSpace(refs[imageID]); existing != "" {
 		return existing, nil
 	}
@@ -955,10 +1395,13 @@ func ensureIconizeImageIcon(inputDir string, outputDir string, imageID string, f
 		return "", nil
 	}
 
-	absoluteSourcePath := filepath.Join(inputDir, filepath.FromSlash(sourceRelPath))
-	content, err := os.ReadFile(absoluteSourcePath)
+	// Prefer the vault copy: attachments may have been renamed on the way.
+	content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(sourceRelPath)))
+	if err != nil {
+		content, err = fs.ReadFile(input, sourceRelPath)
+	}
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
 			return "", nil
 		}
 		return "", err
@@ -973,8 +1416,8 @@ func ensureIconizeImageIcon(inputDir string, outputDir string, imageID string, f
 	}
 
 	iconName := iconizeImageIconName(imageID)
-	iconSVG := wrapBinaryImageAsSVG(content, detectImageMIME(content, absoluteSourcePath))
-	if err := os.WriteFile(filepath.Join(iconDir, iconName+".svg"), []byte(iconSVG), 0o644); err != nil {
+	iconSVG := wrapBinaryImageAsSVG(content, detectImageMIME(content, sourceRelPath))
+	if err := vault.writeFile(filepath.Join(iconDir, iconName+".svg"), []byte(iconSVG)); err != nil {
 		return "", err
 	}
 
@@ -0,0 +1,168 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	formatterPrettier = "prettier"
+	formatterBuiltin  = "builtin"
+	formatterNone     = "none"
+)
+
+// resolveFormatter picks the post-export formatter. An empty mode follows
+// runPrettier, so -prettier=false keeps turning formatting off.
+func resolveFormatter(mode string, runPrettier bool) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		if runPrettier {
+			return formatterPrettier, nil
+		}
+		return formatterNone, nil
+	case formatterPrettier, formatterBuiltin, formatterNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid formatter %q: expected prettier, builtin, or none", mode)
+	}
+}
+
+// runBuiltinFormatter normalizes the given vault-relative markdown files in
+// place and returns how many files it changed.
+func runBuiltinFormatter(outputDir string, files []string) (int, error) {
+	changed := 0
+	for _, rel := range files {
+		path := filepath.Join(outputDir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		formatted := formatMarkdown(string(data))
+		if formatted == string(data) {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// formatMarkdown normalizes markdown without rewrapping it: "*" and "+"
+// bullets become "-", trailing whitespace is dropped (hard breaks keep two
+// spaces), runs of blank lines collapse to one and the file ends with a
+// single newline. Frontmatter, code fences and math blocks are left alone.
+func formatMarkdown(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	start := 0
+	if len(lines) > 0 && lines[0] == "---" {
+		for i := 1; i < len(lines); i++ {
+			if lines[i] == "---" {
+				out = append(out, lines[:i+1]...)
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	fence := ""
+	inMath := false
+	blank := false
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			out = append(out, line)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		case inMath:
+			out = append(out, line)
+			if trimmed == "$$" {
+				inMath = false
+			}
+			continue
+		}
+
+		if trimmed == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+
+		if marker := codeFenceMarker(trimmed); marker != "" {
+			fence = marker
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if trimmed == "$$" {
+			inMath = true
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		out = append(out, normalizeBulletMarker(trimTrailingWhitespace(line, next)))
+	}
+
+	for len(out) > start && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// codeFenceMarker returns the backtick or tilde run that opens a fenced
+// code block on line, or "".
+func codeFenceMarker(trimmed string) string {
+	for _, ch := range []string{"`", "~"} {
+		if strings.HasPrefix(trimmed, ch+ch+ch) {
+			n := len(trimmed) - len(strings.TrimLeft(trimmed, ch))
+			return strings.Repeat(ch, n)
+		}
+	}
+	return ""
+}
+
+// trimTrailingWhitespace drops trailing blanks from line, keeping two
+// spaces when they make a hard line break before next.
+func trimTrailingWhitespace(line string, next string) string {
+	trimmed := strings.TrimRight(line, " \t")
+	hardBreak := strings.HasSuffix(line, "  ") && strings.TrimSpace(next) != "" && !strings.HasPrefix(strings.TrimSpace(line), "#")
+	if hardBreak {
+		return trimmed + "  "
+	}
+	return trimmed
+}
+
+// normalizeBulletMarker turns a "*" or "+" list item, possibly indented or
+// inside a blockquote, into a "-" item. Thematic breaks such as "* * *"
+// are left alone.
+func normalizeBulletMarker(line string) string {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '>') {
+		i++
+	}
+	if i+1 >= len(line) || (line[i] != '*' && line[i] != '+') || (line[i+1] != ' ' && line[i+1] != '\t') {
+		return line
+	}
+	if strings.Trim(line[i:], "*+ \t") == "" {
+		return line
+	}
+	return line[:i] + "-" + line[i+1:]
+}
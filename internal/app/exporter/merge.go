@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	mergeOverwrite       = "overwrite"
+	mergeSkipExisting    = "skip-existing"
+	mergeKeepNewer       = "keep-newer"
+	mergeSuffixConflicts = "suffix-conflicts"
+)
+
+func resolveMergeStrategy(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return mergeOverwrite, nil
+	case mergeOverwrite, mergeSkipExisting, mergeKeepNewer, mergeSuffixConflicts:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid merge strategy %q: expected overwrite, skip-existing, keep-newer, or suffix-conflicts", mode)
+	}
+}
+
+// vaultMerger decides whether an exported file may replace what is already
+// on disk. The previous index records the owner and mtime of every file the
+// exporter wrote; a file whose mtime no longer matches, or that the index
+// does not know about, is treated as edited by the user.
+type vaultMerger struct {
+	strategy   string
+	outputDir  string
+	ownerByRel map[string]string
+	previous   map[string]int64
+	written    map[string]struct{}
+	// stableOnly leaves files whose mtime is the time of the run out of the
+	// index, so identical input yields an identical index.
+	stableOnly bool
+	// protect lists globs of vault paths the exporter must never write.
+	protect []string
+	// updated lists every path written in this run, in write order; only
+	// these are handed to the formatter.
+	updated []string
+}
+
+func loadVaultMerger(outputDir string, strategy string) (*vaultMerger, error) {
+	m := &vaultMerger{
+		strategy:   strategy,
+		outputDir:  outputDir,
+		ownerByRel: map[string]string{},
+		previous:   map[string]int64{},
+		written:    map[string]struct{}{},
+	}
+	raw, err := os.ReadFile(filepath.Join(outputDir, "_anytype", "index.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("read previous index: %w", err)
+	}
+	var idx indexFile
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, fmt.Errorf("decode previous index: %w", err)
+	}
+	for id, relPath := range idx.Notes {
+		m.ownerByRel[relPath] = id
+	}
+	for relPath, mtime := range idx.Files {
+		m.previous[relPath] = mtime
+	}
+	return m, nil
+}
+
+// write stores content at relPath according to the merge strategy and
+// returns the path it actually wrote, or "" when the existing file was kept
+// or the path is protected.
+func (m *vaultMerger) write(relPath string, ownerID string, content []byte, details map[string]any) (string, error) {
+	if m.protected(relPath) {
+		return "", nil
+	}
+	target := relPath
+	absPath := filepath.Join(m.outputDir, filepath.FromSlash(relPath))
+	info, err := os.Stat(absPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return "", err
+	case m.strategy == mergeOverwrite:
+	case m.strategy == mergeSkipExisting:
+		return "", nil
+	case !m.userEdited(relPath, ownerID, info):
+	case m.strategy == mergeKeepNewer:
+		if _, anytypeModified, ok := anytypeTimestamps(details); !ok || info.ModTime().After(anytypeModified) {
+			return "", nil
+		}
+	case m.strategy == mergeSuffixConflicts:
+		existing, err := os.ReadFile(absPath)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(existing, content) {
+			target = conflictRelPath(relPath)
+		}
+	}
+
+	targetAbs := filepath.Join(m.outputDir, filepath.FromSlash(target))
+	if err := os.WriteFile(targetAbs, content, 0o644); err != nil {
+		return "", err
+	}
+	if err := applyExportedFileTimes(targetAbs, details); err != nil {
+		return "", err
+	}
+	m.updated = append(m.updated, target)
+	if target == relPath {
+		if _, _, stable := anytypeTimestamps(details); stable || !m.stableOnly {
+			m.written[relPath] = struct{}{}
+		} else {
+			delete(m.previous, relPath)
+		}
+	}
+	return target, nil
+}
+
+func (m *vaultMerger) protected(relPath string) bool {
+	return matchVaultGlobs(relPath, m.protect)
+}
+
+func (m *vaultMerger) userEdited(relPath string, ownerID string, info os.FileInfo) bool {
+	if owner, ok := m.ownerByRel[relPath]; ok && owner != ownerID {
+		return true
+	}
+	recorded, ok := m.previous[relPath]
+	if !ok {
+		return true
+	}
+	return info.ModTime().UnixNano() != recorded
+}
+
+// fileState returns the mtimes to store in the new index: fresh ones for
+// files written by this run and the previous record for files that were
+// kept, so a kept edit still reads as edited next time.
+func (m *vaultMerger) fileState() (map[string]int64, error) {
+	state := make(map[string]int64, len(m.written)+len(m.previous))
+	for relPath, mtime := range m.previous {
+		state[relPath] = mtime
+	}
+	for relPath := range m.written {
+		info, err := os.Stat(filepath.Join(m.outputDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				delete(state, relPath)
+				continue
+			}
+			return nil, err
+		}
+		state[relPath] = info.ModTime().UnixNano()
+	}
+	return state, nil
+}
+
+func conflictRelPath(relPath string) string {
+	ext := filepath.Ext(relPath)
+	if strings.HasSuffix(relPath, ".excalidraw.md") {
+		ext = ".excalidraw.md"
+	}
+	return strings.TrimSuffix(relPath, ext) + " (conflict)" + ext
+}
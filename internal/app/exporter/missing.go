@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const missingStubDir = "Missing"
+
+// collectMissingObjects returns the objects exported notes point at, via
+// object relations, mentions or link blocks, that the export does not hold,
+// e.g. objects of another space. Values are the best name known for each:
+// the mention text, or "" when only the id is known.
+func collectMissingObjects(objects []objectInfo, blocksOf func(objectInfo) []block, exportedNotePathByID map[string]string, linkPathByID map[string]string, objectNamesByID map[string]string, relations map[string]relationDef, typesByID map[string]typeDef, opts frontmatterOptions) map[string]string {
+	missing := map[string]string{}
+	add := func(id string, name string) {
+		id = strings.TrimSpace(id)
+		// Block links would need an anchor the stub cannot provide.
+		if _, _, ok := splitBlockTarget(id); ok {
+			return
+		}
+		if id == "" || strings.Contains(id, "://") || linkTargetDate(id) != "" {
+			return
+		}
+		if _, ok := linkPathByID[id]; ok {
+			return
+		}
+		if strings.TrimSpace(objectNamesByID[id]) != "" {
+			return
+		}
+		if missing[id] == "" {
+			missing[id] = strings.TrimSpace(name)
+		}
+	}
+
+	for _, target := range collectUnresolvedRelationTargets(objects, exportedNotePathByID, linkPathByID, objectNamesByID, relations, typesByID, opts) {
+		add(target.TargetID, "")
+	}
+	for _, obj := range objects {
+		if _, ok := exportedNotePathByID[obj.ID]; !ok {
+			continue
+		}
+		for _, b := range blocksOf(obj) {
+			if b.Link != nil {
+				add(b.Link.TargetBlockID, "")
+			}
+			if b.Text == nil || b.Text.Marks == nil {
+				continue
+			}
+			runes := []rune(b.Text.Text)
+			for _, mark := range b.Text.Marks.Marks {
+				if strings.ToLower(strings.TrimSpace(mark.Type)) != "mention" {
+					continue
+				}
+				var name string
+				if from, to := mark.Range.From, mark.Range.To; from >= 0 && to <= len(runes) && from < to {
+					name = string(runes[from:to])
+				}
+				add(mark.Param, name)
+			}
+		}
+	}
+	return missing
+}
+
+// buildMissingStubPaths places one stub note per missing object in
+// Missing/, named after the object or, failing that, its id.
+func buildMissingStubPaths(missing map[string]string, naming filenameOptions) map[string]string {
+	ids := make([]string, 0, len(missing))
+	for id := range missing {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	paths := make(map[string]string, len(ids))
+	used := map[string]int{}
+	for _, id := range ids {
+		base := naming.sanitize(missing[id])
+		if strings.TrimSpace(missing[id]) == "" || base == "" {
+			base = naming.sanitize(id)
+		}
+		usedKey := naming.collisionKey(base)
+		n := used[usedKey]
+		used[usedKey] = n + 1
+		if n > 0 {
+			base = base + "-" + strconv.Itoa(n+1)
+		}
+		paths[id] = path.Join(missingStubDir, naming.fit(base, ".md")+".md")
+	}
+	return paths
+}
+
+// renderMissingStub writes the note that stands in for an object missing
+// from the export, so links to it resolve and the graph stays connected.
+func renderMissingStub(id string) string {
+	var b bytes.Buffer
+	b.WriteString("---\nanytype_id: ")
+	writeYAMLString(&b, id)
+	b.WriteString("\nanytype_missing: true\n---\n\n")
+	b.WriteString("This object is not part of the Anytype export, e.g. because it lives in another space. Links to it point here until it is migrated.\n")
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const partialExportFileName = "partial.json"
+
+// partialExport lists the objects an interrupted run finished writing, so a
+// later run (or the user) can tell which parts of the vault are current.
+type partialExport struct {
+	InterruptedAt time.Time `json:"interruptedAt"`
+	Phase         string    `json:"phase"`
+	Bases         []string  `json:"bases"`
+	Templates     []string  `json:"templates"`
+	Notes         []string  `json:"notes"`
+}
+
+func writePartialExportMarker(anytypeDir string, partial partialExport, vault *vaultFiles) error {
+	if partial.InterruptedAt.IsZero() {
+		partial.InterruptedAt = time.Now().UTC()
+	}
+	for _, ids := range []*[]string{&partial.Bases, &partial.Templates, &partial.Notes} {
+		if *ids == nil {
+			*ids = []string{}
+		}
+	}
+	payload, err := json.MarshalIndent(partial, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(anytypeDir, 0o755); err != nil {
+		return err
+	}
+	return vault.writeFile(filepath.Join(anytypeDir, partialExportFileName), append(payload, '\n'))
+}
+
+func removePartialExportMarker(anytypeDir string) error {
+	err := os.Remove(filepath.Join(anytypeDir, partialExportFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
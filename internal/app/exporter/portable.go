@@ -0,0 +1,259 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const flavorPortable = "portable"
+
+// runToPortable renders the vault into a temporary directory, with static
+// member lists in place of .base files, and copies it to e.OutputDir with
+// wikilinks, embeds and callouts rewritten to plain CommonMark.
+func (e Exporter) runToPortable(ctx context.Context) (Stats, error) {
+	if strategy, err := resolveMergeStrategy(e.MergeStrategy); err != nil {
+		return Stats{}, err
+	} else if strategy != mergeOverwrite {
+		return Stats{}, fmt.Errorf("portable output cannot be combined with merge strategy %q", strategy)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "anytype-to-obsidian-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.Flavor = flavorObsidian
+	staged.Atomic = false
+	staged.staticBaseLists = true
+	stats, err := staged.RunContext(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := writePortableVault(tmpDir, e.OutputDir); err != nil {
+		return Stats{}, fmt.Errorf("write portable vault: %w", err)
+	}
+	if stats.ReportPath != "" {
+		if rel, err := filepath.Rel(tmpDir, stats.ReportPath); err == nil {
+			stats.ReportPath = filepath.Join(e.OutputDir, rel)
+		}
+	}
+	return stats, nil
+}
+
+// baseMemberObjects returns the objects a set or collection lists: objects
+// of its setOf types, objects created in the collection and any object one
+// of its views ordered by hand. View filters are not evaluated.
+func baseMemberObjects(base objectInfo, objects []objectInfo) []objectInfo {
+	ordered := map[string]struct{}{}
+	for _, b := range base.Blocks {
+		for _, orderRaw := range asAnySlice(anyMapGet(b.Dataview, "objectOrders", "ObjectOrders")) {
+			orderMap, ok := orderRaw.(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, id := range anyToStringSlice(anyMapGet(orderMap, "objectIds", "ObjectIds")) {
+				ordered[id] = struct{}{}
+			}
+		}
+	}
+	setOf := map[string]struct{}{}
+	for _, id := range anyToStringSlice(base.Details["setOf"]) {
+		setOf[id] = struct{}{}
+	}
+	collection := isCollectionObject(base)
+
+	var members []objectInfo
+	for _, obj := range objects {
+		if obj.ID == base.ID {
+			continue
+		}
+		if _, ok := ordered[obj.ID]; ok || isKanbanMember(obj, base.ID, setOf, collection) {
+			members = append(members, obj)
+		}
+	}
+	return members
+}
+
+// collectBaseMembers returns the exported notes of a set or collection's
+// members, sorted by file name.
+func collectBaseMembers(base objectInfo, objects []objectInfo, notes map[string]string) []string {
+	var members []string
+	for _, obj := range baseMemberObjects(base, objects) {
+		if notePath := notes[obj.ID]; notePath != "" {
+			members = append(members, notePath)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		bi, bj := path.Base(members[i]), path.Base(members[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// renderBaseMemberList is the static stand-in for a .base file: the set's
+// title and a list of links to its members.
+func renderBaseMemberList(title string, listPath string, members []string) string {
+	var b strings.Builder
+	b.WriteString("# " + title + "\n\n")
+	if len(members) == 0 {
+		b.WriteString("No objects.\n")
+		return b.String()
+	}
+	for _, member := range members {
+		name := strings.TrimSuffix(path.Base(member), ".md")
+		b.WriteString("- [" + escapeMarkdownLinkLabel(name) + "](" + htmlEscapeURLPath(relativePathTarget(listPath, member)) + ")\n")
+	}
+	return b.String()
+}
+
+func escapeMarkdownLinkLabel(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(label)
+}
+
+// writePortableVault copies the vault in vaultDir to outDir without its
+// .obsidian config, rewriting every note with portableMarkdown.
+func writePortableVault(vaultDir string, outDir string) error {
+	vault, err := indexVault(vaultDir)
+	if err != nil {
+		return err
+	}
+	for _, rel := range vault.files {
+		src := filepath.Join(vaultDir, filepath.FromSlash(rel))
+		dst := filepath.Join(outDir, filepath.FromSlash(rel))
+		if path.Ext(rel) != ".md" || strings.HasSuffix(rel, ".excalidraw.md") {
+			if err := copyVaultFile(src, dst); err != nil {
+				return err
+			}
+			continue
+		}
+		content, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, []byte(portableMarkdown(rel, string(content), vault)), 0o644); err != nil {
+			return err
+		}
+	}
+
+	// The index and report stay useful for later runs and bug reports.
+	anytypeDir := filepath.Join(vaultDir, "_anytype")
+	return filepath.WalkDir(anytypeDir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(vaultDir, p)
+		if err != nil {
+			return err
+		}
+		return copyVaultFile(p, filepath.Join(outDir, rel))
+	})
+}
+
+// portableMarkdown rewrites the Obsidian-only syntax of one note: wikilinks
+// and embeds become relative markdown links and images, callouts become
+// blockquotes with a bold title, and block ids are dropped. Code blocks are
+// left alone.
+func portableMarkdown(rel string, markdown string, vault vaultIndex) string {
+	lines := strings.Split(markdown, "\n")
+	inFence := false
+	inFrontmatter := strings.HasPrefix(markdown, "---\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inFrontmatter:
+			if i > 0 && trimmed == "---" {
+				inFrontmatter = false
+			} else if i > 0 {
+				lines[i] = portableLinks(rel, line, vault, true)
+			}
+			continue
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inFence = !inFence
+			continue
+		case inFence:
+			continue
+		}
+		if m := calloutPattern.FindStringSubmatch(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))); m != nil && strings.HasPrefix(trimmed, ">") {
+			title := strings.TrimSpace(m[2])
+			if title == "" {
+				title = strings.ToUpper(m[1][:1]) + strings.ToLower(m[1][1:])
+			}
+			line = line[:strings.Index(line, ">")] + "> **" + title + "**"
+		}
+		if strings.HasPrefix(trimmed, "^") && !strings.Contains(trimmed, " ") && blockAnchorPattern.MatchString(trimmed) {
+			lines[i] = ""
+			continue
+		}
+		line, _ = splitBlockAnchorSuffix(line)
+		lines[i] = portableLinks(rel, line, vault, false)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func portableLinks(rel string, line string, vault vaultIndex, frontmatter bool) string {
+	return wikiLinkPattern.ReplaceAllStringFunc(line, func(match string) string {
+		embed := strings.HasPrefix(match, "!")
+		inner := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(match, "!"), "[["), "]]")
+		target, label, _ := strings.Cut(inner, "|")
+		target = strings.ReplaceAll(target, `\`, "")
+		label = strings.ReplaceAll(label, `\`, "")
+		target, fragment, _ := strings.Cut(target, "#")
+		target = strings.TrimSpace(target)
+		if label == "" {
+			label = strings.TrimSuffix(path.Base(target), ".md")
+			if target == "" {
+				label = strings.TrimPrefix(fragment, "^")
+			}
+		}
+		linkLabel := escapeMarkdownLinkLabel(label)
+		if frontmatter {
+			// The link ends up inside a quoted YAML string, where
+			// backslash escapes and quotes would break the value.
+			label = strings.NewReplacer(`"`, "", `'`, "", `\`, "", `[`, "", `]`, "").Replace(label)
+			linkLabel = label
+		}
+		href := ""
+		if target != "" {
+			resolved, ok := vault.resolve(rel, target)
+			if !ok {
+				return label
+			}
+			href = htmlEscapeURLPath(relativePathTarget(rel, resolved))
+			if !strings.HasSuffix(resolved, ".md") {
+				fragment = ""
+			}
+			if embed && !strings.HasSuffix(resolved, ".md") {
+				return "![" + linkLabel + "](" + href + ")"
+			}
+		}
+		if fragment = strings.TrimSpace(fragment); fragment != "" && !strings.HasPrefix(fragment, "^") {
+			href += "#" + url.PathEscape(htmlSlug(fragment))
+		}
+		if href == "" {
+			return label
+		}
+		return "[" + linkLabel + "](" + href + ")"
+	})
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+const exportProfileDirName = "profile"
+
+type phaseTiming struct {
+	Phase   string  `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+// exportProfiler collects a CPU profile for the whole run in memory, so it
+// can be written next to the rest of _anytype once the vault exists (and
+// inside the staging directory of atomic exports).
+type exportProfiler struct {
+	cpu     bytes.Buffer
+	started time.Time
+}
+
+func startExportProfiler() (*exportProfiler, error) {
+	p := &exportProfiler{started: time.Now()}
+	if err := pprof.StartCPUProfile(&p.cpu); err != nil {
+		return nil, fmt.Errorf("start cpu profile: %w", err)
+	}
+	return p, nil
+}
+
+// stop ends CPU sampling; it is safe to call more than once.
+func (p *exportProfiler) stop() {
+	pprof.StopCPUProfile()
+}
+
+// write stores cpu.pprof, heap.pprof and phases.json in
+// <anytypeDir>/profile and returns that directory.
+func (p *exportProfiler) write(anytypeDir string, timings []phaseTiming, vault *vaultFiles) (string, error) {
+	p.stop()
+	dir := filepath.Join(anytypeDir, exportProfileDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := vault.writeFile(filepath.Join(dir, "cpu.pprof"), p.cpu.Bytes()); err != nil {
+		return "", err
+	}
+
+	var heap bytes.Buffer
+	runtime.GC()
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return "", fmt.Errorf("write heap profile: %w", err)
+	}
+	if err := vault.writeFile(filepath.Join(dir, "heap.pprof"), heap.Bytes()); err != nil {
+		return "", err
+	}
+
+	if timings == nil {
+		timings = []phaseTiming{}
+	}
+	payload, err := json.MarshalIndent(map[string]any{
+		"phases":       timings,
+		"totalSeconds": roundSeconds(time.Since(p.started)),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := vault.writeFile(filepath.Join(dir, "phases.json"), append(payload, '\n')); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func roundSeconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
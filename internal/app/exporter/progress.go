@@ -0,0 +1,240 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+)
+
+// Export phases in the order Run goes through them, as reported in
+// Event.Phase.
+const (
+	PhaseReadExport      = "reading export"
+	PhaseCopyFiles       = "copying files"
+	PhaseRenderBases     = "rendering bases"
+	PhaseRenderTemplates = "rendering templates"
+	PhaseRenderNotes     = "rendering notes"
+	PhasePostProcess     = "post-processing"
+)
+
+const progressRenderInterval = 100 * time.Millisecond
+
+// Event reports the export position: the phase, items done and the phase
+// total (zero when the size is unknown). ObjectID names the object just
+// finished in the base, template and note phases.
+type Event struct {
+	Phase    string
+	Current  int
+	Total    int
+	ObjectID string
+}
+
+var exportPhases = []string{
+	PhaseReadExport,
+	PhaseCopyFiles,
+	PhaseRenderBases,
+	PhaseRenderTemplates,
+	PhaseRenderNotes,
+	PhasePostProcess,
+}
+
+// exportProgressBar reports progress one phase at a time: each phase has its
+// own item count, rate and ETA, and the phase position shows overall progress.
+type exportProgressBar struct {
+	enabled         bool
+	phases          []string
+	phaseIndex      int
+	phase           string
+	total           int
+	current         int
+	phaseStarted    time.Time
+	lastRender      time.Time
+	lastRenderWidth int
+	label           string
+	timings         []phaseTiming
+	onProgress      func(Event)
+	now             func() time.Time
+	bar             progress.Model
+}
+
+func newExportProgressBar(phases []string) exportProgressBar {
+	bar := progress.New(progress.WithDefaultGradient(), progress.WithoutPercentage())
+	bar.Width = 36
+
+	if cols, err := strconv.Atoi(strings.TrimSpace(os.Getenv("COLUMNS"))); err == nil && cols > 0 {
+		width := cols - 72
+		if width < 16 {
+			width = 16
+		}
+		if width > 64 {
+			width = 64
+		}
+		bar.Width = width
+	}
+
+	return exportProgressBar{
+		enabled:    isTerminal(os.Stderr),
+		phases:     phases,
+		phaseIndex: -1,
+		now:        time.Now,
+		bar:        bar,
+	}
+}
+
+// StartPhase switches to the named phase with total items; a total of zero
+// marks a phase whose size is unknown, which renders without rate or ETA.
+func (p *exportProgressBar) StartPhase(name string, total int) {
+	p.timings = p.Timings()
+	for i, phase := range p.phases {
+		if phase == name {
+			p.phaseIndex = i
+			break
+		}
+	}
+	p.phase = name
+	p.total = total
+	p.current = 0
+	p.label = ""
+	p.phaseStarted = p.now()
+	p.notify("")
+	if p.enabled {
+		p.render()
+	}
+}
+
+// Timings returns how long each phase took so far, including the running
+// one.
+func (p *exportProgressBar) Timings() []phaseTiming {
+	if p.phase == "" {
+		return p.timings
+	}
+	timings := append([]phaseTiming(nil), p.timings...)
+	return append(timings, phaseTiming{Phase: p.phase, Seconds: roundSeconds(p.now().Sub(p.phaseStarted))})
+}
+
+func (p *exportProgressBar) Advance(label string) {
+	p.advance(label, "")
+}
+
+// AdvanceObject is Advance for a phase that walks objects; objectID is
+// passed on to the progress hook.
+func (p *exportProgressBar) AdvanceObject(objectID string) {
+	p.advance("", objectID)
+}
+
+func (p *exportProgressBar) advance(label string, objectID string) {
+	p.current++
+	if p.total > 0 && p.current > p.total {
+		p.current = p.total
+	}
+	p.label = label
+	p.notify(objectID)
+	if !p.enabled {
+		return
+	}
+	if p.current < p.total && p.now().Sub(p.lastRender) < progressRenderInterval {
+		return
+	}
+	p.render()
+}
+
+func (p *exportProgressBar) notify(objectID string) {
+	if p.onProgress != nil {
+		p.onProgress(Event{Phase: p.phase, Current: p.current, Total: p.total, ObjectID: objectID})
+	}
+}
+
+func (p *exportProgressBar) Finish(label string) {
+	if !p.enabled {
+		return
+	}
+	p.current = p.total
+	p.label = label
+	p.render()
+	fmt.Fprint(os.Stderr, "\n")
+	p.lastRenderWidth = 0
+}
+
+func (p *exportProgressBar) Close() {
+	if !p.enabled {
+		return
+	}
+	if p.lastRenderWidth > 0 {
+		fmt.Fprint(os.Stderr, "\n")
+		p.lastRenderWidth = 0
+	}
+}
+
+func (p *exportProgressBar) render() {
+	percent := 0.0
+	if p.total > 0 {
+		percent = float64(p.current) / float64(p.total)
+	}
+	if percent > 1 {
+		percent = 1
+	}
+	line := fmt.Sprintf("%s %3.0f%% %s", p.bar.ViewAs(percent), percent*100, p.status())
+	pad := ""
+	if p.lastRenderWidth > len(line) {
+		pad = strings.Repeat(" ", p.lastRenderWidth-len(line))
+	}
+	fmt.Fprintf(os.Stderr, "\r%s%s", line, pad)
+	p.lastRender = p.now()
+	p.lastRenderWidth = len(line)
+}
+
+// status formats the text after the bar, e.g.
+// "[5/6] rendering notes 1200/5000 · 410/s · ETA 9s".
+func (p *exportProgressBar) status() string {
+	parts := []string{}
+	if p.phaseIndex >= 0 && len(p.phases) > 0 {
+		parts = append(parts, fmt.Sprintf("[%d/%d]", p.phaseIndex+1, len(p.phases)))
+	}
+	parts = append(parts, p.phase)
+	if p.total > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", p.current, p.total))
+		elapsed := p.now().Sub(p.phaseStarted)
+		if rate := progressRate(p.current, elapsed); rate > 0 {
+			parts = append(parts, "· "+formatProgressRate(rate))
+			if remaining := p.total - p.current; remaining > 0 {
+				parts = append(parts, "· ETA "+formatProgressETA(time.Duration(float64(remaining)/rate*float64(time.Second))))
+			}
+		}
+	}
+	if label := strings.TrimSpace(p.label); label != "" && label != p.phase {
+		parts = append(parts, "· "+label)
+	}
+	return strings.Join(parts, " ")
+}
+
+func progressRate(done int, elapsed time.Duration) float64 {
+	if done <= 0 || elapsed <= 0 {
+		return 0
+	}
+	return float64(done) / elapsed.Seconds()
+}
+
+func formatProgressRate(rate float64) string {
+	if rate >= 100 {
+		return fmt.Sprintf("%.0f/s", rate)
+	}
+	return fmt.Sprintf("%.1f/s", rate)
+}
+
+func formatProgressETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < time.Second {
+		return "<1s"
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
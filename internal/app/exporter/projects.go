@@ -0,0 +1,240 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	queryTargetBases    = "bases"
+	queryTargetProjects = "projects"
+)
+
+func resolveQueryTarget(target string) (string, error) {
+	target = strings.TrimSpace(strings.ToLower(target))
+	switch target {
+	case "", queryTargetBases:
+		return queryTargetBases, nil
+	case queryTargetProjects:
+		return target, nil
+	default:
+		return "", fmt.Errorf("invalid query target %q: expected bases or projects", target)
+	}
+}
+
+// The shapes below follow the data.json of the Projects community plugin
+// (obsidian-projects), version 2.
+
+type projectsProject struct {
+	Name           string             `json:"name"`
+	ID             string             `json:"id"`
+	FieldConfig    map[string]any     `json:"fieldConfig"`
+	DefaultName    string             `json:"defaultName"`
+	Templates      []string           `json:"templates"`
+	ExcludedNotes  []string           `json:"excludedNotes"`
+	IsDefault      bool               `json:"isDefault"`
+	DataSource     projectsDataSource `json:"dataSource"`
+	NewNotesFolder string             `json:"newNotesFolder"`
+	Views          []projectsView     `json:"views"`
+}
+
+type projectsDataSource struct {
+	Kind   string         `json:"kind"`
+	Config map[string]any `json:"config"`
+}
+
+type projectsView struct {
+	Name   string         `json:"name"`
+	ID     string         `json:"id"`
+	Type   string         `json:"type"`
+	Config map[string]any `json:"config"`
+	Filter projectsFilter `json:"filter"`
+	Colors projectsColors `json:"colors"`
+	Sort   projectsSort   `json:"sort"`
+}
+
+type projectsFilter struct {
+	Conjunction string `json:"conjunction"`
+	Conditions  []any  `json:"conditions"`
+}
+
+type projectsColors struct {
+	Conditions []any `json:"conditions"`
+}
+
+type projectsSort struct {
+	Criteria []projectsSortCriteria `json:"criteria"`
+}
+
+type projectsSortCriteria struct {
+	Field   string `json:"field"`
+	Order   string `json:"order"`
+	Enabled bool   `json:"enabled"`
+}
+
+// projectsField names a view property the way Projects does: frontmatter
+// keys as is and the file name as "name". Other file properties have no
+// Projects field.
+func projectsField(property string) (string, bool) {
+	property = strings.TrimPrefix(property, "note.")
+	if property == "file.name" {
+		return "name", true
+	}
+	if strings.HasPrefix(property, "file.") || property == "" {
+		return "", false
+	}
+	return property, true
+}
+
+// buildProject turns a set or collection into a Projects project. Like
+// -kanban-boards, its members are resolved at export time: the project's
+// Dataview source lists their paths, and view filters are not carried over.
+func buildProject(obj objectInfo, name string, members []string, relations map[string]relationDef, optionNamesByID map[string]string, notes map[string]string, objectNamesByID map[string]string, fileObjects map[string]string, keys propertyKeys, dateAnchor time.Time) (projectsProject, bool) {
+	var specs []baseViewSpec
+	for _, b := range obj.Blocks {
+		if len(b.Dataview) == 0 {
+			continue
+		}
+		targetID := strings.TrimSpace(asString(anyMapGet(b.Dataview, "TargetObjectId", "targetObjectId")))
+		if targetID != "" && targetID != obj.ID {
+			continue
+		}
+		specs = append(specs, parseDataviewViews(b.Dataview, relations, optionNamesByID, notes, objectNamesByID, fileObjects, keys, true, dateAnchor)...)
+	}
+	if len(specs) == 0 {
+		return projectsProject{}, false
+	}
+
+	quoted := make([]string, 0, len(members))
+	for _, member := range members {
+		quoted = append(quoted, strconv.Quote(member))
+	}
+	project := projectsProject{
+		Name:          name,
+		ID:            "anytype-" + obj.ID,
+		FieldConfig:   map[string]any{},
+		Templates:     []string{},
+		ExcludedNotes: []string{},
+		DataSource: projectsDataSource{
+			Kind:   "dataview",
+			Config: map[string]any{"query": "TABLE WHERE contains(list(" + strings.Join(quoted, ", ") + "), file.path)"},
+		},
+	}
+	for i, spec := range specs {
+		view := projectsView{
+			Name:   spec.Name,
+			ID:     project.ID + "-" + strconv.Itoa(i+1),
+			Type:   "table",
+			Config: map[string]any{},
+			Filter: projectsFilter{Conjunction: "and", Conditions: []any{}},
+			Colors: projectsColors{Conditions: []any{}},
+			Sort:   projectsSort{Criteria: []projectsSortCriteria{}},
+		}
+		switch spec.Type {
+		case "cumban":
+			view.Type = "board"
+			if spec.GroupBy != nil {
+				if field, ok := projectsField(spec.GroupBy.Property); ok {
+					view.Config["groupByField"] = field
+				}
+			}
+		case "cards":
+			view.Type = "gallery"
+			if field, ok := projectsField(spec.Image); ok && spec.Image != "" {
+				view.Config["coverField"] = field
+			}
+			if spec.ImageFit != "" {
+				view.Config["fitStyle"] = spec.ImageFit
+			}
+		case "calendar":
+			view.Type = "calendar"
+		default:
+			var order []string
+			for _, property := range spec.Select {
+				if field, ok := projectsField(property); ok {
+					order = append(order, field)
+				}
+			}
+			fieldConfig := map[string]any{}
+			for _, column := range spec.ColumnSize {
+				if field, ok := projectsField(column.Property); ok {
+					fieldConfig[field] = map[string]any{"width": column.Width}
+				}
+			}
+			if len(order) > 0 {
+				view.Config["orderFields"] = order
+			}
+			if len(fieldConfig) > 0 {
+				view.Config["fieldConfig"] = fieldConfig
+			}
+		}
+		for _, s := range spec.Sort {
+			field, ok := projectsField(s.Property)
+			if !ok {
+				continue
+			}
+			order := "asc"
+			if s.Direction == "DESC" {
+				order = "desc"
+			}
+			view.Sort.Criteria = append(view.Sort.Criteria, projectsSortCriteria{Field: field, Order: order, Enabled: true})
+		}
+		project.Views = append(project.Views, view)
+	}
+	return project, true
+}
+
+// writeProjectsData adds projects to the Projects plugin's data.json,
+// replacing projects of earlier exports by id and keeping the user's own.
+func writeProjectsData(outputDir string, projects []projectsProject, vault *vaultFiles) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	dataPath := filepath.Join(outputDir, ".obsidian", "plugins", "obsidian-projects", "data.json")
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o755); err != nil {
+		return err
+	}
+	data := map[string]any{}
+	if raw, err := os.ReadFile(dataPath); err == nil {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("decode %s: %w", dataPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	ours := make(map[string]struct{}, len(projects))
+	for _, project := range projects {
+		ours[project.ID] = struct{}{}
+	}
+	var merged []any
+	for _, existing := range asAnySlice(data["projects"]) {
+		if m, ok := existing.(map[string]any); ok {
+			if _, replaced := ours[asString(m["id"])]; replaced {
+				continue
+			}
+		}
+		merged = append(merged, existing)
+	}
+	for _, project := range projects {
+		merged = append(merged, project)
+	}
+	data["projects"] = merged
+	if _, ok := data["version"]; !ok {
+		data["version"] = 2
+	}
+	if _, ok := data["archives"]; !ok {
+		data["archives"] = []any{}
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return vault.writeFile(dataPath, encoded)
+}
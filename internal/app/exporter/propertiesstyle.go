@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	propertiesStyleFrontmatter = "frontmatter"
+	propertiesStyleInline      = "inline"
+	propertiesStyleBoth        = "both"
+)
+
+func resolvePropertiesStyle(style string) (string, error) {
+	style = strings.TrimSpace(strings.ToLower(style))
+	switch style {
+	case "":
+		return propertiesStyleFrontmatter, nil
+	case propertiesStyleFrontmatter, propertiesStyleInline, propertiesStyleBoth:
+		return style, nil
+	default:
+		return "", fmt.Errorf("invalid properties style %q: expected frontmatter, inline, or both", style)
+	}
+}
+
+// renderInlineProperties writes the relation entries as Dataview inline
+// fields ("Key:: value") for the top of the note body, in frontmatter order.
+func renderInlineProperties(entries []frontmatterEntry, style string) string {
+	if style != propertiesStyleInline && style != propertiesStyleBoth {
+		return ""
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		if !entry.relation {
+			continue
+		}
+		text := inlinePropertyText(entry.value)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		b.WriteString(entry.key + ":: " + text + "\n")
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	b.WriteString("\n")
+	return b.String()
+}
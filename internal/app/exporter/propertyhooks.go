@@ -0,0 +1,50 @@
+package exporter
+
+import "sync"
+
+// PropertyInfo is a property value handed to a PropertyConverter.
+type PropertyInfo struct {
+	// Key is the relation key and Value the value as Anytype stores it.
+	Key   string
+	Value any
+	// Name and Format describe the relation when the export defines it.
+	Name        string
+	Format      int
+	HasRelation bool
+	// NotePath is the vault-relative note the value is written to; it is
+	// empty for values in bases and tables.
+	NotePath string
+}
+
+// PropertyConverter customizes how values of a relation are converted.
+// BeforeConvert can replace the conversion: when it returns true its value
+// is used as the converted value. AfterConvert then gets the converted
+// value and returns the one to write.
+type PropertyConverter interface {
+	BeforeConvert(PropertyInfo) (any, bool)
+	AfterConvert(PropertyInfo, any) any
+}
+
+var (
+	propertyConvertersMu sync.RWMutex
+	propertyConverters   = map[string]PropertyConverter{}
+)
+
+// RegisterPropertyConverter makes conv convert the values of relation key
+// wherever they are written. A nil conv removes the registration.
+func RegisterPropertyConverter(key string, conv PropertyConverter) {
+	propertyConvertersMu.Lock()
+	defer propertyConvertersMu.Unlock()
+	if conv == nil {
+		delete(propertyConverters, key)
+		return
+	}
+	propertyConverters[key] = conv
+}
+
+func propertyConverter(key string) (PropertyConverter, bool) {
+	propertyConvertersMu.RLock()
+	defer propertyConvertersMu.RUnlock()
+	conv, ok := propertyConverters[key]
+	return conv, ok
+}
@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+// obsidianPropertyType maps an Anytype relation format to the property type
+// Obsidian stores in .obsidian/types.json.
+func obsidianPropertyType(rel relationDef) string {
+	switch rel.Format {
+	case anytypedomain.RelationFormatNumber:
+		return "number"
+	case anytypedomain.RelationFormatCheckbox:
+		return "checkbox"
+	case anytypedomain.RelationFormatDate:
+		return "date"
+	case anytypedomain.RelationFormatStatus, anytypedomain.RelationFormatTag, anytypedomain.RelationFormatObjectRef, anytypedomain.RelationFormatFile:
+		return "multitext"
+	case anytypedomain.RelationFormatLongText, anytypedomain.RelationFormatShortText, anytypedomain.RelationFormatURL, anytypedomain.RelationFormatEmail, anytypedomain.RelationFormatPhone, anytypedomain.RelationFormatEmoji:
+		return "text"
+	default:
+		return ""
+	}
+}
+
+func collectObsidianPropertyTypes(objects []objectInfo, exportedNotePathByID map[string]string, relations map[string]relationDef, typesByID map[string]typeDef, opts frontmatterOptions) map[string]string {
+	types := map[string]string{}
+	if opts.propertiesStyle == propertiesStyleInline {
+		return types
+	}
+	for _, obj := range objects {
+		if _, ok := exportedNotePathByID[obj.ID]; !ok {
+			continue
+		}
+		forEachFrontmatterProperty(obj, relations, typesByID, opts, func(k string, rel relationDef, hasRel bool, dateByType bool) {
+			outKey := frontmatterKey(k, rel, hasRel, opts.keys())
+			if outKey == "tags" || outKey == "cover" {
+				return
+			}
+			if _, nested := opts.tagHierarchy.prefix(k, rel, hasRel); nested {
+				return
+			}
+			propertyType := ""
+			switch {
+			case dateByType:
+				propertyType = "date"
+			case !hasRel:
+				return
+			case opts.filters.hasLinkAsNote(k, rel, hasRel):
+				propertyType = "multitext"
+			default:
+				propertyType = obsidianPropertyType(rel)
+			}
+			if propertyType == "" {
+				return
+			}
+			if _, exists := types[outKey]; !exists {
+				types[outKey] = propertyType
+			}
+		})
+	}
+	return types
+}
+
+func writeObsidianPropertyTypes(outputDir string, types map[string]string, vault *vaultFiles) error {
+	if len(types) == 0 {
+		return nil
+	}
+
+	typesPath := filepath.Join(outputDir, ".obsidian", "types.json")
+	if err := os.MkdirAll(filepath.Dir(typesPath), 0o755); err != nil {
+		return err
+	}
+
+	data := map[string]any{}
+	if raw, err := os.ReadFile(typesPath); err == nil {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("decode %s: %w", typesPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	existing := ensureAnyMap(data, "types")
+	for key, propertyType := range types {
+		if _, ok := existing[key]; ok {
+			continue
+		}
+		existing[key] = propertyType
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return vault.writeFile(typesPath, append(encoded, '\n'))
+}
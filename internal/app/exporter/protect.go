@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// resolveProtectPatterns trims the configured protect globs, drops blank
+// entries and rejects patterns path.Match cannot parse.
+func resolveProtectPatterns(patterns []string) ([]string, error) {
+	var resolved []string
+	for _, pattern := range patterns {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("invalid protect pattern %q: %w", pattern, err)
+			}
+		}
+		resolved = append(resolved, pattern)
+	}
+	return resolved, nil
+}
+
+// matchVaultGlobs reports whether the vault-relative relPath matches one of
+// patterns. Patterns are matched from the vault root segment by segment;
+// "**" stands for any number of directories, so "Daily/**" covers
+// everything below Daily.
+func matchVaultGlobs(relPath string, patterns []string) bool {
+	parts := strings.Split(strings.Trim(relPath, "/"), "/")
+	for _, pattern := range patterns {
+		if matchGlobSegments(strings.Split(pattern, "/"), parts) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlobSegments(pattern []string, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchGlobSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// vaultFiles writes files below root, the vault directory, and leaves every
+// path that matches a protect glob alone. Every exporter write into the
+// vault goes through it or through vaultMerger.write.
+type vaultFiles struct {
+	root    string
+	protect []string
+	// skipped lists the vault-relative paths that were not written.
+	skipped []string
+}
+
+// protected reports whether path is a vault path matching a protect glob.
+func (v *vaultFiles) protected(path string) bool {
+	if v == nil || len(v.protect) == 0 {
+		return false
+	}
+	rel, ok := v.rel(path)
+	return ok && matchVaultGlobs(rel, v.protect)
+}
+
+// skip reports whether path is protected and, if so, records it in
+// skipped. Writers that do not go through writeFile check it first.
+func (v *vaultFiles) skip(path string) bool {
+	if !v.protected(path) {
+		return false
+	}
+	rel, _ := v.rel(path)
+	v.skipped = append(v.skipped, rel)
+	return true
+}
+
+// writeFile is os.WriteFile for vault files; a protected path is recorded
+// in skipped instead of being written.
+func (v *vaultFiles) writeFile(path string, data []byte) error {
+	if v.skip(path) {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (v *vaultFiles) rel(path string) (string, bool) {
+	root, err := filepath.Abs(v.root)
+	if err != nil {
+		return "", false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func resolveRelationBlockStyle(style string) (string, error) {
+	style = strings.TrimSpace(strings.ToLower(style))
+	switch style {
+	case "":
+		return "dataview", nil
+	case "dataview", "line", "skip":
+		return style, nil
+	default:
+		return "", fmt.Errorf("invalid relation block style %q: expected dataview, line, or skip", style)
+	}
+}
+
+// renderRelationBlock shows an inline relation block as a Dataview field
+// ("Key:: value") or a bold label line.
+func renderRelationBlock(ctx bodyContext, key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" || (ctx.relationBlocks != "dataview" && ctx.relationBlocks != "line") {
+		return ""
+	}
+	value, ok := ctx.details[key]
+	if !ok {
+		return ""
+	}
+	converted := convertPropertyValue(key, value, ctx.relations, ctx.optionNamesByID, ctx.notes, ctx.sourceNotePath, ctx.objectNamesByID, ctx.fileObjects, false, false)
+	text := inlinePropertyText(converted)
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	label := key
+	if rel, ok := ctx.relations[key]; ok && strings.TrimSpace(rel.Name) != "" {
+		label = strings.TrimSpace(rel.Name)
+	}
+	if ctx.relationBlocks == "line" {
+		return "**" + label + "**: " + text + "\n"
+	}
+	return label + ":: " + text + "\n"
+}
+
+func inlinePropertyText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.ReplaceAll(strings.TrimSpace(v), "\n", " ")
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := inlinePropertyText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return ""
+	}
+}
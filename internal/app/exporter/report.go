@@ -0,0 +1,49 @@
+package exporter
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+)
+
+const exportReportFileName = "report.json"
+
+func (s Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Notes          int     `json:"notes"`
+		Bases          int     `json:"bases"`
+		Templates      int     `json:"templates"`
+		Files          int     `json:"files"`
+		Warnings       int     `json:"warnings"`
+		ElapsedSeconds float64 `json:"elapsedSeconds,omitempty"`
+		ReportPath     string  `json:"reportPath,omitempty"`
+		SkippedEmpty   int     `json:"skippedEmpty,omitempty"`
+	}{
+		Notes:          s.Notes,
+		Bases:          s.Bases,
+		Templates:      s.Templates,
+		Files:          s.Files,
+		Warnings:       s.Warnings,
+		ElapsedSeconds: s.Elapsed.Round(time.Millisecond).Seconds(),
+		ReportPath:     s.ReportPath,
+		SkippedEmpty:   s.SkippedEmpty,
+	})
+}
+
+func writeExportReport(anytypeDir string, stats Stats, warnings []string, vault *vaultFiles) (string, error) {
+	if warnings == nil {
+		warnings = []string{}
+	}
+	reportPath := filepath.Join(anytypeDir, exportReportFileName)
+	payload, err := json.MarshalIndent(map[string]any{
+		"stats":    stats,
+		"warnings": warnings,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := vault.writeFile(reportPath, append(payload, '\n')); err != nil {
+		return "", err
+	}
+	return reportPath, nil
+}
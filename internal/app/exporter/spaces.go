@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sleroq/anytype-to-obsidian/internal/infra/anytypejson"
+)
+
+// openInput opens e.InputDir, or hands out the view a split-by-space run
+// prepared.
+func (e Exporter) openInput() (fs.FS, func() error, error) {
+	if e.input != nil {
+		return e.input, func() error { return nil }, nil
+	}
+	return anytypejson.OpenInput(e.InputDir)
+}
+
+// runSplitBySpace exports every space of the input into its own vault at
+// <output>/<Space Name>/, each with its own .obsidian config, index and
+// files. Objects shared by all spaces, like relations without a spaceId, go
+// into every vault. An export without space ids is written as one vault.
+func (e Exporter) runSplitBySpace(ctx context.Context) (Stats, error) {
+	input, closeInput, err := e.openInput()
+	if err != nil {
+		return Stats{}, err
+	}
+	defer closeInput()
+	spaces, err := anytypejson.SplitSpaces(input)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	staged := e
+	staged.SplitBySpace = false
+	if len(spaces) == 0 {
+		e.logger().Warn("export has no space ids; writing a single vault")
+		staged.input = input
+		return staged.RunContext(ctx)
+	}
+
+	filenameEscaping, err := resolveFilenameEscaping(e.FilenameEscaping)
+	if err != nil {
+		return Stats{}, err
+	}
+	var total Stats
+	used := map[string]struct{}{}
+	for _, space := range spaces {
+		dirName := spaceDirName(space.Name, filenameEscaping, used)
+		e.logger().Info("exporting space", "space", space.ID, "dir", dirName)
+		staged.input = space.FS
+		staged.OutputDir = filepath.Join(e.OutputDir, dirName)
+		stats, err := staged.RunContext(ctx)
+		if err != nil {
+			return Stats{}, fmt.Errorf("export space %s: %w", space.Name, err)
+		}
+		total.Notes += stats.Notes
+		total.Bases += stats.Bases
+		total.Templates += stats.Templates
+		total.Files += stats.Files
+		total.Warnings += stats.Warnings
+		total.Elapsed += stats.Elapsed
+		for _, msg := range stats.WarningMessages {
+			total.WarningMessages = append(total.WarningMessages, space.Name+": "+msg)
+		}
+	}
+	// Every space vault has its own report.
+	return total, nil
+}
+
+// spaceDirName turns a space name into a vault folder name that is unique
+// among the spaces exported so far.
+func spaceDirName(name string, mode string, used map[string]struct{}) string {
+	base := sanitizeName(name, mode)
+	dirName := base
+	for n := 2; ; n++ {
+		if _, ok := used[strings.ToLower(dirName)]; !ok {
+			break
+		}
+		dirName = base + "-" + strconv.Itoa(n)
+	}
+	used[strings.ToLower(dirName)] = struct{}{}
+	return dirName
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+// systemSbTypes are the smart block types Anytype keeps for its own
+// bookkeeping: dashboards, the bin, widgets, the workspace and the account
+// profile. Exported as notes they are empty or meaningless.
+var systemSbTypes = map[string]struct{}{
+	"Home":           {},
+	"Archive":        {},
+	"Widget":         {},
+	"Workspace":      {},
+	"SpaceView":      {},
+	"ProfilePage":    {},
+	"AnytypeProfile": {},
+	"Identity":       {},
+}
+
+var systemLayoutNames = map[int]string{
+	anytypedomain.LayoutDashboard: "Dashboard",
+	anytypedomain.LayoutSpace:     "Space",
+	anytypedomain.LayoutSpaceView: "SpaceView",
+}
+
+// systemObjectKind names the kind of system object obj is: its sbType, or
+// its layout for objects whose sbType is a plain page.
+func systemObjectKind(obj objectInfo) (string, bool) {
+	if _, ok := systemSbTypes[obj.SbType]; ok {
+		return obj.SbType, true
+	}
+	layout, ok := obj.Details["resolvedLayout"]
+	if !ok {
+		layout, ok = obj.Details["layout"]
+	}
+	if !ok {
+		return "", false
+	}
+	name, ok := systemLayoutNames[asInt(layout)]
+	return name, ok
+}
+
+// filterSystemObjects drops system objects unless includeAll is set or
+// their kind is listed in keepKinds (case-insensitive). onSkip is called
+// for every dropped object.
+func filterSystemObjects(objects []objectInfo, includeAll bool, keepKinds []string, onSkip func(objectInfo, string)) []objectInfo {
+	if includeAll {
+		return objects
+	}
+	keep := map[string]struct{}{}
+	for _, kind := range keepKinds {
+		if kind = strings.ToLower(strings.TrimSpace(kind)); kind != "" {
+			keep[kind] = struct{}{}
+		}
+	}
+	filtered := make([]objectInfo, 0, len(objects))
+	for _, obj := range objects {
+		if kind, ok := systemObjectKind(obj); ok {
+			if _, kept := keep[strings.ToLower(kind)]; !kept {
+				onSkip(obj, kind)
+				continue
+			}
+		}
+		filtered = append(filtered, obj)
+	}
+	return filtered
+}
@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"html"
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+// inlineStyleMarks lists the Anytype marks kept inside table cells, in the
+// order their delimiters are opened.
+var inlineStyleMarks = []struct {
+	mark      string
+	delimiter string
+	tag       string
+}{
+	{mark: "bold", delimiter: "**", tag: "strong"},
+	{mark: "italic", delimiter: "*", tag: "em"},
+	{mark: "strikethrough", delimiter: "~~", tag: "del"},
+	{mark: "keyboard", delimiter: "`", tag: "code"},
+}
+
+// renderTableCell renders the content of a table cell as inline markdown,
+// or inline HTML when asHTML is set. Text keeps its links, mentions and
+// bold, italic, strikethrough and code marks; checkboxes become emoji.
+// Blocks inside the cell are separated by newlines, which
+// writeMarkdownTableRow turns into <br>, or by <br> in HTML.
+func renderTableCell(ctx bodyContext, id string, asHTML bool) string {
+	b, ok := ctx.byID[id]
+	if !ok {
+		return ""
+	}
+	var parts []string
+	if b.Text != nil {
+		text := strings.TrimSpace(renderInlineText(b.Text.Text, b.Text.Marks, ctx.notes, ctx.sourceNotePath, asHTML))
+		if b.Text.Style == "Checkbox" {
+			box := "⬜"
+			if b.Text.Checked {
+				box = "✅"
+			}
+			text = strings.TrimSpace(box + " " + text)
+		}
+		if text != "" {
+			parts = append(parts, text)
+		}
+	} else if b.Bookmark != nil || b.File != nil {
+		if asHTML {
+			return html.EscapeString(extractPlainText(ctx.byID, id))
+		}
+		return extractPlainText(ctx.byID, id)
+	}
+	for _, cid := range b.ChildrenID {
+		if part := renderTableCell(ctx, cid, asHTML); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if asHTML {
+		return strings.Join(parts, "<br>")
+	}
+	return strings.Join(parts, "\n")
+}
+
+// renderInlineText applies the link, mention and style marks of text.
+// Style delimiters wrap the trimmed run they cover, since markdown does not
+// allow whitespace just inside them. With asHTML the text is escaped, marks
+// become tags and wikilinks become Obsidian internal-link anchors.
+func renderInlineText(text string, marks *anytypedomain.TextMarks, notes map[string]string, sourceNotePath string, asHTML bool) string {
+	plain := func(s string) string {
+		if asHTML {
+			return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>")
+		}
+		return s
+	}
+	if strings.TrimSpace(text) == "" || marks == nil || len(marks.Marks) == 0 {
+		return plain(text)
+	}
+	runes := []rune(text)
+	styles := make([]int, len(runes))
+	for _, mark := range marks.Marks {
+		from, to, ok := clampMarkRange(mark.Range, len(runes))
+		if !ok {
+			continue
+		}
+		for i, style := range inlineStyleMarks {
+			if strings.EqualFold(strings.TrimSpace(mark.Type), style.mark) {
+				for pos := from; pos < to; pos++ {
+					styles[pos] |= 1 << i
+				}
+			}
+		}
+	}
+
+	type inlineRun struct {
+		text  strings.Builder
+		style int
+	}
+	var runs []*inlineRun
+	add := func(text string, style int) {
+		if len(runs) == 0 || runs[len(runs)-1].style != style {
+			runs = append(runs, &inlineRun{style: style})
+		}
+		runs[len(runs)-1].text.WriteString(text)
+	}
+	replacements := textMarkReplacements(runes, marks, notes, sourceNotePath)
+	for pos := 0; pos < len(runes); {
+		if len(replacements) > 0 && replacements[0].from == pos {
+			// A link is styled with what covers all of it.
+			style := styles[pos]
+			for _, s := range styles[pos:replacements[0].to] {
+				style &= s
+			}
+			repl := replacements[0].repl
+			if asHTML {
+				repl = htmlInlineLink(replacements[0], string(runes[pos:replacements[0].to]))
+			}
+			add(repl, style)
+			pos = replacements[0].to
+			replacements = replacements[1:]
+			continue
+		}
+		add(plain(string(runes[pos])), styles[pos])
+		pos++
+	}
+
+	var out strings.Builder
+	for _, run := range runs {
+		content := run.text.String()
+		trimmed := strings.TrimSpace(content)
+		if run.style == 0 || trimmed == "" {
+			out.WriteString(content)
+			continue
+		}
+		open, closing := "", ""
+		for i, style := range inlineStyleMarks {
+			if run.style&(1<<i) == 0 {
+				continue
+			}
+			if asHTML {
+				open += "<" + style.tag + ">"
+				closing = "</" + style.tag + ">" + closing
+				continue
+			}
+			open += style.delimiter
+			closing = style.delimiter + closing
+		}
+		start := strings.Index(content, trimmed)
+		out.WriteString(content[:start] + open + trimmed + closing + content[start+len(trimmed):])
+	}
+	return out.String()
+}
+
+// htmlInlineLink renders a link mark as an anchor. Wikilink targets use the
+// internal-link class Obsidian resolves like a [[link]].
+func htmlInlineLink(r textReplacement, text string) string {
+	label := r.label
+	if label == "" {
+		label = strings.TrimSpace(text)
+	}
+	if label == "" {
+		label = r.href
+	}
+	if r.wiki {
+		href := html.EscapeString(r.href)
+		return `<a class="internal-link" data-href="` + href + `" href="` + href + `">` + html.EscapeString(label) + "</a>"
+	}
+	return `<a href="` + html.EscapeString(r.href) + `">` + html.EscapeString(label) + "</a>"
+}
@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	tableFormatCSV  = "csv"
+	tableFormatJSON = "json"
+)
+
+func resolveTableFormat(format string) (string, error) {
+	format = strings.TrimSpace(strings.ToLower(format))
+	switch format {
+	case "", tableFormatCSV:
+		return tableFormatCSV, nil
+	case tableFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid table format %q: expected csv or json", format)
+	}
+}
+
+type tableColumn struct {
+	Key  string
+	Name string
+}
+
+// objectTable is a set or collection as a spreadsheet: one row per member,
+// one column per visible relation of its first view.
+type objectTable struct {
+	Columns []tableColumn
+	Rows    [][]any
+}
+
+// buildObjectTable lists the members of base with the relations its first
+// view shows, in the view's column order. Name always comes first.
+func buildObjectTable(base objectInfo, objects []objectInfo, relations map[string]relationDef, optionNamesByID map[string]string, objectNamesByID map[string]string, fileObjects map[string]string) objectTable {
+	columns := []tableColumn{{Key: "name", Name: "Name"}}
+	seen := map[string]struct{}{"name": {}}
+	for _, b := range base.Blocks {
+		views := asAnySlice(anyMapGet(b.Dataview, "views", "Views"))
+		if len(views) == 0 {
+			continue
+		}
+		viewMap, _ := views[0].(map[string]any)
+		for _, relationRaw := range asAnySlice(anyMapGet(viewMap, "relations", "Relations")) {
+			relationMap, ok := relationRaw.(map[string]any)
+			if !ok {
+				continue
+			}
+			if visible, ok := anyMapGet(relationMap, "isVisible", "IsVisible").(bool); ok && !visible {
+				continue
+			}
+			key := strings.TrimSpace(asString(anyMapGet(relationMap, "key", "Key")))
+			if _, dup := seen[key]; dup || key == "" {
+				continue
+			}
+			seen[key] = struct{}{}
+			name := key
+			if rel, ok := relations[key]; ok && strings.TrimSpace(rel.Name) != "" {
+				name = strings.TrimSpace(rel.Name)
+			}
+			columns = append(columns, tableColumn{Key: key, Name: name})
+		}
+		break
+	}
+
+	members := baseMemberObjects(base, objects)
+	sort.SliceStable(members, func(i, j int) bool { return inferObjectTitle(members[i]) < inferObjectTitle(members[j]) })
+	table := objectTable{Columns: columns}
+	for _, obj := range members {
+		row := make([]any, len(columns))
+		for i, column := range columns {
+			if column.Key == "name" {
+				row[i] = inferObjectTitle(obj)
+				continue
+			}
+			raw, ok := obj.Details[column.Key]
+			if !ok || raw == nil {
+				continue
+			}
+			// Without note paths object relations resolve to names.
+			row[i] = convertPropertyValue(column.Key, raw, relations, optionNamesByID, nil, "", objectNamesByID, fileObjects, false, false)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+func tableCellString(value any) string {
+	switch t := value.(type) {
+	case nil:
+		return ""
+	case []string:
+		return strings.Join(t, ", ")
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			parts = append(parts, tableCellString(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return mappedToString(value)
+	}
+}
+
+// writeObjectTable writes table to dir/<name>.csv, or as a JSON array of
+// column name to value objects to dir/<name>.json.
+func writeObjectTable(dir string, name string, format string, table objectTable, vault *vaultFiles) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if format == tableFormatJSON {
+		rows := make([]map[string]any, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			entry := make(map[string]any, len(row))
+			for i, column := range table.Columns {
+				entry[column.Name] = row[i]
+			}
+			rows = append(rows, entry)
+		}
+		encoded, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		return vault.writeFile(filepath.Join(dir, name+".json"), append(encoded, '\n'))
+	}
+
+	csvPath := filepath.Join(dir, name+".csv")
+	if vault.skip(csvPath) {
+		return nil
+	}
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	header := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column.Name
+	}
+	_ = w.Write(header)
+	for _, row := range table.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = tableCellString(value)
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
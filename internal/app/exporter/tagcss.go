@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const tagColorsSnippetName = "anytype-tag-colors"
+
+// collectTagColors maps every tag the export writes for a relation option to
+// the option's Anytype color. Options without a color, or with the default
+// grey, are left to the theme.
+func collectTagColors(relations map[string]relationDef, optionsByID map[string]relationOption, opts frontmatterOptions) map[string]string {
+	colors := map[string]string{}
+	keys := opts.keys()
+	for _, option := range optionsByID {
+		name := strings.TrimSpace(option.Name)
+		if name == "" {
+			continue
+		}
+		color, ok := mapAnytypePrettyPropertiesColor(asString(option.Details["relationOptionColor"]))
+		if !ok || color == "default" || color == "none" {
+			continue
+		}
+		relationKey := strings.TrimSpace(asString(option.Details["relationKey"]))
+		rel, hasRel := relations[relationKey]
+		var tags []string
+		if prefix, ok := opts.tagHierarchy.prefix(relationKey, rel, hasRel); ok {
+			tags = hierarchyTags(prefix, name)
+		} else if keys.isTags(relationKey, rel, hasRel) {
+			if tag := sanitizeObsidianTag(name); tag != "" {
+				tags = []string{tag}
+			}
+		}
+		for _, tag := range tags {
+			colors[tag] = color
+		}
+	}
+	return colors
+}
+
+// renderTagColorsCSS styles each tag through the --tag-* variables Obsidian
+// reads for .tag elements, using the theme's palette for the color.
+func renderTagColorsCSS(colors map[string]string) string {
+	tags := make([]string, 0, len(colors))
+	for tag := range colors {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var b strings.Builder
+	b.WriteString("/* Anytype tag colors written by anytype-to-obsidian; re-running the export overwrites this file. */\n")
+	for _, tag := range tags {
+		color := colors[tag]
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(tag)
+		fmt.Fprintf(&b, "\n.tag[href=\"#%s\"] {\n", escaped)
+		fmt.Fprintf(&b, "  --tag-color: var(--color-%s);\n", color)
+		fmt.Fprintf(&b, "  --tag-background: rgba(var(--color-%s-rgb), 0.15);\n", color)
+		fmt.Fprintf(&b, "  --tag-background-hover: rgba(var(--color-%s-rgb), 0.25);\n", color)
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// writeTagColorsSnippet writes the tag color snippet and enables it in
+// .obsidian/appearance.json, keeping the vault's other appearance settings.
+func writeTagColorsSnippet(outputDir string, colors map[string]string, vault *vaultFiles) error {
+	if len(colors) == 0 {
+		return nil
+	}
+	snippetPath := filepath.Join(outputDir, ".obsidian", "snippets", tagColorsSnippetName+".css")
+	if err := os.MkdirAll(filepath.Dir(snippetPath), 0o755); err != nil {
+		return err
+	}
+	if err := vault.writeFile(snippetPath, []byte(renderTagColorsCSS(colors))); err != nil {
+		return err
+	}
+
+	appearancePath := filepath.Join(outputDir, ".obsidian", "appearance.json")
+	data := map[string]any{}
+	if raw, err := os.ReadFile(appearancePath); err == nil {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("decode %s: %w", appearancePath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	enabled := anyToStringSlice(data["enabledCssSnippets"])
+	for _, name := range enabled {
+		if name == tagColorsSnippetName {
+			return nil
+		}
+	}
+	data["enabledCssSnippets"] = append(enabled, tagColorsSnippetName)
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return vault.writeFile(appearancePath, encoded)
+}
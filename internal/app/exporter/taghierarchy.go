@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagPrefix is the nested tag root for one relation mapped with
+// -tag-hierarchy. fromName means the root is the relation name.
+type tagPrefix struct {
+	value    string
+	fromName bool
+}
+
+type tagHierarchy map[string]tagPrefix
+
+// resolveTagHierarchy parses "relation" and "relation=prefix" entries. A
+// bare relation uses its name as the tag root; an empty prefix keeps the
+// option names as they are, "/" included.
+func resolveTagHierarchy(entries []string) (tagHierarchy, error) {
+	out := tagHierarchy{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, prefix, hasPrefix := strings.Cut(entry, "=")
+		norm := normalizePropertyKey(key)
+		if norm == "" {
+			return nil, fmt.Errorf("invalid tag hierarchy entry %q: expected relation or relation=prefix", entry)
+		}
+		if hasPrefix {
+			out[norm] = tagPrefix{value: strings.Trim(strings.TrimSpace(prefix), "/")}
+		} else {
+			out[norm] = tagPrefix{fromName: true}
+		}
+	}
+	return out, nil
+}
+
+func (h tagHierarchy) prefix(rawKey string, rel relationDef, hasRel bool) (string, bool) {
+	if len(h) == 0 {
+		return "", false
+	}
+	for _, candidate := range propertyCandidates(rawKey, rel, hasRel) {
+		p, ok := h[normalizePropertyKey(candidate)]
+		if !ok {
+			continue
+		}
+		if !p.fromName {
+			return p.value, true
+		}
+		name := rawKey
+		if hasRel && strings.TrimSpace(rel.Name) != "" {
+			name = rel.Name
+		}
+		return sanitizeObsidianTagPart(name), true
+	}
+	return "", false
+}
+
+// hierarchyTags turns the option names of a mapped relation into nested
+// tags below prefix.
+func hierarchyTags(prefix string, value any) []string {
+	var items []string
+	switch v := value.(type) {
+	case string:
+		items = []string{v}
+	case []string:
+		items = v
+	case []any:
+		for _, item := range v {
+			items = append(items, asString(item))
+		}
+	}
+	tags := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if prefix != "" {
+			item = prefix + "/" + item
+		}
+		if tag := sanitizeObsidianTag(item); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// resolveTagSources returns the relations merged into tags, or nil for the
+// default of the relation called "tag". noMerge writes every relation under
+// its own key, "tag" included.
+func resolveTagSources(from []string, noMerge bool) (map[string]struct{}, error) {
+	if noMerge && len(from) > 0 {
+		return nil, fmt.Errorf("tags-from-properties and no-merge-tags cannot be combined")
+	}
+	if noMerge {
+		return map[string]struct{}{}, nil
+	}
+	if len(from) == 0 {
+		return nil, nil
+	}
+	return normalizePropertyKeySet(from), nil
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var transliterationTable = buildTransliterationTable()
+
+func buildTransliterationTable() map[rune]string {
+	table := map[rune]string{}
+	add := func(pairs ...string) {
+		for i := 0; i+1 < len(pairs); i += 2 {
+			for _, r := range pairs[i] {
+				table[r] = pairs[i+1]
+			}
+		}
+	}
+
+	// Cyrillic (Russian, Ukrainian, Belarusian), lowercase; uppercase is derived below.
+	add(
+		"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "е", "e", "ё", "yo", "ж", "zh",
+		"з", "z", "и", "i", "й", "y", "к", "k", "л", "l", "м", "m", "н", "n", "о", "o",
+		"п", "p", "р", "r", "с", "s", "т", "t", "у", "u", "ф", "f", "х", "kh", "ц", "ts",
+		"ч", "ch", "ш", "sh", "щ", "shch", "ъ", "", "ы", "y", "ь", "", "э", "e", "ю", "yu",
+		"я", "ya", "є", "ye", "і", "i", "ї", "yi", "ґ", "g", "ў", "u",
+	)
+	// Greek.
+	add(
+		"αά", "a", "β", "v", "γ", "g", "δ", "d", "εέ", "e", "ζ", "z", "ηή", "i", "θ", "th",
+		"ιίϊΐ", "i", "κ", "k", "λ", "l", "μ", "m", "ν", "n", "ξ", "x", "οό", "o", "π", "p",
+		"ρ", "r", "σς", "s", "τ", "t", "υύϋΰ", "y", "φ", "f", "χ", "ch", "ψ", "ps", "ωώ", "o",
+	)
+	// Latin letters with diacritics.
+	add(
+		"àáâãäåāăą", "a", "æ", "ae", "çćĉċč", "c", "ďđ", "d", "èéêëēĕėęě", "e",
+		"ĝğġģ", "g", "ĥħ", "h", "ìíîïĩīĭįı", "i", "ĵ", "j", "ķ", "k", "ĺļľŀł", "l",
+		"ñńņňŉ", "n", "òóôõöøōŏő", "o", "œ", "oe", "ŕŗř", "r", "śŝşšș", "s", "ß", "ss",
+		"ţťŧț", "t", "ùúûüũūŭůűų", "u", "ŵ", "w", "ýÿŷ", "y", "źżž", "z", "þ", "th", "ð", "d",
+	)
+	// Japanese kana (Hepburn).
+	kana := []string{
+		"あア", "a", "いイ", "i", "うウ", "u", "えエ", "e", "おオ", "o",
+		"かカ", "ka", "きキ", "ki", "くク", "ku", "けケ", "ke", "こコ", "ko",
+		"がガ", "ga", "ぎギ", "gi", "ぐグ", "gu", "げゲ", "ge", "ごゴ", "go",
+		"さサ", "sa", "しシ", "shi", "すス", "su", "せセ", "se", "そソ", "so",
+		"ざザ", "za", "じジ", "ji", "ずズ", "zu", "ぜゼ", "ze", "ぞゾ", "zo",
+		"たタ", "ta", "ちチ", "chi", "つツ", "tsu", "てテ", "te", "とト", "to",
+		"だダ", "da", "ぢヂ", "ji", "づヅ", "zu", "でデ", "de", "どド", "do",
+		"なナ", "na", "にニ", "ni", "ぬヌ", "nu", "ねネ", "ne", "のノ", "no",
+		"はハ", "ha", "ひヒ", "hi", "ふフ", "fu", "へヘ", "he", "ほホ", "ho",
+		"ばバ", "ba", "びビ", "bi", "ぶブ", "bu", "べベ", "be", "ぼボ", "bo",
+		"ぱパ", "pa", "ぴピ", "pi", "ぷプ", "pu", "ぺペ", "pe", "ぽポ", "po",
+		"まマ", "ma", "みミ", "mi", "むム", "mu", "めメ", "me", "もモ", "mo",
+		"やヤ", "ya", "ゆユ", "yu", "よヨ", "yo",
+		"らラ", "ra", "りリ", "ri", "るル", "ru", "れレ", "re", "ろロ", "ro",
+		"わワ", "wa", "をヲ", "o", "んン", "n", "ー", "-",
+		"ぁァ", "a", "ぃィ", "i", "ぅゥ", "u", "ぇェ", "e", "ぉォ", "o",
+		"ゃャ", "ya", "ゅュ", "yu", "ょョ", "yo",
+	}
+	add(kana...)
+
+	for r, latin := range table {
+		upper := unicode.ToUpper(r)
+		if upper == r {
+			continue
+		}
+		if _, exists := table[upper]; exists {
+			continue
+		}
+		table[upper] = capitalizeASCII(latin)
+	}
+	return table
+}
+
+func capitalizeASCII(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Unmapped letters/digits become uXXXX code points; other symbols are dropped.
+func transliterateToASCII(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII {
+			b.WriteRune(r)
+			continue
+		}
+		if latin, ok := transliterationTable[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		if unicode.IsSpace(r) {
+			b.WriteRune(' ')
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteString("u" + strings.ToUpper(strconv.FormatInt(int64(r), 16)))
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// asciiSlug turns a name into a lowercase ASCII slug ("Привет, мир!" ->
+// "privet-mir") for filesystems and sync services that reject Unicode.
+func asciiSlug(s string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range transliterateToASCII(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			pendingDash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+)
+
+const trivialObjectMaxBlocks = 8
+
+// trivialFastPath turns the fast path off in tests, which compare its output
+// with full rendering across the exporter options.
+var trivialFastPath = true
+
+// renderTrivialNote is a fast path for short notes without user relations.
+// It must produce exactly what renderFrontmatter+renderBody would.
+func renderTrivialNote(obj objectInfo, relations map[string]relationDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, aliases []string, opts frontmatterOptions) (string, string, bool) {
+	if !trivialFastPath || len(aliases) > 0 || !isTrivialObject(obj, relations, opts) {
+		return "", "", false
+	}
+
+	var fm bytes.Buffer
+	fm.WriteString("---\n")
+	if value, ok := obj.Details["type"]; ok {
+		rel, hasRel := relations["type"]
+		converted := convertPropertyValue("type", value, relations, optionsByID, notes, sourceNotePath, objectNamesByID, fileObjects, false, false)
+		if !opts.filters.excludeEmpty || !isEmptyFrontmatterValue(converted) {
+			writeYAMLKeyValue(&fm, frontmatterKey("type", rel, hasRel, opts.keys()), converted)
+		}
+	}
+	fm.WriteString("---\n\n")
+
+	var body strings.Builder
+	for _, b := range obj.Blocks {
+		if b.ID == obj.ID || isSystemTitleBlock(b) {
+			continue
+		}
+		line := renderTextBlock(*b.Text, b.Fields, notes, sourceNotePath, 0)
+		body.WriteString(line)
+		if line != "" && !strings.HasSuffix(line, "\n") {
+			body.WriteString("\n")
+		}
+	}
+	text := strings.TrimLeft(body.String(), "\n")
+	if strings.TrimSpace(text) == "" {
+		text = snippetNoteBody(obj.Details)
+	}
+	return fm.String(), text, true
+}
+
+func isTrivialObject(obj objectInfo, relations map[string]relationDef, opts frontmatterOptions) bool {
+	if len(obj.Blocks) > trivialObjectMaxBlocks {
+		return false
+	}
+	// The fast path renders text itself, so a registered text renderer
+	// needs the full one.
+	if _, ok := customBlockRenderer("text"); ok {
+		return false
+	}
+	if opts.embedAnytypeMetadata || len(opts.propertyOrder) > 0 || len(opts.tagHierarchy) > 0 || opts.tagSources != nil || opts.typeAsTag || opts.webClippings || (opts.properties != "" && opts.properties != propertiesAll) || (opts.propertiesStyle != "" && opts.propertiesStyle != propertiesStyleFrontmatter) || len(opts.filters.exclude) > 0 || len(opts.filters.forceInclude) > 0 || len(opts.filters.linkAsNote) > 0 {
+		return false
+	}
+	for key := range obj.Details {
+		if key == "type" {
+			if rel, ok := relations[key]; ok && strings.TrimSpace(rel.Name) == "" {
+				return false
+			}
+			continue
+		}
+		if key == "coverId" {
+			return false
+		}
+		if _, hidden := defaultHiddenPropertyKeys[key]; hidden {
+			continue
+		}
+		if _, dynamic := dynamicPropertyKeys[key]; dynamic && !opts.includeDynamicProperties {
+			continue
+		}
+		return false
+	}
+
+	var root *block
+	for i := range obj.Blocks {
+		if obj.Blocks[i].ID == obj.ID {
+			root = &obj.Blocks[i]
+			break
+		}
+	}
+	if root == nil || len(root.ChildrenID) != len(obj.Blocks)-1 {
+		return false
+	}
+	position := make(map[string]int, len(root.ChildrenID))
+	for i, id := range root.ChildrenID {
+		position[id] = i
+	}
+	next := 0
+	for _, b := range obj.Blocks {
+		if b.ID == obj.ID {
+			continue
+		}
+		if idx, ok := position[b.ID]; !ok || idx != next {
+			return false
+		}
+		next++
+		if len(b.ChildrenID) > 0 || !isTrivialTextBlock(b) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTrivialTextBlock(b block) bool {
+	if b.Text == nil || b.File != nil || b.Bookmark != nil || b.Latex != nil || b.Link != nil || b.Relation != nil || b.Layout != nil {
+		return false
+	}
+	if len(b.Dataview) > 0 || b.Table != nil || b.Div != nil || b.TOC != nil {
+		return false
+	}
+	if b.Text.Marks != nil && len(b.Text.Marks.Marks) > 0 {
+		return false
+	}
+	switch b.Text.Style {
+	case "", "Paragraph", "Title", "Header1", "Header2", "Header3", "Header4":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,59 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+type unresolvedRelationTarget struct {
+	NotePath string
+	Property string
+	TargetID string
+}
+
+func (u unresolvedRelationTarget) String() string {
+	return u.NotePath + ": " + u.Property + " -> " + u.TargetID
+}
+
+func collectUnresolvedRelationTargets(objects []objectInfo, exportedNotePathByID map[string]string, linkPathByID map[string]string, objectNamesByID map[string]string, relations map[string]relationDef, typesByID map[string]typeDef, opts frontmatterOptions) []unresolvedRelationTarget {
+	var unresolved []unresolvedRelationTarget
+	for _, obj := range objects {
+		notePath, ok := exportedNotePathByID[obj.ID]
+		if !ok {
+			continue
+		}
+		forEachFrontmatterProperty(obj, relations, typesByID, opts, func(k string, rel relationDef, hasRel bool, _ bool) {
+			if !hasRel || rel.Format != anytypedomain.RelationFormatObjectRef {
+				return
+			}
+			ids := anyToStringSlice(obj.Details[k])
+			if len(ids) == 0 {
+				if id := asString(obj.Details[k]); id != "" {
+					ids = []string{id}
+				}
+			}
+			for _, id := range ids {
+				if _, ok := linkPathByID[id]; ok {
+					continue
+				}
+				if strings.TrimSpace(objectNamesByID[id]) != "" {
+					continue
+				}
+				unresolved = append(unresolved, unresolvedRelationTarget{NotePath: notePath, Property: frontmatterKey(k, rel, hasRel, opts.keys()), TargetID: id})
+			}
+		})
+	}
+	sort.SliceStable(unresolved, func(i, j int) bool { return unresolved[i].NotePath < unresolved[j].NotePath })
+	return unresolved
+}
+
+func formatUnresolvedRelationTargets(targets []unresolvedRelationTarget) string {
+	lines := make([]string, 0, len(targets))
+	for _, target := range targets {
+		lines = append(lines, "  "+target.String())
+	}
+	return fmt.Sprintf("found %d unresolved relation targets:\n%s", len(targets), strings.Join(lines, "\n"))
+}
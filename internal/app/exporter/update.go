@@ -0,0 +1,197 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	updateAll         = "all"
+	updateFrontmatter = "frontmatter"
+)
+
+func resolveUpdateMode(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "":
+		return updateAll, nil
+	case updateAll, updateFrontmatter:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid update mode %q: expected all or frontmatter", mode)
+	}
+}
+
+// runFrontmatterUpdate renders the notes into a temporary directory and
+// copies only the frontmatter of each note into the matching note of the
+// existing vault, found through the vault's index by object ID. The staged
+// run keeps every note at its previous path so links resolve as they do in
+// the vault, and copies no attachments, runs no formatter and writes no
+// side exports. Note bodies, attachments, bases and everything else in the
+// vault stay as they are. Notes are counted in Stats.Notes when their
+// frontmatter changed.
+func (e Exporter) runFrontmatterUpdate(ctx context.Context) (Stats, error) {
+	started := time.Now()
+	log := e.logger()
+	indexPath := filepath.Join(e.OutputDir, "_anytype", "index.json")
+	raw, err := os.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Stats{}, fmt.Errorf("frontmatter update needs a previous export: %s not found", indexPath)
+		}
+		return Stats{}, fmt.Errorf("read previous index: %w", err)
+	}
+	var previous indexFile
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		return Stats{}, fmt.Errorf("decode previous index: %w", err)
+	}
+	protectPatterns, err := resolveProtectPatterns(e.Protect)
+	if err != nil {
+		return Stats{}, err
+	}
+	if style, err := resolvePropertiesStyle(e.PropertiesStyle); err != nil {
+		return Stats{}, err
+	} else if style != propertiesStyleFrontmatter {
+		return Stats{}, fmt.Errorf("frontmatter update cannot refresh %s properties in note bodies: use properties style frontmatter", style)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "anytype-to-obsidian-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.Update = ""
+	staged.Atomic = false
+	staged.MergeStrategy = mergeOverwrite
+	staged.Protect = nil
+	staged.frontmatterOnly = true
+	staged.notePaths = previous.Notes
+	staged.Formatter = formatterNone
+	staged.VerifyLinks = ""
+	staged.ExportTablesDir = ""
+	staged.ExportVCards = false
+	staged.ExportICS = false
+	staged.GraphStats = false
+	staged.ReportDuplicates = false
+	staged.KanbanBoards = false
+	staged.TagColorsCSS = false
+	staged.WriteDefaultTemplateMap = false
+	staged.Profile = false
+	stagedStats, err := staged.RunContext(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	raw, err = os.ReadFile(filepath.Join(tmpDir, "_anytype", "index.json"))
+	if err != nil {
+		return Stats{}, fmt.Errorf("read staged index: %w", err)
+	}
+	var current indexFile
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return Stats{}, fmt.Errorf("decode staged index: %w", err)
+	}
+
+	ids := make([]string, 0, len(current.Notes))
+	for id, relPath := range current.Notes {
+		if path.Ext(relPath) == ".md" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	warnings := stagedStats.WarningMessages
+	updated := 0
+	for _, id := range ids {
+		relPath, ok := previous.Notes[id]
+		if !ok || path.Ext(relPath) != ".md" {
+			log.Debug("skipped note missing from vault", "id", id)
+			continue
+		}
+		if matchVaultGlobs(relPath, protectPatterns) {
+			warnings = append(warnings, fmt.Sprintf("protected: %s was not written", relPath))
+			continue
+		}
+		absPath := filepath.Join(e.OutputDir, filepath.FromSlash(relPath))
+		existing, err := os.ReadFile(absPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Debug("skipped deleted note", "id", id, "path", relPath)
+				continue
+			}
+			return Stats{}, err
+		}
+		rendered, err := os.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(current.Notes[id])))
+		if err != nil {
+			return Stats{}, fmt.Errorf("read staged note %s: %w", id, err)
+		}
+		_, renderedBody := splitMarkdownFrontmatter(string(rendered))
+		_, body := splitMarkdownFrontmatter(string(existing))
+		content := string(rendered)[:len(rendered)-len(renderedBody)] + body
+		if content == string(existing) {
+			continue
+		}
+
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return Stats{}, err
+		}
+		if err := os.WriteFile(absPath, []byte(content), 0o644); err != nil {
+			return Stats{}, fmt.Errorf("update note %s: %w", id, err)
+		}
+		// A note untouched since the last export stays untouched in the
+		// index; a note edited in Obsidian keeps reading as edited.
+		if recorded, ok := previous.Files[relPath]; ok && recorded == info.ModTime().UnixNano() {
+			if info, err := os.Stat(absPath); err == nil {
+				previous.Files[relPath] = info.ModTime().UnixNano()
+			}
+		}
+		log.Debug("updated frontmatter", "id", id, "path", relPath)
+		updated++
+	}
+
+	indexBytes, _ := json.MarshalIndent(previous, "", "  ")
+	vault := &vaultFiles{root: e.OutputDir, protect: protectPatterns}
+	if err := vault.writeFile(indexPath, indexBytes); err != nil {
+		return Stats{}, fmt.Errorf("write index: %w", err)
+	}
+	log.Info("frontmatter update finished", "notes", updated)
+	return Stats{
+		Notes:           updated,
+		Warnings:        len(warnings),
+		WarningMessages: warnings,
+		Elapsed:         time.Since(started),
+	}, nil
+}
+
+// pinNotePaths moves every object in paths that also appears in pinned to
+// its pinned path. Other objects whose path a pinned one now takes are
+// dropped, so no two objects share a file.
+func pinNotePaths(paths map[string]string, pinned map[string]string) {
+	if len(pinned) == 0 {
+		return
+	}
+	taken := map[string]struct{}{}
+	for id := range paths {
+		if relPath, ok := pinned[id]; ok {
+			paths[id] = relPath
+			taken[relPath] = struct{}{}
+		}
+	}
+	for id, relPath := range paths {
+		if _, ok := pinned[id]; ok {
+			continue
+		}
+		if _, ok := taken[relPath]; ok {
+			delete(paths, id)
+		}
+	}
+}
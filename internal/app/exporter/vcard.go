@@ -0,0 +1,152 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+const vcardDir = "contacts"
+
+// contactTypeNames are the type names, besides the Profile layout, that
+// mark an object as a person.
+var contactTypeNames = map[string]struct{}{
+	"human":   {},
+	"contact": {},
+	"person":  {},
+}
+
+type vcardContact struct {
+	id     string
+	name   string
+	emails []string
+	phones []string
+	urls   []string
+	note   string
+}
+
+func isContactObject(obj objectInfo, typesByID map[string]typeDef) bool {
+	if layout, ok := recommendedLayoutForObject(obj, typesByID); ok && layout == anytypedomain.LayoutProfile {
+		return true
+	}
+	typeInfo, ok := typesByID[asString(obj.Details["type"])]
+	if !ok {
+		return false
+	}
+	_, ok = contactTypeNames[strings.ToLower(strings.TrimSpace(typeInfo.Name))]
+	return ok
+}
+
+// collectContacts returns the people among objects that have an email
+// address or phone number, in relation key order within each contact.
+func collectContacts(objects []objectInfo, relations map[string]relationDef, typesByID map[string]typeDef) []vcardContact {
+	var contacts []vcardContact
+	for _, obj := range objects {
+		if !isContactObject(obj, typesByID) {
+			continue
+		}
+		keys := make([]string, 0, len(obj.Details))
+		for k := range obj.Details {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		contact := vcardContact{
+			id:   obj.ID,
+			name: inferObjectTitle(obj),
+			note: strings.TrimSpace(asString(obj.Details["description"])),
+		}
+		for _, k := range keys {
+			rel, ok := relations[k]
+			if !ok {
+				continue
+			}
+			values := anyToStringSlice(obj.Details[k])
+			if s := strings.TrimSpace(asString(obj.Details[k])); len(values) == 0 && s != "" {
+				values = []string{s}
+			}
+			switch rel.Format {
+			case anytypedomain.RelationFormatEmail:
+				contact.emails = append(contact.emails, values...)
+			case anytypedomain.RelationFormatPhone:
+				contact.phones = append(contact.phones, values...)
+			case anytypedomain.RelationFormatURL:
+				contact.urls = append(contact.urls, values...)
+			}
+		}
+		if len(contact.emails) == 0 && len(contact.phones) == 0 {
+			continue
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts
+}
+
+func vcardEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// renderVCard writes contact as a vCard 3.0. The structured name splits
+// the title at its last space into given and family name.
+func renderVCard(contact vcardContact) string {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(s + "\r\n")
+	}
+	line("BEGIN:VCARD")
+	line("VERSION:3.0")
+	line("UID:anytype-" + vcardEscape(contact.id))
+	line("FN:" + vcardEscape(contact.name))
+	given, family := contact.name, ""
+	if i := strings.LastIndex(contact.name, " "); i > 0 {
+		given, family = contact.name[:i], contact.name[i+1:]
+	}
+	line("N:" + vcardEscape(family) + ";" + vcardEscape(given) + ";;;")
+	for _, email := range contact.emails {
+		line("EMAIL;TYPE=INTERNET:" + vcardEscape(strings.TrimSpace(email)))
+	}
+	for _, phone := range contact.phones {
+		line("TEL:" + vcardEscape(strings.TrimSpace(phone)))
+	}
+	for _, url := range contact.urls {
+		line("URL:" + vcardEscape(strings.TrimSpace(url)))
+	}
+	if contact.note != "" {
+		line("NOTE:" + vcardEscape(contact.note))
+	}
+	line("END:VCARD")
+	return b.String()
+}
+
+// writeVCards writes one contacts/<name>.vcf per contact and returns how
+// many were written.
+func writeVCards(outputDir string, contacts []vcardContact, naming filenameOptions, vault *vaultFiles) (int, error) {
+	if len(contacts) == 0 {
+		return 0, nil
+	}
+	dir := filepath.Join(outputDir, vcardDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, err
+	}
+	used := map[string]int{}
+	for _, contact := range contacts {
+		base := naming.sanitize(contact.name)
+		if base == "" {
+			base = naming.sanitize(contact.id)
+		}
+		usedKey := naming.collisionKey(base)
+		n := used[usedKey]
+		used[usedKey] = n + 1
+		if n > 0 {
+			base = base + "-" + strconv.Itoa(n+1)
+		}
+		if err := vault.writeFile(filepath.Join(dir, naming.fit(base, ".vcf")+".vcf"), []byte(renderVCard(contact))); err != nil {
+			return 0, err
+		}
+	}
+	return len(contacts), nil
+}
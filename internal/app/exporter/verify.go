@@ -0,0 +1,285 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var wikiLinkPattern = regexp.MustCompile(`!?\[\[([^\]\n]+?)\]\]`)
+var markdownLinkPattern = regexp.MustCompile(`!?\[(?:[^\]\n\\]|\\.)*\]\(([^)\n]+)\)`)
+var urlSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+
+type brokenLink struct {
+	Source string
+	Target string
+}
+
+func (l brokenLink) String() string {
+	return l.Source + " -> " + l.Target
+}
+
+func resolveVerifyMode(mode string) (string, error) {
+	mode = strings.TrimSpace(strings.ToLower(mode))
+	switch mode {
+	case "", "off":
+		return "off", nil
+	case "warn", "fail":
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid verify mode %q: expected off, warn, or fail", mode)
+	}
+}
+
+// vaultIndex lists the files of a rendered vault outside .obsidian and
+// _anytype, for resolving the links between them.
+type vaultIndex struct {
+	files  []string
+	exists map[string]struct{}
+	byName map[string]string
+}
+
+func indexVault(dir string) (vaultIndex, error) {
+	vault := vaultIndex{exists: map[string]struct{}{}, byName: map[string]string{}}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == ".obsidian" || rel == "_anytype" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		vault.files = append(vault.files, rel)
+		vault.exists[rel] = struct{}{}
+		if _, ok := vault.byName[path.Base(rel)]; !ok {
+			vault.byName[path.Base(rel)] = rel
+		}
+		return nil
+	})
+	sort.Strings(vault.files)
+	return vault, err
+}
+
+func (v vaultIndex) resolve(source string, target string) (string, bool) {
+	return resolveVaultLink(source, target, v.exists, v.byName)
+}
+
+func verifyVaultLinks(outputDir string) ([]brokenLink, error) {
+	vault, err := indexVault(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	var sources []string
+	for _, rel := range vault.files {
+		if ext := path.Ext(rel); (ext == ".md" || ext == ".base") && !strings.HasSuffix(rel, ".excalidraw.md") {
+			sources = append(sources, rel)
+		}
+	}
+
+	var broken []brokenLink
+	for _, source := range sources {
+		content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(source)))
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range extractLinkTargets(string(content)) {
+			if _, ok := vault.resolve(source, target); !ok {
+				broken = append(broken, brokenLink{Source: source, Target: target})
+			}
+		}
+	}
+	return broken, nil
+}
+
+func extractLinkTargets(content string) []string {
+	var targets []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range wikiLinkPattern.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if idx := strings.IndexAny(target, "|#^"); idx >= 0 {
+				target = target[:idx]
+			}
+			target = strings.TrimSpace(strings.ReplaceAll(target, `\`, ""))
+			if target != "" {
+				targets = append(targets, target)
+			}
+		}
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			target := strings.TrimSpace(m[1])
+			if target == "" || strings.HasPrefix(target, "#") || urlSchemePattern.MatchString(target) {
+				continue
+			}
+			if idx := strings.Index(target, "#"); idx >= 0 {
+				target = target[:idx]
+			}
+			if unescaped, err := url.PathUnescape(target); err == nil {
+				target = unescaped
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// resolveVaultLink finds the vault file a link in source points at the way
+// Obsidian does: relative to the note, from the vault root, then by bare
+// file name, with or without the .md extension.
+func resolveVaultLink(source string, target string, vaultFiles map[string]struct{}, filesByName map[string]string) (string, bool) {
+	candidates := []string{
+		path.Clean(path.Join(path.Dir(source), target)),
+		path.Clean(target),
+	}
+	for _, candidate := range candidates {
+		if _, ok := vaultFiles[candidate]; ok {
+			return candidate, true
+		}
+		if _, ok := vaultFiles[candidate+".md"]; ok {
+			return candidate + ".md", true
+		}
+	}
+	if strings.Contains(target, "/") {
+		return "", false
+	}
+	if rel, ok := filesByName[target]; ok {
+		return rel, true
+	}
+	rel, ok := filesByName[target+".md"]
+	return rel, ok
+}
+
+func formatBrokenLinks(links []brokenLink) string {
+	lines := make([]string, 0, len(links))
+	for _, link := range links {
+		lines = append(lines, "  "+link.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// VerifyVault checks an existing export against _anytype/index.json and the
+// plugin data files, returning one message per missing or unparsable entry.
+func VerifyVault(outputDir string) ([]string, error) {
+	if strings.TrimSpace(outputDir) == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+	if info, err := os.Stat(outputDir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", outputDir)
+	}
+
+	var problems []string
+	anytypeDir := filepath.Join(outputDir, "_anytype")
+
+	indexBytes, err := os.ReadFile(filepath.Join(anytypeDir, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	var idx indexFile
+	if err := json.Unmarshal(indexBytes, &idx); err != nil {
+		return nil, fmt.Errorf("decode index: %w", err)
+	}
+
+	ids := make([]string, 0, len(idx.Notes))
+	for id := range idx.Notes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		relPath := idx.Notes[id]
+		rawPath := filepath.Join(anytypeDir, "raw", id+".json")
+		if rawBytes, err := os.ReadFile(rawPath); err == nil {
+			if !json.Valid(rawBytes) {
+				problems = append(problems, fmt.Sprintf("_anytype/raw/%s.json: invalid JSON", id))
+			}
+		}
+		content, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("index entry %s: %s is missing", id, relPath))
+			continue
+		}
+		switch path.Ext(relPath) {
+		case ".md":
+			err = validateFrontmatterYAML(string(content))
+		case ".base":
+			err = validateBaseYAML(string(content))
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", relPath, err))
+		}
+	}
+
+	iconData, err := readPluginData(outputDir, "obsidian-icon-folder")
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	iconPaths := make([]string, 0, len(iconData))
+	for key := range iconData {
+		iconPaths = append(iconPaths, key)
+	}
+	sort.Strings(iconPaths)
+	for _, notePath := range iconPaths {
+		if notePath == "settings" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(notePath))); err != nil {
+			problems = append(problems, fmt.Sprintf("iconize entry %s: note is missing", notePath))
+		}
+		iconRef := asString(iconData[notePath])
+		if strings.HasPrefix(iconRef, iconizeAnytypePackPrefix) {
+			iconName := strings.TrimPrefix(iconRef, iconizeAnytypePackPrefix)
+			if _, err := os.Stat(filepath.Join(outputDir, ".obsidian", "icons", iconizeAnytypePackName, iconName+".svg")); err != nil {
+				problems = append(problems, fmt.Sprintf("iconize entry %s: icon %s is missing", notePath, iconName))
+			}
+		}
+	}
+	if _, err := readPluginData(outputDir, "pretty-properties"); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	broken, err := verifyVaultLinks(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range broken {
+		problems = append(problems, "broken link "+link.String())
+	}
+	return problems, nil
+}
+
+func readPluginData(outputDir string, pluginID string) (map[string]any, error) {
+	relPath := path.Join(".obsidian", "plugins", pluginID, "data.json")
+	raw, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", relPath, err)
+	}
+	data := map[string]any{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", relPath, err)
+	}
+	return data, nil
+}
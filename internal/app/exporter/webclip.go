@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+func isBookmarkObject(obj objectInfo, typesByID map[string]typeDef) bool {
+	layout, ok := recommendedLayoutForObject(obj, typesByID)
+	return ok && layout == anytypedomain.LayoutBookmark
+}
+
+// bookmarkSourceURL is the page a bookmark object points at: its source
+// relation, or else the URL of its first bookmark block.
+func bookmarkSourceURL(obj objectInfo) string {
+	if url := strings.TrimSpace(asString(obj.Details["source"])); url != "" {
+		return url
+	}
+	for _, b := range obj.Blocks {
+		if b.Bookmark != nil && strings.TrimSpace(b.Bookmark.URL) != "" {
+			return strings.TrimSpace(b.Bookmark.URL)
+		}
+	}
+	return ""
+}
+
+// webClippingBody lays out a bookmark object the way Obsidian Web Clipper
+// writes clippings: the page description as a blockquote and the preview
+// picture below it, ahead of the note's own content.
+func webClippingBody(obj objectInfo, fileObjects map[string]string, sourceNotePath string) string {
+	var b strings.Builder
+	if description := strings.TrimSpace(asString(obj.Details["description"])); description != "" {
+		for _, line := range strings.Split(description, "\n") {
+			b.WriteString(strings.TrimRight("> "+strings.TrimSpace(line), " ") + "\n")
+		}
+		b.WriteString("\n")
+	}
+	ids := anyToStringSlice(obj.Details["picture"])
+	if id := asString(obj.Details["picture"]); len(ids) == 0 && id != "" {
+		ids = []string{id}
+	}
+	for _, id := range ids {
+		if src, ok := fileObjects[id]; ok {
+			b.WriteString("![[" + relativeWikiTarget(sourceNotePath, src) + "]]\n\n")
+			break
+		}
+	}
+	return b.String()
+}
+
+// insertWebClipping puts clipping at the top of body, below the title
+// heading when the body opens with one.
+func insertWebClipping(body string, clipping string) string {
+	if clipping == "" {
+		return body
+	}
+	if strings.HasPrefix(body, "# ") {
+		heading, rest, _ := strings.Cut(body, "\n")
+		return heading + "\n\n" + clipping + strings.TrimLeft(rest, "\n")
+	}
+	return clipping + body
+}
@@ -0,0 +1,41 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sleroq/anytype-to-obsidian/internal/infra/exportfs"
+)
+
+// runToZip renders the vault into a temporary directory and streams it into
+// e.OutputZip. The archive only replaces an existing one once it is
+// complete; the temporary vault is removed either way.
+func (e Exporter) runToZip(ctx context.Context) (Stats, error) {
+	if strategy, err := resolveMergeStrategy(e.MergeStrategy); err != nil {
+		return Stats{}, err
+	} else if strategy != mergeOverwrite {
+		return Stats{}, fmt.Errorf("zip output cannot be combined with merge strategy %q", strategy)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "anytype-to-obsidian-*")
+	if err != nil {
+		return Stats{}, fmt.Errorf("create temp vault: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	staged := e
+	staged.OutputDir = tmpDir
+	staged.OutputZip = ""
+	staged.Atomic = false
+	stats, err := staged.RunContext(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := exportfs.ZipDir(tmpDir, e.OutputZip); err != nil {
+		return Stats{}, fmt.Errorf("write %s: %w", e.OutputZip, err)
+	}
+	// The report only exists inside the archive now.
+	stats.ReportPath = ""
+	return stats, nil
+}
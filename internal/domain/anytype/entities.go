@@ -1,5 +1,10 @@
 package anytype
 
+import (
+	"encoding/json"
+	"sort"
+)
+
 type SnapshotFile struct {
 	SbType   string `json:"sbType"`
 	Snapshot struct {
@@ -7,6 +12,9 @@ type SnapshotFile struct {
 			Blocks      []Block        `json:"blocks"`
 			Details     map[string]any `json:"details"`
 			ObjectTypes []any          `json:"objectTypes"`
+			// Legacy exports embed relation definitions in every object.
+			ExtraRelations []any `json:"extraRelations"`
+			RelationLinks  []any `json:"relationLinks"`
 		} `json:"data"`
 	} `json:"snapshot"`
 }
@@ -27,6 +35,75 @@ type Block struct {
 	Table    map[string]any `json:"table"`
 	Div      map[string]any `json:"div"`
 	TOC      map[string]any `json:"tableOfContents"`
+	Chat     *ChatBlock     `json:"chat"`
+	TableRow *TableRowBlock `json:"tableRow"`
+	// Align is AlignLeft, AlignCenter or AlignRight.
+	Align string `json:"align"`
+
+	// Kind is the key of the block's content in the export, such as "text"
+	// or "widget". Content keeps that content as exported when Block has no
+	// field for it.
+	Kind    string          `json:"-"`
+	Content json.RawMessage `json:"-"`
+}
+
+// blockSharedKeys are the keys every block has besides its content.
+var blockSharedKeys = map[string]bool{
+	"id":              true,
+	"childrenIds":     true,
+	"fields":          true,
+	"align":           true,
+	"verticalAlign":   true,
+	"backgroundColor": true,
+	"restrictions":    true,
+}
+
+// blockContentKeys are the block contents Block decodes into fields.
+var blockContentKeys = map[string]bool{
+	"text":            true,
+	"file":            true,
+	"bookmark":        true,
+	"latex":           true,
+	"link":            true,
+	"relation":        true,
+	"layout":          true,
+	"dataview":        true,
+	"table":           true,
+	"div":             true,
+	"tableOfContents": true,
+	"chat":            true,
+	"tableRow":        true,
+}
+
+// UnmarshalJSON decodes a block and records the kind of its content.
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type plainBlock Block
+	if err := json.Unmarshal(data, (*plainBlock)(b)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	content := make([]string, 0, 1)
+	for key := range keys {
+		if !blockSharedKeys[key] {
+			content = append(content, key)
+		}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	sort.Strings(content)
+	b.Kind = content[0]
+	if !blockContentKeys[b.Kind] {
+		b.Content = keys[b.Kind]
+	}
+	return nil
+}
+
+type TableRowBlock struct {
+	IsHeader bool `json:"isHeader"`
 }
 
 type TextBlock struct {
@@ -34,6 +111,9 @@ type TextBlock struct {
 	Style   string     `json:"style"`
 	Checked bool       `json:"checked"`
 	Marks   *TextMarks `json:"marks"`
+	// IconEmoji and IconImage are the icon of a callout block.
+	IconEmoji string `json:"iconEmoji"`
+	IconImage string `json:"iconImage"`
 }
 
 type TextMarks struct {
@@ -68,7 +148,10 @@ type LatexBlock struct {
 }
 
 type LinkBlock struct {
-	TargetBlockID string `json:"targetBlockId"`
+	TargetBlockID string   `json:"targetBlockId"`
+	CardStyle     any      `json:"cardStyle"`
+	Description   any      `json:"description"`
+	Relations     []string `json:"relations"`
 }
 
 type LayoutBlock struct {
@@ -79,6 +162,22 @@ type RelationBlock struct {
 	Key string `json:"key"`
 }
 
+type ChatBlock struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+type ChatMessage struct {
+	ID               string             `json:"id"`
+	Creator          string             `json:"creator"`
+	CreatedAt        any                `json:"createdAt"`
+	ReplyToMessageID string             `json:"replyToMessageId"`
+	Message          ChatMessageContent `json:"message"`
+}
+
+type ChatMessageContent struct {
+	Text string `json:"text"`
+}
+
 type RelationDef struct {
 	ID     string
 	Key    string
@@ -114,6 +213,9 @@ type ObjectInfo struct {
 	Details     map[string]any
 	Blocks      []Block
 	ObjectTypes []string
+	// BlocksPath is set when Blocks holds only the skeleton kept by a
+	// low-memory read; the full block tree is re-read from this snapshot.
+	BlocksPath string
 }
 
 type TemplateInfo struct {
@@ -126,10 +228,21 @@ type TemplateInfo struct {
 }
 
 type ExportData struct {
+	Format      string
 	Objects     []ObjectInfo
 	Relations   map[string]RelationDef
 	OptionsByID map[string]RelationOption
 	FileObjects map[string]string
 	Templates   []TemplateInfo
 	TypesByID   map[string]TypeDef
+	Problems    []SnapshotProblem
+	// FileTranscripts holds the transcript text of audio file objects that
+	// carry one, by file object ID.
+	FileTranscripts map[string]string
+}
+
+// SnapshotProblem is a snapshot file that could not be read and was skipped.
+type SnapshotProblem struct {
+	Path string
+	Err  error
 }
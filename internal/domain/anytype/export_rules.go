@@ -1,6 +1,7 @@
 package anytype
 
 import (
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -14,10 +15,38 @@ const (
 	RelationFormatStatus    = 3
 	RelationFormatTag       = 11
 	RelationFormatObjectRef = 100
+	RelationFormatLongText  = 0
+	RelationFormatShortText = 1
+	RelationFormatNumber    = 2
+	RelationFormatCheckbox  = 6
+	RelationFormatURL       = 7
+	RelationFormatEmail     = 8
+	RelationFormatPhone     = 9
+	RelationFormatEmoji     = 10
+)
+
+const (
+	// Anytype object layout enum IDs. Verify against Anytype Heart:
+	// anytype-heart/pkg/lib/pb/model/models.pb.go (ObjectType_* layout constants).
+	LayoutBasic       = 0
+	LayoutProfile     = 1
+	LayoutTodo        = 2
+	LayoutSet         = 3
+	LayoutDashboard   = 7
+	LayoutNote        = 9
+	LayoutSpace       = 10
+	LayoutBookmark    = 11
+	LayoutSpaceView   = 18
+	LayoutParticipant = 19
 )
 
 func ConvertPropertyValue(key string, value any, relations map[string]RelationDef, optionsByID map[string]string, notes map[string]string, sourceNotePath string, objectNamesByID map[string]string, fileObjects map[string]string, dateByType bool, linkAsNote bool, relativeWikiTarget func(sourceNotePath string, targetNotePath string) string, relativePathTarget func(sourcePath string, targetPath string) string) any {
 	rel, hasRel := relations[key]
+	if hasRel && rel.Max == 1 {
+		// Single-value relations are sometimes stored as one-item lists;
+		// emit the item itself so Bases filters can compare it as a scalar.
+		value = singleListItem(value)
+	}
 	listValue := isListValue(value)
 	if !hasRel {
 		if dateByType {
@@ -44,7 +73,7 @@ func ConvertPropertyValue(key string, value any, relations map[string]RelationDe
 			} else if name, ok := objectNamesByID[id]; ok && strings.TrimSpace(name) != "" {
 				out = append(out, name)
 			} else {
-				out = append(out, id)
+				out = append(out, UnresolvedObjectName(id))
 			}
 		}
 		if listValue {
@@ -109,6 +138,99 @@ func ConvertPropertyValue(key string, value any, relations map[string]RelationDe
 		return value
 	case RelationFormatDate:
 		return FormatDateValue(value)
+	case RelationFormatNumber:
+		return mapScalarValues(value, FormatNumberValue)
+	case RelationFormatURL:
+		return mapScalarValues(value, FormatURLValue)
+	case RelationFormatEmail:
+		return mapScalarValues(value, FormatEmailValue)
+	case RelationFormatPhone:
+		return mapScalarValues(value, FormatPhoneValue)
+	default:
+		return value
+	}
+}
+
+// UnresolvedObjectName is shown for object relation targets that are neither
+// exported nor known by name (deleted objects, members of shared spaces).
+func UnresolvedObjectName(id string) string {
+	return "Unknown (" + id + ")"
+}
+
+func mapScalarValues(value any, convert func(any) any) any {
+	switch v := value.(type) {
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, item := range v {
+			out = append(out, convert(item))
+		}
+		return out
+	case []string:
+		out := make([]any, 0, len(v))
+		for _, item := range v {
+			out = append(out, convert(item))
+		}
+		return out
+	default:
+		return convert(value)
+	}
+}
+
+// FormatNumberValue keeps numbers numeric, parsing numeric strings so they are
+// not quoted in YAML.
+func FormatNumberValue(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return value
+	}
+	if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+var urlSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+
+// FormatURLValue emits a plain, clickable URL, adding https:// to bare domains.
+func FormatURLValue(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || urlSchemePattern.MatchString(trimmed) {
+		return trimmed
+	}
+	if strings.ContainsAny(trimmed, " \t") || !strings.Contains(trimmed, ".") {
+		return trimmed
+	}
+	return "https://" + strings.TrimPrefix(trimmed, "//")
+}
+
+func FormatEmailValue(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(s)
+	if len(trimmed) > len("mailto:") && strings.EqualFold(trimmed[:len("mailto:")], "mailto:") {
+		trimmed = trimmed[len("mailto:"):]
+	}
+	return trimmed
+}
+
+// FormatPhoneValue keeps phone numbers as strings so leading zeros and "+"
+// prefixes survive and YAML does not read them as numbers.
+func FormatPhoneValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64, int:
+		return asString(v)
 	default:
 		return value
 	}
@@ -233,6 +355,20 @@ func ParseAnytypeTimestamp(value any) (time.Time, bool) {
 	return time.Time{}, false
 }
 
+func singleListItem(v any) any {
+	switch t := v.(type) {
+	case []any:
+		if len(t) == 1 {
+			return t[0]
+		}
+	case []string:
+		if len(t) == 1 {
+			return t[0]
+		}
+	}
+	return v
+}
+
 func isListValue(v any) bool {
 	switch v.(type) {
 	case []any, []string:
@@ -0,0 +1,78 @@
+package anytypejson
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenInput opens an export given as a directory or as the .zip archive
+// Anytype produces. Archives are read in place; when the export sits in a
+// single top-level folder of the archive, that folder becomes the root.
+// The returned func releases the archive and is a no-op for directories.
+func OpenInput(inputPath string) (fs.FS, func() error, error) {
+	if !IsZipInput(inputPath) {
+		input, err := openDir(inputPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return input, func() error { return nil }, nil
+	}
+
+	archive, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read export archive: %w", err)
+	}
+	root, err := zipExportRoot(archive)
+	if err != nil {
+		_ = archive.Close()
+		return nil, nil, fmt.Errorf("read export archive %s: %w", inputPath, err)
+	}
+	if root == "." {
+		return archive, archive.Close, nil
+	}
+	sub, err := fs.Sub(archive, root)
+	if err != nil {
+		_ = archive.Close()
+		return nil, nil, err
+	}
+	return sub, archive.Close, nil
+}
+
+// IsZipInput reports whether inputPath names a zip archive rather than an
+// export directory.
+func IsZipInput(inputPath string) bool {
+	if info, err := os.Stat(inputPath); err == nil && info.IsDir() {
+		return false
+	}
+	return strings.EqualFold(filepath.Ext(inputPath), ".zip")
+}
+
+func zipExportRoot(archive fs.FS) (string, error) {
+	if _, err := fs.Stat(archive, "objects"); err == nil {
+		return ".", nil
+	}
+	entries, err := fs.ReadDir(archive, ".")
+	if err != nil {
+		return "", err
+	}
+	var dirs []string
+	for _, ent := range entries {
+		if ent.IsDir() && !strings.HasPrefix(ent.Name(), "__MACOSX") {
+			dirs = append(dirs, ent.Name())
+		}
+	}
+	if len(dirs) == 1 {
+		if _, err := fs.Stat(archive, dirs[0]+"/objects"); err == nil {
+			return dirs[0], nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	// Let format detection name what the archive holds instead.
+	return ".", nil
+}
@@ -2,8 +2,10 @@ package anytypejson
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -12,75 +14,163 @@ import (
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
 )
 
-func ReadExport(inputDir string) (anytypedomain.ExportData, error) {
-	objects, err := readObjects(filepath.Join(inputDir, "objects"))
+// ReadOptions tunes how ReadExport reads snapshots.
+type ReadOptions struct {
+	// FailFast aborts on the first unreadable snapshot instead of skipping
+	// it and listing it in ExportData.Problems.
+	FailFast bool
+	// LowMemory keeps only the blocks needed to index objects (root, title
+	// and dataview blocks); callers reload the rest with ReadObjectBlocks
+	// while rendering each object.
+	LowMemory bool
+}
+
+func ReadExport(inputDir string, opts ReadOptions) (anytypedomain.ExportData, error) {
+	input, err := openDir(inputDir)
 	if err != nil {
 		return anytypedomain.ExportData{}, err
 	}
-	relations, err := readRelations(filepath.Join(inputDir, "relations"))
+	return ReadExportFS(input, opts)
+}
+
+// ReadExportFS reads an export rooted at input, e.g. a directory opened with
+// os.DirFS or an archive opened with OpenInput.
+func ReadExportFS(input fs.FS, opts ReadOptions) (anytypedomain.ExportData, error) {
+	format, err := detectFormat(input)
 	if err != nil {
 		return anytypedomain.ExportData{}, err
 	}
-	optionsByID, err := readOptions(filepath.Join(inputDir, "relationsOptions"))
+	r := &snapshotReader{input: input, failFast: opts.FailFast}
+	objects, embeddedRelations, err := readObjects(r, "objects", opts.LowMemory)
 	if err != nil {
 		return anytypedomain.ExportData{}, err
 	}
-	fileObjects, err := readFileObjects(filepath.Join(inputDir, "filesObjects"))
-	if err != nil {
-		return anytypedomain.ExportData{}, err
+	relations := embeddedRelations
+	if format == FormatJSON {
+		relations, err = readRelations(r, "relations")
+		if err != nil {
+			return anytypedomain.ExportData{}, err
+		}
 	}
-	templates, err := readTemplates(filepath.Join(inputDir, "templates"))
+	optionsByID := map[string]anytypedomain.RelationOption{}
+	if format == FormatJSON || dirExists(input, "relationsOptions") {
+		optionsByID, err = readOptions(r, "relationsOptions")
+		if err != nil {
+			return anytypedomain.ExportData{}, err
+		}
+	}
+	fileObjects := map[string]string{}
+	fileTranscripts := map[string]string{}
+	if format == FormatJSON || dirExists(input, "filesObjects") {
+		fileObjects, fileTranscripts, err = readFileObjects(r, "filesObjects")
+		if err != nil {
+			return anytypedomain.ExportData{}, err
+		}
+	}
+	templates, err := readTemplates(r, "templates")
 	if err != nil {
 		return anytypedomain.ExportData{}, err
 	}
-	typesByID, err := readTypes(filepath.Join(inputDir, "types"))
+	typesByID, err := readTypes(r, "types")
 	if err != nil {
 		return anytypedomain.ExportData{}, err
 	}
 
 	return anytypedomain.ExportData{
+		Format:      format,
 		Objects:     objects,
 		Relations:   relations,
 		OptionsByID: optionsByID,
 		FileObjects: fileObjects,
 		Templates:   templates,
 		TypesByID:   typesByID,
+		Problems:    r.problems,
+
+		FileTranscripts: fileTranscripts,
 	}, nil
 }
 
-func readObjects(dir string) ([]anytypedomain.ObjectInfo, error) {
-	entries, err := os.ReadDir(dir)
+// readObjects also returns the relation definitions embedded in the
+// snapshots, which legacy exports use instead of a relations/ directory.
+func readObjects(r *snapshotReader, dir string, lowMemory bool) ([]anytypedomain.ObjectInfo, map[string]anytypedomain.RelationDef, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
-		return nil, fmt.Errorf("read objects dir: %w", err)
+		return nil, nil, fmt.Errorf("read objects dir: %w", err)
 	}
 	var out []anytypedomain.ObjectInfo
+	embedded := map[string]anytypedomain.RelationDef{}
 	for _, ent := range entries {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		if !ok {
+			continue
+		}
+		addLegacyRelations(embedded, f)
 		id := asString(f.Snapshot.Data.Details["id"])
 		if id == "" {
 			id = strings.TrimSuffix(ent.Name(), ".pb.json")
 		}
-		out = append(out, anytypedomain.ObjectInfo{
+		obj := anytypedomain.ObjectInfo{
 			ID:          id,
 			Name:        asString(f.Snapshot.Data.Details["name"]),
 			SbType:      f.SbType,
 			Details:     f.Snapshot.Data.Details,
 			Blocks:      f.Snapshot.Data.Blocks,
 			ObjectTypes: anyToStringSlice(f.Snapshot.Data.ObjectTypes),
-		})
+		}
+		if lowMemory {
+			obj.Blocks = skeletonBlocks(id, obj.Blocks)
+			obj.BlocksPath = path.Join(dir, ent.Name())
+		}
+		out = append(out, obj)
 	}
 	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
-	return out, nil
+	return out, embedded, nil
+}
+
+// skeletonBlocks keeps the root block, its Title children and dataview
+// blocks: what title lookup and base rendering need before the body is
+// rendered.
+func skeletonBlocks(rootID string, blocks []anytypedomain.Block) []anytypedomain.Block {
+	titles := map[string]bool{}
+	for _, b := range blocks {
+		if b.ID == rootID {
+			for _, childID := range b.ChildrenID {
+				titles[childID] = true
+			}
+			break
+		}
+	}
+	var out []anytypedomain.Block
+	for _, b := range blocks {
+		keep := b.ID == rootID || len(b.Dataview) > 0 || (titles[b.ID] && b.Text != nil && b.Text.Style == "Title")
+		if keep {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// ReadObjectBlocks re-reads the full block tree of an object read from
+// input with ReadOptions.LowMemory.
+func ReadObjectBlocks(input fs.FS, obj anytypedomain.ObjectInfo) ([]anytypedomain.Block, error) {
+	if obj.BlocksPath == "" {
+		return obj.Blocks, nil
+	}
+	f, err := readSnapshot(input, obj.BlocksPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.Snapshot.Data.Blocks, nil
 }
 
-func readRelations(dir string) (map[string]anytypedomain.RelationDef, error) {
-	entries, err := os.ReadDir(dir)
+func readRelations(r *snapshotReader, dir string) (map[string]anytypedomain.RelationDef, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
 		return nil, fmt.Errorf("read relations dir: %w", err)
 	}
@@ -89,10 +179,13 @@ func readRelations(dir string) (map[string]anytypedomain.RelationDef, error) {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			continue
+		}
 		id := asString(f.Snapshot.Data.Details["id"])
 		key := asString(f.Snapshot.Data.Details["relationKey"])
 		if key == "" && id == "" {
@@ -102,7 +195,7 @@ func readRelations(dir string) (map[string]anytypedomain.RelationDef, error) {
 			ID:     id,
 			Key:    key,
 			Name:   asString(f.Snapshot.Data.Details["name"]),
-			Format: asInt(f.Snapshot.Data.Details["relationFormat"]),
+			Format: asRelationFormat(f.Snapshot.Data.Details["relationFormat"]),
 			Max:    asInt(f.Snapshot.Data.Details["relationMaxCount"]),
 		}
 		if key != "" {
@@ -115,8 +208,8 @@ func readRelations(dir string) (map[string]anytypedomain.RelationDef, error) {
 	return out, nil
 }
 
-func readOptions(dir string) (map[string]anytypedomain.RelationOption, error) {
-	entries, err := os.ReadDir(dir)
+func readOptions(r *snapshotReader, dir string) (map[string]anytypedomain.RelationOption, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
 		return nil, fmt.Errorf("read relation options dir: %w", err)
 	}
@@ -125,10 +218,13 @@ func readOptions(dir string) (map[string]anytypedomain.RelationOption, error) {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			continue
+		}
 		id := asString(f.Snapshot.Data.Details["id"])
 		if id == "" {
 			continue
@@ -144,27 +240,48 @@ func readOptions(dir string) (map[string]anytypedomain.RelationOption, error) {
 	return out, nil
 }
 
-func readFileObjects(dir string) (map[string]string, error) {
-	entries, err := os.ReadDir(dir)
+// transcriptDetailKeys are the file object details that may hold the
+// transcript of an audio file, compared case-insensitively.
+var transcriptDetailKeys = map[string]struct{}{
+	"transcript":      {},
+	"transcription":   {},
+	"audiotranscript": {},
+}
+
+// readFileObjects returns the vault path of every file object and, for
+// those that carry one, its transcript text.
+func readFileObjects(r *snapshotReader, dir string) (map[string]string, map[string]string, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
-		return nil, fmt.Errorf("read filesObjects dir: %w", err)
+		return nil, nil, fmt.Errorf("read filesObjects dir: %w", err)
 	}
 	out := make(map[string]string)
+	transcripts := make(map[string]string)
 	for _, ent := range entries {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if !ok {
+			continue
 		}
 		id := asString(f.Snapshot.Data.Details["id"])
 		source := asString(f.Snapshot.Data.Details["source"])
 		if id == "" {
 			continue
 		}
-		if source != "" {
-			out[id] = filepath.ToSlash(source)
+		for key, value := range f.Snapshot.Data.Details {
+			if _, ok := transcriptDetailKeys[strings.ToLower(key)]; ok {
+				if text := strings.TrimSpace(asString(value)); text != "" {
+					transcripts[id] = text
+				}
+			}
+		}
+		if source = normalizeFileSource(source); source != "" {
+			out[id] = source
 			continue
 		}
 		fileExt := asString(f.Snapshot.Data.Details["fileExt"])
@@ -177,13 +294,27 @@ func readFileObjects(dir string) (map[string]string, error) {
 		}
 		out[id] = filepath.ToSlash(filepath.Join("files", name))
 	}
-	return out, nil
+	return out, transcripts, nil
 }
 
-func readTypes(dir string) (map[string]anytypedomain.TypeDef, error) {
-	entries, err := os.ReadDir(dir)
+// normalizeFileSource turns a file object's source into a clean vault-relative
+// slash path, keeping nested folders below files/ intact.
+func normalizeFileSource(source string) string {
+	source = strings.TrimSpace(filepath.ToSlash(source))
+	if source == "" {
+		return ""
+	}
+	source = path.Clean(strings.TrimPrefix(source, "./"))
+	if source == "." || strings.HasPrefix(source, "../") || path.IsAbs(source) {
+		return ""
+	}
+	return source
+}
+
+func readTypes(r *snapshotReader, dir string) (map[string]anytypedomain.TypeDef, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return map[string]anytypedomain.TypeDef{}, nil
 		}
 		return nil, fmt.Errorf("read dir %s: %w", dir, err)
@@ -193,10 +324,13 @@ func readTypes(dir string) (map[string]anytypedomain.TypeDef, error) {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			continue
+		}
 		id := asString(f.Snapshot.Data.Details["id"])
 		if id == "" {
 			continue
@@ -216,10 +350,10 @@ func readTypes(dir string) (map[string]anytypedomain.TypeDef, error) {
 	return out, nil
 }
 
-func readTemplates(dir string) ([]anytypedomain.TemplateInfo, error) {
-	entries, err := os.ReadDir(dir)
+func readTemplates(r *snapshotReader, dir string) ([]anytypedomain.TemplateInfo, error) {
+	entries, err := fs.ReadDir(r.input, dir)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("read templates dir: %w", err)
@@ -230,10 +364,13 @@ func readTemplates(dir string) ([]anytypedomain.TemplateInfo, error) {
 		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
 			continue
 		}
-		f, err := readSnapshot(filepath.Join(dir, ent.Name()))
+		f, ok, err := r.read(path.Join(dir, ent.Name()))
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			continue
+		}
 		id := asString(f.Snapshot.Data.Details["id"])
 		if id == "" {
 			id = strings.TrimSuffix(ent.Name(), ".pb.json")
@@ -251,18 +388,51 @@ func readTemplates(dir string) ([]anytypedomain.TemplateInfo, error) {
 	return out, nil
 }
 
-func readSnapshot(path string) (anytypedomain.SnapshotFile, error) {
+// snapshotReader reads snapshots and, unless failFast is set, records the
+// ones it cannot decode instead of aborting the whole export.
+type snapshotReader struct {
+	input    fs.FS
+	failFast bool
+	problems []anytypedomain.SnapshotProblem
+}
+
+func (r *snapshotReader) read(path string) (anytypedomain.SnapshotFile, bool, error) {
+	f, err := readSnapshot(r.input, path)
+	if err == nil {
+		return f, true, nil
+	}
+	if r.failFast {
+		return f, false, err
+	}
+	r.problems = append(r.problems, anytypedomain.SnapshotProblem{Path: path, Err: err})
+	return f, false, nil
+}
+
+func readSnapshot(input fs.FS, path string) (anytypedomain.SnapshotFile, error) {
 	var s anytypedomain.SnapshotFile
-	b, err := os.ReadFile(path)
+	b, err := fs.ReadFile(input, path)
 	if err != nil {
 		return s, fmt.Errorf("read %s: %w", path, err)
 	}
-	if err := json.Unmarshal(b, &s); err != nil {
+	// sbType is a name in current exports and a number in legacy ones.
+	decoded := struct {
+		*anytypedomain.SnapshotFile
+		SbType json.RawMessage `json:"sbType"`
+	}{SnapshotFile: &s}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return s, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if s.SbType, err = normalizeSbType(decoded.SbType); err != nil {
 		return s, fmt.Errorf("decode %s: %w", path, err)
 	}
 	return s, nil
 }
 
+func dirExists(input fs.FS, path string) bool {
+	info, err := fs.Stat(input, path)
+	return err == nil && info.IsDir()
+}
+
 func asString(v any) string {
 	switch t := v.(type) {
 	case string:
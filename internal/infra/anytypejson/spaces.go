@@ -0,0 +1,155 @@
+package anytypejson
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Space is one space of an export together with a view of the export that
+// only holds the space's snapshots and attachments.
+type Space struct {
+	ID   string
+	Name string
+	FS   fs.FS
+}
+
+var snapshotDirs = []string{"objects", "relations", "relationsOptions", "filesObjects", "types", "templates"}
+
+// SplitSpaces groups the snapshots of input by their spaceId. Snapshots
+// without a spaceId, and attachments no file object claims, are shared by
+// every space. Spaces are named after their workspace or space view object
+// and fall back to the space id. An export whose snapshots carry no spaceId
+// yields no spaces.
+func SplitSpaces(input fs.FS) ([]Space, error) {
+	owners := map[string]string{}
+	fileOwners := map[string]map[string]struct{}{}
+	names := map[string]string{}
+	spaceIDs := map[string]struct{}{}
+	for _, dir := range snapshotDirs {
+		entries, err := fs.ReadDir(input, dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read dir %s: %w", dir, err)
+		}
+		for _, ent := range entries {
+			if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".pb.json") {
+				continue
+			}
+			p := path.Join(dir, ent.Name())
+			f, err := readSnapshot(input, p)
+			if err != nil {
+				// Left to every space, so the reader reports it.
+				continue
+			}
+			details := f.Snapshot.Data.Details
+			spaceID := strings.TrimSpace(asString(details["spaceId"]))
+			switch f.SbType {
+			case "SpaceView":
+				// Space views live in the tech space and describe another one.
+				if target := strings.TrimSpace(asString(details["targetSpaceId"])); target != "" {
+					if name := strings.TrimSpace(asString(details["name"])); name != "" {
+						names[target] = name
+					}
+				}
+				continue
+			case "Workspace":
+				if name := strings.TrimSpace(asString(details["name"])); name != "" && spaceID != "" && names[spaceID] == "" {
+					names[spaceID] = name
+				}
+			}
+			if spaceID == "" {
+				continue
+			}
+			owners[p] = spaceID
+			spaceIDs[spaceID] = struct{}{}
+			if dir == "filesObjects" {
+				if file := fileObjectPath(details); file != "" {
+					if fileOwners[file] == nil {
+						fileOwners[file] = map[string]struct{}{}
+					}
+					fileOwners[file][spaceID] = struct{}{}
+				}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(spaceIDs))
+	for id := range spaceIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	spaces := make([]Space, 0, len(ids))
+	for _, id := range ids {
+		hidden := map[string]struct{}{}
+		for p, owner := range owners {
+			if owner != id {
+				hidden[p] = struct{}{}
+			}
+		}
+		for file, spaces := range fileOwners {
+			if _, ok := spaces[id]; !ok {
+				hidden[file] = struct{}{}
+			}
+		}
+		name := names[id]
+		if name == "" {
+			name = id
+		}
+		spaces = append(spaces, Space{ID: id, Name: name, FS: spaceFS{input: input, hidden: hidden}})
+	}
+	return spaces, nil
+}
+
+// fileObjectPath is the attachment path readFileObjects resolves for a file
+// object.
+func fileObjectPath(details map[string]any) string {
+	if source := normalizeFileSource(asString(details["source"])); source != "" {
+		return source
+	}
+	name := asString(details["name"])
+	if name == "" {
+		name = asString(details["id"])
+	}
+	if name == "" {
+		return ""
+	}
+	if ext := asString(details["fileExt"]); ext != "" {
+		name = name + "." + ext
+	}
+	return filepath.ToSlash(filepath.Join("files", name))
+}
+
+// spaceFS hides the snapshots and attachments of other spaces.
+type spaceFS struct {
+	input  fs.FS
+	hidden map[string]struct{}
+}
+
+func (s spaceFS) Open(name string) (fs.File, error) {
+	if _, ok := s.hidden[name]; ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return s.input.Open(name)
+}
+
+func (s spaceFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(s.input, name)
+	if err != nil {
+		return nil, err
+	}
+	out := entries[:0:0]
+	for _, ent := range entries {
+		if _, ok := s.hidden[path.Join(name, ent.Name())]; ok {
+			continue
+		}
+		out = append(out, ent)
+	}
+	return out, nil
+}
@@ -0,0 +1,201 @@
+package anytypejson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
+)
+
+const (
+	// FormatJSON is the current "Any-Block" JSON export: one snapshot per
+	// object with relations, options and types in their own directories.
+	FormatJSON = "json"
+	// FormatLegacyJSON is the JSON export of older Anytype releases. It has
+	// no relations/ directory; relation definitions travel inside each
+	// object as extraRelations/relationLinks, and enums are often numbers.
+	FormatLegacyJSON = "json-legacy"
+)
+
+const supportedFormatsHint = "supported: Anytype JSON export (\"Any-Block\" format, current and legacy layouts)"
+
+// DetectFormat inspects inputDir and reports which export layout it holds,
+// or an error naming the unsupported layout.
+func DetectFormat(inputDir string) (string, error) {
+	input, err := openDir(inputDir)
+	if err != nil {
+		return "", err
+	}
+	return detectFormat(input)
+}
+
+func openDir(inputDir string) (fs.FS, error) {
+	info, err := os.Stat(inputDir)
+	if err != nil {
+		return nil, fmt.Errorf("read export: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("read export: %s is not a directory", inputDir)
+	}
+	return os.DirFS(inputDir), nil
+}
+
+func detectFormat(input fs.FS) (string, error) {
+	if _, err := fs.Stat(input, "objects"); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", unsupportedFormatError(input)
+		}
+		return "", err
+	}
+	if _, err := fs.Stat(input, "relations"); err == nil {
+		return FormatJSON, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	return FormatLegacyJSON, nil
+}
+
+// unsupportedFormatError names the export kind found in input, so users
+// who picked Markdown or Protobuf in Anytype's export dialog know what to
+// re-export.
+func unsupportedFormatError(input fs.FS) error {
+	var pbFiles, jsonFiles, mdFiles int
+	_ = fs.WalkDir(input, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch name := d.Name(); {
+		case strings.HasSuffix(name, ".pb.json"):
+			jsonFiles++
+		case strings.HasSuffix(name, ".pb"):
+			pbFiles++
+		case strings.HasSuffix(name, ".md"):
+			mdFiles++
+		}
+		return nil
+	})
+	switch {
+	case pbFiles > 0 && jsonFiles == 0:
+		return fmt.Errorf("unsupported Anytype export: Protobuf snapshots (.pb); re-export with the JSON option (%s)", supportedFormatsHint)
+	case mdFiles > 0 && jsonFiles == 0:
+		return fmt.Errorf("unsupported Anytype export: Markdown export; re-export as Any-Block JSON (%s)", supportedFormatsHint)
+	case jsonFiles > 0:
+		return fmt.Errorf("unsupported Anytype export: .pb.json snapshots without an objects/ directory (%s)", supportedFormatsHint)
+	default:
+		return fmt.Errorf("unsupported Anytype export: no objects/ directory (%s)", supportedFormatsHint)
+	}
+}
+
+// smartBlockTypeNames maps the numeric SmartBlockType values legacy exports
+// write for sbType to the names current exports use.
+var smartBlockTypeNames = map[int]string{
+	0x10:  "Page",
+	0x11:  "ProfilePage",
+	0x20:  "Home",
+	0x30:  "Archive",
+	0x70:  "Widget",
+	0x100: "File",
+	0x120: "Template",
+	0x121: "BundledTemplate",
+	0x200: "BundledRelation",
+	0x201: "SubObject",
+	0x202: "BundledObjectType",
+	0x203: "AnytypeProfile",
+	0x204: "Date",
+	0x205: "Workspace",
+	0x209: "STRelation",
+	0x210: "STType",
+	0x211: "STRelationOption",
+	0x212: "SpaceView",
+	0x214: "Identity",
+	0x216: "Participant",
+	0x218: "FileObject",
+	0x221: "ChatObject",
+	0x222: "ChatDerivedObject",
+}
+
+func normalizeSbType(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name, nil
+	}
+	var number int
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return "", fmt.Errorf("unsupported sbType %s", raw)
+	}
+	if name, ok := smartBlockTypeNames[number]; ok {
+		return name, nil
+	}
+	return strconv.Itoa(number), nil
+}
+
+// relationFormatNames maps the enum names some exports use for
+// relationFormat to the numeric formats the exporter works with.
+var relationFormatNames = map[string]int{
+	"longtext":  anytypedomain.RelationFormatLongText,
+	"shorttext": anytypedomain.RelationFormatShortText,
+	"number":    anytypedomain.RelationFormatNumber,
+	"status":    anytypedomain.RelationFormatStatus,
+	"date":      anytypedomain.RelationFormatDate,
+	"file":      anytypedomain.RelationFormatFile,
+	"checkbox":  anytypedomain.RelationFormatCheckbox,
+	"url":       anytypedomain.RelationFormatURL,
+	"email":     anytypedomain.RelationFormatEmail,
+	"phone":     anytypedomain.RelationFormatPhone,
+	"emoji":     anytypedomain.RelationFormatEmoji,
+	"tag":       anytypedomain.RelationFormatTag,
+	"object":    anytypedomain.RelationFormatObjectRef,
+}
+
+func asRelationFormat(v any) int {
+	if name, ok := v.(string); ok {
+		if format, ok := relationFormatNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return format
+		}
+	}
+	return asInt(v)
+}
+
+// addLegacyRelations collects the relation definitions legacy exports
+// embed in each object. Definitions from extraRelations carry names and win
+// over the bare key/format pairs of relationLinks.
+func addLegacyRelations(out map[string]anytypedomain.RelationDef, f anytypedomain.SnapshotFile) {
+	add := func(raw any, named bool) {
+		entry, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		key := strings.TrimSpace(asString(entry["key"]))
+		if key == "" {
+			return
+		}
+		if existing, ok := out[key]; ok && (existing.Name != "" || !named) {
+			return
+		}
+		def := anytypedomain.RelationDef{
+			ID:     strings.TrimSpace(asString(entry["id"])),
+			Key:    key,
+			Name:   strings.TrimSpace(asString(entry["name"])),
+			Format: asRelationFormat(entry["format"]),
+			Max:    asInt(entry["maxCount"]),
+		}
+		out[key] = def
+		if def.ID != "" && def.ID != key {
+			out[def.ID] = def
+		}
+	}
+	for _, raw := range f.Snapshot.Data.ExtraRelations {
+		add(raw, true)
+	}
+	for _, raw := range f.Snapshot.Data.RelationLinks {
+		add(raw, false)
+	}
+}
@@ -1,11 +1,14 @@
 package exportfs
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -14,45 +17,150 @@ import (
 	anytypedomain "github.com/sleroq/anytype-to-obsidian/internal/domain/anytype"
 )
 
-func CopyDir(src, dst string) (int, error) {
-	entries, err := os.ReadDir(src)
-	if err != nil {
-		if os.IsNotExist(err) {
+// CopyDir copies every regular file below src in input into dst, keeping
+// relative paths so nested attachment folders survive. Files for which skip
+// returns true, given their slash path relative to src, are left alone.
+// onFile, when set, is called after each copied file with the running and
+// total counts.
+func CopyDir(input fs.FS, src, dst string, skip func(rel string) bool, onFile func(done, total int)) (int, error) {
+	if _, err := fs.Stat(input, src); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
 			return 0, nil
 		}
 		return 0, fmt.Errorf("read dir %s: %w", src, err)
 	}
+
+	var relPaths []string
+	err := fs.WalkDir(input, src, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, src), "/")
+		if skip != nil && skip(rel) {
+			return nil
+		}
+		relPaths = append(relPaths, rel)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("read dir %s: %w", src, err)
+	}
 	if err := os.MkdirAll(dst, 0o755); err != nil {
 		return 0, err
 	}
 
 	copied := 0
-	for _, ent := range entries {
-		if ent.IsDir() {
-			continue
+	for _, rel := range relPaths {
+		outPath := filepath.Join(dst, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return copied, err
 		}
-		inPath := filepath.Join(src, ent.Name())
-		outPath := filepath.Join(dst, ent.Name())
-		if err := copyFile(inPath, outPath); err != nil {
+		if err := copyFile(input, path.Join(src, rel), outPath); err != nil {
 			return copied, err
 		}
 		copied++
+		if onFile != nil {
+			onFile(copied, len(relPaths))
+		}
 	}
 	return copied, nil
 }
 
-func NormalizeExportedFileObjectPaths(inputDir, outputDir string, fileObjects map[string]string) error {
-	rewrittenPaths := map[string]string{}
+// CopyTree recursively copies src into dst, keeping file modes, mtimes and
+// symlinks. Paths for which skip returns true, given their slash path
+// relative to src, are left out; a skipped directory is not descended into.
+// A missing src copies nothing.
+func CopyTree(src, dst string, skip func(rel string) bool) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	tree := os.DirFS(src)
+	return fs.WalkDir(tree, ".", func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if p != "." && skip != nil && skip(p) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, filepath.FromSlash(p))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			link, err := os.Readlink(filepath.Join(src, filepath.FromSlash(p)))
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := copyFile(tree, p, target); err != nil {
+			return err
+		}
+		if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+}
+
+// NormalizeExportedFileObjectPaths gives every extensionless attachment an
+// extension sniffed from its content: both file-object sources and any other
+// file copied under files/. The copies in outputDir are renamed and
+// fileObjects is updated so every reference (file blocks, covers, icons)
+// points at the new name. A rename from or to a vault path for which skip
+// returns true is not made.
+func NormalizeExportedFileObjectPaths(input fs.FS, outputDir string, fileObjects map[string]string, skip func(relPath string) bool) error {
+	candidates := map[string]struct{}{}
 	for _, sourceRelPath := range fileObjects {
 		sourceRelPath = filepath.ToSlash(strings.TrimSpace(sourceRelPath))
 		if sourceRelPath == "" || filepath.Ext(sourceRelPath) != "" {
 			continue
 		}
-		if _, seen := rewrittenPaths[sourceRelPath]; seen {
-			continue
+		candidates[sourceRelPath] = struct{}{}
+	}
+	filesDir := filepath.Join(outputDir, "files")
+	err := filepath.WalkDir(filesDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) && path == filesDir {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if !d.Type().IsRegular() || filepath.Ext(d.Name()) != "" {
+			return nil
 		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+		candidates[filepath.ToSlash(rel)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan copied files: %w", err)
+	}
 
-		ext := DetectFileExtensionFromContent(filepath.Join(inputDir, filepath.FromSlash(sourceRelPath)))
+	rewrittenPaths := map[string]string{}
+	for sourceRelPath := range candidates {
+		// Sniff the copy when present; file objects outside files/ are only
+		// in the input.
+		ext := DetectFileExtensionFromContent(filepath.Join(outputDir, filepath.FromSlash(sourceRelPath)))
+		if ext == "" {
+			ext = detectFileExtensionInFS(input, sourceRelPath)
+		}
 		if ext == "" {
 			continue
 		}
@@ -60,6 +168,10 @@ func NormalizeExportedFileObjectPaths(inputDir, outputDir string, fileObjects ma
 	}
 
 	for sourceRelPath, rewrittenRelPath := range rewrittenPaths {
+		if skip != nil && (skip(sourceRelPath) || skip(rewrittenRelPath)) {
+			delete(rewrittenPaths, sourceRelPath)
+			continue
+		}
 		sourceAbsPath := filepath.Join(outputDir, filepath.FromSlash(sourceRelPath))
 		rewrittenAbsPath := filepath.Join(outputDir, filepath.FromSlash(rewrittenRelPath))
 
@@ -91,17 +203,35 @@ func NormalizeExportedFileObjectPaths(inputDir, outputDir string, fileObjects ma
 }
 
 func DetectFileExtensionFromContent(path string) string {
-	content, err := os.ReadFile(path)
-	if err != nil || len(content) == 0 {
+	f, err := os.Open(path)
+	if err != nil {
 		return ""
 	}
+	defer f.Close()
+	return detectFileExtension(f)
+}
 
-	sniffLen := len(content)
-	if sniffLen > 512 {
-		sniffLen = 512
+func detectFileExtensionInFS(input fs.FS, name string) string {
+	f, err := input.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return detectFileExtension(f)
+}
+
+func detectFileExtension(f io.Reader) string {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	content := head[:n]
+	if len(content) == 0 {
+		return ""
 	}
 
-	mimeType := strings.TrimSpace(http.DetectContentType(content[:sniffLen]))
+	mimeType := strings.TrimSpace(http.DetectContentType(content))
 	if idx := strings.Index(mimeType, ";"); idx >= 0 {
 		mimeType = strings.TrimSpace(mimeType[:idx])
 	}
@@ -115,10 +245,21 @@ func DetectFileExtensionFromContent(path string) string {
 		"image/png":        ".png",
 		"image/gif":        ".gif",
 		"image/webp":       ".webp",
+		"image/bmp":        ".bmp",
 		"image/svg+xml":    ".svg",
 		"image/x-icon":     ".ico",
 		"application/pdf":  ".pdf",
 		"application/json": ".json",
+		"application/zip":  ".zip",
+		"audio/mpeg":       ".mp3",
+		"audio/wave":       ".wav",
+		"audio/ogg":        ".ogg",
+		"audio/aiff":       ".aiff",
+		"audio/midi":       ".mid",
+		"video/mp4":        ".mp4",
+		"video/webm":       ".webm",
+		"video/avi":        ".avi",
+		"text/html":        ".html",
 		"text/plain":       ".txt",
 	}
 	if ext, ok := preferredExt[mimeType]; ok {
@@ -150,8 +291,8 @@ func ApplyExportedFileTimes(path string, details map[string]any, createdDateKeys
 	return nil
 }
 
-func copyFile(src, dst string) (err error) {
-	in, err := os.Open(src)
+func copyFile(input fs.FS, src, dst string) (err error) {
+	in, err := input.Open(src)
 	if err != nil {
 		return err
 	}
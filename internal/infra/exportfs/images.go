@@ -0,0 +1,214 @@
+package exportfs
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImageOptions controls how oversized attachments are shrunk after copying.
+type ImageOptions struct {
+	// MaxDimension is the longest allowed side in pixels; 0 disables resizing.
+	MaxDimension int
+	// Quality is the JPEG quality (1-100) used when re-encoding; 0 uses
+	// DefaultImageQuality.
+	Quality int
+	// KeepOriginals copies every resized image to files/originals/ first.
+	KeepOriginals bool
+	// Skip, when set, reports vault-relative paths that must not be
+	// rewritten; such images are left as they are.
+	Skip func(relPath string) bool
+}
+
+const OriginalsDirName = "originals"
+
+// DefaultImageQuality is the JPEG quality resized images are written with
+// unless another is set.
+const DefaultImageQuality = 85
+
+func (opts ImageOptions) skip(relPath string) bool {
+	return opts.Skip != nil && opts.Skip(filepath.ToSlash(relPath))
+}
+
+// ShrinkImages downscales JPEG and PNG files under outputDir/files whose
+// longest side exceeds opts.MaxDimension and returns the vault-relative
+// paths it rewrote. A re-encode that is not smaller than the original is
+// discarded, so the option never grows the vault.
+func ShrinkImages(outputDir string, opts ImageOptions) ([]string, error) {
+	if opts.MaxDimension <= 0 {
+		return nil, nil
+	}
+	if opts.Quality == 0 {
+		opts.Quality = DefaultImageQuality
+	} else if opts.Quality < 1 || opts.Quality > 100 {
+		return nil, fmt.Errorf("invalid image quality %d: expected 1-100", opts.Quality)
+	}
+
+	filesDir := filepath.Join(outputDir, "files")
+	originalsDir := filepath.Join(filesDir, OriginalsDirName)
+	var resized []string
+	err := filepath.WalkDir(filesDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) && path == filesDir {
+				return filepath.SkipDir
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			if path == originalsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		format := imageFormatByExt(path)
+		if format == "" {
+			return nil
+		}
+		rel, err := filepath.Rel(filesDir, path)
+		if err != nil {
+			return err
+		}
+		if opts.skip(filepath.Join("files", rel)) {
+			return nil
+		}
+		originalPath := ""
+		if opts.KeepOriginals {
+			if opts.skip(filepath.Join("files", OriginalsDirName, rel)) {
+				return nil
+			}
+			originalPath = filepath.Join(originalsDir, rel)
+		}
+		changed, err := shrinkImageFile(path, format, originalPath, opts)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		resized = append(resized, filepath.ToSlash(filepath.Join("files", rel)))
+		return nil
+	})
+	return resized, err
+}
+
+func imageFormatByExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".png":
+		return "png"
+	default:
+		return ""
+	}
+}
+
+func shrinkImageFile(path string, format string, originalPath string, opts ImageOptions) (bool, error) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	// Images that fail to decode are left as they are.
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return false, nil
+	}
+	width, height := fitWithin(cfg.Width, cfg.Height, opts.MaxDimension)
+	if width == cfg.Width && height == cfg.Height {
+		return false, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return false, nil
+	}
+	dst := downscaleBox(src, width, height)
+
+	var encoded bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&encoded, dst, &jpeg.Options{Quality: opts.Quality})
+	case "png":
+		err = png.Encode(&encoded, dst)
+	}
+	if err != nil {
+		return false, err
+	}
+	if encoded.Len() >= len(original) {
+		return false, nil
+	}
+
+	if originalPath != "" {
+		if err := os.MkdirAll(filepath.Dir(originalPath), 0o755); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(originalPath, original, 0o644); err != nil {
+			return false, err
+		}
+	}
+	if err := os.WriteFile(path, encoded.Bytes(), 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func fitWithin(width int, height int, maxDimension int) (int, int) {
+	if width <= maxDimension && height <= maxDimension {
+		return width, height
+	}
+	if width >= height {
+		h := height * maxDimension / width
+		if h < 1 {
+			h = 1
+		}
+		return maxDimension, h
+	}
+	w := width * maxDimension / height
+	if w < 1 {
+		w = 1
+	}
+	return w, maxDimension
+}
+
+// downscaleBox shrinks src to width x height by averaging the source pixels
+// that fall into each destination pixel (premultiplied, so alpha edges stay
+// clean).
+func downscaleBox(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	sums := make([]uint64, width*height*4)
+	counts := make([]uint64, width*height)
+	for y := 0; y < srcH; y++ {
+		dy := y * height / srcH
+		row := rgba.Pix[y*rgba.Stride:]
+		for x := 0; x < srcW; x++ {
+			dx := x * width / srcW
+			i := dy*width + dx
+			p := row[x*4 : x*4+4]
+			sums[i*4] += uint64(p[0])
+			sums[i*4+1] += uint64(p[1])
+			sums[i*4+2] += uint64(p[2])
+			sums[i*4+3] += uint64(p[3])
+			counts[i]++
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		for c := 0; c < 4; c++ {
+			dst.Pix[i*4+c] = uint8(sums[i*4+c] / count)
+		}
+	}
+	return dst
+}
@@ -10,7 +10,7 @@ buildGoModule (finalAttrs: {
 
   src = lib.cleanSource ./.;
 
-  vendorHash = "sha256-FabH6dC+vYeR2CTAqgk5oXdBJNK0YljTRoKPfmNIdXM=";
+  vendorHash = "sha256-+jYHOuy5YUZTu+Q+mp4wdVbvOQnVsT+gchZUnt1nDXw=";
 
   ldflags = [
     "-s"
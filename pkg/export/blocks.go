@@ -0,0 +1,56 @@
+package export
+
+import (
+	"encoding/json"
+
+	"github.com/sleroq/anytype-to-obsidian/internal/app/exporter"
+)
+
+// Block is an Anytype block offered to a BlockRenderer.
+type Block struct {
+	ID string
+	// Kind is the key of the block's content in the export: "text",
+	// "file", "table", or the name of a block type the exporter does not
+	// know.
+	Kind string
+	// Content is that content as JSON, for example
+	// {"text":"Hello","style":"Paragraph"} for a text block.
+	Content     json.RawMessage
+	Fields      map[string]any
+	ChildrenIDs []string
+	// ObjectID is the object whose note is being rendered and NotePath its
+	// vault-relative path.
+	ObjectID string
+	NotePath string
+	// Depth is how deep the block is nested: 0 for the top-level blocks of
+	// the note.
+	Depth int
+	// RenderChildren renders the block's children as the exporter would.
+	RenderChildren func() string
+}
+
+// BlockRenderer turns a block into markdown for the note. It returns false
+// to leave the block to the exporter. When it handles a block, the block's
+// children are not rendered unless it includes Block.RenderChildren.
+type BlockRenderer func(Block) (markdown string, ok bool)
+
+// RegisterBlockRenderer makes render handle every block of kind in later
+// runs, ahead of the exporter's own renderer for that kind. This lets
+// programs render block types the exporter does not know, or render known
+// ones their own way:
+//
+//	export.RegisterBlockRenderer("widget", func(b export.Block) (string, bool) {
+//		return "> [!info] Widget " + b.ID, true
+//	})
+//
+// A nil render removes the renderer for kind. Registrations are process-wide
+// and safe for concurrent use.
+func RegisterBlockRenderer(kind string, render BlockRenderer) {
+	if render == nil {
+		exporter.RegisterBlockRenderer(kind, nil)
+		return
+	}
+	exporter.RegisterBlockRenderer(kind, func(b exporter.BlockInfo) (string, bool) {
+		return render(Block(b))
+	})
+}
@@ -0,0 +1,377 @@
+// Package export converts an Anytype JSON export into an Obsidian vault.
+//
+// It is the supported way to embed the converter in other programs (GUI
+// wrappers, sync daemons) without shelling out to the CLI. Zero-valued
+// Options fields select the same defaults as the exporter itself; a minimal
+// call only sets InputDir and OutputDir:
+//
+//	result, err := export.Run(ctx, export.Options{
+//		InputDir:  "Anytype-json",
+//		OutputDir: "vault",
+//		Progress:  func(p export.Progress) { log.Println(p.Phase, p.Current, p.Total) },
+//	})
+package export
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sleroq/anytype-to-obsidian/internal/app/exporter"
+)
+
+// Options configures a conversion. String modes accept the same values as
+// the matching CLI flags; the empty string selects the default mode.
+type Options struct {
+	// InputDir is the Anytype "Any-Block" JSON export directory or its .zip
+	// archive.
+	InputDir string
+	// OutputDir is the Obsidian vault to write; it is created when missing.
+	OutputDir string
+	// OutputZip, when set, writes the vault into this .zip archive instead
+	// of OutputDir.
+	OutputZip string
+	// SplitBySpace writes one vault per space of the export into
+	// OutputDir/<Space Name>/ instead of mixing spaces into one vault.
+	SplitBySpace bool
+	// Format is "markdown" (the default), "html" for a static site of
+	// standalone pages instead of a vault, or "both" for HTML pages next to
+	// the notes.
+	Format string
+	// HTMLEmbedImages inlines images into the HTML pages as data URIs.
+	HTMLEmbedImages bool
+	// Flavor is "obsidian" (the default), "logseq" for a Logseq graph with
+	// pages/, journals/ and assets/ folders instead of a vault, or
+	// "portable" for plain CommonMark without Obsidian-only syntax.
+	Flavor string
+
+	// Logger receives per-object decisions and warnings. A nil Logger writes
+	// warnings to stderr.
+	Logger *slog.Logger
+	// Progress, when set, receives an update on every phase change and every
+	// processed item instead of the terminal progress bar. It is called
+	// synchronously from the export goroutine.
+	Progress func(Progress)
+
+	// Atomic renders into a temp folder next to OutputDir and only replaces
+	// OutputDir after a successful run. Files the previous export did not
+	// write are carried over.
+	Atomic bool
+	// MergeStrategy decides what happens to notes already in OutputDir:
+	// overwrite, skip-existing, keep-newer or suffix-conflicts.
+	MergeStrategy string
+	// FailFast aborts on the first unreadable snapshot instead of skipping
+	// it and listing it in the warnings.
+	FailFast bool
+	// LowMemory re-reads each object's blocks while rendering instead of
+	// keeping the whole export in memory.
+	LowMemory bool
+	// Profile writes pprof profiles and phase timings to _anytype/profile.
+	Profile bool
+	// NoDynamicTimestamps keeps run-time values out of the vault so repeated
+	// exports of the same input are byte-identical.
+	NoDynamicTimestamps bool
+	// RunPrettier formats the vault with npx prettier when available.
+	RunPrettier bool
+	// VerifyLinks checks the written vault for broken links: off, warn or
+	// fail.
+	VerifyLinks string
+
+	// FilenameEscaping is auto, posix or windows.
+	FilenameEscaping string
+	// FilenameScheme is name, id, name-id-suffix or zettel.
+	FilenameScheme string
+	// MaxFilenameLength caps filenames in bytes and must be at least 23;
+	// -1 disables the limit.
+	MaxFilenameLength      int
+	TransliterateFilenames bool
+	ASCIIFilenames         bool
+	EmojiInFilename        bool
+	// NFCFilenames normalizes filenames (and so links) to Unicode NFC.
+	NFCFilenames bool
+
+	IncludeDynamicProperties  bool
+	IncludeArchivedObjects    bool
+	IncludeDeleted            bool
+	IncludeSystemObjects      bool
+	IncludeArchivedProperties bool
+	ExcludeEmptyProperties    bool
+	// KeepSystemObjectTypes lists system object kinds (sbTypes such as
+	// Home or Workspace, or the Dashboard, Space and SpaceView layouts)
+	// exported even without IncludeSystemObjects.
+	KeepSystemObjectTypes []string
+	// ExcludePropertyKeys and ForceIncludePropertyKeys take relation keys or
+	// names.
+	ExcludePropertyKeys      []string
+	ForceIncludePropertyKeys []string
+	// LinkAsNotePropertyKeys renders these relations as note links when the
+	// targets are exported.
+	LinkAsNotePropertyKeys []string
+	// PropertyOrder lists frontmatter keys to write first; "*" stands for
+	// the remaining keys in their default order.
+	PropertyOrder []string
+	// PropertiesStyle is frontmatter, inline (Dataview "Key:: value" lines
+	// in the note body) or both.
+	PropertiesStyle string
+	// Properties is all, featured (also write the object's featured
+	// properties as a featured: list) or featured-only (keep only featured
+	// and type-recommended properties).
+	Properties string
+	// TagHierarchy lists "relation" or "relation=prefix" entries whose
+	// options are written as nested tags instead of their own property.
+	TagHierarchy []string
+	// TagsFromProperties lists the relations merged into tags (default:
+	// tag). NoMergeTags writes every relation under its own key instead.
+	TagsFromProperties []string
+	NoMergeTags        bool
+	// TypeAsTag adds a type/<Name> tag for each note's Anytype type.
+	TypeAsTag            bool
+	StrictRelations      bool
+	EmbedAnytypeMetadata bool
+	// ExportTablesDir, when set, also writes every set and collection as a
+	// table of its members and visible columns into this directory, in
+	// ExportTablesFormat (csv, the default, or json).
+	ExportTablesDir    string
+	ExportTablesFormat string
+	// QueryTarget selects what sets and collections become: "bases" (the
+	// default) for .base files, or "projects" for projects of the Projects
+	// community plugin, for Obsidian versions without Bases.
+	QueryTarget string
+	// MissingStubs writes a Missing/<name or id>.md stub with
+	// anytype_missing: true for every linked object absent from the export.
+	MissingStubs bool
+
+	DisableIconizeIcons       bool
+	DisablePrettyPropertyIcon bool
+	DisablePictureToCover     bool
+	// TagColorsCSS writes .obsidian/snippets/anytype-tag-colors.css, which
+	// colors tags natively with their Anytype option colors.
+	TagColorsCSS bool
+	// IconStyle is property, heading or filename.
+	IconStyle string
+
+	EnableBasesKanban bool
+	// KanbanBoards also writes every Board view as an Obsidian Kanban
+	// plugin board (bases/<base> - <view>.md).
+	KanbanBoards             bool
+	DisableCollectionFilters bool
+	ScaffoldEmptyNotes       bool
+	WriteDefaultTemplateMap  bool
+	ExportPeople             bool
+	LinkCards                bool
+	// SkipEmptyNotes leaves out objects without body text or visible
+	// properties; Result.SkippedEmpty counts them.
+	SkipEmptyNotes bool
+	// ReportDuplicates writes duplicates.md, listing the notes that share a
+	// name and which of them have the same content.
+	ReportDuplicates bool
+	// WebClippings lays out Bookmark objects like Obsidian Web Clipper
+	// notes: source URL, description blockquote and preview image.
+	WebClippings bool
+	// ExportVCards also writes contacts/<name>.vcf for every person (Profile
+	// layout or a Human/Contact/Person type) with an email or phone.
+	ExportVCards bool
+	// ExportICS also writes anytype-events.ics with an event for every user
+	// date relation set on an exported note.
+	ExportICS bool
+	// Protect lists vault-relative globs, such as "Daily/**" or "Inbox.md",
+	// that the exporter never writes; "**" matches any number of folders.
+	Protect []string
+	// PrettierCommand replaces npx prettier with another formatter command
+	// line, such as "dprint fmt"; the markdown files written by the run are
+	// appended to it.
+	PrettierCommand string
+	// PrettierConfig is "" to ignore formatter config files, "auto" to let
+	// the formatter find them, or a config file passed as --config.
+	PrettierConfig string
+	// PrettierTargets lists the vault folders or globs whose newly written
+	// markdown is formatted; empty means notes, people, trash, bases and
+	// templates.
+	PrettierTargets []string
+	// Formatter is prettier, builtin (a markdown normalizer that needs no
+	// Node) or none; empty follows RunPrettier.
+	Formatter string
+	// Update is all (default) or frontmatter, which rewrites only the
+	// frontmatter of notes already in the vault, matched by object ID
+	// through _anytype/index.json, and keeps their bodies.
+	Update string
+	// GraphStats writes graph-stats.md at the vault root: notes per type,
+	// the most linked notes, orphan notes and broken relation targets.
+	GraphStats bool
+	// ColumnLayout is flatten, html or multi-column.
+	ColumnLayout string
+	// RelationBlocks is dataview, line or skip.
+	RelationBlocks string
+	// TOCMode is wikilink-anchors, links or skip.
+	TOCMode string
+	// CalloutTypes lists "icon=type" pairs, such as "🚀=tip", that extend
+	// the mapping from callout icons to Obsidian callout types.
+	CalloutTypes []string
+	// ToggleHeadings is heading or callout, which folds the content of a
+	// toggle heading into a collapsed callout below it.
+	ToggleHeadings string
+	// MultiValueLists writes relations that allow several values as YAML
+	// lists even when a note holds only one.
+	MultiValueLists bool
+	// ComplexTables is markdown or html, which writes tables with merged
+	// cells, several blocks in a cell or many columns as HTML tables.
+	ComplexTables string
+
+	// MaxImageDimension downscales larger JPEG/PNG attachments; 0 disables.
+	MaxImageDimension int
+	// ImageQuality is the JPEG quality (1-100) of resized images; 0 uses
+	// DefaultImageQuality.
+	ImageQuality       int
+	KeepOriginalImages bool
+}
+
+// DefaultImageQuality is the JPEG quality resized images are written with
+// when Options.ImageQuality is zero.
+const DefaultImageQuality = exporter.DefaultImageQuality
+
+// Phases reported in Progress.Phase, in the order a run goes through them.
+const (
+	PhaseReadExport      = exporter.PhaseReadExport
+	PhaseCopyFiles       = exporter.PhaseCopyFiles
+	PhaseRenderBases     = exporter.PhaseRenderBases
+	PhaseRenderTemplates = exporter.PhaseRenderTemplates
+	PhaseRenderNotes     = exporter.PhaseRenderNotes
+	PhasePostProcess     = exporter.PhasePostProcess
+)
+
+// Progress is one progress update. Total is zero for phases whose size is
+// not known up front; ObjectID is set while bases, templates and notes are
+// rendered.
+type Progress struct {
+	Phase    string
+	Current  int
+	Total    int
+	ObjectID string
+}
+
+// Result summarizes a finished conversion.
+type Result struct {
+	Notes     int
+	Bases     int
+	Templates int
+	Files     int
+	// Warnings are the non-fatal problems also listed in the report.
+	Warnings []string
+	Elapsed  time.Duration
+	// ReportPath is the _anytype/report.json written for this run.
+	ReportPath string
+	// SkippedEmpty counts the objects left out by SkipEmptyNotes.
+	SkippedEmpty int
+}
+
+// Run converts opts.InputDir into opts.OutputDir. Cancelling ctx stops the
+// export at the next object boundary; the returned error then wraps
+// ctx.Err() and the vault records what was written in _anytype/partial.json.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	stats, err := newExporter(opts).RunContext(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Notes:      stats.Notes,
+		Bases:      stats.Bases,
+		Templates:  stats.Templates,
+		Files:      stats.Files,
+		Warnings:   stats.WarningMessages,
+		Elapsed:    stats.Elapsed,
+		ReportPath: stats.ReportPath,
+
+		SkippedEmpty: stats.SkippedEmpty,
+	}, nil
+}
+
+func newExporter(opts Options) exporter.Exporter {
+	exp := exporter.Exporter{
+		Logger:                    opts.Logger,
+		InputDir:                  opts.InputDir,
+		OutputDir:                 opts.OutputDir,
+		OutputZip:                 opts.OutputZip,
+		SplitBySpace:              opts.SplitBySpace,
+		Format:                    opts.Format,
+		HTMLEmbedImages:           opts.HTMLEmbedImages,
+		Flavor:                    opts.Flavor,
+		DisableIconizeIcons:       opts.DisableIconizeIcons,
+		DisablePrettyPropertyIcon: opts.DisablePrettyPropertyIcon,
+		DisablePictureToCover:     opts.DisablePictureToCover,
+		EnableBasesKanban:         opts.EnableBasesKanban,
+		DisableCollectionFilters:  opts.DisableCollectionFilters,
+		RunPrettier:               opts.RunPrettier,
+		FilenameEscaping:          opts.FilenameEscaping,
+		IncludeDynamicProperties:  opts.IncludeDynamicProperties,
+		IncludeArchivedObjects:    opts.IncludeArchivedObjects,
+		IncludeDeleted:            opts.IncludeDeleted,
+		IncludeSystemObjects:      opts.IncludeSystemObjects,
+		KeepSystemObjectTypes:     opts.KeepSystemObjectTypes,
+		IncludeArchivedProperties: opts.IncludeArchivedProperties,
+		ExcludeEmptyProperties:    opts.ExcludeEmptyProperties,
+		ExcludePropertyKeys:       opts.ExcludePropertyKeys,
+		ForceIncludePropertyKeys:  opts.ForceIncludePropertyKeys,
+		LinkAsNotePropertyKeys:    opts.LinkAsNotePropertyKeys,
+		ScaffoldEmptyNotes:        opts.ScaffoldEmptyNotes,
+		SkipEmptyNotes:            opts.SkipEmptyNotes,
+		ReportDuplicates:          opts.ReportDuplicates,
+		WebClippings:              opts.WebClippings,
+		ExportVCards:              opts.ExportVCards,
+		ExportICS:                 opts.ExportICS,
+		Protect:                   opts.Protect,
+		PrettierCommand:           opts.PrettierCommand,
+		PrettierConfig:            opts.PrettierConfig,
+		PrettierTargets:           opts.PrettierTargets,
+		Formatter:                 opts.Formatter,
+		Update:                    opts.Update,
+		GraphStats:                opts.GraphStats,
+		TransliterateFilenames:    opts.TransliterateFilenames,
+		WriteDefaultTemplateMap:   opts.WriteDefaultTemplateMap,
+		VerifyLinks:               opts.VerifyLinks,
+		StrictRelations:           opts.StrictRelations,
+		MissingStubs:              opts.MissingStubs,
+		ExportPeople:              opts.ExportPeople,
+		ColumnLayout:              opts.ColumnLayout,
+		LinkCards:                 opts.LinkCards,
+		RelationBlocks:            opts.RelationBlocks,
+		TOCMode:                   opts.TOCMode,
+		CalloutTypes:              opts.CalloutTypes,
+		ToggleHeadings:            opts.ToggleHeadings,
+		ComplexTables:             opts.ComplexTables,
+		MultiValueLists:           opts.MultiValueLists,
+		Atomic:                    opts.Atomic,
+		MergeStrategy:             opts.MergeStrategy,
+		EmbedAnytypeMetadata:      opts.EmbedAnytypeMetadata,
+		FilenameScheme:            opts.FilenameScheme,
+		MaxFilenameLength:         opts.MaxFilenameLength,
+		ASCIIFilenames:            opts.ASCIIFilenames,
+		EmojiInFilename:           opts.EmojiInFilename,
+		NFCFilenames:              opts.NFCFilenames,
+		PropertyOrder:             opts.PropertyOrder,
+		PropertiesStyle:           opts.PropertiesStyle,
+		Properties:                opts.Properties,
+		TagHierarchy:              opts.TagHierarchy,
+		TagsFromProperties:        opts.TagsFromProperties,
+		NoMergeTags:               opts.NoMergeTags,
+		TypeAsTag:                 opts.TypeAsTag,
+		ExportTablesDir:           opts.ExportTablesDir,
+		ExportTablesFormat:        opts.ExportTablesFormat,
+		QueryTarget:               opts.QueryTarget,
+		KanbanBoards:              opts.KanbanBoards,
+		TagColorsCSS:              opts.TagColorsCSS,
+		IconStyle:                 opts.IconStyle,
+		NoDynamicTimestamps:       opts.NoDynamicTimestamps,
+		FailFast:                  opts.FailFast,
+		LowMemory:                 opts.LowMemory,
+		Profile:                   opts.Profile,
+		MaxImageDimension:         opts.MaxImageDimension,
+		ImageQuality:              opts.ImageQuality,
+		KeepOriginalImages:        opts.KeepOriginalImages,
+	}
+	if opts.Progress != nil {
+		exp.Progress = func(event exporter.Event) {
+			opts.Progress(Progress{Phase: event.Phase, Current: event.Current, Total: event.Total, ObjectID: event.ObjectID})
+		}
+	}
+	return exp
+}
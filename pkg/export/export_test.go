@@ -0,0 +1,64 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunConvertsExportAndReportsProgress(t *testing.T) {
+	output := t.TempDir()
+	var events []Progress
+
+	result, err := Run(context.Background(), Options{
+		InputDir:            filepath.Join("..", "..", "internal", "app", "exporter", "testdata", "golden", "input"),
+		OutputDir:           output,
+		NoDynamicTimestamps: true,
+		Progress:            func(p Progress) { events = append(events, p) },
+	})
+	if err != nil {
+		t.Fatalf("run export: %v", err)
+	}
+
+	if result.Notes == 0 || result.Bases == 0 {
+		t.Fatalf("expected notes and bases in result, got %+v", result)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("expected a clean export, got warnings %v", result.Warnings)
+	}
+	if _, err := os.Stat(result.ReportPath); err != nil {
+		t.Fatalf("expected report at %s: %v", result.ReportPath, err)
+	}
+
+	phases := map[string]bool{}
+	renderedNotes := map[string]bool{}
+	for _, event := range events {
+		phases[event.Phase] = true
+		if event.Total > 0 && event.Current > event.Total {
+			t.Fatalf("progress overshoots total: %+v", event)
+		}
+		if event.Phase == PhaseRenderNotes && event.ObjectID != "" {
+			renderedNotes[event.ObjectID] = true
+		}
+	}
+	if !renderedNotes["page-plain"] {
+		t.Fatalf("expected a note progress event for page-plain, got %v", renderedNotes)
+	}
+	for _, phase := range []string{PhaseReadExport, PhaseRenderNotes, PhasePostProcess} {
+		if !phases[phase] {
+			t.Fatalf("expected progress for phase %q, got %v", phase, phases)
+		}
+	}
+}
+
+func TestRunRejectsInvalidMode(t *testing.T) {
+	_, err := Run(context.Background(), Options{
+		InputDir:  filepath.Join("..", "..", "internal", "app", "exporter", "testdata", "golden", "input"),
+		OutputDir: t.TempDir(),
+		IconStyle: "sideways",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown icon style")
+	}
+}
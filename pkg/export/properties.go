@@ -0,0 +1,69 @@
+package export
+
+import "github.com/sleroq/anytype-to-obsidian/internal/app/exporter"
+
+// Property is a relation value offered to a PropertyConverter.
+type Property struct {
+	// Key is the relation key and Value the value as Anytype stores it:
+	// option and object IDs, Unix timestamps for dates, and so on.
+	Key   string
+	Value any
+	// Name and Format describe the relation when the export defines it.
+	// Format is Anytype's relationFormat number, such as 3 for status.
+	Name        string
+	Format      int
+	HasRelation bool
+	// NotePath is the vault-relative note the value is written to; it is
+	// empty for values in bases and tables.
+	NotePath string
+}
+
+// PropertyConverter customizes the values of one relation. BeforeConvert
+// sees the Anytype value and can replace the built-in conversion by
+// returning true. AfterConvert gets the converted value, from
+// BeforeConvert or the built-in conversion, and returns the value to write:
+// a string, number, bool, or a []string or []any list.
+type PropertyConverter interface {
+	BeforeConvert(p Property) (value any, ok bool)
+	AfterConvert(p Property, converted any) any
+}
+
+// RegisterPropertyConverter makes conv convert the values of relation key
+// in later runs: in note frontmatter, relation blocks, base filters and
+// exported tables alike, so they keep matching. For example, to map a CRM
+// stage relation onto your own taxonomy:
+//
+//	type stages struct{}
+//
+//	func (stages) BeforeConvert(export.Property) (any, bool) { return nil, false }
+//	func (stages) AfterConvert(p export.Property, v any) any {
+//		if s, ok := v.(string); ok {
+//			return "pipeline/" + strings.ToLower(s)
+//		}
+//		return v
+//	}
+//
+//	export.RegisterPropertyConverter("stage", stages{})
+//
+// A nil conv removes the converter for key. Registrations are process-wide
+// and safe for concurrent use.
+func RegisterPropertyConverter(key string, conv PropertyConverter) {
+	if conv == nil {
+		exporter.RegisterPropertyConverter(key, nil)
+		return
+	}
+	exporter.RegisterPropertyConverter(key, propertyConverter{conv})
+}
+
+// propertyConverter adapts a PropertyConverter to the exporter's hook.
+type propertyConverter struct {
+	conv PropertyConverter
+}
+
+func (c propertyConverter) BeforeConvert(p exporter.PropertyInfo) (any, bool) {
+	return c.conv.BeforeConvert(Property(p))
+}
+
+func (c propertyConverter) AfterConvert(p exporter.PropertyInfo, converted any) any {
+	return c.conv.AfterConvert(Property(p), converted)
+}